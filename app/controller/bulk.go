@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type BulkEmailController struct {
+	bulkService *service.BulkEmailService
+}
+
+// NewBulkEmailController constructs the HTTP bulk email controller.
+func NewBulkEmailController(bulkService *service.BulkEmailService) *BulkEmailController {
+	return &BulkEmailController{bulkService: bulkService}
+}
+
+// SendBulk validates a bulk send, stores one email per accepted recipient, and reports
+// per-recipient results; the outbox relay enqueues the accepted emails.
+func (c *BulkEmailController) SendBulk(ctx echo.Context) error {
+	req, err := dto.SendBulkFromEchoContext(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to bind send bulk request")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		logrus.WithError(err).WithField("batch_id", req.BatchID).Debug("Send bulk validation failed")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := dto.ValidateBulkRecipientCount(len(req.Recipients)); err != nil {
+		logrus.WithError(err).WithField("batch_id", req.BatchID).Debug("Send bulk validation failed")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"batch_id":   req.BatchID,
+		"recipients": len(req.Recipients),
+	}).Info("Received send bulk request (http)")
+
+	send, err := c.bulkService.Start(ctx.Request().Context(), service.BulkEmail{
+		BatchID:  req.BatchID,
+		Category: req.Category,
+		Priority: req.Priority,
+		Subject:  req.Subject,
+		Content:  req.Content,
+		SendAt:   req.SendAt,
+		Tenant:   req.Tenant,
+		Template: req.Template,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTemplate) {
+			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if errors.Is(err, service.ErrBatchCancelled) {
+			return ctx.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		logrus.WithError(err).WithField("batch_id", req.BatchID).Error("Failed to create email batch")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create email batch"})
+	}
+
+	recipients := make([]service.BulkRecipient, 0, len(req.Recipients))
+	for i, recipient := range req.Recipients {
+		recipients = append(recipients, service.BulkRecipient{
+			Index:     i,
+			RequestID: recipient.RequestID,
+			Recipient: recipient.Recipient,
+			UserID:    recipient.UserID,
+			Timezone:  recipient.Timezone,
+			Variables: recipient.Variables,
+			Invalid:   recipient.Validate(),
+		})
+	}
+	results, err := send.Add(ctx.Request().Context(), recipients)
+	if err != nil {
+		logrus.WithError(err).WithField("batch_id", req.BatchID).Error("Failed to create email history")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create email history"})
+	}
+
+	resp := dto.NewSendBulkResponse(req.BatchID, results)
+	logrus.WithFields(logrus.Fields{
+		"batch_id": req.BatchID,
+		"accepted": resp.Accepted,
+		"rejected": resp.Rejected,
+	}).Info("Bulk email requests queued (http)")
+	return ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+func TestBulkEmailControllerSendBulkSuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO email_batches").WithArgs("batch-1").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT status").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailBatchStatusActive))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT request_id, batch_id").WithArgs("batch-1:0").
+		WillReturnRows(sqlmock.NewRows([]string{"request_id", "batch_id"}))
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("batch-1:0", uint64(0), "a@b.com", "marketing", entity.PriorityNormal, "Hi Ana", "Your code is X1", entity.EmailStatusNew, nil, "batch-1", "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").WithArgs("batch-1:0", "").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ctrl := NewBulkEmailController(service.NewBulkEmailService(repository.NewEmailHistoryRepository(db), repository.NewEmailBatchRepository(db)))
+
+	e := echo.New()
+	body := `{"batch_id":"batch-1","category":"Marketing","subject":"Hi {{.name}}","content":"Your code is {{.code}}","recipients":[` +
+		`{"recipient":"a@b.com","variables":{"name":"Ana","code":"X1"}},{"recipient":"bad"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/email/send/bulk", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+
+	if err := ctrl.SendBulk(ctx); err != nil {
+		t.Fatalf("SendBulk: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"accepted":1,"rejected":1`) ||
+		!strings.Contains(rec.Body.String(), `{"index":1,"request_id":"batch-1:1","accepted":false,"error":"recipient must be a valid email address"}`) {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestBulkEmailControllerSendBulkInvalidTemplate(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewBulkEmailController(service.NewBulkEmailService(nil, nil))
+
+	e := echo.New()
+	body := `{"batch_id":"batch-1","subject":"Hi {{.name","content":"long enough","recipients":[{"recipient":"a@b.com"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/email/send/bulk", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+
+	if err := ctrl.SendBulk(ctx); err != nil {
+		t.Fatalf("SendBulk: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestBulkEmailControllerSendBulkWithoutRecipients(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewBulkEmailController(service.NewBulkEmailService(nil, nil))
+
+	e := echo.New()
+	body := `{"batch_id":"batch-1","subject":"Hi there","content":"long enough"}`
+	req := httptest.NewRequest(http.MethodPost, "/email/send/bulk", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+
+	if err := ctrl.SendBulk(ctx); err != nil {
+		t.Fatalf("SendBulk: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type CampaignController struct {
+	campaignService *service.CampaignService
+}
+
+// NewCampaignController constructs the HTTP campaign controller.
+func NewCampaignController(campaignService *service.CampaignService) *CampaignController {
+	return &CampaignController{campaignService: campaignService}
+}
+
+// Get returns the status and aggregate progress of a bulk send batch.
+func (c *CampaignController) Get(ctx echo.Context) error {
+	batchID, err := dto.BatchIDFromEchoParam(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	batch, err := c.campaignService.Get(ctx.Request().Context(), batchID)
+	if err != nil {
+		if errors.Is(err, service.ErrBatchNotFound) {
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "email batch not found"})
+		}
+		logrus.WithError(err).WithField("batch_id", batchID).Error("Failed to load email batch")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load email batch"})
+	}
+
+	return ctx.JSON(http.StatusOK, dto.NewEmailBatchResponse(*batch))
+}
+
+// Pause holds a batch's unsent emails until it is resumed.
+func (c *CampaignController) Pause(ctx echo.Context) error {
+	return c.transition(ctx, entity.EmailBatchStatusPaused, c.campaignService.Pause)
+}
+
+// Resume lets a paused batch's emails be sent again.
+func (c *CampaignController) Resume(ctx echo.Context) error {
+	return c.transition(ctx, entity.EmailBatchStatusActive, c.campaignService.Resume)
+}
+
+// Cancel cancels a batch and its emails that have not started sending yet.
+func (c *CampaignController) Cancel(ctx echo.Context) error {
+	batchID, err := dto.BatchIDFromEchoParam(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	batch, cancelled, err := c.campaignService.Cancel(ctx.Request().Context(), batchID)
+	if err != nil {
+		if errors.Is(err, service.ErrBatchNotFound) {
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "email batch not found"})
+		}
+		logrus.WithError(err).WithField("batch_id", batchID).Error("Failed to cancel email batch")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to cancel email batch"})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"batch_id":  batchID,
+		"cancelled": cancelled,
+	}).Info("Email batch cancelled (http)")
+	return ctx.JSON(http.StatusOK, map[string]any{"batch": dto.NewEmailBatchResponse(*batch), "cancelled": cancelled})
+}
+
+// transition applies a pause or resume and maps its errors.
+func (c *CampaignController) transition(
+	ctx echo.Context,
+	status string,
+	apply func(context.Context, string) (*entity.EmailBatch, error),
+) error {
+	batchID, err := dto.BatchIDFromEchoParam(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	batch, err := apply(ctx.Request().Context(), batchID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBatchNotFound):
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "email batch not found"})
+		case errors.Is(err, service.ErrBatchCancelled):
+			return ctx.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		logrus.WithError(err).WithField("batch_id", batchID).Error("Failed to update email batch")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update email batch"})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"batch_id": batchID,
+		"status":   status,
+	}).Info("Email batch status updated (http)")
+	return ctx.JSON(http.StatusOK, dto.NewEmailBatchResponse(*batch))
+}
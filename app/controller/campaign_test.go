@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+func newBatchContext(method string, path string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(method, path, nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("batch_id")
+	ctx.SetParamValues("batch-1")
+	return ctx, rec
+}
+
+func TestCampaignControllerGetNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM email_batches").WithArgs("batch-1").WillReturnError(sql.ErrNoRows)
+
+	ctrl := NewCampaignController(service.NewCampaignService(repository.NewEmailBatchRepository(db)))
+	ctx, rec := newBatchContext(http.MethodGet, "/email/batches/batch-1")
+
+	if err := ctrl.Get(ctx); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestCampaignControllerPause(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE email_batches").
+		WithArgs(entity.EmailBatchStatusPaused, "batch-1", entity.EmailBatchStatusActive).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM email_batches").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "batch_id", "status", "created_at"}).
+			AddRow(uint64(1), "batch-1", entity.EmailBatchStatusPaused, time.Now()))
+	mock.ExpectQuery("FROM email_history").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).AddRow(entity.EmailStatusNew, 4))
+
+	ctrl := NewCampaignController(service.NewCampaignService(repository.NewEmailBatchRepository(db)))
+	ctx, rec := newBatchContext(http.MethodPost, "/email/batches/batch-1/pause")
+
+	if err := ctrl.Pause(ctx); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"status":"paused"`) || !strings.Contains(rec.Body.String(), `"queued":4`) {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestCampaignControllerResumeCancelledBatch(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE email_batches").
+		WithArgs(entity.EmailBatchStatusActive, "batch-1", entity.EmailBatchStatusPaused).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM email_batches").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "batch_id", "status", "created_at"}).
+			AddRow(uint64(1), "batch-1", entity.EmailBatchStatusCancelled, time.Now()))
+	mock.ExpectQuery("FROM email_history").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}))
+
+	ctrl := NewCampaignController(service.NewCampaignService(repository.NewEmailBatchRepository(db)))
+	ctx, rec := newBatchContext(http.MethodPost, "/email/batches/batch-1/resume")
+
+	if err := ctrl.Resume(ctx); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+}
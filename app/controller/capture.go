@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+// captureInbox is the web inbox listing the captured emails. Links are relative to
+// /capture, so the inbox works behind a path prefix.
+var captureInbox = template.Must(template.New("inbox").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Captured emails</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.4em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Captured emails ({{len .}})</h1>
+<button onclick="fetch('capture/messages', {method: 'DELETE'}).then(() => location.reload())">Clear</button>
+<table>
+<tr><th>Captured</th><th>Recipient</th><th>From</th><th>Subject</th><th></th></tr>
+{{range .}}<tr>
+<td>{{.CapturedAt.Format "2006-01-02 15:04:05"}}</td>
+<td>{{.Recipient}}</td>
+<td>{{.From}}</td>
+<td><a href="capture/messages/{{.ID}}/html" target="_blank">{{if .Subject}}{{.Subject}}{{else}}(no subject){{end}}</a></td>
+<td><a href="capture/messages/{{.ID}}/raw">.eml</a></td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type CaptureController struct {
+	captureService *service.CaptureService
+}
+
+// NewCaptureController constructs the HTTP captured email controller.
+func NewCaptureController(captureService *service.CaptureService) *CaptureController {
+	return &CaptureController{captureService: captureService}
+}
+
+// Inbox renders the web inbox of captured emails.
+func (c *CaptureController) Inbox(ctx echo.Context) error {
+	emails, err := c.captureService.List("")
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list captured emails")
+		return ctx.String(http.StatusInternalServerError, "failed to list captured emails")
+	}
+
+	summaries := make([]dto.CapturedEmailSummaryResponse, 0, len(emails))
+	for _, email := range emails {
+		summaries = append(summaries, dto.NewCapturedEmailSummaryResponse(email))
+	}
+	ctx.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	ctx.Response().WriteHeader(http.StatusOK)
+	return captureInbox.Execute(ctx.Response(), summaries)
+}
+
+// List returns the captured emails, newest first, optionally filtered by recipient.
+func (c *CaptureController) List(ctx echo.Context) error {
+	emails, err := c.captureService.List(ctx.QueryParam("recipient"))
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list captured emails")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list captured emails"})
+	}
+
+	resp := dto.ListCapturedEmailsResponse{Messages: make([]dto.CapturedEmailSummaryResponse, 0, len(emails))}
+	for _, email := range emails {
+		resp.Messages = append(resp.Messages, dto.NewCapturedEmailSummaryResponse(email))
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// Get returns a captured email with its decoded bodies.
+func (c *CaptureController) Get(ctx echo.Context) error {
+	id := ctx.Param("id")
+	email, err := c.captureService.Get(id)
+	if err != nil {
+		return c.getError(ctx, id, err)
+	}
+	return ctx.JSON(http.StatusOK, dto.NewCapturedEmailResponse(*email))
+}
+
+// HTML renders the body of a captured email, falling back to its text body. The page is
+// sandboxed so scripts in the email do not run.
+func (c *CaptureController) HTML(ctx echo.Context) error {
+	id := ctx.Param("id")
+	email, err := c.captureService.Get(id)
+	if err != nil {
+		return c.getError(ctx, id, err)
+	}
+
+	content := dto.NewCapturedEmailResponse(*email)
+	body := content.HTML
+	if body == "" {
+		body = "<pre>" + html.EscapeString(content.Text) + "</pre>"
+	}
+	ctx.Response().Header().Set("Content-Security-Policy", "sandbox")
+	return ctx.HTML(http.StatusOK, body)
+}
+
+// Raw downloads a captured email as an .eml file.
+func (c *CaptureController) Raw(ctx echo.Context) error {
+	id := ctx.Param("id")
+	email, err := c.captureService.Get(id)
+	if err != nil {
+		return c.getError(ctx, id, err)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", email.ID+".eml"))
+	return ctx.Blob(http.StatusOK, "message/rfc822", email.Raw)
+}
+
+// Clear removes every captured email.
+func (c *CaptureController) Clear(ctx echo.Context) error {
+	if err := c.captureService.Clear(); err != nil {
+		logrus.WithError(err).Error("Failed to clear captured emails")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to clear captured emails"})
+	}
+
+	logrus.Info("Captured emails cleared (http)")
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c *CaptureController) getError(ctx echo.Context, id string, err error) error {
+	if errors.Is(err, service.ErrCapturedEmailNotFound) {
+		return ctx.JSON(http.StatusNotFound, map[string]string{"error": "captured email not found"})
+	}
+	logrus.WithError(err).WithField("id", id).Error("Failed to load captured email")
+	return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load captured email"})
+}
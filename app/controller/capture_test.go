@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+const capturedRawEmail = "From: noreply@example.com\r\n" +
+	"To: ana@example.com\r\n" +
+	"Subject: Welcome\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: text/html; charset=UTF-8\r\n" +
+	"\r\n" +
+	"<p>Hello Ana</p>"
+
+// newCaptureController returns a controller over a store holding one email to each
+// recipient, and the ID of the email to ana@example.com.
+func newCaptureController(t *testing.T) (*CaptureController, string) {
+	t.Helper()
+	store := provider.NewMemoryCaptureStore(0)
+	p := provider.NewCaptureProvider(store)
+	result, err := p.SendRaw(context.Background(), "ana@example.com", []byte(capturedRawEmail))
+	if err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if _, err := p.SendRaw(context.Background(), "bob@example.com", []byte(capturedRawEmail)); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	return NewCaptureController(service.NewCaptureService(store)), result.MessageID
+}
+
+func TestCaptureControllerList(t *testing.T) {
+	t.Parallel()
+
+	ctrl, id := newCaptureController(t)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/capture/messages?recipient=ANA@example.com", nil)
+	rec := httptest.NewRecorder()
+
+	if err := ctrl.List(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got dto.ListCapturedEmailsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(got.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %+v", got.Messages)
+	}
+	msg := got.Messages[0]
+	if msg.ID != id || msg.Recipient != "ana@example.com" || msg.Subject != "Welcome" || msg.Size != len(capturedRawEmail) {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
+
+func TestCaptureControllerViews(t *testing.T) {
+	t.Parallel()
+
+	ctrl, id := newCaptureController(t)
+	e := echo.New()
+	tests := []struct {
+		name        string
+		handler     echo.HandlerFunc
+		contentType string
+		body        string
+	}{
+		{name: "html", handler: ctrl.HTML, contentType: echo.MIMETextHTMLCharsetUTF8, body: "<p>Hello Ana</p>"},
+		{name: "raw", handler: ctrl.Raw, contentType: "message/rfc822", body: capturedRawEmail},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/capture/messages/"+id+"/"+tc.name, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+
+		if err := tc.handler(c); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if rec.Code != http.StatusOK || rec.Header().Get(echo.HeaderContentType) != tc.contentType || rec.Body.String() != tc.body {
+			t.Fatalf("%s: unexpected response %d %q: %q", tc.name, rec.Code, rec.Header().Get(echo.HeaderContentType), rec.Body.String())
+		}
+	}
+}
+
+func TestCaptureControllerGetNotFound(t *testing.T) {
+	t.Parallel()
+
+	ctrl, _ := newCaptureController(t)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/capture/messages/missing", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("missing")
+
+	if err := ctrl.Get(c); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestCaptureControllerClear(t *testing.T) {
+	t.Parallel()
+
+	ctrl, _ := newCaptureController(t)
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	if err := ctrl.Clear(e.NewContext(httptest.NewRequest(http.MethodDelete, "/capture/messages", nil), rec)); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	if err := ctrl.Inbox(e.NewContext(httptest.NewRequest(http.MethodGet, "/capture", nil), rec)); err != nil {
+		t.Fatalf("Inbox: %v", err)
+	}
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Captured emails (0)") {
+		t.Fatalf("unexpected inbox: %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -3,25 +3,25 @@ package controller
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 	"github.com/vibast-solutions/ms-go-notifications/app/dto"
-	"github.com/vibast-solutions/ms-go-notifications/app/queue"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
 	"github.com/vibast-solutions/ms-go-notifications/app/service"
 )
 
 type EmailController struct {
 	emailService *service.EmailService
-	producer     queue.EmailPublisher
 }
 
 // NewEmailController constructs the HTTP email controller.
-func NewEmailController(emailService *service.EmailService, producer queue.EmailPublisher) *EmailController {
-	return &EmailController{emailService: emailService, producer: producer}
+func NewEmailController(emailService *service.EmailService) *EmailController {
+	return &EmailController{emailService: emailService}
 }
 
-// SendRaw validates, stores, and enqueues an email send request.
+// SendRaw validates and stores an email send request; the outbox relay enqueues it.
 func (c *EmailController) SendRaw(ctx echo.Context) error {
 	req, err := dto.FromEchoContext(ctx)
 	if err != nil {
@@ -32,6 +32,7 @@ func (c *EmailController) SendRaw(ctx echo.Context) error {
 		logrus.WithError(err).WithFields(logrus.Fields{
 			"request_id": req.RequestID,
 			"recipient":  req.Recipient,
+			"user_id":    req.UserID,
 		}).Debug("Send raw validation failed")
 		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
@@ -39,9 +40,23 @@ func (c *EmailController) SendRaw(ctx echo.Context) error {
 	logrus.WithFields(logrus.Fields{
 		"request_id": req.RequestID,
 		"recipient":  req.Recipient,
+		"user_id":    req.UserID,
 	}).Info("Received send raw request (http)")
 
-	if err := c.emailService.CreateRequest(ctx.Request().Context(), req.RequestID, req.Recipient, req.Subject, req.Content); err != nil {
+	email := service.RawEmail{
+		Recipient: req.Recipient,
+		UserID:    req.UserID,
+		Category:  req.Category,
+		Priority:  req.Priority,
+		Timezone:  req.Timezone,
+		Subject:   req.Subject,
+		Content:   req.Content,
+		SendAt:    req.SendAt,
+		DigestKey: req.DigestKey,
+		Tenant:    req.Tenant,
+		Template:  req.Template,
+	}
+	if err := c.emailService.CreateRequest(ctx.Request().Context(), req.RequestID, email); err != nil {
 		if errors.Is(err, service.ErrDuplicateRequestID) {
 			logrus.WithField("request_id", req.RequestID).Warn("Duplicate request_id")
 			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "duplicate request_id"})
@@ -50,17 +65,55 @@ func (c *EmailController) SendRaw(ctx echo.Context) error {
 		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create email history"})
 	}
 
-	if err := c.producer.Publish(ctx.Request().Context(), queue.EmailMessage{
-		RequestID: req.RequestID,
-		Recipient: req.Recipient,
-		Subject:   req.Subject,
-		Content:   req.Content,
-	}); err != nil {
-		_ = c.emailService.DeleteRequest(ctx.Request().Context(), req.RequestID)
-		logrus.WithError(err).WithField("request_id", req.RequestID).Error("Failed to queue email")
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to queue email"})
+	if email.Digested() {
+		logrus.WithFields(logrus.Fields{
+			"request_id": req.RequestID,
+			"digest_key": req.DigestKey,
+		}).Info("Email request held for digest (http)")
+		return ctx.JSON(http.StatusOK, map[string]string{"message": "email accepted"})
 	}
 
 	logrus.WithField("request_id", req.RequestID).Info("Email request queued (http)")
 	return ctx.JSON(http.StatusOK, map[string]string{"message": "email accepted"})
 }
+
+// Attempts returns the status of an email and the timeline of its send attempts.
+func (c *EmailController) Attempts(ctx echo.Context) error {
+	requestID := strings.TrimSpace(ctx.Param("request_id"))
+	if requestID == "" {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": dto.ErrMissingRequestID.Error()})
+	}
+
+	status, attempts, err := c.emailService.Attempts(ctx.Request().Context(), requestID)
+	if err != nil {
+		if errors.Is(err, service.ErrEmailNotFound) {
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "email not found"})
+		}
+		logrus.WithError(err).WithField("request_id", requestID).Error("Failed to load email attempts")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load email attempts"})
+	}
+
+	return ctx.JSON(http.StatusOK, dto.NewEmailAttemptsResponse(requestID, status, attempts))
+}
+
+// Cancel stops a scheduled, deferred, or not yet processed email.
+func (c *EmailController) Cancel(ctx echo.Context) error {
+	requestID := strings.TrimSpace(ctx.Param("request_id"))
+	if requestID == "" {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": dto.ErrMissingRequestID.Error()})
+	}
+
+	if err := c.emailService.Cancel(ctx.Request().Context(), requestID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailNotFound):
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "email not found"})
+		case errors.Is(err, service.ErrEmailNotCancellable):
+			return ctx.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		logrus.WithError(err).WithField("request_id", requestID).Error("Failed to cancel email")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to cancel email"})
+	}
+
+	logrus.WithField("request_id", requestID).Info("Email cancelled (http)")
+	return ctx.JSON(http.StatusOK, map[string]any{"request_id": requestID, "status": entity.EmailStatusCancelled})
+}
@@ -3,7 +3,8 @@ package controller
 import (
 	"bytes"
 	"context"
-	"errors"
+	"database/sql"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,8 +13,9 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-sql-driver/mysql"
 	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
 	"github.com/vibast-solutions/ms-go-notifications/app/entity"
-	"github.com/vibast-solutions/ms-go-notifications/app/queue"
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
 	"github.com/vibast-solutions/ms-go-notifications/app/repository"
 	"github.com/vibast-solutions/ms-go-notifications/app/service"
 )
@@ -31,19 +33,8 @@ func (p noopPreparer) Prepare(_ context.Context, _ string, _ string, _ string) (
 
 type noopProvider struct{}
 
-func (p noopProvider) SendRaw(_ context.Context, _ string, _ []byte) error { return nil }
-
-type mockPublisher struct {
-	err      error
-	messages []queue.EmailMessage
-}
-
-func (p *mockPublisher) Publish(_ context.Context, msg queue.EmailMessage) error {
-	if p.err != nil {
-		return p.err
-	}
-	p.messages = append(p.messages, msg)
-	return nil
+func (p noopProvider) SendRaw(_ context.Context, _ string, _ []byte) (provider.SendResult, error) {
+	return provider.SendResult{}, nil
 }
 
 func TestEmailControllerSendRawSuccess(t *testing.T) {
@@ -55,13 +46,17 @@ func TestEmailControllerSendRawSuccess(t *testing.T) {
 	}
 	defer db.Close()
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO email_history").
-		WithArgs("req-1", "a@b.com", "subj", "content-long", entity.EmailStatusNew).
+		WithArgs("req-1", uint64(0), "a@b.com", "", entity.PriorityNormal, "subj", "content-long", entity.EmailStatusNew, nil, "", "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("req-1", "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), noopLocker{})
-	pub := &mockPublisher{}
-	ctrl := NewEmailController(emailService, pub)
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	ctrl := NewEmailController(emailService)
 
 	e := echo.New()
 	body := `{"request_id":"req-1","recipient":"a@b.com","subject":"subj","content":"content-long"}`
@@ -77,13 +72,6 @@ func TestEmailControllerSendRawSuccess(t *testing.T) {
 		t.Fatalf("expected 200, got %d", rec.Code)
 	}
 
-	if len(pub.messages) != 1 {
-		t.Fatalf("expected 1 published message, got %d", len(pub.messages))
-	}
-	if pub.messages[0].RequestID != "req-1" {
-		t.Fatalf("expected request_id req-1, got %s", pub.messages[0].RequestID)
-	}
-
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("expectations: %v", err)
 	}
@@ -99,13 +87,14 @@ func TestEmailControllerSendRawDuplicate(t *testing.T) {
 	defer db.Close()
 
 	mysqlErr := &mysql.MySQLError{Number: 1062}
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO email_history").
-		WithArgs("req-dup", "a@b.com", "subj", "content-long", entity.EmailStatusNew).
+		WithArgs("req-dup", uint64(0), "a@b.com", "", entity.PriorityNormal, "subj", "content-long", entity.EmailStatusNew, nil, "", "", "").
 		WillReturnError(mysqlErr)
+	mock.ExpectRollback()
 
-	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), noopLocker{})
-	pub := &mockPublisher{}
-	ctrl := NewEmailController(emailService, pub)
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	ctrl := NewEmailController(emailService)
 
 	e := echo.New()
 	body := `{"request_id":"req-dup","recipient":"a@b.com","subject":"subj","content":"content-long"}`
@@ -121,16 +110,12 @@ func TestEmailControllerSendRawDuplicate(t *testing.T) {
 		t.Fatalf("expected 400, got %d", rec.Code)
 	}
 
-	if len(pub.messages) != 0 {
-		t.Fatalf("expected 0 published messages, got %d", len(pub.messages))
-	}
-
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("expectations: %v", err)
 	}
 }
 
-func TestEmailControllerSendRawPublishFailureDeletesRequest(t *testing.T) {
+func TestEmailControllerSendRawOutboxFailureRollsBack(t *testing.T) {
 	t.Parallel()
 
 	db, mock, err := sqlmock.New()
@@ -139,16 +124,17 @@ func TestEmailControllerSendRawPublishFailureDeletesRequest(t *testing.T) {
 	}
 	defer db.Close()
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO email_history").
-		WithArgs("req-1", "a@b.com", "subj", "content-long", entity.EmailStatusNew).
+		WithArgs("req-1", uint64(0), "a@b.com", "", entity.PriorityNormal, "subj", "content-long", entity.EmailStatusNew, nil, "", "", "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectExec("DELETE FROM email_history").
-		WithArgs("req-1").
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("req-1", "").
+		WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectRollback()
 
-	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), noopLocker{})
-	pub := &mockPublisher{err: errors.New("publish failed")}
-	ctrl := NewEmailController(emailService, pub)
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	ctrl := NewEmailController(emailService)
 
 	e := echo.New()
 	body := `{"request_id":"req-1","recipient":"a@b.com","subject":"subj","content":"content-long"}`
@@ -172,9 +158,8 @@ func TestEmailControllerSendRawPublishFailureDeletesRequest(t *testing.T) {
 func TestEmailControllerSendRawValidationError(t *testing.T) {
 	t.Parallel()
 
-	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(nil), noopLocker{})
-	pub := &mockPublisher{}
-	ctrl := NewEmailController(emailService, pub)
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(nil), nil, nil, nil, noopLocker{})
+	ctrl := NewEmailController(emailService)
 
 	e := echo.New()
 	body := `{"request_id":"1","recipient":"bad","subject":"abcd","content":"long enough!"}`
@@ -194,9 +179,8 @@ func TestEmailControllerSendRawValidationError(t *testing.T) {
 func TestEmailControllerSendRawInvalidBody(t *testing.T) {
 	t.Parallel()
 
-	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(nil), noopLocker{})
-	pub := &mockPublisher{}
-	ctrl := NewEmailController(emailService, pub)
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(nil), nil, nil, nil, noopLocker{})
+	ctrl := NewEmailController(emailService)
 
 	e := echo.New()
 	body := `not json`
@@ -212,3 +196,192 @@ func TestEmailControllerSendRawInvalidBody(t *testing.T) {
 		t.Fatalf("expected 400, got %d", rec.Code)
 	}
 }
+
+func TestEmailControllerSendRawScheduled(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	sendAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("req-1", uint64(0), "a@b.com", "", entity.PriorityNormal, "subj", "content-long", entity.EmailStatusScheduled, sendAt, "", "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("req-1", "Europe/Bucharest").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	ctrl := NewEmailController(emailService)
+
+	e := echo.New()
+	body := `{"request_id":"req-1","recipient":"a@b.com","subject":"subj","content":"content-long","timezone":"Europe/Bucharest","send_at":"` + sendAt.Format(time.RFC3339) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/email/send/raw", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+
+	if err := ctrl.SendRaw(ctx); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailControllerCancelNotCancellable(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE email_history").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusSuccess))
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	ctrl := NewEmailController(emailService)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/email/req-1/cancel", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("request_id")
+	ctx.SetParamValues("req-1")
+
+	if err := ctrl.Cancel(ctx); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailControllerSendRawDigestIsHeld(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	// A digest item is recorded without an outbox entry, so it is not queued on its own.
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("req-1", uint64(7), "", "", entity.PriorityNormal, "subj", "content-long", entity.EmailStatusDigestPending, nil, "comments", "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	ctrl := NewEmailController(emailService)
+
+	e := echo.New()
+	body := `{"request_id":"req-1","user_id":7,"subject":"subj","content":"content-long","digest_key":"comments"}`
+	req := httptest.NewRequest(http.MethodPost, "/email/send/raw", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+
+	if err := ctrl.SendRaw(ctx); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailControllerAttempts(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	startedAt := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusSuccess))
+	mock.ExpectQuery("FROM email_attempts").WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "request_id", "worker", "provider", "started_at", "finished_at", "error_class", "error"}).
+			AddRow(uint64(1), "req-1", "worker-1", "ses", startedAt, startedAt.Add(1500*time.Millisecond), "throttled", "ses: throttled").
+			AddRow(uint64(2), "req-1", "worker-2", "ses", startedAt.Add(time.Minute), startedAt.Add(time.Minute+200*time.Millisecond), "", ""))
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), repository.NewEmailAttemptRepository(db), nil, nil, noopLocker{})
+	ctrl := NewEmailController(emailService)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/email/req-1/attempts", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("request_id")
+	ctx.SetParamValues("req-1")
+
+	if err := ctrl.Attempts(ctx); err != nil {
+		t.Fatalf("Attempts: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got dto.EmailAttemptsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if got.Status != entity.EmailStatusSuccess || len(got.Attempts) != 2 {
+		t.Fatalf("unexpected response: %+v", got)
+	}
+	if first := got.Attempts[0]; first.Worker != "worker-1" || first.ErrorClass != "throttled" || first.DurationMS != 1500 {
+		t.Fatalf("unexpected first attempt: %+v", first)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailControllerAttemptsNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-1").WillReturnError(sql.ErrNoRows)
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), repository.NewEmailAttemptRepository(db), nil, nil, noopLocker{})
+	ctrl := NewEmailController(emailService)
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(httptest.NewRequest(http.MethodGet, "/email/req-1/attempts", nil), rec)
+	ctx.SetParamNames("request_id")
+	ctx.SetParamValues("req-1")
+
+	if err := ctrl.Attempts(ctx); err != nil {
+		t.Fatalf("Attempts: %v", err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+// sseKeepAliveInterval keeps idle SSE connections open through proxies.
+const sseKeepAliveInterval = 15 * time.Second
+
+type InAppController struct {
+	inAppService *service.InAppService
+}
+
+// NewInAppController constructs the HTTP in-app notifications controller.
+func NewInAppController(inAppService *service.InAppService) *InAppController {
+	return &InAppController{inAppService: inAppService}
+}
+
+// Send validates, stores, and broadcasts an in-app notification.
+func (c *InAppController) Send(ctx echo.Context) error {
+	req, err := dto.SendInAppFromEchoContext(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to bind send in-app request")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"request_id": req.RequestID,
+			"user_id":    req.UserID,
+		}).Debug("Send in-app validation failed")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	notification := req.ToEntity()
+	if err := c.inAppService.Send(ctx.Request().Context(), notification); err != nil {
+		if errors.Is(err, service.ErrDuplicateRequestID) {
+			logrus.WithField("request_id", req.RequestID).Warn("Duplicate request_id")
+			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "duplicate request_id"})
+		}
+		logrus.WithError(err).WithField("request_id", req.RequestID).Error("Failed to create in-app notification")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create in-app notification"})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"request_id":      req.RequestID,
+		"notification_id": notification.ID,
+	}).Info("In-app notification created (http)")
+	return ctx.JSON(http.StatusOK, dto.NewInAppNotificationResponse(*notification))
+}
+
+// List returns a user's notifications created after the given id.
+func (c *InAppController) List(ctx echo.Context) error {
+	req, err := dto.ListInAppFromEchoContext(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid query parameters"})
+	}
+	if err := req.Validate(); err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	notifications, err := c.inAppService.List(ctx.Request().Context(), req.UserID, req.AfterID, req.Limit)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", req.UserID).Error("Failed to list in-app notifications")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list in-app notifications"})
+	}
+
+	resp := make([]dto.InAppNotificationResponse, 0, len(notifications))
+	for _, n := range notifications {
+		resp = append(resp, dto.NewInAppNotificationResponse(n))
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"notifications": resp})
+}
+
+// Stream delivers a user's notifications as Server-Sent Events until the client disconnects.
+func (c *InAppController) Stream(ctx echo.Context) error {
+	req, err := dto.ListInAppFromEchoContext(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := req.Validate(); err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	reqCtx := ctx.Request().Context()
+	events, err := c.inAppService.Subscribe(reqCtx, req.UserID, req.AfterID)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", req.UserID).Error("Failed to subscribe to in-app notifications")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to subscribe"})
+	}
+
+	w := ctx.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	logrus.WithField("user_id", req.UserID).Debug("SSE subscriber connected")
+	defer logrus.WithField("user_id", req.UserID).Debug("SSE subscriber disconnected")
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-reqCtx.Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		case n, ok := <-events:
+			if !ok {
+				return nil
+			}
+			payload, err := json.Marshal(dto.NewInAppNotificationResponse(n))
+			if err != nil {
+				logrus.WithError(err).WithField("notification_id", n.ID).Warn("Failed to encode SSE event")
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: notification\ndata: %s\n\n", n.ID, payload); err != nil {
+				return nil
+			}
+			w.Flush()
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type fakeBroker struct {
+	published []entity.InAppNotification
+	live      chan entity.InAppNotification
+}
+
+func (b *fakeBroker) Publish(_ context.Context, notification entity.InAppNotification) error {
+	b.published = append(b.published, notification)
+	return nil
+}
+
+func (b *fakeBroker) Subscribe(_ uint64) (<-chan entity.InAppNotification, func()) {
+	return b.live, func() {}
+}
+
+func TestInAppControllerSendSuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO inapp_notifications").
+		WithArgs("req-1", uint64(7), "comment", "title", "body", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	broker := &fakeBroker{}
+	ctrl := NewInAppController(service.NewInAppService(repository.NewInAppNotificationRepository(db), broker))
+
+	e := echo.New()
+	body := `{"request_id":"req-1","user_id":7,"type":"comment","title":"title","body":"body"}`
+	req := httptest.NewRequest(http.MethodPost, "/inapp/send", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if err := ctrl.Send(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"id":3`) {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+	if len(broker.published) != 1 {
+		t.Fatalf("expected 1 published notification, got %d", len(broker.published))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestInAppControllerSendValidation(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewInAppController(nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/inapp/send", bytes.NewBufferString(`{"request_id":"req-1"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if err := ctrl.Send(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestInAppControllerList(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, request_id, user_id, type, title, body, created_at FROM inapp_notifications").
+		WithArgs(uint64(7), uint64(2), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "request_id", "user_id", "type", "title", "body", "created_at"}).
+			AddRow(3, "req-3", 7, "comment", "t", "b", time.Now()))
+
+	ctrl := NewInAppController(service.NewInAppService(repository.NewInAppNotificationRepository(db), &fakeBroker{}))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/inapp/notifications?user_id=7&after_id=2&limit=10", nil)
+	rec := httptest.NewRecorder()
+
+	if err := ctrl.List(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"request_id":"req-3"`) {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestInAppControllerStreamWritesEvents(t *testing.T) {
+	t.Parallel()
+
+	broker := &fakeBroker{live: make(chan entity.InAppNotification, 1)}
+	broker.live <- entity.InAppNotification{ID: 9, RequestID: "req-9", UserID: 7, Type: "comment", Title: "t", Body: "b"}
+	close(broker.live)
+
+	ctrl := NewInAppController(service.NewInAppService(nil, broker))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/inapp/stream?user_id=7", nil)
+	rec := httptest.NewRecorder()
+
+	if err := ctrl.Stream(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if ct := rec.Header().Get(echo.HeaderContentType); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "id: 9\nevent: notification\ndata: ") || !strings.Contains(body, `"request_id":"req-9"`) {
+		t.Fatalf("unexpected SSE body: %q", body)
+	}
+}
+
+func TestInAppControllerStreamRequiresUser(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewInAppController(nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/inapp/stream", nil)
+	rec := httptest.NewRecorder()
+
+	if err := ctrl.Stream(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
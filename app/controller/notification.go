@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/notify"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type NotificationController struct {
+	notifyService *notify.Service
+}
+
+// NewNotificationController constructs the HTTP multi-channel notification controller.
+func NewNotificationController(notifyService *notify.Service) *NotificationController {
+	return &NotificationController{notifyService: notifyService}
+}
+
+// Notify validates a logical notification and sends it according to its channel policy.
+func (c *NotificationController) Notify(ctx echo.Context) error {
+	req, err := dto.NotifyFromEchoContext(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to bind notify request")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"request_id": req.RequestID,
+			"user_id":    req.UserID,
+		}).Debug("Notify validation failed")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"request_id": req.RequestID,
+		"user_id":    req.UserID,
+	}).Info("Received notify request (http)")
+
+	notification := req.ToEntity()
+	deliveries, err := c.notifyService.Notify(ctx.Request().Context(), notification)
+	if err != nil {
+		switch {
+		case errors.Is(err, notify.ErrUnsupportedChannel):
+			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		case errors.Is(err, service.ErrDuplicateRequestID):
+			logrus.WithField("request_id", req.RequestID).Warn("Duplicate request_id")
+			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "duplicate request_id"})
+		}
+		logrus.WithError(err).WithField("request_id", req.RequestID).Error("Failed to process notification")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to process notification"})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"request_id": req.RequestID,
+		"status":     notification.Status,
+	}).Info("Notification processed (http)")
+	return ctx.JSON(http.StatusOK, dto.NewNotificationResponse(*notification, deliveries))
+}
+
+// Get returns a notification and the status of each channel delivery.
+func (c *NotificationController) Get(ctx echo.Context) error {
+	requestID := strings.TrimSpace(ctx.Param("request_id"))
+	if requestID == "" {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": dto.ErrMissingNotificationID.Error()})
+	}
+
+	notification, deliveries, err := c.notifyService.Get(ctx.Request().Context(), requestID)
+	if err != nil {
+		if errors.Is(err, notify.ErrNotificationNotFound) {
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "notification not found"})
+		}
+		logrus.WithError(err).WithField("request_id", requestID).Error("Failed to load notification")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load notification"})
+	}
+
+	return ctx.JSON(http.StatusOK, dto.NewNotificationResponse(*notification, deliveries))
+}
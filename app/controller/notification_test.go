@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/notify"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+type stubChannel struct {
+	name string
+}
+
+func (c stubChannel) Name() string { return c.name }
+
+func (c stubChannel) Send(_ context.Context, n entity.Notification) (string, error) {
+	return n.RequestID + ":" + c.name, nil
+}
+
+func TestNotificationControllerNotifySuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO notifications").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO notification_deliveries").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE notifications").
+		WithArgs(entity.NotificationStatusCompleted, "n-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	svc := notify.NewService(repository.NewNotificationRepository(db), nil, stubChannel{name: entity.ChannelInApp})
+	ctrl := NewNotificationController(svc)
+
+	e := echo.New()
+	body := `{"request_id":"n-1","user_id":7,"type":"comment","payload":{"title":"t","body":"b"},"policy":{"fallback":["in_app"]}}`
+	req := httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if err := ctrl.Notify(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"reference":"n-1:in_app"`) {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestNotificationControllerNotifyUnsupportedChannel(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewNotificationController(notify.NewService(nil, nil, stubChannel{name: entity.ChannelEmail}))
+
+	e := echo.New()
+	body := `{"request_id":"n-1","user_id":7,"type":"comment","payload":{"title":"t","body":"b"},"policy":{"fallback":["sms"]}}`
+	req := httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if err := ctrl.Notify(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestNotificationControllerGetNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT request_id").WithArgs("missing").WillReturnError(sql.ErrNoRows)
+
+	ctrl := NewNotificationController(notify.NewService(repository.NewNotificationRepository(db), nil))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/notifications/missing", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("request_id")
+	ctx.SetParamValues("missing")
+
+	if err := ctrl.Get(ctx); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type PreferenceController struct {
+	preferenceService *service.PreferenceService
+}
+
+// NewPreferenceController constructs the HTTP categories and preferences controller.
+func NewPreferenceController(preferenceService *service.PreferenceService) *PreferenceController {
+	return &PreferenceController{preferenceService: preferenceService}
+}
+
+// UpsertCategory validates and stores a notification category.
+func (c *PreferenceController) UpsertCategory(ctx echo.Context) error {
+	req, err := dto.UpsertCategoryFromEchoContext(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to bind category upsert request")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	category := req.ToEntity()
+	if err := c.preferenceService.UpsertCategory(ctx.Request().Context(), category); err != nil {
+		logrus.WithError(err).WithField("category", req.Name).Error("Failed to store notification category")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store notification category"})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"category":      category.Name,
+		"transactional": category.Transactional,
+	}).Info("Notification category stored (http)")
+	return ctx.JSON(http.StatusOK, dto.NewCategoryResponse(*category))
+}
+
+// ListCategories returns all notification categories.
+func (c *PreferenceController) ListCategories(ctx echo.Context) error {
+	categories, err := c.preferenceService.ListCategories(ctx.Request().Context())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list notification categories")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list notification categories"})
+	}
+
+	resp := make([]dto.CategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		resp = append(resp, dto.NewCategoryResponse(category))
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"categories": resp})
+}
+
+// Get returns a user's effective preferences for every category.
+func (c *PreferenceController) Get(ctx echo.Context) error {
+	userID, err := dto.UserIDFromEchoParam(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	preferences, err := c.preferenceService.Get(ctx.Request().Context(), userID)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", userID).Error("Failed to load notification preferences")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load notification preferences"})
+	}
+
+	return ctx.JSON(http.StatusOK, dto.NewPreferencesResponse(userID, preferences))
+}
+
+// Update stores a user's preferences and returns the resulting effective preferences.
+func (c *PreferenceController) Update(ctx echo.Context) error {
+	req, err := dto.UpdatePreferencesFromEchoContext(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to bind preferences update request")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := c.preferenceService.Update(ctx.Request().Context(), req.UserID, req.ToEntities()); err != nil {
+		if errors.Is(err, service.ErrUnknownCategory) || errors.Is(err, service.ErrTransactionalCategory) {
+			return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		logrus.WithError(err).WithField("user_id", req.UserID).Error("Failed to store notification preferences")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store notification preferences"})
+	}
+	logrus.WithField("user_id", req.UserID).Info("Notification preferences updated (http)")
+
+	preferences, err := c.preferenceService.Get(ctx.Request().Context(), req.UserID)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", req.UserID).Error("Failed to load notification preferences")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load notification preferences"})
+	}
+	return ctx.JSON(http.StatusOK, dto.NewPreferencesResponse(req.UserID, preferences))
+}
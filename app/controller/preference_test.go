@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+func TestPreferenceControllerUpdateSuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	categoryColumns := []string{"name", "description", "transactional", "updated_at"}
+	mock.ExpectQuery("FROM notification_categories").WithArgs("marketing").
+		WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow("marketing", "", false, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO notification_preferences").
+		WithArgs(uint64(7), "marketing", entity.ChannelEmail, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("FROM notification_categories").
+		WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow("marketing", "", false, time.Now()))
+	mock.ExpectQuery("FROM notification_preferences").WithArgs(uint64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "category", "channel", "enabled"}).
+			AddRow(uint64(7), "marketing", entity.ChannelEmail, false))
+
+	ctrl := NewPreferenceController(service.NewPreferenceService(repository.NewPreferenceRepository(db)))
+
+	e := echo.New()
+	body := `{"preferences":[{"category":"marketing","channel":"email","enabled":false}]}`
+	req := httptest.NewRequest(http.MethodPut, "/preferences/7", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("user_id")
+	ctx.SetParamValues("7")
+
+	if err := ctrl.Update(ctx); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"channels":{"email":false,"in_app":true}`) {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestPreferenceControllerUpdateUnknownCategory(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM notification_categories").WithArgs("nope").WillReturnError(sql.ErrNoRows)
+
+	ctrl := NewPreferenceController(service.NewPreferenceService(repository.NewPreferenceRepository(db)))
+
+	e := echo.New()
+	body := `{"preferences":[{"category":"nope","channel":"email","enabled":false}]}`
+	req := httptest.NewRequest(http.MethodPut, "/preferences/7", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("user_id")
+	ctx.SetParamValues("7")
+
+	if err := ctrl.Update(ctx); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestPreferenceControllerUpsertCategoryValidationError(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewPreferenceController(service.NewPreferenceService(repository.NewPreferenceRepository(nil)))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPut, "/categories/Bad%20Name", bytes.NewBufferString(`{}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("name")
+	ctx.SetParamValues("Bad Name")
+
+	if err := ctrl.UpsertCategory(ctx); err != nil {
+		t.Fatalf("UpsertCategory: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type ProfileController struct {
+	profileService *service.ProfileService
+}
+
+// NewProfileController constructs the HTTP recipient profile controller.
+func NewProfileController(profileService *service.ProfileService) *ProfileController {
+	return &ProfileController{profileService: profileService}
+}
+
+// Upsert validates and stores a user's recipient profile.
+func (c *ProfileController) Upsert(ctx echo.Context) error {
+	req, err := dto.UpsertProfileFromEchoContext(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to bind profile upsert request")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		logrus.WithError(err).WithField("user_id", req.UserID).Debug("Profile upsert validation failed")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	profile := req.ToEntity()
+	if err := c.profileService.Upsert(ctx.Request().Context(), profile); err != nil {
+		logrus.WithError(err).WithField("user_id", req.UserID).Error("Failed to store recipient profile")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store recipient profile"})
+	}
+
+	logrus.WithField("user_id", req.UserID).Info("Recipient profile stored (http)")
+	return ctx.JSON(http.StatusOK, dto.NewProfileResponse(*profile))
+}
+
+// Get returns a user's recipient profile.
+func (c *ProfileController) Get(ctx echo.Context) error {
+	userID, err := dto.UserIDFromEchoParam(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	profile, err := c.profileService.Get(ctx.Request().Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "recipient profile not found"})
+		}
+		logrus.WithError(err).WithField("user_id", userID).Error("Failed to load recipient profile")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load recipient profile"})
+	}
+
+	return ctx.JSON(http.StatusOK, dto.NewProfileResponse(*profile))
+}
+
+// Delete removes a user's recipient profile.
+func (c *ProfileController) Delete(ctx echo.Context) error {
+	userID, err := dto.UserIDFromEchoParam(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := c.profileService.Delete(ctx.Request().Context(), userID); err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "recipient profile not found"})
+		}
+		logrus.WithError(err).WithField("user_id", userID).Error("Failed to delete recipient profile")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete recipient profile"})
+	}
+
+	logrus.WithField("user_id", userID).Info("Recipient profile deleted (http)")
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+func TestProfileControllerUpsertSuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO recipient_profiles").
+		WithArgs(uint64(7), "a@b.com", "", "en", "UTC", `[]`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctrl := NewProfileController(service.NewProfileService(repository.NewRecipientProfileRepository(db)))
+
+	e := echo.New()
+	body := `{"email":"a@b.com","locale":"en","timezone":"UTC"}`
+	req := httptest.NewRequest(http.MethodPut, "/profiles/7", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("user_id")
+	ctx.SetParamValues("7")
+
+	if err := ctrl.Upsert(ctx); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"email":"a@b.com"`) {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestProfileControllerUpsertValidationError(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewProfileController(service.NewProfileService(repository.NewRecipientProfileRepository(nil)))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPut, "/profiles/7", bytes.NewBufferString(`{"phone":"123"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("user_id")
+	ctx.SetParamValues("7")
+
+	if err := ctrl.Upsert(ctx); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestProfileControllerDeleteNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM recipient_profiles").
+		WithArgs(uint64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctrl := NewProfileController(service.NewProfileService(repository.NewRecipientProfileRepository(db)))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodDelete, "/profiles/7", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("user_id")
+	ctx.SetParamValues("7")
+
+	if err := ctrl.Delete(ctx); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type RouteController struct {
+	routeService *service.RouteService
+}
+
+// NewRouteController constructs the HTTP provider routing controller.
+func NewRouteController(routeService *service.RouteService) *RouteController {
+	return &RouteController{routeService: routeService}
+}
+
+// DryRun shows which provider an email would be sent through, without sending it.
+func (c *RouteController) DryRun(ctx echo.Context) error {
+	req, err := dto.DryRunRouteFromEchoContext(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to bind dry-run route request")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		logrus.WithError(err).WithField("recipient", req.Recipient).Debug("Dry-run route validation failed")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	decision := c.routeService.DryRun(provider.Route{
+		Recipient: req.Recipient,
+		Sender:    req.Sender,
+		Category:  req.Category,
+		Tenant:    req.Tenant,
+	})
+	return ctx.JSON(http.StatusOK, dto.DryRunRouteResponse{
+		Provider: decision.Provider,
+		Rule:     decision.Rule,
+		Matched:  decision.Matched,
+	})
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+func newRouteController(t *testing.T) *RouteController {
+	t.Helper()
+	router, err := provider.NewRouter([]provider.RoutingRule{
+		{Name: "microsoft", Match: provider.RouteMatch{Domains: []string{"outlook.com"}}, Provider: "smtp"},
+	}, "ses", "noreply@example.com")
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return NewRouteController(service.NewRouteService(router))
+}
+
+func TestRouteControllerDryRun(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newRouteController(t)
+	tests := []struct {
+		body string
+		want dto.DryRunRouteResponse
+	}{
+		{body: `{"recipient":"ana@outlook.com"}`, want: dto.DryRunRouteResponse{Provider: "smtp", Rule: "microsoft", Matched: true}},
+		{body: `{"recipient":"ana@b.com","category":"marketing"}`, want: dto.DryRunRouteResponse{Provider: "ses"}},
+	}
+	for _, tc := range tests {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/email/route/dry-run", strings.NewReader(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		if err := ctrl.DryRun(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("DryRun: %v", err)
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var got dto.DryRunRouteResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if got != tc.want {
+			t.Fatalf("expected %+v, got %+v", tc.want, got)
+		}
+	}
+}
+
+func TestRouteControllerDryRunValidationError(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/email/route/dry-run", strings.NewReader(`{"recipient":"bad"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if err := newRouteController(t).DryRun(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("DryRun: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type ScheduleController struct {
+	scheduleService *service.ScheduleService
+}
+
+// NewScheduleController constructs the HTTP recurring schedule controller.
+func NewScheduleController(scheduleService *service.ScheduleService) *ScheduleController {
+	return &ScheduleController{scheduleService: scheduleService}
+}
+
+// Upsert validates and stores a recurring email schedule.
+func (c *ScheduleController) Upsert(ctx echo.Context) error {
+	req, err := dto.UpsertScheduleFromEchoContext(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to bind schedule upsert request")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		logrus.WithError(err).WithField("schedule", req.Name).Debug("Schedule upsert validation failed")
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	schedule, err := c.scheduleService.Upsert(ctx.Request().Context(), req.ToEntity())
+	if err != nil {
+		logrus.WithError(err).WithField("schedule", req.Name).Error("Failed to store email schedule")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store email schedule"})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"schedule":    schedule.Name,
+		"next_run_at": schedule.NextRunAt,
+	}).Info("Email schedule stored (http)")
+	return ctx.JSON(http.StatusOK, dto.NewScheduleResponse(*schedule))
+}
+
+// Get returns a recurring email schedule.
+func (c *ScheduleController) Get(ctx echo.Context) error {
+	name, err := dto.ScheduleNameFromEchoParam(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	schedule, err := c.scheduleService.Get(ctx.Request().Context(), name)
+	if err != nil {
+		if errors.Is(err, service.ErrScheduleNotFound) {
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "email schedule not found"})
+		}
+		logrus.WithError(err).WithField("schedule", name).Error("Failed to load email schedule")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load email schedule"})
+	}
+
+	return ctx.JSON(http.StatusOK, dto.NewScheduleResponse(*schedule))
+}
+
+// List returns all recurring email schedules.
+func (c *ScheduleController) List(ctx echo.Context) error {
+	schedules, err := c.scheduleService.List(ctx.Request().Context())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list email schedules")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list email schedules"})
+	}
+
+	resp := make([]dto.ScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		resp = append(resp, dto.NewScheduleResponse(schedule))
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{"schedules": resp})
+}
+
+// Delete removes a recurring email schedule.
+func (c *ScheduleController) Delete(ctx echo.Context) error {
+	name, err := dto.ScheduleNameFromEchoParam(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := c.scheduleService.Delete(ctx.Request().Context(), name); err != nil {
+		if errors.Is(err, service.ErrScheduleNotFound) {
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "email schedule not found"})
+		}
+		logrus.WithError(err).WithField("schedule", name).Error("Failed to delete email schedule")
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete email schedule"})
+	}
+
+	logrus.WithField("schedule", name).Info("Email schedule deleted (http)")
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+var scheduleColumns = []string{
+	"id", "name", "cron_expr", "timezone", "category", "priority", "subject", "content",
+	"recipients", "enabled", "last_run_at", "next_run_at", "updated_at",
+}
+
+func TestScheduleControllerUpsertSuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	next := time.Date(2030, 1, 7, 7, 0, 0, 0, time.UTC)
+	mock.ExpectExec("INSERT INTO email_schedules").
+		WithArgs("weekly", "0 9 * * MON", "Europe/Bucharest", "", entity.PriorityNormal, "Weekly digest", "What happened this week.",
+			`{"user_ids":[7]}`, true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("FROM email_schedules WHERE name").WithArgs("weekly").
+		WillReturnRows(sqlmock.NewRows(scheduleColumns).AddRow(
+			uint64(1), "weekly", "0 9 * * MON", "Europe/Bucharest", "", entity.PriorityNormal, "Weekly digest", "What happened this week.",
+			`{"user_ids":[7]}`, true, nil, next, time.Now()))
+
+	ctrl := NewScheduleController(service.NewScheduleService(repository.NewEmailScheduleRepository(db)))
+
+	e := echo.New()
+	body := `{"cron":"0 9 * * MON","timezone":"Europe/Bucharest","subject":"Weekly digest","content":"What happened this week.","recipients":{"user_ids":[7]}}`
+	req := httptest.NewRequest(http.MethodPut, "/schedules/weekly", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("name")
+	ctx.SetParamValues("weekly")
+
+	if err := ctrl.Upsert(ctx); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"next_run_at":"2030-01-07T07:00:00Z"`) {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestScheduleControllerUpsertInvalidCron(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewScheduleController(service.NewScheduleService(repository.NewEmailScheduleRepository(nil)))
+
+	e := echo.New()
+	body := `{"cron":"whenever","subject":"Weekly digest","content":"What happened this week.","recipients":{"all_profiles":true}}`
+	req := httptest.NewRequest(http.MethodPut, "/schedules/weekly", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("name")
+	ctx.SetParamValues("weekly")
+
+	if err := ctrl.Upsert(ctx); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestScheduleControllerDeleteNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM email_schedules").WithArgs("weekly").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctrl := NewScheduleController(service.NewScheduleService(repository.NewEmailScheduleRepository(db)))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodDelete, "/schedules/weekly", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("name")
+	ctx.SetParamValues("weekly")
+
+	if err := ctrl.Delete(ctx); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/lock"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+const (
+	// FlushLockKey is held by the consumer that flushes due digests.
+	FlushLockKey = "notifications:digest:flush"
+
+	groupBatchSize = 50
+	maxDigestItems = 100
+)
+
+// Flusher sends digests: once the oldest pending item of a recipient's digest group has
+// waited for the window, the group's items are rendered into one email, recorded as
+// digested into it, and the digest email is queued through the outbox like any other email.
+type Flusher struct {
+	history  *repository.EmailHistoryRepository
+	renderer *Renderer
+	locker   lock.Locker
+	window   time.Duration
+	interval time.Duration
+}
+
+// NewFlusher constructs a flusher that checks for due digests every interval.
+func NewFlusher(
+	history *repository.EmailHistoryRepository,
+	renderer *Renderer,
+	locker lock.Locker,
+	window time.Duration,
+	interval time.Duration,
+) *Flusher {
+	return &Flusher{
+		history:  history,
+		renderer: renderer,
+		locker:   locker,
+		window:   window,
+		interval: interval,
+	}
+}
+
+// Run flushes due digests every interval until the context is cancelled.
+func (f *Flusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := f.Flush(ctx); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Warn("Digest flush failed")
+		}
+	}
+}
+
+// Flush sends every due digest if no other consumer is flushing.
+func (f *Flusher) Flush(ctx context.Context) error {
+	if err := f.locker.Acquire(ctx, FlushLockKey, 5*f.interval); err != nil {
+		if errors.Is(err, lock.ErrNotAcquired) || errors.Is(err, lock.ErrAlreadyHeld) {
+			return nil
+		}
+		return fmt.Errorf("acquire digest lock: %w", err)
+	}
+	defer func() {
+		_ = f.locker.Release(context.Background(), FlushLockKey)
+	}()
+
+	groups, err := f.history.ListDueDigests(ctx, f.window, groupBatchSize)
+	if err != nil {
+		return fmt.Errorf("list due digests: %w", err)
+	}
+	for _, group := range groups {
+		if err := f.send(ctx, group); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"digest_key": group.DigestKey,
+				"user_id":    group.UserID,
+				"recipient":  group.Recipient,
+			}).Warn("Failed to send digest")
+		}
+	}
+	return nil
+}
+
+// send renders and queues one digest. Items beyond maxDigestItems stay pending for the next digest.
+func (f *Flusher) send(ctx context.Context, group entity.DigestGroup) error {
+	items, err := f.history.ListDigestItems(ctx, group, maxDigestItems)
+	if err != nil {
+		return fmt.Errorf("list digest items: %w", err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	subject, content, err := f.renderer.Render(group, items)
+	if err != nil {
+		return fmt.Errorf("render digest: %w", err)
+	}
+
+	parent := entity.EmailHistory{
+		RequestID: fmt.Sprintf("digest-%d", items[0].ID),
+		UserID:    group.UserID,
+		Recipient: group.Recipient,
+		Category:  group.Category,
+		Priority:  entity.PriorityNormal,
+		Subject:   subject,
+		Content:   content,
+		Status:    entity.EmailStatusNew,
+	}
+	itemIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.RequestID)
+	}
+	if err := f.history.CreateDigest(ctx, parent, itemIDs); err != nil {
+		return fmt.Errorf("create digest: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"request_id": parent.RequestID,
+		"digest_key": group.DigestKey,
+		"items":      len(items),
+	}).Info("Digest queued")
+	return nil
+}
@@ -0,0 +1,93 @@
+package digest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+type noopLocker struct{}
+
+func (l noopLocker) Acquire(_ context.Context, _ string, _ time.Duration) error { return nil }
+func (l noopLocker) Release(_ context.Context, _ string) error                  { return nil }
+
+func expectDueDigest(mock sqlmock.Sqlmock, digested int64) {
+	mock.ExpectQuery("FROM email_history").
+		WithArgs(entity.EmailStatusDigestPending, int64(3600), groupBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"digest_key", "user_id", "recipient", "category", "min_id"}).
+			AddRow("comments", uint64(7), "", "social", uint64(11)))
+	mock.ExpectQuery("SELECT id, request_id, subject, content, created_at").
+		WithArgs(entity.EmailStatusDigestPending, "comments", uint64(7), "", "social", maxDigestItems).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "request_id", "subject", "content", "created_at"}).
+			AddRow(uint64(11), "c-1", "New comment", "<p>First</p>", time.Now()).
+			AddRow(uint64(12), "c-2", "New reply", "<p>Second</p>", time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("digest-11", uint64(7), "", "social", entity.PriorityNormal, "2 new notifications", sqlmock.AnyArg(), entity.EmailStatusNew, nil, "", "", "").
+		WillReturnResult(sqlmock.NewResult(13, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("digest-11", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(entity.EmailStatusDigested, "digest-11", entity.EmailStatusDigestPending, "c-1", "c-2").
+		WillReturnResult(sqlmock.NewResult(0, digested))
+}
+
+func TestFlusherSendsDueDigest(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	expectDueDigest(mock, 2)
+	mock.ExpectCommit()
+
+	renderer, err := NewRenderer("", "")
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	f := NewFlusher(repository.NewEmailHistoryRepository(db), renderer, noopLocker{}, time.Hour, time.Second)
+
+	if err := f.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestFlusherRollsBackWhenItemsChanged(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	// One item was cancelled after it was listed, so the digest and its outbox entry are rolled back.
+	expectDueDigest(mock, 1)
+	mock.ExpectRollback()
+
+	renderer, err := NewRenderer("", "")
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	f := NewFlusher(repository.NewEmailHistoryRepository(db), renderer, noopLocker{}, time.Hour, time.Second)
+
+	if err := f.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
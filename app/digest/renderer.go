@@ -0,0 +1,93 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"os"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+// DefaultSubject is the subject template used when none is configured.
+const DefaultSubject = "{{.Count}} new notifications"
+
+//go:embed templates/digest.html
+var templates embed.FS
+
+// Data is passed to the digest subject and body templates.
+type Data struct {
+	DigestKey string
+	Category  string
+	Count     int
+	Items     []Item
+}
+
+// Item is one digested email. Content is the item's HTML body and is not escaped.
+type Item struct {
+	RequestID string
+	Subject   string
+	Content   htmltemplate.HTML
+	CreatedAt time.Time
+}
+
+type Renderer struct {
+	subject *texttemplate.Template
+	body    *htmltemplate.Template
+}
+
+// NewRenderer parses the subject template and the HTML body template at bodyPath;
+// empty values select the built-in templates.
+func NewRenderer(subject string, bodyPath string) (*Renderer, error) {
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	subjectTemplate, err := texttemplate.New("subject").Parse(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if bodyPath == "" {
+		body, err = templates.ReadFile("templates/digest.html")
+	} else {
+		body, err = os.ReadFile(bodyPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	bodyTemplate, err := htmltemplate.New("body").Parse(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Renderer{subject: subjectTemplate, body: bodyTemplate}, nil
+}
+
+// Render builds the digest subject and HTML content for a group's items.
+func (r *Renderer) Render(group entity.DigestGroup, items []entity.EmailHistory) (string, string, error) {
+	data := Data{
+		DigestKey: group.DigestKey,
+		Category:  group.Category,
+		Count:     len(items),
+	}
+	for _, item := range items {
+		data.Items = append(data.Items, Item{
+			RequestID: item.RequestID,
+			Subject:   item.Subject,
+			Content:   htmltemplate.HTML(item.Content),
+			CreatedAt: item.CreatedAt,
+		})
+	}
+
+	var subject, body bytes.Buffer
+	if err := r.subject.Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	if err := r.body.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+	return subject.String(), body.String(), nil
+}
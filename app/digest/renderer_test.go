@@ -0,0 +1,58 @@
+package digest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+func TestRendererDefaultTemplates(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewRenderer("", "")
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+
+	subject, content, err := r.Render(entity.DigestGroup{DigestKey: "comments"}, []entity.EmailHistory{
+		{RequestID: "c-1", Subject: "New comment", Content: "<p>First</p>"},
+		{RequestID: "c-2", Subject: "Tom & Jerry replied", Content: "<p>Second</p>"},
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if subject != "2 new notifications" {
+		t.Fatalf("unexpected subject: %q", subject)
+	}
+	if !strings.Contains(content, "<p>First</p>") || !strings.Contains(content, "Tom &amp; Jerry replied") {
+		t.Fatalf("unexpected content: %s", content)
+	}
+}
+
+func TestRendererCustomTemplates(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "digest.html")
+	if err := os.WriteFile(path, []byte(`{{range .Items}}[{{.Subject}}]{{end}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	r, err := NewRenderer("Your {{.DigestKey}} digest", path)
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	subject, content, err := r.Render(entity.DigestGroup{DigestKey: "comments"}, []entity.EmailHistory{{Subject: "a"}, {Subject: "b"}})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if subject != "Your comments digest" || content != "[a][b]" {
+		t.Fatalf("unexpected render: %q %q", subject, content)
+	}
+
+	if _, err := NewRenderer("{{.Count", ""); err == nil {
+		t.Fatalf("expected invalid subject template error")
+	}
+}
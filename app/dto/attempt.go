@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type EmailAttemptResponse struct {
+	Worker     string    `json:"worker"`
+	Provider   string    `json:"provider"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// EmailAttemptsResponse is the send attempt timeline of an email, oldest first.
+type EmailAttemptsResponse struct {
+	RequestID string                 `json:"request_id"`
+	Status    int16                  `json:"status"`
+	Attempts  []EmailAttemptResponse `json:"attempts"`
+}
+
+// NewEmailAttemptsResponse maps an email's status and attempts to the HTTP representation.
+func NewEmailAttemptsResponse(requestID string, status int16, attempts []entity.EmailAttempt) EmailAttemptsResponse {
+	resp := EmailAttemptsResponse{
+		RequestID: requestID,
+		Status:    status,
+		Attempts:  make([]EmailAttemptResponse, 0, len(attempts)),
+	}
+	for _, a := range attempts {
+		resp.Attempts = append(resp.Attempts, EmailAttemptResponse{
+			Worker:     a.Worker,
+			Provider:   a.Provider,
+			StartedAt:  a.StartedAt,
+			FinishedAt: a.FinishedAt,
+			DurationMS: a.FinishedAt.Sub(a.StartedAt).Milliseconds(),
+			ErrorClass: a.ErrorClass,
+			Error:      a.Error,
+		})
+	}
+	return resp
+}
+
+// EmailAttemptsToGRPC maps an email's status and attempts to the gRPC representation.
+func EmailAttemptsToGRPC(requestID string, status int16, attempts []entity.EmailAttempt) *types.ListEmailAttemptsResponse {
+	resp := &types.ListEmailAttemptsResponse{
+		RequestId: requestID,
+		Status:    int32(status),
+	}
+	for _, a := range attempts {
+		resp.Attempts = append(resp.Attempts, &types.EmailAttempt{
+			Worker:     a.Worker,
+			Provider:   a.Provider,
+			StartedAt:  timestamppb.New(a.StartedAt),
+			FinishedAt: timestamppb.New(a.FinishedAt),
+			ErrorClass: a.ErrorClass,
+			Error:      a.Error,
+		})
+	}
+	return resp
+}
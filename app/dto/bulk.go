@@ -0,0 +1,283 @@
+package dto
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// MaxBulkRecipients bounds the recipients of one bulk send.
+	MaxBulkRecipients = 50000
+	// MaxBatchIDLength leaves room for the per-recipient suffix derived from the batch ID.
+	MaxBatchIDLength = 48
+)
+
+var (
+	ErrMissingBulkFields     = errors.New("batch_id, subject, and content are required")
+	ErrBatchIDTooLong        = errors.New("batch_id must be at most 48 characters")
+	ErrMissingBulkRecipients = errors.New("recipients must not be empty")
+	ErrTooManyBulkRecipients = errors.New("a bulk send may have at most 50000 recipients")
+	ErrRequestIDTooLong      = errors.New("request_id must be at most 64 characters")
+	ErrMissingBatchID        = errors.New("batch_id is required")
+)
+
+type BulkRecipient struct {
+	RequestID string            `json:"request_id"`
+	Recipient string            `json:"recipient"`
+	UserID    uint64            `json:"user_id"`
+	Timezone  string            `json:"timezone"`
+	Variables map[string]string `json:"variables"`
+}
+
+type SendBulkRequest struct {
+	BatchID    string          `json:"batch_id"`
+	Category   string          `json:"category"`
+	Priority   string          `json:"priority"`
+	Subject    string          `json:"subject"`
+	Content    string          `json:"content"`
+	SendAt     time.Time       `json:"send_at"`
+	Tenant     string          `json:"tenant"`
+	Template   string          `json:"template"`
+	Recipients []BulkRecipient `json:"recipients"`
+}
+
+type BulkResultResponse struct {
+	Index     int    `json:"index"`
+	RequestID string `json:"request_id"`
+	Accepted  bool   `json:"accepted"`
+	Error     string `json:"error,omitempty"`
+}
+
+type SendBulkResponse struct {
+	BatchID  string               `json:"batch_id"`
+	Accepted int                  `json:"accepted"`
+	Rejected int                  `json:"rejected"`
+	Results  []BulkResultResponse `json:"results"`
+}
+
+type EmailBatchResponse struct {
+	BatchID    string    `json:"batch_id"`
+	Status     string    `json:"status"`
+	Total      int       `json:"total"`
+	Queued     int       `json:"queued"`
+	Sent       int       `json:"sent"`
+	Failed     int       `json:"failed"`
+	Suppressed int       `json:"suppressed"`
+	Cancelled  int       `json:"cancelled"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SendBulkFromEchoContext binds and normalizes a bulk send request from Echo.
+func SendBulkFromEchoContext(ctx echo.Context) (SendBulkRequest, error) {
+	var req SendBulkRequest
+	if err := ctx.Bind(&req); err != nil {
+		return SendBulkRequest{}, err
+	}
+	req.normalize()
+	return req, nil
+}
+
+// SendBulkFromGRPC converts and normalizes one message of a bulk send stream. Only the
+// first message's batch fields are used; every message may carry recipients.
+func SendBulkFromGRPC(req *types.SendBulkEmailRequest) SendBulkRequest {
+	if req == nil {
+		return SendBulkRequest{}
+	}
+	dto := SendBulkRequest{
+		BatchID:  req.GetBatchId(),
+		Category: req.GetCategory(),
+		Priority: req.GetPriority(),
+		Subject:  req.GetSubject(),
+		Content:  req.GetContent(),
+		Tenant:   req.GetTenant(),
+		Template: req.GetTemplate(),
+	}
+	if req.GetSendAt() != nil {
+		dto.SendAt = req.GetSendAt().AsTime()
+	}
+	for _, recipient := range req.GetRecipients() {
+		dto.Recipients = append(dto.Recipients, BulkRecipient{
+			RequestID: recipient.GetRequestId(),
+			Recipient: recipient.GetRecipient(),
+			UserID:    recipient.GetUserId(),
+			Timezone:  recipient.GetTimezone(),
+			Variables: recipient.GetVariables(),
+		})
+	}
+	dto.normalize()
+	return dto
+}
+
+// BatchIDFromEchoParam reads and normalizes the batch ID path parameter.
+func BatchIDFromEchoParam(ctx echo.Context) (string, error) {
+	return ValidateBatchID(ctx.Param("batch_id"))
+}
+
+// ValidateBatchID normalizes a batch ID and checks that it is present.
+func ValidateBatchID(batchID string) (string, error) {
+	batchID = strings.TrimSpace(batchID)
+	if batchID == "" {
+		return "", ErrMissingBatchID
+	}
+	return batchID, nil
+}
+
+// Validate checks the batch fields shared by all recipients; recipients are validated
+// one by one so that an invalid recipient only rejects itself.
+func (r *SendBulkRequest) Validate() error {
+	if r.BatchID == "" || r.Subject == "" || r.Content == "" {
+		return ErrMissingBulkFields
+	}
+	if len(r.BatchID) > MaxBatchIDLength {
+		return ErrBatchIDTooLong
+	}
+	if len(r.Subject) < 4 {
+		return ErrSubjectTooShort
+	}
+	if len(r.Content) < 11 {
+		return ErrContentTooShort
+	}
+	if len(r.Category) > 64 {
+		return ErrCategoryTooLong
+	}
+	if !isValidPriority(r.Priority) {
+		return ErrInvalidPriority
+	}
+	if !r.SendAt.IsZero() {
+		now := time.Now()
+		if !r.SendAt.After(now) {
+			return ErrSendAtInPast
+		}
+		if r.SendAt.Sub(now) > MaxSendAtHorizon {
+			return ErrSendAtTooFar
+		}
+	}
+	if len(r.Tenant) > 64 {
+		return ErrTenantTooLong
+	}
+	if len(r.Template) > 64 {
+		return ErrTemplateTooLong
+	}
+	return nil
+}
+
+// ValidateBulkRecipientCount checks the number of recipients of a whole bulk send.
+func ValidateBulkRecipientCount(count int) error {
+	if count == 0 {
+		return ErrMissingBulkRecipients
+	}
+	if count > MaxBulkRecipients {
+		return ErrTooManyBulkRecipients
+	}
+	return nil
+}
+
+// Validate checks one recipient's address and options.
+func (r *BulkRecipient) Validate() error {
+	if len(r.RequestID) > 64 {
+		return ErrRequestIDTooLong
+	}
+	if r.Recipient == "" && r.UserID == 0 {
+		return ErrMissingRecipient
+	}
+	if r.Recipient != "" {
+		if _, err := mail.ParseAddress(r.Recipient); err != nil {
+			return ErrInvalidRecipient
+		}
+	}
+	if r.Timezone != "" && !isValidTimezone(r.Timezone) {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
+// normalize trims whitespace for the batch and recipient fields.
+func (r *SendBulkRequest) normalize() {
+	r.BatchID = strings.TrimSpace(r.BatchID)
+	r.Category = strings.ToLower(strings.TrimSpace(r.Category))
+	r.Priority = normalizePriority(r.Priority)
+	r.Subject = strings.TrimSpace(r.Subject)
+	r.Content = strings.TrimSpace(r.Content)
+	r.Tenant = strings.TrimSpace(r.Tenant)
+	r.Template = strings.TrimSpace(r.Template)
+	for i := range r.Recipients {
+		r.Recipients[i].RequestID = strings.TrimSpace(r.Recipients[i].RequestID)
+		r.Recipients[i].Recipient = strings.TrimSpace(r.Recipients[i].Recipient)
+		r.Recipients[i].Timezone = strings.TrimSpace(r.Recipients[i].Timezone)
+	}
+}
+
+// NewSendBulkResponse maps bulk send results to their HTTP representation.
+func NewSendBulkResponse(batchID string, results []entity.BulkEmailResult) SendBulkResponse {
+	resp := SendBulkResponse{BatchID: batchID, Results: make([]BulkResultResponse, 0, len(results))}
+	for _, result := range results {
+		if result.Accepted {
+			resp.Accepted++
+		} else {
+			resp.Rejected++
+		}
+		resp.Results = append(resp.Results, BulkResultResponse{
+			Index:     result.Index,
+			RequestID: result.RequestID,
+			Accepted:  result.Accepted,
+			Error:     result.Error,
+		})
+	}
+	return resp
+}
+
+// SendBulkResultsToGRPC maps bulk send results to their gRPC representation.
+func SendBulkResultsToGRPC(batchID string, results []entity.BulkEmailResult) *types.SendBulkEmailResponse {
+	resp := &types.SendBulkEmailResponse{BatchId: batchID, Results: make([]*types.BulkEmailResult, 0, len(results))}
+	for _, result := range results {
+		if result.Accepted {
+			resp.Accepted++
+		} else {
+			resp.Rejected++
+		}
+		resp.Results = append(resp.Results, &types.BulkEmailResult{
+			Index:     uint32(result.Index),
+			RequestId: result.RequestID,
+			Accepted:  result.Accepted,
+			Error:     result.Error,
+		})
+	}
+	return resp
+}
+
+// NewEmailBatchResponse maps a batch entity to its HTTP representation.
+func NewEmailBatchResponse(b entity.EmailBatch) EmailBatchResponse {
+	return EmailBatchResponse{
+		BatchID:    b.BatchID,
+		Status:     b.Status,
+		Total:      b.Total,
+		Queued:     b.Queued,
+		Sent:       b.Sent,
+		Failed:     b.Failed,
+		Suppressed: b.Suppressed,
+		Cancelled:  b.Cancelled,
+		CreatedAt:  b.CreatedAt,
+	}
+}
+
+// EmailBatchToGRPC maps a batch entity to its gRPC representation.
+func EmailBatchToGRPC(b entity.EmailBatch) *types.EmailBatch {
+	return &types.EmailBatch{
+		BatchId:    b.BatchID,
+		Status:     b.Status,
+		Total:      uint32(b.Total),
+		Queued:     uint32(b.Queued),
+		Sent:       uint32(b.Sent),
+		Failed:     uint32(b.Failed),
+		Suppressed: uint32(b.Suppressed),
+		Cancelled:  uint32(b.Cancelled),
+		CreatedAt:  timestamppb.New(b.CreatedAt),
+	}
+}
@@ -0,0 +1,99 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+)
+
+func TestSendBulkRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  SendBulkRequest
+		err  error
+	}{
+		{name: "missing fields", req: SendBulkRequest{}, err: ErrMissingBulkFields},
+		{name: "long batch id", req: SendBulkRequest{BatchID: strings.Repeat("b", 49), Subject: "abcd", Content: "long enough"}, err: ErrBatchIDTooLong},
+		{name: "short subject", req: SendBulkRequest{BatchID: "b", Subject: "abc", Content: "long enough"}, err: ErrSubjectTooShort},
+		{name: "short content", req: SendBulkRequest{BatchID: "b", Subject: "abcd", Content: "short"}, err: ErrContentTooShort},
+		{name: "invalid priority", req: SendBulkRequest{BatchID: "b", Subject: "abcd", Content: "long enough", Priority: "urgent"}, err: ErrInvalidPriority},
+		{name: "send_at in past", req: SendBulkRequest{BatchID: "b", Subject: "abcd", Content: "long enough", SendAt: time.Now().Add(-time.Minute)}, err: ErrSendAtInPast},
+		{name: "valid", req: SendBulkRequest{BatchID: "b", Subject: "abcd", Content: "long enough"}, err: nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.req.Validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestBulkRecipientValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		recipient BulkRecipient
+		err       error
+	}{
+		{name: "missing recipient", recipient: BulkRecipient{}, err: ErrMissingRecipient},
+		{name: "invalid recipient", recipient: BulkRecipient{Recipient: "bad"}, err: ErrInvalidRecipient},
+		{name: "long request id", recipient: BulkRecipient{RequestID: strings.Repeat("r", 65), UserID: 7}, err: ErrRequestIDTooLong},
+		{name: "invalid timezone", recipient: BulkRecipient{UserID: 7, Timezone: "Mars/Olympus"}, err: ErrInvalidTimezone},
+		{name: "valid", recipient: BulkRecipient{Recipient: "a@b.com", Timezone: "Europe/Bucharest"}, err: nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.recipient.Validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateBulkRecipientCount(t *testing.T) {
+	t.Parallel()
+
+	if err := ValidateBulkRecipientCount(0); err != ErrMissingBulkRecipients {
+		t.Fatalf("expected ErrMissingBulkRecipients, got %v", err)
+	}
+	if err := ValidateBulkRecipientCount(MaxBulkRecipients + 1); err != ErrTooManyBulkRecipients {
+		t.Fatalf("expected ErrTooManyBulkRecipients, got %v", err)
+	}
+	if err := ValidateBulkRecipientCount(MaxBulkRecipients); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+}
+
+func TestSendBulkFromGRPCNormalizes(t *testing.T) {
+	t.Parallel()
+
+	req := &types.SendBulkEmailRequest{
+		BatchId:  " batch-1 ",
+		Category: " Marketing ",
+		Subject:  " Hi {{.name}} ",
+		Content:  " content ",
+		Recipients: []*types.BulkEmailRecipient{
+			{Recipient: " a@b.com ", Variables: map[string]string{"name": "Ana"}},
+		},
+	}
+
+	dto := SendBulkFromGRPC(req)
+	if dto.BatchID != "batch-1" || dto.Category != "marketing" || dto.Priority != "normal" || dto.Subject != "Hi {{.name}}" {
+		t.Fatalf("unexpected normalization: %+v", dto)
+	}
+	if len(dto.Recipients) != 1 || dto.Recipients[0].Recipient != "a@b.com" || dto.Recipients[0].Variables["name"] != "Ana" {
+		t.Fatalf("unexpected recipients: %+v", dto.Recipients)
+	}
+}
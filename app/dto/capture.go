@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
+)
+
+// CapturedEmailSummaryResponse lists a captured email. The parsed fields are empty when
+// the raw email cannot be parsed.
+type CapturedEmailSummaryResponse struct {
+	ID         string    `json:"id"`
+	Recipient  string    `json:"recipient"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Subject    string    `json:"subject"`
+	Size       int       `json:"size"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+type ListCapturedEmailsResponse struct {
+	Messages []CapturedEmailSummaryResponse `json:"messages"`
+}
+
+// CapturedEmailResponse is a captured email with its decoded bodies and custom headers.
+type CapturedEmailResponse struct {
+	CapturedEmailSummaryResponse
+	HTML    string            `json:"html,omitempty"`
+	Text    string            `json:"text,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// NewCapturedEmailSummaryResponse maps a captured email to its HTTP list representation.
+func NewCapturedEmailSummaryResponse(email provider.CapturedEmail) CapturedEmailSummaryResponse {
+	content, _ := email.Content()
+	return newCapturedEmailSummaryResponse(email, content)
+}
+
+// NewCapturedEmailResponse maps a captured email to its HTTP representation.
+func NewCapturedEmailResponse(email provider.CapturedEmail) CapturedEmailResponse {
+	content, _ := email.Content()
+	return CapturedEmailResponse{
+		CapturedEmailSummaryResponse: newCapturedEmailSummaryResponse(email, content),
+		HTML:                         content.HTML,
+		Text:                         content.Text,
+		Headers:                      content.Headers,
+	}
+}
+
+func newCapturedEmailSummaryResponse(email provider.CapturedEmail, content provider.CapturedContent) CapturedEmailSummaryResponse {
+	return CapturedEmailSummaryResponse{
+		ID:         email.ID,
+		Recipient:  email.Recipient,
+		From:       content.From,
+		To:         content.To,
+		Subject:    content.Subject,
+		Size:       len(email.Raw),
+		CapturedAt: email.CapturedAt,
+	}
+}
@@ -0,0 +1,179 @@
+package dto
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	DefaultInAppListLimit = 50
+	MaxInAppListLimit     = 100
+)
+
+var (
+	ErrMissingInAppFields = errors.New("request_id, user_id, type, title, and body are required")
+	ErrInAppTypeTooLong   = errors.New("type must be at most 64 characters")
+	ErrInAppTitleTooLong  = errors.New("title must be at most 255 characters")
+	ErrMissingUserID      = errors.New("user_id is required")
+	ErrInvalidLimit       = errors.New("limit must be between 1 and 100")
+	ErrInvalidLastEventID = errors.New("Last-Event-ID must be a notification id")
+)
+
+type SendInAppRequest struct {
+	RequestID string `json:"request_id"`
+	UserID    uint64 `json:"user_id"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+}
+
+type ListInAppRequest struct {
+	UserID  uint64 `query:"user_id"`
+	AfterID uint64 `query:"after_id"`
+	Limit   int    `query:"limit"`
+}
+
+type InAppNotificationResponse struct {
+	ID        uint64    `json:"id"`
+	RequestID string    `json:"request_id"`
+	UserID    uint64    `json:"user_id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SendInAppFromEchoContext binds and normalizes an in-app send request from Echo.
+func SendInAppFromEchoContext(ctx echo.Context) (SendInAppRequest, error) {
+	var req SendInAppRequest
+	if err := ctx.Bind(&req); err != nil {
+		return SendInAppRequest{}, err
+	}
+	req.normalize()
+	return req, nil
+}
+
+// SendInAppFromGRPC converts and normalizes a gRPC in-app send request.
+func SendInAppFromGRPC(req *types.SendInAppNotificationRequest) SendInAppRequest {
+	if req == nil {
+		return SendInAppRequest{}
+	}
+	dto := SendInAppRequest{
+		RequestID: req.GetRequestId(),
+		UserID:    req.GetUserId(),
+		Type:      req.GetType(),
+		Title:     req.GetTitle(),
+		Body:      req.GetBody(),
+	}
+	dto.normalize()
+	return dto
+}
+
+// Validate checks required fields and length constraints.
+func (r *SendInAppRequest) Validate() error {
+	if r.RequestID == "" || r.UserID == 0 || r.Type == "" || r.Title == "" || r.Body == "" {
+		return ErrMissingInAppFields
+	}
+	if len(r.Type) > 64 {
+		return ErrInAppTypeTooLong
+	}
+	if len(r.Title) > 255 {
+		return ErrInAppTitleTooLong
+	}
+	return nil
+}
+
+// ToEntity maps the request to an in-app notification entity.
+func (r *SendInAppRequest) ToEntity() *entity.InAppNotification {
+	return &entity.InAppNotification{
+		RequestID: r.RequestID,
+		UserID:    r.UserID,
+		Type:      r.Type,
+		Title:     r.Title,
+		Body:      r.Body,
+	}
+}
+
+// normalize trims whitespace for all string fields.
+func (r *SendInAppRequest) normalize() {
+	r.RequestID = strings.TrimSpace(r.RequestID)
+	r.Type = strings.TrimSpace(r.Type)
+	r.Title = strings.TrimSpace(r.Title)
+	r.Body = strings.TrimSpace(r.Body)
+}
+
+// ListInAppFromEchoContext binds list/stream query parameters from Echo.
+// For streams, a Last-Event-ID header takes precedence over after_id.
+func ListInAppFromEchoContext(ctx echo.Context) (ListInAppRequest, error) {
+	var req ListInAppRequest
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, &req); err != nil {
+		return ListInAppRequest{}, err
+	}
+	if lastEventID := strings.TrimSpace(ctx.Request().Header.Get("Last-Event-ID")); lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return ListInAppRequest{}, ErrInvalidLastEventID
+		}
+		req.AfterID = id
+	}
+	return req, nil
+}
+
+// ListInAppFromGRPC converts a gRPC list request.
+func ListInAppFromGRPC(req *types.ListInAppNotificationsRequest) ListInAppRequest {
+	if req == nil {
+		return ListInAppRequest{}
+	}
+	return ListInAppRequest{
+		UserID:  req.GetUserId(),
+		AfterID: req.GetAfterId(),
+		Limit:   int(req.GetLimit()),
+	}
+}
+
+// Validate checks the user and applies the default limit.
+func (r *ListInAppRequest) Validate() error {
+	if r.UserID == 0 {
+		return ErrMissingUserID
+	}
+	if r.Limit == 0 {
+		r.Limit = DefaultInAppListLimit
+	}
+	if r.Limit < 1 || r.Limit > MaxInAppListLimit {
+		return ErrInvalidLimit
+	}
+	return nil
+}
+
+// NewInAppNotificationResponse maps an entity to its HTTP representation.
+func NewInAppNotificationResponse(n entity.InAppNotification) InAppNotificationResponse {
+	return InAppNotificationResponse{
+		ID:        n.ID,
+		RequestID: n.RequestID,
+		UserID:    n.UserID,
+		Type:      n.Type,
+		Title:     n.Title,
+		Body:      n.Body,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+// InAppNotificationToGRPC maps an entity to its gRPC representation.
+func InAppNotificationToGRPC(n entity.InAppNotification) *types.InAppNotification {
+	return &types.InAppNotification{
+		Id:        n.ID,
+		RequestId: n.RequestID,
+		UserId:    n.UserID,
+		Type:      n.Type,
+		Title:     n.Title,
+		Body:      n.Body,
+		CreatedAt: timestamppb.New(n.CreatedAt),
+	}
+}
@@ -0,0 +1,110 @@
+package dto
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+)
+
+func TestSendInAppRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := SendInAppRequest{RequestID: "1", UserID: 7, Type: "comment", Title: "title", Body: "body"}
+	longType := valid
+	longType.Type = strings.Repeat("t", 65)
+	longTitle := valid
+	longTitle.Title = strings.Repeat("t", 256)
+
+	tests := []struct {
+		name string
+		req  SendInAppRequest
+		err  error
+	}{
+		{name: "missing fields", req: SendInAppRequest{}, err: ErrMissingInAppFields},
+		{name: "missing user", req: SendInAppRequest{RequestID: "1", Type: "comment", Title: "title", Body: "body"}, err: ErrMissingInAppFields},
+		{name: "long type", req: longType, err: ErrInAppTypeTooLong},
+		{name: "long title", req: longTitle, err: ErrInAppTitleTooLong},
+		{name: "valid", req: valid, err: nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.req.Validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestSendInAppFromEchoContextNormalizes(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	body := `{"request_id":" 1 ","user_id":7,"type":" comment ","title":" title ","body":" body "}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	ctx := e.NewContext(req, httptest.NewRecorder())
+
+	dto, err := SendInAppFromEchoContext(ctx)
+	if err != nil {
+		t.Fatalf("SendInAppFromEchoContext returned error: %v", err)
+	}
+	if dto.RequestID != "1" || dto.UserID != 7 || dto.Type != "comment" || dto.Title != "title" || dto.Body != "body" {
+		t.Fatalf("unexpected normalization: %+v", dto)
+	}
+}
+
+func TestSendInAppFromGRPCNormalizes(t *testing.T) {
+	t.Parallel()
+
+	dto := SendInAppFromGRPC(&types.SendInAppNotificationRequest{RequestId: " 1 ", UserId: 7, Type: " comment ", Title: " t ", Body: " b "})
+	if dto.RequestID != "1" || dto.UserID != 7 || dto.Type != "comment" || dto.Title != "t" || dto.Body != "b" {
+		t.Fatalf("unexpected normalization: %+v", dto)
+	}
+}
+
+func TestListInAppFromEchoContextLastEventID(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/?user_id=7&after_id=3", nil)
+	req.Header.Set("Last-Event-ID", "9")
+	ctx := e.NewContext(req, httptest.NewRecorder())
+
+	dto, err := ListInAppFromEchoContext(ctx)
+	if err != nil {
+		t.Fatalf("ListInAppFromEchoContext returned error: %v", err)
+	}
+	if dto.UserID != 7 || dto.AfterID != 9 {
+		t.Fatalf("unexpected request: %+v", dto)
+	}
+}
+
+func TestListInAppRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	req := ListInAppRequest{UserID: 7}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if req.Limit != DefaultInAppListLimit {
+		t.Fatalf("expected default limit, got %d", req.Limit)
+	}
+
+	missing := ListInAppRequest{}
+	if err := missing.Validate(); err != ErrMissingUserID {
+		t.Fatalf("expected ErrMissingUserID, got %v", err)
+	}
+
+	tooMany := ListInAppRequest{UserID: 7, Limit: 500}
+	if err := tooMany.Validate(); err != ErrInvalidLimit {
+		t.Fatalf("expected ErrInvalidLimit, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package dto
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MaxNotifyRequestIDLength leaves room for the per-channel suffix derived from the request ID.
+const MaxNotifyRequestIDLength = 48
+
+var (
+	ErrMissingNotifyFields      = errors.New("request_id, user_id, type, payload.title, and payload.body are required")
+	ErrNotifyRequestIDTooLong   = errors.New("request_id must be at most 48 characters")
+	ErrEmptyChannelPolicy       = errors.New("policy must list at least one channel")
+	ErrDuplicatePolicyChannel   = errors.New("policy must not list a channel more than once")
+	ErrInvalidNotifyEmail       = errors.New("email must be a valid email address")
+	ErrMissingNotificationID    = errors.New("request_id is required")
+	ErrNotificationTypeTooLong  = errors.New("type must be at most 64 characters")
+	ErrNotificationTitleTooLong = errors.New("payload.title must be at most 255 characters")
+	ErrNotificationCategoryLong = errors.New("category must be at most 64 characters")
+	ErrInvalidNotifyPriority    = errors.New("priority must be one of low, normal, or high")
+	ErrNotifyDigestKeyTooLong   = errors.New("digest_key must be at most 64 characters")
+)
+
+type NotificationPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type NotifyRequest struct {
+	RequestID string               `json:"request_id"`
+	UserID    uint64               `json:"user_id"`
+	Type      string               `json:"type"`
+	Category  string               `json:"category"`
+	Priority  string               `json:"priority"`
+	Payload   NotificationPayload  `json:"payload"`
+	Policy    entity.ChannelPolicy `json:"policy"`
+	Email     string               `json:"email"`
+	DigestKey string               `json:"digest_key"`
+}
+
+type NotificationDeliveryResponse struct {
+	Channel   string `json:"channel"`
+	Tier      string `json:"tier"`
+	Status    int16  `json:"status"`
+	Reference string `json:"reference,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type NotificationResponse struct {
+	RequestID  string                         `json:"request_id"`
+	UserID     uint64                         `json:"user_id"`
+	Type       string                         `json:"type"`
+	Category   string                         `json:"category,omitempty"`
+	Priority   string                         `json:"priority,omitempty"`
+	Status     int16                          `json:"status"`
+	Deliveries []NotificationDeliveryResponse `json:"deliveries"`
+	CreatedAt  time.Time                      `json:"created_at,omitempty"`
+}
+
+// NotifyFromEchoContext binds and normalizes a notify request from Echo.
+func NotifyFromEchoContext(ctx echo.Context) (NotifyRequest, error) {
+	var req NotifyRequest
+	if err := ctx.Bind(&req); err != nil {
+		return NotifyRequest{}, err
+	}
+	req.normalize()
+	return req, nil
+}
+
+// NotifyFromGRPC converts and normalizes a gRPC notify request.
+func NotifyFromGRPC(req *types.NotifyRequest) NotifyRequest {
+	if req == nil {
+		return NotifyRequest{}
+	}
+	dto := NotifyRequest{
+		RequestID: req.GetRequestId(),
+		UserID:    req.GetUserId(),
+		Type:      req.GetType(),
+		Category:  req.GetCategory(),
+		Priority:  req.GetPriority(),
+		Payload: NotificationPayload{
+			Title: req.GetPayload().GetTitle(),
+			Body:  req.GetPayload().GetBody(),
+		},
+		Policy: entity.ChannelPolicy{
+			Fallback: req.GetPolicy().GetFallback(),
+			Always:   req.GetPolicy().GetAlways(),
+		},
+		Email:     req.GetEmail(),
+		DigestKey: req.GetDigestKey(),
+	}
+	dto.normalize()
+	return dto
+}
+
+// Validate checks required fields and the structure of the channel policy.
+func (r *NotifyRequest) Validate() error {
+	if r.RequestID == "" || r.UserID == 0 || r.Type == "" || r.Payload.Title == "" || r.Payload.Body == "" {
+		return ErrMissingNotifyFields
+	}
+	if len(r.RequestID) > MaxNotifyRequestIDLength {
+		return ErrNotifyRequestIDTooLong
+	}
+	if len(r.Type) > 64 {
+		return ErrNotificationTypeTooLong
+	}
+	if len(r.Payload.Title) > 255 {
+		return ErrNotificationTitleTooLong
+	}
+	if len(r.Category) > 64 {
+		return ErrNotificationCategoryLong
+	}
+	if !isValidPriority(r.Priority) {
+		return ErrInvalidNotifyPriority
+	}
+	if len(r.DigestKey) > 64 {
+		return ErrNotifyDigestKeyTooLong
+	}
+	if len(r.Policy.Fallback)+len(r.Policy.Always) == 0 {
+		return ErrEmptyChannelPolicy
+	}
+	seen := make(map[string]bool)
+	for _, names := range [][]string{r.Policy.Fallback, r.Policy.Always} {
+		for _, name := range names {
+			if name == "" || seen[name] {
+				return ErrDuplicatePolicyChannel
+			}
+			seen[name] = true
+		}
+	}
+	if r.Email != "" {
+		if _, err := mail.ParseAddress(r.Email); err != nil {
+			return ErrInvalidNotifyEmail
+		}
+	}
+	return nil
+}
+
+// ToEntity maps the request to a notification entity.
+func (r *NotifyRequest) ToEntity() *entity.Notification {
+	return &entity.Notification{
+		RequestID: r.RequestID,
+		UserID:    r.UserID,
+		Type:      r.Type,
+		Category:  r.Category,
+		Priority:  r.Priority,
+		Title:     r.Payload.Title,
+		Body:      r.Payload.Body,
+		Email:     r.Email,
+		DigestKey: r.DigestKey,
+		Policy:    r.Policy,
+	}
+}
+
+// normalize trims whitespace and lowercases channel names.
+func (r *NotifyRequest) normalize() {
+	r.RequestID = strings.TrimSpace(r.RequestID)
+	r.Type = strings.TrimSpace(r.Type)
+	r.Category = strings.ToLower(strings.TrimSpace(r.Category))
+	r.Priority = normalizePriority(r.Priority)
+	r.Payload.Title = strings.TrimSpace(r.Payload.Title)
+	r.Payload.Body = strings.TrimSpace(r.Payload.Body)
+	r.Email = strings.TrimSpace(r.Email)
+	r.DigestKey = strings.TrimSpace(r.DigestKey)
+	r.Policy.Fallback = normalizeChannels(r.Policy.Fallback)
+	r.Policy.Always = normalizeChannels(r.Policy.Always)
+}
+
+// normalizeChannels trims and lowercases channel names.
+func normalizeChannels(channels []string) []string {
+	if len(channels) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		out = append(out, strings.ToLower(strings.TrimSpace(ch)))
+	}
+	return out
+}
+
+// NewNotificationResponse maps a notification and its deliveries to the HTTP representation.
+func NewNotificationResponse(n entity.Notification, deliveries []entity.NotificationDelivery) NotificationResponse {
+	resp := NotificationResponse{
+		RequestID:  n.RequestID,
+		UserID:     n.UserID,
+		Type:       n.Type,
+		Category:   n.Category,
+		Priority:   n.Priority,
+		Status:     n.Status,
+		Deliveries: make([]NotificationDeliveryResponse, 0, len(deliveries)),
+		CreatedAt:  n.CreatedAt,
+	}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, NotificationDeliveryResponse{
+			Channel:   d.Channel,
+			Tier:      d.Tier,
+			Status:    d.Status,
+			Reference: d.Reference,
+			Error:     d.Error,
+		})
+	}
+	return resp
+}
+
+// NotificationToGRPC maps a notification and its deliveries to the gRPC representation.
+func NotificationToGRPC(n entity.Notification, deliveries []entity.NotificationDelivery) *types.Notification {
+	resp := &types.Notification{
+		RequestId: n.RequestID,
+		UserId:    n.UserID,
+		Type:      n.Type,
+		Category:  n.Category,
+		Priority:  n.Priority,
+		Status:    int32(n.Status),
+	}
+	if !n.CreatedAt.IsZero() {
+		resp.CreatedAt = timestamppb.New(n.CreatedAt)
+	}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, &types.NotificationDelivery{
+			Channel:   d.Channel,
+			Tier:      d.Tier,
+			Status:    int32(d.Status),
+			Reference: d.Reference,
+			Error:     d.Error,
+		})
+	}
+	return resp
+}
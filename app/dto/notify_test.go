@@ -0,0 +1,92 @@
+package dto
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+)
+
+func TestNotifyRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := NotifyRequest{
+		RequestID: "n-1",
+		UserID:    7,
+		Type:      "comment",
+		Payload:   NotificationPayload{Title: "title", Body: "body"},
+		Policy:    entity.ChannelPolicy{Fallback: []string{"in_app"}, Always: []string{"email"}},
+	}
+	longID := valid
+	longID.RequestID = strings.Repeat("x", 49)
+	emptyPolicy := valid
+	emptyPolicy.Policy = entity.ChannelPolicy{}
+	duplicate := valid
+	duplicate.Policy = entity.ChannelPolicy{Fallback: []string{"email"}, Always: []string{"email"}}
+	badEmail := valid
+	badEmail.Email = "bad"
+
+	tests := []struct {
+		name string
+		req  NotifyRequest
+		err  error
+	}{
+		{name: "missing fields", req: NotifyRequest{}, err: ErrMissingNotifyFields},
+		{name: "long request id", req: longID, err: ErrNotifyRequestIDTooLong},
+		{name: "empty policy", req: emptyPolicy, err: ErrEmptyChannelPolicy},
+		{name: "duplicate channel", req: duplicate, err: ErrDuplicatePolicyChannel},
+		{name: "invalid email", req: badEmail, err: ErrInvalidNotifyEmail},
+		{name: "valid", req: valid, err: nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.req.Validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestNotifyFromEchoContextNormalizes(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	body := `{"request_id":" n-1 ","user_id":7,"type":" comment ","payload":{"title":" t ","body":" b "},"policy":{"fallback":[" PUSH ","in_app"],"always":["Email"]},"email":" a@b.com "}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	ctx := e.NewContext(req, httptest.NewRecorder())
+
+	dto, err := NotifyFromEchoContext(ctx)
+	if err != nil {
+		t.Fatalf("NotifyFromEchoContext returned error: %v", err)
+	}
+	if dto.RequestID != "n-1" || dto.Type != "comment" || dto.Payload.Title != "t" || dto.Email != "a@b.com" {
+		t.Fatalf("unexpected normalization: %+v", dto)
+	}
+	if dto.Policy.Fallback[0] != "push" || dto.Policy.Always[0] != "email" {
+		t.Fatalf("unexpected policy normalization: %+v", dto.Policy)
+	}
+}
+
+func TestNotifyFromGRPC(t *testing.T) {
+	t.Parallel()
+
+	dto := NotifyFromGRPC(&types.NotifyRequest{
+		RequestId: "n-1",
+		UserId:    7,
+		Type:      "comment",
+		Payload:   &types.NotificationPayload{Title: "t", Body: "b"},
+		Policy:    &types.ChannelPolicy{Fallback: []string{"in_app"}},
+	})
+	if dto.UserID != 7 || dto.Payload.Body != "b" || len(dto.Policy.Fallback) != 1 || dto.Policy.Always != nil {
+		t.Fatalf("unexpected conversion: %+v", dto)
+	}
+}
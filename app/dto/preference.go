@@ -0,0 +1,221 @@
+package dto
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+)
+
+var (
+	ErrInvalidCategoryName        = errors.New("category name must be 1-64 lowercase letters, digits, '.', '_' or '-'")
+	ErrCategoryDescriptionTooLong = errors.New("description must be at most 255 characters")
+	ErrMissingPreferences         = errors.New("preferences must list at least one entry")
+	ErrMissingPreferenceCategory  = errors.New("every preference needs a category")
+	ErrUnsupportedPrefChannel     = errors.New("preference channel must be one of: email, in_app")
+)
+
+var categoryNamePattern = regexp.MustCompile(`^[a-z0-9_.-]{1,64}$`)
+
+type UpsertCategoryRequest struct {
+	Name          string `param:"name" json:"-"`
+	Description   string `json:"description"`
+	Transactional bool   `json:"transactional"`
+}
+
+type CategoryResponse struct {
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	Transactional bool      `json:"transactional"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type PreferenceItem struct {
+	Category string `json:"category"`
+	Channel  string `json:"channel"`
+	Enabled  bool   `json:"enabled"`
+}
+
+type UpdatePreferencesRequest struct {
+	UserID      uint64           `param:"user_id" json:"-"`
+	Preferences []PreferenceItem `json:"preferences"`
+}
+
+type CategoryPreferencesResponse struct {
+	Category      string          `json:"category"`
+	Transactional bool            `json:"transactional"`
+	Channels      map[string]bool `json:"channels"`
+}
+
+type PreferencesResponse struct {
+	UserID     uint64                        `json:"user_id"`
+	Categories []CategoryPreferencesResponse `json:"categories"`
+}
+
+// UpsertCategoryFromEchoContext binds the category name path parameter and body from Echo.
+func UpsertCategoryFromEchoContext(ctx echo.Context) (UpsertCategoryRequest, error) {
+	var req UpsertCategoryRequest
+	if err := ctx.Bind(&req); err != nil {
+		return UpsertCategoryRequest{}, err
+	}
+	req.normalize()
+	return req, nil
+}
+
+// UpsertCategoryFromGRPC converts and normalizes a gRPC category upsert request.
+func UpsertCategoryFromGRPC(req *types.UpsertNotificationCategoryRequest) UpsertCategoryRequest {
+	category := req.GetCategory()
+	dto := UpsertCategoryRequest{
+		Name:          category.GetName(),
+		Description:   category.GetDescription(),
+		Transactional: category.GetTransactional(),
+	}
+	dto.normalize()
+	return dto
+}
+
+// Validate checks the category name format and description length.
+func (r *UpsertCategoryRequest) Validate() error {
+	if !categoryNamePattern.MatchString(r.Name) {
+		return ErrInvalidCategoryName
+	}
+	if len(r.Description) > 255 {
+		return ErrCategoryDescriptionTooLong
+	}
+	return nil
+}
+
+// ToEntity maps the request to a category entity.
+func (r *UpsertCategoryRequest) ToEntity() *entity.NotificationCategory {
+	return &entity.NotificationCategory{
+		Name:          r.Name,
+		Description:   r.Description,
+		Transactional: r.Transactional,
+	}
+}
+
+// normalize trims whitespace and lowercases the category name.
+func (r *UpsertCategoryRequest) normalize() {
+	r.Name = strings.ToLower(strings.TrimSpace(r.Name))
+	r.Description = strings.TrimSpace(r.Description)
+}
+
+// UpdatePreferencesFromEchoContext binds the user id path parameter and preferences body from Echo.
+func UpdatePreferencesFromEchoContext(ctx echo.Context) (UpdatePreferencesRequest, error) {
+	var req UpdatePreferencesRequest
+	if err := ctx.Bind(&req); err != nil {
+		return UpdatePreferencesRequest{}, err
+	}
+	req.normalize()
+	return req, nil
+}
+
+// UpdatePreferencesFromGRPC converts and normalizes a gRPC preferences update request.
+func UpdatePreferencesFromGRPC(req *types.UpdateNotificationPreferencesRequest) UpdatePreferencesRequest {
+	if req == nil {
+		return UpdatePreferencesRequest{}
+	}
+	dto := UpdatePreferencesRequest{UserID: req.GetUserId()}
+	for _, p := range req.GetPreferences() {
+		dto.Preferences = append(dto.Preferences, PreferenceItem{
+			Category: p.GetCategory(),
+			Channel:  p.GetChannel(),
+			Enabled:  p.GetEnabled(),
+		})
+	}
+	dto.normalize()
+	return dto
+}
+
+// Validate checks the user and that every preference targets a supported channel.
+func (r *UpdatePreferencesRequest) Validate() error {
+	if r.UserID == 0 {
+		return ErrMissingUserID
+	}
+	if len(r.Preferences) == 0 {
+		return ErrMissingPreferences
+	}
+	for _, p := range r.Preferences {
+		if p.Category == "" {
+			return ErrMissingPreferenceCategory
+		}
+		if !slices.Contains(entity.SupportedChannels, p.Channel) {
+			return ErrUnsupportedPrefChannel
+		}
+	}
+	return nil
+}
+
+// ToEntities maps the request to preference entities.
+func (r *UpdatePreferencesRequest) ToEntities() []entity.NotificationPreference {
+	preferences := make([]entity.NotificationPreference, 0, len(r.Preferences))
+	for _, p := range r.Preferences {
+		preferences = append(preferences, entity.NotificationPreference{
+			UserID:   r.UserID,
+			Category: p.Category,
+			Channel:  p.Channel,
+			Enabled:  p.Enabled,
+		})
+	}
+	return preferences
+}
+
+// normalize trims and lowercases categories and channels.
+func (r *UpdatePreferencesRequest) normalize() {
+	for i := range r.Preferences {
+		r.Preferences[i].Category = strings.ToLower(strings.TrimSpace(r.Preferences[i].Category))
+		r.Preferences[i].Channel = strings.ToLower(strings.TrimSpace(r.Preferences[i].Channel))
+	}
+}
+
+// NewCategoryResponse maps a category entity to its HTTP representation.
+func NewCategoryResponse(c entity.NotificationCategory) CategoryResponse {
+	return CategoryResponse{
+		Name:          c.Name,
+		Description:   c.Description,
+		Transactional: c.Transactional,
+		UpdatedAt:     c.UpdatedAt,
+	}
+}
+
+// CategoryToGRPC maps a category entity to its gRPC representation.
+func CategoryToGRPC(c entity.NotificationCategory) *types.NotificationCategory {
+	return &types.NotificationCategory{
+		Name:          c.Name,
+		Description:   c.Description,
+		Transactional: c.Transactional,
+	}
+}
+
+// NewPreferencesResponse maps a user's effective preferences to the HTTP representation.
+func NewPreferencesResponse(userID uint64, preferences []entity.CategoryPreferences) PreferencesResponse {
+	resp := PreferencesResponse{
+		UserID:     userID,
+		Categories: make([]CategoryPreferencesResponse, 0, len(preferences)),
+	}
+	for _, p := range preferences {
+		resp.Categories = append(resp.Categories, CategoryPreferencesResponse{
+			Category:      p.Category.Name,
+			Transactional: p.Category.Transactional,
+			Channels:      p.Channels,
+		})
+	}
+	return resp
+}
+
+// PreferencesToGRPC maps a user's effective preferences to the gRPC representation.
+func PreferencesToGRPC(preferences []entity.CategoryPreferences) []*types.CategoryPreferences {
+	resp := make([]*types.CategoryPreferences, 0, len(preferences))
+	for _, p := range preferences {
+		resp = append(resp, &types.CategoryPreferences{
+			Category: CategoryToGRPC(p.Category),
+			Channels: p.Channels,
+		})
+	}
+	return resp
+}
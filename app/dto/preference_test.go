@@ -0,0 +1,69 @@
+package dto
+
+import (
+	"testing"
+
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+)
+
+func TestUpsertCategoryRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  UpsertCategoryRequest
+		err  error
+	}{
+		{name: "missing name", req: UpsertCategoryRequest{}, err: ErrInvalidCategoryName},
+		{name: "invalid name", req: UpsertCategoryRequest{Name: "product updates"}, err: ErrInvalidCategoryName},
+		{name: "valid", req: UpsertCategoryRequest{Name: "product_updates", Transactional: false}, err: nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.req.Validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestUpdatePreferencesRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  UpdatePreferencesRequest
+		err  error
+	}{
+		{name: "missing user", req: UpdatePreferencesRequest{}, err: ErrMissingUserID},
+		{name: "empty", req: UpdatePreferencesRequest{UserID: 7}, err: ErrMissingPreferences},
+		{name: "missing category", req: UpdatePreferencesRequest{UserID: 7, Preferences: []PreferenceItem{{Channel: "email"}}}, err: ErrMissingPreferenceCategory},
+		{name: "unsupported channel", req: UpdatePreferencesRequest{UserID: 7, Preferences: []PreferenceItem{{Category: "marketing", Channel: "sms"}}}, err: ErrUnsupportedPrefChannel},
+		{name: "valid", req: UpdatePreferencesRequest{UserID: 7, Preferences: []PreferenceItem{{Category: "marketing", Channel: "email"}}}, err: nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.req.Validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestUpdatePreferencesFromGRPCNormalizes(t *testing.T) {
+	t.Parallel()
+
+	dto := UpdatePreferencesFromGRPC(&types.UpdateNotificationPreferencesRequest{
+		UserId:      7,
+		Preferences: []*types.NotificationPreference{{Category: " Marketing ", Channel: " EMAIL ", Enabled: true}},
+	})
+	if dto.UserID != 7 || dto.Preferences[0].Category != "marketing" || dto.Preferences[0].Channel != "email" || !dto.Preferences[0].Enabled {
+		t.Fatalf("unexpected normalization: %+v", dto)
+	}
+}
@@ -0,0 +1,183 @@
+package dto
+
+import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	MaxDeviceTokens      = 10
+	MaxDeviceTokenLength = 255
+)
+
+var (
+	ErrInvalidProfileEmail = errors.New("email must be a valid email address")
+	ErrInvalidPhone        = errors.New("phone must be in E.164 format")
+	ErrInvalidLocale       = errors.New("locale must be a language tag such as en or en-US")
+	ErrInvalidTimezone     = errors.New("timezone must be an IANA time zone name")
+	ErrTooManyDeviceTokens = errors.New("at most 10 device tokens are allowed")
+	ErrInvalidDeviceToken  = errors.New("device tokens must be non-empty and at most 255 characters")
+)
+
+var (
+	phonePattern  = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+	localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+)
+
+type UpsertProfileRequest struct {
+	UserID       uint64   `param:"user_id" json:"-"`
+	Email        string   `json:"email"`
+	Phone        string   `json:"phone"`
+	Locale       string   `json:"locale"`
+	Timezone     string   `json:"timezone"`
+	DeviceTokens []string `json:"device_tokens"`
+}
+
+type ProfileResponse struct {
+	UserID       uint64    `json:"user_id"`
+	Email        string    `json:"email"`
+	Phone        string    `json:"phone"`
+	Locale       string    `json:"locale"`
+	Timezone     string    `json:"timezone"`
+	DeviceTokens []string  `json:"device_tokens"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// UpsertProfileFromEchoContext binds the user id path parameter and profile body from Echo.
+func UpsertProfileFromEchoContext(ctx echo.Context) (UpsertProfileRequest, error) {
+	var req UpsertProfileRequest
+	if err := ctx.Bind(&req); err != nil {
+		return UpsertProfileRequest{}, err
+	}
+	req.normalize()
+	return req, nil
+}
+
+// UpsertProfileFromGRPC converts and normalizes a gRPC profile upsert request.
+func UpsertProfileFromGRPC(req *types.UpsertRecipientProfileRequest) UpsertProfileRequest {
+	if req == nil {
+		return UpsertProfileRequest{}
+	}
+	dto := UpsertProfileRequest{
+		UserID:       req.GetUserId(),
+		Email:        req.GetEmail(),
+		Phone:        req.GetPhone(),
+		Locale:       req.GetLocale(),
+		Timezone:     req.GetTimezone(),
+		DeviceTokens: req.GetDeviceTokens(),
+	}
+	dto.normalize()
+	return dto
+}
+
+// UserIDFromEchoParam parses the user_id path parameter.
+func UserIDFromEchoParam(ctx echo.Context) (uint64, error) {
+	userID, err := strconv.ParseUint(strings.TrimSpace(ctx.Param("user_id")), 10, 64)
+	if err != nil || userID == 0 {
+		return 0, ErrMissingUserID
+	}
+	return userID, nil
+}
+
+// Validate checks the user and the format of every contact field that is set.
+func (r *UpsertProfileRequest) Validate() error {
+	if r.UserID == 0 {
+		return ErrMissingUserID
+	}
+	if r.Email != "" {
+		if _, err := mail.ParseAddress(r.Email); err != nil || len(r.Email) > 255 {
+			return ErrInvalidProfileEmail
+		}
+	}
+	if r.Phone != "" && !phonePattern.MatchString(r.Phone) {
+		return ErrInvalidPhone
+	}
+	if r.Locale != "" && (len(r.Locale) > 35 || !localePattern.MatchString(r.Locale)) {
+		return ErrInvalidLocale
+	}
+	if r.Timezone != "" && !isValidTimezone(r.Timezone) {
+		return ErrInvalidTimezone
+	}
+	if len(r.DeviceTokens) > MaxDeviceTokens {
+		return ErrTooManyDeviceTokens
+	}
+	for _, token := range r.DeviceTokens {
+		if token == "" || len(token) > MaxDeviceTokenLength {
+			return ErrInvalidDeviceToken
+		}
+	}
+	return nil
+}
+
+// ToEntity maps the request to a recipient profile entity.
+func (r *UpsertProfileRequest) ToEntity() *entity.RecipientProfile {
+	return &entity.RecipientProfile{
+		UserID:       r.UserID,
+		Email:        r.Email,
+		Phone:        r.Phone,
+		Locale:       r.Locale,
+		Timezone:     r.Timezone,
+		DeviceTokens: r.DeviceTokens,
+	}
+}
+
+// normalize trims whitespace for all string fields.
+func (r *UpsertProfileRequest) normalize() {
+	r.Email = strings.TrimSpace(r.Email)
+	r.Phone = strings.TrimSpace(r.Phone)
+	r.Locale = strings.TrimSpace(r.Locale)
+	r.Timezone = strings.TrimSpace(r.Timezone)
+	tokens := make([]string, 0, len(r.DeviceTokens))
+	for _, token := range r.DeviceTokens {
+		tokens = append(tokens, strings.TrimSpace(token))
+	}
+	r.DeviceTokens = tokens
+}
+
+// NewProfileResponse maps a profile entity to its HTTP representation.
+func NewProfileResponse(p entity.RecipientProfile) ProfileResponse {
+	tokens := p.DeviceTokens
+	if tokens == nil {
+		tokens = []string{}
+	}
+	return ProfileResponse{
+		UserID:       p.UserID,
+		Email:        p.Email,
+		Phone:        p.Phone,
+		Locale:       p.Locale,
+		Timezone:     p.Timezone,
+		DeviceTokens: tokens,
+		UpdatedAt:    p.UpdatedAt,
+	}
+}
+
+// ProfileToGRPC maps a profile entity to its gRPC representation.
+func ProfileToGRPC(p entity.RecipientProfile) *types.RecipientProfile {
+	return &types.RecipientProfile{
+		UserId:       p.UserID,
+		Email:        p.Email,
+		Phone:        p.Phone,
+		Locale:       p.Locale,
+		Timezone:     p.Timezone,
+		DeviceTokens: p.DeviceTokens,
+		UpdatedAt:    timestamppb.New(p.UpdatedAt),
+	}
+}
+
+// isValidTimezone reports whether tz is a loadable IANA zone name.
+func isValidTimezone(tz string) bool {
+	if tz == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
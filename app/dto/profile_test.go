@@ -0,0 +1,79 @@
+package dto
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+)
+
+func TestUpsertProfileRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  UpsertProfileRequest
+		err  error
+	}{
+		{name: "missing user", req: UpsertProfileRequest{}, err: ErrMissingUserID},
+		{name: "invalid email", req: UpsertProfileRequest{UserID: 1, Email: "bad"}, err: ErrInvalidProfileEmail},
+		{name: "invalid phone", req: UpsertProfileRequest{UserID: 1, Phone: "0700 000 000"}, err: ErrInvalidPhone},
+		{name: "invalid locale", req: UpsertProfileRequest{UserID: 1, Locale: "english!"}, err: ErrInvalidLocale},
+		{name: "invalid timezone", req: UpsertProfileRequest{UserID: 1, Timezone: "Mars/Olympus"}, err: ErrInvalidTimezone},
+		{name: "too many tokens", req: UpsertProfileRequest{UserID: 1, DeviceTokens: make([]string, 11)}, err: ErrTooManyDeviceTokens},
+		{name: "empty token", req: UpsertProfileRequest{UserID: 1, DeviceTokens: []string{""}}, err: ErrInvalidDeviceToken},
+		{name: "long token", req: UpsertProfileRequest{UserID: 1, DeviceTokens: []string{strings.Repeat("a", 256)}}, err: ErrInvalidDeviceToken},
+		{name: "valid", req: UpsertProfileRequest{
+			UserID:       1,
+			Email:        "a@b.com",
+			Phone:        "+40700000000",
+			Locale:       "ro-RO",
+			Timezone:     "Europe/Bucharest",
+			DeviceTokens: []string{"tok"},
+		}, err: nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.req.Validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestUpsertProfileFromEchoContextBindsPathUserID(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	body := `{"user_id":99,"email":" a@b.com ","device_tokens":[" tok "]}`
+	req := httptest.NewRequest(http.MethodPut, "/profiles/7", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("user_id")
+	ctx.SetParamValues("7")
+
+	dto, err := UpsertProfileFromEchoContext(ctx)
+	if err != nil {
+		t.Fatalf("UpsertProfileFromEchoContext returned error: %v", err)
+	}
+	if dto.UserID != 7 || dto.Email != "a@b.com" || len(dto.DeviceTokens) != 1 || dto.DeviceTokens[0] != "tok" {
+		t.Fatalf("unexpected binding: %+v", dto)
+	}
+}
+
+func TestUpsertProfileFromGRPCNormalizes(t *testing.T) {
+	t.Parallel()
+
+	dto := UpsertProfileFromGRPC(&types.UpsertRecipientProfileRequest{UserId: 7, Timezone: " UTC "})
+	if dto.UserID != 7 || dto.Timezone != "UTC" {
+		t.Fatalf("unexpected normalization: %+v", dto)
+	}
+}
@@ -0,0 +1,84 @@
+package dto
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+)
+
+var (
+	ErrMissingRouteRecipient = errors.New("recipient is required")
+	ErrInvalidSender         = errors.New("sender must be a valid email address")
+)
+
+// DryRunRouteRequest describes an email to resolve the sending provider for.
+type DryRunRouteRequest struct {
+	Recipient string `json:"recipient"`
+	Sender    string `json:"sender"`
+	Category  string `json:"category"`
+	Tenant    string `json:"tenant"`
+}
+
+type DryRunRouteResponse struct {
+	Provider string `json:"provider"`
+	Rule     string `json:"rule,omitempty"`
+	Matched  bool   `json:"matched"`
+}
+
+// DryRunRouteFromEchoContext binds and normalizes a dry-run route request from Echo.
+func DryRunRouteFromEchoContext(ctx echo.Context) (DryRunRouteRequest, error) {
+	var req DryRunRouteRequest
+	if err := ctx.Bind(&req); err != nil {
+		return DryRunRouteRequest{}, err
+	}
+	req.normalize()
+	return req, nil
+}
+
+// DryRunRouteFromGRPC converts and normalizes a gRPC dry-run route request.
+func DryRunRouteFromGRPC(req *types.DryRunEmailRouteRequest) DryRunRouteRequest {
+	if req == nil {
+		return DryRunRouteRequest{}
+	}
+	dto := DryRunRouteRequest{
+		Recipient: req.GetRecipient(),
+		Sender:    req.GetSender(),
+		Category:  req.GetCategory(),
+		Tenant:    req.GetTenant(),
+	}
+	dto.normalize()
+	return dto
+}
+
+// Validate checks the addresses and field lengths.
+func (r *DryRunRouteRequest) Validate() error {
+	if r.Recipient == "" {
+		return ErrMissingRouteRecipient
+	}
+	if _, err := mail.ParseAddress(r.Recipient); err != nil {
+		return ErrInvalidRecipient
+	}
+	if r.Sender != "" {
+		if _, err := mail.ParseAddress(r.Sender); err != nil {
+			return ErrInvalidSender
+		}
+	}
+	if len(r.Category) > 64 {
+		return ErrCategoryTooLong
+	}
+	if len(r.Tenant) > 64 {
+		return ErrTenantTooLong
+	}
+	return nil
+}
+
+// normalize trims whitespace and lowercases the category.
+func (r *DryRunRouteRequest) normalize() {
+	r.Recipient = strings.TrimSpace(r.Recipient)
+	r.Sender = strings.TrimSpace(r.Sender)
+	r.Category = strings.ToLower(strings.TrimSpace(r.Category))
+	r.Tenant = strings.TrimSpace(r.Tenant)
+}
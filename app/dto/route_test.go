@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+)
+
+func TestDryRunRouteRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  DryRunRouteRequest
+		err  error
+	}{
+		{name: "missing recipient", req: DryRunRouteRequest{}, err: ErrMissingRouteRecipient},
+		{name: "invalid recipient", req: DryRunRouteRequest{Recipient: "bad"}, err: ErrInvalidRecipient},
+		{name: "invalid sender", req: DryRunRouteRequest{Recipient: "a@b.com", Sender: "bad"}, err: ErrInvalidSender},
+		{name: "long tenant", req: DryRunRouteRequest{Recipient: "a@b.com", Tenant: strings.Repeat("t", 65)}, err: ErrTenantTooLong},
+		{name: "valid", req: DryRunRouteRequest{Recipient: "a@b.com", Sender: "news@example.com", Category: "marketing", Tenant: "acme"}, err: nil},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.req.Validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestDryRunRouteFromGRPCNormalizes(t *testing.T) {
+	t.Parallel()
+
+	req := DryRunRouteFromGRPC(&types.DryRunEmailRouteRequest{Recipient: " a@outlook.com ", Category: " Marketing ", Tenant: " acme "})
+	if req.Recipient != "a@outlook.com" || req.Category != "marketing" || req.Tenant != "acme" {
+		t.Fatalf("unexpected normalized request: %+v", req)
+	}
+}
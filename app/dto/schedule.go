@@ -0,0 +1,252 @@
+package dto
+
+import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/robfig/cron/v3"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MaxScheduleRecipients bounds the explicit user IDs and addresses of one schedule.
+const MaxScheduleRecipients = 1000
+
+var (
+	ErrInvalidScheduleName    = errors.New("schedule name must be 1-64 lowercase letters, digits, '.', '_' or '-'")
+	ErrInvalidCronExpr        = errors.New("cron must be a five-field cron expression or descriptor such as @daily")
+	ErrMissingScheduleContent = errors.New("subject and content are required")
+	ErrMissingScheduleTarget  = errors.New("recipients must list user_ids or emails, or set all_profiles")
+	ErrTooManyScheduleTargets = errors.New("recipients may list at most 1000 user_ids and 1000 emails")
+	ErrInvalidScheduleUserID  = errors.New("recipient user_ids must be non-zero")
+	ErrInvalidScheduleEmail   = errors.New("recipient emails must be valid email addresses")
+	ErrMissingScheduleName    = errors.New("schedule name is required")
+)
+
+var scheduleNamePattern = regexp.MustCompile(`^[a-z0-9_.-]{1,64}$`)
+
+type ScheduleRecipients struct {
+	UserIDs     []uint64 `json:"user_ids"`
+	Emails      []string `json:"emails"`
+	AllProfiles bool     `json:"all_profiles"`
+}
+
+type UpsertScheduleRequest struct {
+	Name       string             `param:"name" json:"-"`
+	CronExpr   string             `json:"cron"`
+	Timezone   string             `json:"timezone"`
+	Category   string             `json:"category"`
+	Priority   string             `json:"priority"`
+	Subject    string             `json:"subject"`
+	Content    string             `json:"content"`
+	Recipients ScheduleRecipients `json:"recipients"`
+	Enabled    *bool              `json:"enabled"`
+}
+
+type ScheduleResponse struct {
+	Name       string             `json:"name"`
+	Cron       string             `json:"cron"`
+	Timezone   string             `json:"timezone"`
+	Category   string             `json:"category"`
+	Priority   string             `json:"priority"`
+	Subject    string             `json:"subject"`
+	Content    string             `json:"content"`
+	Recipients ScheduleRecipients `json:"recipients"`
+	Enabled    bool               `json:"enabled"`
+	LastRunAt  *time.Time         `json:"last_run_at"`
+	NextRunAt  time.Time          `json:"next_run_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// UpsertScheduleFromEchoContext binds the schedule name path parameter and body from Echo.
+func UpsertScheduleFromEchoContext(ctx echo.Context) (UpsertScheduleRequest, error) {
+	var req UpsertScheduleRequest
+	if err := ctx.Bind(&req); err != nil {
+		return UpsertScheduleRequest{}, err
+	}
+	req.normalize()
+	return req, nil
+}
+
+// UpsertScheduleFromGRPC converts and normalizes a gRPC schedule upsert request.
+func UpsertScheduleFromGRPC(req *types.UpsertEmailScheduleRequest) UpsertScheduleRequest {
+	schedule := req.GetSchedule()
+	dto := UpsertScheduleRequest{
+		Name:     schedule.GetName(),
+		CronExpr: schedule.GetCron(),
+		Timezone: schedule.GetTimezone(),
+		Category: schedule.GetCategory(),
+		Priority: schedule.GetPriority(),
+		Subject:  schedule.GetSubject(),
+		Content:  schedule.GetContent(),
+		Recipients: ScheduleRecipients{
+			UserIDs:     schedule.GetRecipients().GetUserIds(),
+			Emails:      schedule.GetRecipients().GetEmails(),
+			AllProfiles: schedule.GetRecipients().GetAllProfiles(),
+		},
+		Enabled: schedule.Enabled,
+	}
+	dto.normalize()
+	return dto
+}
+
+// UpsertScheduleFromCLI normalizes a schedule upsert request assembled from command flags.
+func UpsertScheduleFromCLI(req UpsertScheduleRequest) UpsertScheduleRequest {
+	req.normalize()
+	return req
+}
+
+// ScheduleNameFromEchoParam reads and normalizes the schedule name path parameter.
+func ScheduleNameFromEchoParam(ctx echo.Context) (string, error) {
+	return ValidateScheduleName(ctx.Param("name"))
+}
+
+// ValidateScheduleName normalizes a schedule name and checks that it is present.
+func ValidateScheduleName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", ErrMissingScheduleName
+	}
+	return name, nil
+}
+
+// Validate checks the name, cron expression, timezone, template, and recipient selection.
+func (r *UpsertScheduleRequest) Validate() error {
+	if !scheduleNamePattern.MatchString(r.Name) {
+		return ErrInvalidScheduleName
+	}
+	if _, err := cron.ParseStandard(r.CronExpr); err != nil {
+		return ErrInvalidCronExpr
+	}
+	if !isValidTimezone(r.Timezone) {
+		return ErrInvalidTimezone
+	}
+	if r.Subject == "" || r.Content == "" {
+		return ErrMissingScheduleContent
+	}
+	if len(r.Subject) < 4 {
+		return ErrSubjectTooShort
+	}
+	if len(r.Content) < 11 {
+		return ErrContentTooShort
+	}
+	if len(r.Category) > 64 {
+		return ErrCategoryTooLong
+	}
+	if !isValidPriority(r.Priority) {
+		return ErrInvalidPriority
+	}
+	if len(r.Recipients.UserIDs) == 0 && len(r.Recipients.Emails) == 0 && !r.Recipients.AllProfiles {
+		return ErrMissingScheduleTarget
+	}
+	if len(r.Recipients.UserIDs) > MaxScheduleRecipients || len(r.Recipients.Emails) > MaxScheduleRecipients {
+		return ErrTooManyScheduleTargets
+	}
+	for _, userID := range r.Recipients.UserIDs {
+		if userID == 0 {
+			return ErrInvalidScheduleUserID
+		}
+	}
+	for _, address := range r.Recipients.Emails {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return ErrInvalidScheduleEmail
+		}
+	}
+	return nil
+}
+
+// ToEntity maps the request to a schedule entity; schedules are enabled unless disabled explicitly.
+func (r *UpsertScheduleRequest) ToEntity() *entity.EmailSchedule {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return &entity.EmailSchedule{
+		Name:     r.Name,
+		CronExpr: r.CronExpr,
+		Timezone: r.Timezone,
+		Category: r.Category,
+		Priority: r.Priority,
+		Subject:  r.Subject,
+		Content:  r.Content,
+		Recipients: entity.ScheduleRecipients{
+			UserIDs:     r.Recipients.UserIDs,
+			Emails:      r.Recipients.Emails,
+			AllProfiles: r.Recipients.AllProfiles,
+		},
+		Enabled: enabled,
+	}
+}
+
+// normalize trims whitespace, lowercases identifiers, and applies defaults.
+func (r *UpsertScheduleRequest) normalize() {
+	r.Name = strings.ToLower(strings.TrimSpace(r.Name))
+	r.CronExpr = strings.TrimSpace(r.CronExpr)
+	r.Timezone = strings.TrimSpace(r.Timezone)
+	if r.Timezone == "" {
+		r.Timezone = "UTC"
+	}
+	r.Category = strings.ToLower(strings.TrimSpace(r.Category))
+	r.Priority = normalizePriority(r.Priority)
+	r.Subject = strings.TrimSpace(r.Subject)
+	r.Content = strings.TrimSpace(r.Content)
+	for i := range r.Recipients.Emails {
+		r.Recipients.Emails[i] = strings.TrimSpace(r.Recipients.Emails[i])
+	}
+}
+
+// NewScheduleResponse maps a schedule entity to its HTTP representation.
+func NewScheduleResponse(s entity.EmailSchedule) ScheduleResponse {
+	resp := ScheduleResponse{
+		Name:     s.Name,
+		Cron:     s.CronExpr,
+		Timezone: s.Timezone,
+		Category: s.Category,
+		Priority: s.Priority,
+		Subject:  s.Subject,
+		Content:  s.Content,
+		Recipients: ScheduleRecipients{
+			UserIDs:     s.Recipients.UserIDs,
+			Emails:      s.Recipients.Emails,
+			AllProfiles: s.Recipients.AllProfiles,
+		},
+		Enabled:   s.Enabled,
+		NextRunAt: s.NextRunAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+	if !s.LastRunAt.IsZero() {
+		lastRunAt := s.LastRunAt
+		resp.LastRunAt = &lastRunAt
+	}
+	return resp
+}
+
+// ScheduleToGRPC maps a schedule entity to its gRPC representation.
+func ScheduleToGRPC(s entity.EmailSchedule) *types.EmailSchedule {
+	enabled := s.Enabled
+	schedule := &types.EmailSchedule{
+		Name:     s.Name,
+		Cron:     s.CronExpr,
+		Timezone: s.Timezone,
+		Category: s.Category,
+		Priority: s.Priority,
+		Subject:  s.Subject,
+		Content:  s.Content,
+		Recipients: &types.EmailScheduleRecipients{
+			UserIds:     s.Recipients.UserIDs,
+			Emails:      s.Recipients.Emails,
+			AllProfiles: s.Recipients.AllProfiles,
+		},
+		Enabled:   &enabled,
+		NextRunAt: timestamppb.New(s.NextRunAt),
+	}
+	if !s.LastRunAt.IsZero() {
+		schedule.LastRunAt = timestamppb.New(s.LastRunAt)
+	}
+	return schedule
+}
@@ -0,0 +1,86 @@
+package dto
+
+import (
+	"testing"
+
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+)
+
+func validScheduleRequest() UpsertScheduleRequest {
+	return UpsertScheduleRequest{
+		Name:       "weekly-digest",
+		CronExpr:   "0 9 * * MON",
+		Timezone:   "Europe/Bucharest",
+		Priority:   "normal",
+		Subject:    "Weekly digest",
+		Content:    "Here is what happened this week.",
+		Recipients: ScheduleRecipients{UserIDs: []uint64{7}},
+	}
+}
+
+func TestUpsertScheduleRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		mutate func(r *UpsertScheduleRequest)
+		err    error
+	}{
+		{name: "valid", mutate: func(r *UpsertScheduleRequest) {}, err: nil},
+		{name: "descriptor", mutate: func(r *UpsertScheduleRequest) { r.CronExpr = "@daily" }, err: nil},
+		{name: "invalid name", mutate: func(r *UpsertScheduleRequest) { r.Name = "weekly digest" }, err: ErrInvalidScheduleName},
+		{name: "invalid cron", mutate: func(r *UpsertScheduleRequest) { r.CronExpr = "every monday" }, err: ErrInvalidCronExpr},
+		{name: "seconds field rejected", mutate: func(r *UpsertScheduleRequest) { r.CronExpr = "0 0 9 * * MON" }, err: ErrInvalidCronExpr},
+		{name: "invalid timezone", mutate: func(r *UpsertScheduleRequest) { r.Timezone = "Mars/Olympus" }, err: ErrInvalidTimezone},
+		{name: "missing content", mutate: func(r *UpsertScheduleRequest) { r.Content = "" }, err: ErrMissingScheduleContent},
+		{name: "invalid priority", mutate: func(r *UpsertScheduleRequest) { r.Priority = "urgent" }, err: ErrInvalidPriority},
+		{name: "no recipients", mutate: func(r *UpsertScheduleRequest) { r.Recipients = ScheduleRecipients{} }, err: ErrMissingScheduleTarget},
+		{name: "all profiles", mutate: func(r *UpsertScheduleRequest) { r.Recipients = ScheduleRecipients{AllProfiles: true} }, err: nil},
+		{name: "zero user id", mutate: func(r *UpsertScheduleRequest) { r.Recipients.UserIDs = []uint64{0} }, err: ErrInvalidScheduleUserID},
+		{name: "invalid email", mutate: func(r *UpsertScheduleRequest) { r.Recipients.Emails = []string{"bad"} }, err: ErrInvalidScheduleEmail},
+		{name: "too many users", mutate: func(r *UpsertScheduleRequest) { r.Recipients.UserIDs = make([]uint64, MaxScheduleRecipients+1) }, err: ErrTooManyScheduleTargets},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := validScheduleRequest()
+			tc.mutate(&req)
+			if err := req.Validate(); err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestUpsertScheduleFromGRPCDefaults(t *testing.T) {
+	t.Parallel()
+
+	req := UpsertScheduleFromGRPC(&types.UpsertEmailScheduleRequest{Schedule: &types.EmailSchedule{
+		Name:       " Weekly-Digest ",
+		Cron:       " @weekly ",
+		Subject:    "Weekly digest",
+		Content:    "Here is what happened this week.",
+		Recipients: &types.EmailScheduleRecipients{Emails: []string{" a@b.com "}},
+	}})
+
+	if req.Name != "weekly-digest" || req.CronExpr != "@weekly" {
+		t.Fatalf("unexpected normalization: %+v", req)
+	}
+	if req.Timezone != "UTC" || req.Priority != "normal" {
+		t.Fatalf("expected UTC/normal defaults, got %q/%q", req.Timezone, req.Priority)
+	}
+	if req.Recipients.Emails[0] != "a@b.com" {
+		t.Fatalf("expected trimmed email, got %q", req.Recipients.Emails[0])
+	}
+	if !req.ToEntity().Enabled {
+		t.Fatal("expected schedule enabled by default")
+	}
+
+	disabled := false
+	req = UpsertScheduleFromGRPC(&types.UpsertEmailScheduleRequest{Schedule: &types.EmailSchedule{Name: "x", Enabled: &disabled}})
+	if req.ToEntity().Enabled {
+		t.Fatal("expected explicit enabled=false to be kept")
+	}
+}
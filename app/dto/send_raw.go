@@ -4,23 +4,46 @@ import (
 	"errors"
 	"net/mail"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
 	types "github.com/vibast-solutions/ms-go-notifications/app/types"
 )
 
+// MaxSendAtHorizon bounds how far ahead an email can be scheduled.
+const MaxSendAtHorizon = 365 * 24 * time.Hour
+
 var (
-	ErrMissingFields    = errors.New("request_id, recipient, subject, and content are required")
+	ErrMissingFields    = errors.New("request_id, subject, and content are required")
+	ErrMissingRecipient = errors.New("recipient or user_id is required")
 	ErrInvalidRecipient = errors.New("recipient must be a valid email address")
 	ErrSubjectTooShort  = errors.New("subject must be at least 4 characters")
 	ErrContentTooShort  = errors.New("content must be at least 11 characters")
+	ErrCategoryTooLong  = errors.New("category must be at most 64 characters")
+	ErrInvalidPriority  = errors.New("priority must be one of low, normal, or high")
+	ErrSendAtInPast     = errors.New("send_at must be in the future")
+	ErrSendAtTooFar     = errors.New("send_at must be at most one year ahead")
+	ErrMissingRequestID = errors.New("request_id is required")
+	ErrDigestKeyTooLong = errors.New("digest_key must be at most 64 characters")
+	ErrDigestWithSendAt = errors.New("digest_key cannot be combined with send_at")
+	ErrTenantTooLong    = errors.New("tenant must be at most 64 characters")
+	ErrTemplateTooLong  = errors.New("template must be at most 64 characters")
 )
 
 type SendRawRequest struct {
-	RequestID string `json:"request_id"`
-	Recipient string `json:"recipient"`
-	Subject   string `json:"subject"`
-	Content   string `json:"content"`
+	RequestID string    `json:"request_id"`
+	Recipient string    `json:"recipient"`
+	UserID    uint64    `json:"user_id"`
+	Category  string    `json:"category"`
+	Priority  string    `json:"priority"`
+	Timezone  string    `json:"timezone"`
+	Subject   string    `json:"subject"`
+	Content   string    `json:"content"`
+	SendAt    time.Time `json:"send_at"`
+	DigestKey string    `json:"digest_key"`
+	Tenant    string    `json:"tenant"`
+	Template  string    `json:"template"`
 }
 
 // FromEchoContext binds and normalizes a request from Echo.
@@ -41,8 +64,18 @@ func FromGRPC(req *types.SendRawEmailRequest) SendRawRequest {
 	dto := SendRawRequest{
 		RequestID: req.GetRequestId(),
 		Recipient: req.GetRecipient(),
+		UserID:    req.GetUserId(),
+		Category:  req.GetCategory(),
+		Priority:  req.GetPriority(),
+		Timezone:  req.GetTimezone(),
 		Subject:   req.GetSubject(),
 		Content:   req.GetContent(),
+		DigestKey: req.GetDigestKey(),
+		Tenant:    req.GetTenant(),
+		Template:  req.GetTemplate(),
+	}
+	if req.GetSendAt() != nil {
+		dto.SendAt = req.GetSendAt().AsTime()
 	}
 	dto.normalize()
 	return dto
@@ -50,11 +83,16 @@ func FromGRPC(req *types.SendRawEmailRequest) SendRawRequest {
 
 // Validate checks required fields and format constraints.
 func (r *SendRawRequest) Validate() error {
-	if r.RequestID == "" || r.Recipient == "" || r.Subject == "" || r.Content == "" {
+	if r.RequestID == "" || r.Subject == "" || r.Content == "" {
 		return ErrMissingFields
 	}
-	if _, err := mail.ParseAddress(r.Recipient); err != nil {
-		return ErrInvalidRecipient
+	if r.Recipient == "" && r.UserID == 0 {
+		return ErrMissingRecipient
+	}
+	if r.Recipient != "" {
+		if _, err := mail.ParseAddress(r.Recipient); err != nil {
+			return ErrInvalidRecipient
+		}
 	}
 	if len(r.Subject) < 4 {
 		return ErrSubjectTooShort
@@ -62,6 +100,36 @@ func (r *SendRawRequest) Validate() error {
 	if len(r.Content) < 11 {
 		return ErrContentTooShort
 	}
+	if len(r.Category) > 64 {
+		return ErrCategoryTooLong
+	}
+	if !isValidPriority(r.Priority) {
+		return ErrInvalidPriority
+	}
+	if r.Timezone != "" && !isValidTimezone(r.Timezone) {
+		return ErrInvalidTimezone
+	}
+	if !r.SendAt.IsZero() {
+		now := time.Now()
+		if !r.SendAt.After(now) {
+			return ErrSendAtInPast
+		}
+		if r.SendAt.Sub(now) > MaxSendAtHorizon {
+			return ErrSendAtTooFar
+		}
+	}
+	if len(r.DigestKey) > 64 {
+		return ErrDigestKeyTooLong
+	}
+	if r.DigestKey != "" && !r.SendAt.IsZero() {
+		return ErrDigestWithSendAt
+	}
+	if len(r.Tenant) > 64 {
+		return ErrTenantTooLong
+	}
+	if len(r.Template) > 64 {
+		return ErrTemplateTooLong
+	}
 	return nil
 }
 
@@ -69,6 +137,34 @@ func (r *SendRawRequest) Validate() error {
 func (r *SendRawRequest) normalize() {
 	r.RequestID = strings.TrimSpace(r.RequestID)
 	r.Recipient = strings.TrimSpace(r.Recipient)
+	r.Category = strings.ToLower(strings.TrimSpace(r.Category))
+	r.Priority = normalizePriority(r.Priority)
+	r.Timezone = strings.TrimSpace(r.Timezone)
 	r.Subject = strings.TrimSpace(r.Subject)
 	r.Content = strings.TrimSpace(r.Content)
+	r.DigestKey = strings.TrimSpace(r.DigestKey)
+	r.Tenant = strings.TrimSpace(r.Tenant)
+	r.Template = strings.TrimSpace(r.Template)
+}
+
+// normalizePriority lowercases the priority and defaults it to normal.
+func normalizePriority(priority string) string {
+	priority = strings.ToLower(strings.TrimSpace(priority))
+	if priority == "" {
+		return entity.PriorityNormal
+	}
+	return priority
+}
+
+// isValidPriority reports whether priority is a supported value; empty means normal.
+func isValidPriority(priority string) bool {
+	if priority == "" {
+		return true
+	}
+	for _, p := range entity.SupportedPriorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
 }
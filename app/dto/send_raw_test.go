@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	types "github.com/vibast-solutions/ms-go-notifications/app/types"
@@ -19,10 +21,22 @@ func TestSendRawRequestValidate(t *testing.T) {
 		err  error
 	}{
 		{name: "missing fields", req: SendRawRequest{}, err: ErrMissingFields},
+		{name: "missing recipient", req: SendRawRequest{RequestID: "1", Subject: "abcd", Content: "long enough"}, err: ErrMissingRecipient},
+		{name: "user id without recipient", req: SendRawRequest{RequestID: "1", UserID: 7, Subject: "abcd", Content: "long enough"}, err: nil},
 		{name: "invalid recipient", req: SendRawRequest{RequestID: "1", Recipient: "bad", Subject: "abcd", Content: "long enough"}, err: ErrInvalidRecipient},
 		{name: "short subject", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abc", Content: "long enough"}, err: ErrSubjectTooShort},
 		{name: "short content", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abcd", Content: "short"}, err: ErrContentTooShort},
+		{name: "invalid priority", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abcd", Content: "long enough", Priority: "urgent"}, err: ErrInvalidPriority},
+		{name: "invalid timezone", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abcd", Content: "long enough", Timezone: "Mars/Olympus"}, err: ErrInvalidTimezone},
+		{name: "send_at in past", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abcd", Content: "long enough", SendAt: time.Now().Add(-time.Minute)}, err: ErrSendAtInPast},
+		{name: "send_at too far", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abcd", Content: "long enough", SendAt: time.Now().Add(2 * MaxSendAtHorizon)}, err: ErrSendAtTooFar},
 		{name: "valid", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abcd", Content: "long enough"}, err: nil},
+		{name: "valid scheduled", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abcd", Content: "long enough", SendAt: time.Now().Add(time.Hour)}, err: nil},
+		{name: "valid with priority and timezone", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abcd", Content: "long enough", Priority: "high", Timezone: "Europe/Bucharest"}, err: nil},
+		{name: "valid digest", req: SendRawRequest{RequestID: "1", UserID: 7, Subject: "abcd", Content: "long enough", DigestKey: "comments"}, err: nil},
+		{name: "digest with send_at", req: SendRawRequest{RequestID: "1", UserID: 7, Subject: "abcd", Content: "long enough", DigestKey: "comments", SendAt: time.Now().Add(time.Hour)}, err: ErrDigestWithSendAt},
+		{name: "long tenant", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abcd", Content: "long enough", Tenant: strings.Repeat("t", 65)}, err: ErrTenantTooLong},
+		{name: "long template", req: SendRawRequest{RequestID: "1", Recipient: "a@b.com", Subject: "abcd", Content: "long enough", Template: strings.Repeat("t", 65)}, err: ErrTemplateTooLong},
 	}
 
 	for _, tc := range tests {
@@ -51,7 +65,7 @@ func TestFromEchoContextNormalizes(t *testing.T) {
 	if err != nil {
 		t.Fatalf("FromEchoContext returned error: %v", err)
 	}
-	if dto.RequestID != "1" || dto.Recipient != "test@example.com" || dto.Subject != "subj" || dto.Content != "content" {
+	if dto.RequestID != "1" || dto.Recipient != "test@example.com" || dto.Subject != "subj" || dto.Content != "content" || dto.Priority != "normal" {
 		t.Fatalf("unexpected normalization: %+v", dto)
 	}
 }
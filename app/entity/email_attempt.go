@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// EmailAttempt is one provider send of an email request, by the worker that made it.
+// ErrorClass and Error are empty for a successful send; Provider is empty when a failure
+// cannot be attributed to a provider.
+type EmailAttempt struct {
+	ID         uint64
+	RequestID  string
+	Worker     string
+	Provider   string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	ErrorClass string
+	Error      string
+}
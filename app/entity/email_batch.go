@@ -0,0 +1,51 @@
+package entity
+
+import "time"
+
+const (
+	EmailBatchStatusActive    = "active"
+	EmailBatchStatusPaused    = "paused"
+	EmailBatchStatusCancelled = "cancelled"
+)
+
+// EmailBatch is one bulk send, also called a campaign. Its emails are email history
+// records carrying its BatchID; consumers hold them while the batch is paused and drop
+// them once it is cancelled. The counters split the accepted emails by their current status.
+type EmailBatch struct {
+	ID         uint64
+	BatchID    string
+	Status     string
+	Total      int
+	Queued     int
+	Sent       int
+	Failed     int
+	Suppressed int
+	Cancelled  int
+	CreatedAt  time.Time
+}
+
+// Count adds n emails with status to the batch counters.
+func (b *EmailBatch) Count(status int16, n int) {
+	b.Total += n
+	switch status {
+	case EmailStatusSuccess:
+		b.Sent += n
+	case EmailStatusSkippedByPreference, EmailStatusCapped:
+		b.Suppressed += n
+	case EmailStatusCancelled:
+		b.Cancelled += n
+	case EmailStatusTemporaryFailure, EmailStatusUnknownFailure, EmailStatusPermanentFailure:
+		b.Failed += n
+	default:
+		b.Queued += n
+	}
+}
+
+// BulkEmailResult reports whether one recipient of a bulk send was accepted into its batch.
+// Index is the recipient's position in the send; Error explains a rejection.
+type BulkEmailResult struct {
+	Index     int
+	RequestID string
+	Accepted  bool
+	Error     string
+}
@@ -1,19 +1,54 @@
 package entity
 
+import "time"
+
 const (
-	EmailStatusNew              int16 = 0
-	EmailStatusProcessing       int16 = 1
-	EmailStatusSuccess          int16 = 10
-	EmailStatusTemporaryFailure int16 = 40
-	EmailStatusUnknownFailure   int16 = 49
-	EmailStatusPermanentFailure int16 = 50
+	EmailStatusNew                 int16 = 0
+	EmailStatusProcessing          int16 = 1
+	EmailStatusDeferred            int16 = 2
+	EmailStatusScheduled           int16 = 3
+	EmailStatusDigestPending       int16 = 4
+	EmailStatusCapDeferred         int16 = 5
+	EmailStatusPaused              int16 = 6
+	EmailStatusSuccess             int16 = 10
+	EmailStatusDigested            int16 = 11
+	EmailStatusSkippedByPreference int16 = 20
+	EmailStatusCapped              int16 = 21
+	EmailStatusCancelled           int16 = 30
+	EmailStatusTemporaryFailure    int16 = 40
+	EmailStatusUnknownFailure      int16 = 49
+	EmailStatusPermanentFailure    int16 = 50
 )
 
+// EmailHistory is one stored email request. Requests with a DigestKey wait with status
+// EmailStatusDigestPending until they are rendered into a digest email; DigestRequestID
+// then names that parent request. BatchID names the bulk send a request belongs to,
+// Tenant the tenant it is sent for, and Template the template it was rendered from.
 type EmailHistory struct {
-	RequestID string
+	ID              uint64
+	RequestID       string
+	UserID          uint64
+	Recipient       string
+	Category        string
+	Priority        string
+	Subject         string
+	Content         string
+	Status          int16
+	Retries         int
+	SendAt          time.Time
+	DigestKey       string
+	DigestRequestID string
+	BatchID         string
+	Tenant          string
+	Template        string
+	CreatedAt       time.Time
+}
+
+// DigestGroup identifies the pending digest items of one recipient that are sent together.
+type DigestGroup struct {
+	DigestKey string
+	UserID    uint64
 	Recipient string
-	Subject   string
-	Content   string
-	Status    int16
-	Retries   int
+	Category  string
+	FirstID   uint64
 }
@@ -0,0 +1,85 @@
+package entity
+
+// waitingEmailStatuses are the statuses of requests that are queued but not being sent.
+var waitingEmailStatuses = []int16{
+	EmailStatusNew,
+	EmailStatusScheduled,
+	EmailStatusDeferred,
+	EmailStatusCapDeferred,
+	EmailStatusPaused,
+	EmailStatusTemporaryFailure,
+}
+
+// emailStatusSources lists, for each status a request can move to, the statuses it may
+// move there from. Processing is a source wherever a message can be redelivered after its
+// consumer crashed mid-send. A send accepted by the provider is recorded from any status
+// it could have been sent from, so a delivery is never lost to a concurrent deferral. An
+// unknown failure is recorded when the queue gives up on a message, whatever it was doing.
+var emailStatusSources = map[int16][]int16{
+	EmailStatusProcessing:          withProcessing(waitingEmailStatuses),
+	EmailStatusDeferred:            withProcessing(waitingEmailStatuses),
+	EmailStatusCapDeferred:         withProcessing(waitingEmailStatuses),
+	EmailStatusPaused:              withProcessing(waitingEmailStatuses),
+	EmailStatusCapped:              withProcessing(waitingEmailStatuses),
+	EmailStatusSuccess:             withProcessing(waitingEmailStatuses),
+	EmailStatusDigested:            {EmailStatusDigestPending},
+	EmailStatusSkippedByPreference: {EmailStatusProcessing},
+	EmailStatusTemporaryFailure:    {EmailStatusProcessing},
+	EmailStatusUnknownFailure:      withProcessing(waitingEmailStatuses),
+	EmailStatusPermanentFailure:    {EmailStatusProcessing},
+	EmailStatusCancelled: {
+		EmailStatusNew,
+		EmailStatusDeferred,
+		EmailStatusScheduled,
+		EmailStatusDigestPending,
+		EmailStatusCapDeferred,
+		EmailStatusPaused,
+	},
+}
+
+func withProcessing(statuses []int16) []int16 {
+	return append(append([]int16{}, statuses...), EmailStatusProcessing)
+}
+
+// EmailStatusSources returns the statuses a request may move to status from; it is empty
+// for statuses a request can only be created with.
+func EmailStatusSources(status int16) []int16 {
+	return emailStatusSources[status]
+}
+
+// CanTransitionEmailStatus reports whether a request may move from one status to another.
+func CanTransitionEmailStatus(from int16, to int16) bool {
+	for _, source := range emailStatusSources[to] {
+		if source == from {
+			return true
+		}
+	}
+	return false
+}
+
+// terminalEmailStatuses are the statuses of requests that are finished.
+var terminalEmailStatuses = []int16{
+	EmailStatusSuccess,
+	EmailStatusDigested,
+	EmailStatusSkippedByPreference,
+	EmailStatusCapped,
+	EmailStatusCancelled,
+	EmailStatusUnknownFailure,
+	EmailStatusPermanentFailure,
+}
+
+// TerminalEmailStatuses returns the statuses of finished requests.
+func TerminalEmailStatuses() []int16 {
+	return append([]int16{}, terminalEmailStatuses...)
+}
+
+// IsTerminalEmailStatus reports whether a request in status is finished: it was sent,
+// dropped, cancelled, or failed permanently, and must not be sent (again).
+func IsTerminalEmailStatus(status int16) bool {
+	for _, terminal := range terminalEmailStatuses {
+		if terminal == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type InAppNotification struct {
+	ID        uint64
+	RequestID string
+	UserID    uint64
+	Type      string
+	Title     string
+	Body      string
+	CreatedAt time.Time
+}
@@ -0,0 +1,65 @@
+package entity
+
+import "time"
+
+const (
+	ChannelEmail = "email"
+	ChannelInApp = "in_app"
+)
+
+// SupportedChannels lists the channels notifications and preferences can target.
+var SupportedChannels = []string{ChannelEmail, ChannelInApp}
+
+const (
+	PolicyTierFallback = "fallback"
+	PolicyTierAlways   = "always"
+)
+
+// A notification is processing until the outcome of every channel send is known.
+const (
+	NotificationStatusProcessing int16 = 1
+	NotificationStatusCompleted  int16 = 10
+	NotificationStatusPartial    int16 = 20
+	NotificationStatusFailed     int16 = 50
+)
+
+// A pending delivery was queued by its channel and waits for the queued send to finish.
+const (
+	DeliveryStatusPending             int16 = 1
+	DeliveryStatusAccepted            int16 = 10
+	DeliveryStatusSkipped             int16 = 20
+	DeliveryStatusSkippedByPreference int16 = 30
+	DeliveryStatusFailed              int16 = 50
+)
+
+// ChannelPolicy describes how a notification is routed across channels: fallback
+// channels are tried in order until one accepts, always channels are all attempted.
+type ChannelPolicy struct {
+	Fallback []string `json:"fallback"`
+	Always   []string `json:"always"`
+}
+
+// Notification is one logical multi-channel notification. DigestKey is passed to the
+// email channel, which then holds the email for a digest.
+type Notification struct {
+	RequestID string
+	UserID    uint64
+	Type      string
+	Category  string
+	Priority  string
+	Title     string
+	Body      string
+	Email     string
+	DigestKey string
+	Policy    ChannelPolicy
+	Status    int16
+	CreatedAt time.Time
+}
+
+type NotificationDelivery struct {
+	Channel   string
+	Tier      string
+	Status    int16
+	Reference string
+	Error     string
+}
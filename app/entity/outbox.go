@@ -0,0 +1,9 @@
+package entity
+
+// EmailOutboxEntry is a queued email waiting for the outbox relay to publish it to the
+// email stream. Timezone only drives quiet hours and is not part of the history record.
+type EmailOutboxEntry struct {
+	ID       uint64
+	Timezone string
+	Email    EmailHistory
+}
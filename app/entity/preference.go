@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// NotificationCategory groups notifications for preference purposes. Transactional
+// categories (password resets, receipts) are always delivered.
+type NotificationCategory struct {
+	Name          string
+	Description   string
+	Transactional bool
+	UpdatedAt     time.Time
+}
+
+type NotificationPreference struct {
+	UserID   uint64
+	Category string
+	Channel  string
+	Enabled  bool
+}
+
+// CategoryPreferences is a user's effective per-channel opt-in state for one category.
+type CategoryPreferences struct {
+	Category NotificationCategory
+	Channels map[string]bool
+}
@@ -0,0 +1,15 @@
+package entity
+
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// SupportedPriorities lists the accepted priority values, lowest first.
+var SupportedPriorities = []string{PriorityLow, PriorityNormal, PriorityHigh}
+
+// IsUrgent reports whether a priority bypasses quiet hours and frequency caps.
+func IsUrgent(priority string) bool {
+	return priority == PriorityHigh
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type RecipientProfile struct {
+	UserID       uint64
+	Email        string
+	Phone        string
+	Locale       string
+	Timezone     string
+	DeviceTokens []string
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// ScheduleRecipients selects who a recurring schedule sends to. User IDs and profiles
+// are resolved at send time; AllProfiles targets every user with a recipient profile.
+type ScheduleRecipients struct {
+	UserIDs     []uint64 `json:"user_ids,omitempty"`
+	Emails      []string `json:"emails,omitempty"`
+	AllProfiles bool     `json:"all_profiles,omitempty"`
+}
+
+// EmailSchedule is a recurring email send driven by a cron expression evaluated in Timezone.
+type EmailSchedule struct {
+	ID         uint64
+	Name       string
+	CronExpr   string
+	Timezone   string
+	Category   string
+	Priority   string
+	Subject    string
+	Content    string
+	Recipients ScheduleRecipients
+	Enabled    bool
+	LastRunAt  time.Time
+	NextRunAt  time.Time
+	UpdatedAt  time.Time
+}
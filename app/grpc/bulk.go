@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"errors"
+	"io"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SendBulkEmail reads a stream of recipients for one batch, stores one email per accepted
+// recipient as the messages arrive, and reports per-recipient results once the client
+// closes the stream.
+func (s *Server) SendBulkEmail(stream types.NotificationsService_SendBulkEmailServer) error {
+	ctx := stream.Context()
+	req, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, dto.ErrMissingBulkFields.Error())
+	}
+	if err != nil {
+		return err
+	}
+	msg := dto.SendBulkFromGRPC(req)
+	if err := msg.Validate(); err != nil {
+		logrus.WithError(err).WithField("batch_id", msg.BatchID).Debug("Send bulk validation failed (grpc)")
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	logrus.WithField("batch_id", msg.BatchID).Info("Received send bulk request (grpc)")
+
+	send, err := s.bulkService.Start(ctx, service.BulkEmail{
+		BatchID:  msg.BatchID,
+		Category: msg.Category,
+		Priority: msg.Priority,
+		Subject:  msg.Subject,
+		Content:  msg.Content,
+		SendAt:   msg.SendAt,
+		Tenant:   msg.Tenant,
+		Template: msg.Template,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTemplate) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, service.ErrBatchCancelled) {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		logrus.WithError(err).WithField("batch_id", msg.BatchID).Error("Failed to create email batch")
+		return status.Error(codes.Internal, "failed to create email batch")
+	}
+
+	var results []entity.BulkEmailResult
+	count := 0
+	for {
+		if count+len(msg.Recipients) > dto.MaxBulkRecipients {
+			return status.Error(codes.InvalidArgument, dto.ErrTooManyBulkRecipients.Error())
+		}
+		recipients := make([]service.BulkRecipient, 0, len(msg.Recipients))
+		for _, recipient := range msg.Recipients {
+			recipients = append(recipients, service.BulkRecipient{
+				Index:     count,
+				RequestID: recipient.RequestID,
+				Recipient: recipient.Recipient,
+				UserID:    recipient.UserID,
+				Timezone:  recipient.Timezone,
+				Variables: recipient.Variables,
+				Invalid:   recipient.Validate(),
+			})
+			count++
+		}
+		added, err := send.Add(ctx, recipients)
+		if err != nil {
+			logrus.WithError(err).WithField("batch_id", msg.BatchID).Error("Failed to create email history")
+			return status.Error(codes.Internal, "failed to create email history")
+		}
+		results = append(results, added...)
+
+		next, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		msg.Recipients = dto.SendBulkFromGRPC(next).Recipients
+	}
+	if err := dto.ValidateBulkRecipientCount(count); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp := dto.SendBulkResultsToGRPC(msg.BatchID, results)
+	logrus.WithFields(logrus.Fields{
+		"batch_id": msg.BatchID,
+		"accepted": resp.GetAccepted(),
+		"rejected": resp.GetRejected(),
+	}).Info("Bulk email requests queued (grpc)")
+	return stream.SendAndClose(resp)
+}
@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type bulkEmailStream struct {
+	grpc.ServerStream
+	requests []*types.SendBulkEmailRequest
+	response *types.SendBulkEmailResponse
+}
+
+func (s *bulkEmailStream) Context() context.Context { return context.Background() }
+
+func (s *bulkEmailStream) Recv() (*types.SendBulkEmailRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *bulkEmailStream) SendAndClose(resp *types.SendBulkEmailResponse) error {
+	s.response = resp
+	return nil
+}
+
+func TestSendBulkEmailStream(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO email_batches").WithArgs("batch-1").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT status").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailBatchStatusActive))
+	// Each stream message is stored as it arrives, in its own transaction.
+	for _, recipient := range []struct{ requestID, address string }{{"batch-1:0", "a@b.com"}, {"batch-1:1", "c@d.com"}} {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT request_id, batch_id").WithArgs(recipient.requestID).
+			WillReturnRows(sqlmock.NewRows([]string{"request_id", "batch_id"}))
+		mock.ExpectExec("INSERT INTO email_history").
+			WithArgs(recipient.requestID, uint64(0), recipient.address, "", entity.PriorityHigh, "Your code", "Your code is long enough", entity.EmailStatusNew, nil, "batch-1", "", "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO email_outbox").WithArgs(recipient.requestID, "").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+
+	server := NewServer(nil, nil, nil, nil, nil, nil, service.NewBulkEmailService(repository.NewEmailHistoryRepository(db), repository.NewEmailBatchRepository(db)), nil, nil)
+	stream := &bulkEmailStream{requests: []*types.SendBulkEmailRequest{
+		{
+			BatchId:    "batch-1",
+			Priority:   "high",
+			Subject:    "Your code",
+			Content:    "Your code is long enough",
+			Recipients: []*types.BulkEmailRecipient{{Recipient: "a@b.com"}},
+		},
+		{Recipients: []*types.BulkEmailRecipient{{Recipient: "c@d.com"}}},
+	}}
+
+	if err := server.SendBulkEmail(stream); err != nil {
+		t.Fatalf("SendBulkEmail: %v", err)
+	}
+	resp := stream.response
+	if resp.GetBatchId() != "batch-1" || resp.GetAccepted() != 2 || resp.GetRejected() != 0 || len(resp.GetResults()) != 2 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.GetResults()[1].GetIndex() != 1 || resp.GetResults()[1].GetRequestId() != "batch-1:1" {
+		t.Fatalf("unexpected second result: %+v", resp.GetResults()[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestSendBulkEmailValidationError(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	stream := &bulkEmailStream{requests: []*types.SendBulkEmailRequest{{BatchId: "batch-1"}}}
+
+	err := server.SendBulkEmail(stream)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetEmailBatch returns the status and aggregate progress of a bulk send batch.
+func (s *Server) GetEmailBatch(ctx context.Context, req *types.GetEmailBatchRequest) (*types.GetEmailBatchResponse, error) {
+	batchID, err := dto.ValidateBatchID(req.GetBatchId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	batch, err := s.campaignService.Get(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, service.ErrBatchNotFound) {
+			return nil, status.Error(codes.NotFound, "email batch not found")
+		}
+		logrus.WithError(err).WithField("batch_id", batchID).Error("Failed to load email batch")
+		return nil, status.Error(codes.Internal, "failed to load email batch")
+	}
+
+	return &types.GetEmailBatchResponse{Batch: dto.EmailBatchToGRPC(*batch)}, nil
+}
+
+// PauseEmailBatch holds a batch's unsent emails until it is resumed.
+func (s *Server) PauseEmailBatch(ctx context.Context, req *types.PauseEmailBatchRequest) (*types.PauseEmailBatchResponse, error) {
+	batch, err := s.transitionEmailBatch(ctx, req.GetBatchId(), entity.EmailBatchStatusPaused, s.campaignService.Pause)
+	if err != nil {
+		return nil, err
+	}
+	return &types.PauseEmailBatchResponse{Batch: batch}, nil
+}
+
+// ResumeEmailBatch lets a paused batch's emails be sent again.
+func (s *Server) ResumeEmailBatch(ctx context.Context, req *types.ResumeEmailBatchRequest) (*types.ResumeEmailBatchResponse, error) {
+	batch, err := s.transitionEmailBatch(ctx, req.GetBatchId(), entity.EmailBatchStatusActive, s.campaignService.Resume)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ResumeEmailBatchResponse{Batch: batch}, nil
+}
+
+// CancelEmailBatch cancels a batch and its emails that have not started sending yet.
+func (s *Server) CancelEmailBatch(ctx context.Context, req *types.CancelEmailBatchRequest) (*types.CancelEmailBatchResponse, error) {
+	batchID, err := dto.ValidateBatchID(req.GetBatchId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	batch, cancelled, err := s.campaignService.Cancel(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, service.ErrBatchNotFound) {
+			return nil, status.Error(codes.NotFound, "email batch not found")
+		}
+		logrus.WithError(err).WithField("batch_id", batchID).Error("Failed to cancel email batch")
+		return nil, status.Error(codes.Internal, "failed to cancel email batch")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"batch_id":  batchID,
+		"cancelled": cancelled,
+	}).Info("Email batch cancelled (grpc)")
+	return &types.CancelEmailBatchResponse{Batch: dto.EmailBatchToGRPC(*batch), Cancelled: uint32(cancelled)}, nil
+}
+
+// transitionEmailBatch applies a pause or resume and maps its errors.
+func (s *Server) transitionEmailBatch(
+	ctx context.Context,
+	batchID string,
+	batchStatus string,
+	apply func(context.Context, string) (*entity.EmailBatch, error),
+) (*types.EmailBatch, error) {
+	batchID, err := dto.ValidateBatchID(batchID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	batch, err := apply(ctx, batchID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBatchNotFound):
+			return nil, status.Error(codes.NotFound, "email batch not found")
+		case errors.Is(err, service.ErrBatchCancelled):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		logrus.WithError(err).WithField("batch_id", batchID).Error("Failed to update email batch")
+		return nil, status.Error(codes.Internal, "failed to update email batch")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"batch_id": batchID,
+		"status":   batchStatus,
+	}).Info("Email batch status updated (grpc)")
+	return dto.EmailBatchToGRPC(*batch), nil
+}
@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetEmailBatch(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	createdAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM email_batches").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "batch_id", "status", "created_at"}).
+			AddRow(uint64(1), "batch-1", entity.EmailBatchStatusActive, createdAt))
+	mock.ExpectQuery("FROM email_history").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+			AddRow(entity.EmailStatusProcessing, 2).
+			AddRow(entity.EmailStatusCapped, 1).
+			AddRow(entity.EmailStatusSuccess, 8))
+
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, service.NewCampaignService(repository.NewEmailBatchRepository(db)), nil)
+
+	resp, err := server.GetEmailBatch(context.Background(), &types.GetEmailBatchRequest{BatchId: " batch-1 "})
+	if err != nil {
+		t.Fatalf("GetEmailBatch: %v", err)
+	}
+	batch := resp.GetBatch()
+	if batch.GetStatus() != entity.EmailBatchStatusActive || batch.GetTotal() != 11 || batch.GetQueued() != 2 ||
+		batch.GetSent() != 8 || batch.GetSuppressed() != 1 || !batch.GetCreatedAt().AsTime().Equal(createdAt) {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+}
+
+func TestCancelEmailBatch(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uint64(1)))
+	mock.ExpectExec("UPDATE email_batches").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+	mock.ExpectQuery("FROM email_batches").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "batch_id", "status", "created_at"}).
+			AddRow(uint64(1), "batch-1", entity.EmailBatchStatusCancelled, time.Now()))
+	mock.ExpectQuery("FROM email_history").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+			AddRow(entity.EmailStatusSuccess, 2).
+			AddRow(entity.EmailStatusCancelled, 3))
+
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, service.NewCampaignService(repository.NewEmailBatchRepository(db)), nil)
+
+	resp, err := server.CancelEmailBatch(context.Background(), &types.CancelEmailBatchRequest{BatchId: "batch-1"})
+	if err != nil {
+		t.Fatalf("CancelEmailBatch: %v", err)
+	}
+	if resp.GetCancelled() != 3 || resp.GetBatch().GetStatus() != entity.EmailBatchStatusCancelled || resp.GetBatch().GetCancelled() != 3 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestPauseEmailBatchValidationError(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	_, err := server.PauseEmailBatch(context.Background(), &types.PauseEmailBatchRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SendInAppNotification validates, stores, and broadcasts an in-app notification.
+func (s *Server) SendInAppNotification(ctx context.Context, req *types.SendInAppNotificationRequest) (*types.SendInAppNotificationResponse, error) {
+	msg := dto.SendInAppFromGRPC(req)
+	if err := msg.Validate(); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"request_id": msg.RequestID,
+			"user_id":    msg.UserID,
+		}).Debug("Send in-app validation failed (grpc)")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	notification := msg.ToEntity()
+	if err := s.inAppService.Send(ctx, notification); err != nil {
+		if errors.Is(err, service.ErrDuplicateRequestID) {
+			logrus.WithField("request_id", msg.RequestID).Warn("Duplicate request_id")
+			return nil, status.Error(codes.AlreadyExists, "duplicate request_id")
+		}
+		logrus.WithError(err).WithField("request_id", msg.RequestID).Error("Failed to create in-app notification")
+		return nil, status.Error(codes.Internal, "failed to create in-app notification")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"request_id":      msg.RequestID,
+		"notification_id": notification.ID,
+	}).Info("In-app notification created (grpc)")
+	return &types.SendInAppNotificationResponse{Notification: dto.InAppNotificationToGRPC(*notification)}, nil
+}
+
+// ListInAppNotifications returns a user's notifications created after the given id.
+func (s *Server) ListInAppNotifications(ctx context.Context, req *types.ListInAppNotificationsRequest) (*types.ListInAppNotificationsResponse, error) {
+	msg := dto.ListInAppFromGRPC(req)
+	if err := msg.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	notifications, err := s.inAppService.List(ctx, msg.UserID, msg.AfterID, msg.Limit)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", msg.UserID).Error("Failed to list in-app notifications")
+		return nil, status.Error(codes.Internal, "failed to list in-app notifications")
+	}
+
+	resp := &types.ListInAppNotificationsResponse{}
+	for _, n := range notifications {
+		resp.Notifications = append(resp.Notifications, dto.InAppNotificationToGRPC(n))
+	}
+	return resp, nil
+}
+
+// SubscribeNotifications streams a user's in-app notifications until the client disconnects.
+func (s *Server) SubscribeNotifications(req *types.SubscribeNotificationsRequest, stream types.NotificationsService_SubscribeNotificationsServer) error {
+	if req.GetUserId() == 0 {
+		return status.Error(codes.InvalidArgument, dto.ErrMissingUserID.Error())
+	}
+
+	ctx := stream.Context()
+	events, err := s.inAppService.Subscribe(ctx, req.GetUserId(), req.GetAfterId())
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", req.GetUserId()).Error("Failed to subscribe to in-app notifications")
+		return status.Error(codes.Internal, "failed to subscribe")
+	}
+
+	logrus.WithField("user_id", req.GetUserId()).Debug("gRPC subscriber connected")
+	defer logrus.WithField("user_id", req.GetUserId()).Debug("gRPC subscriber disconnected")
+
+	for n := range events {
+		if err := stream.Send(dto.InAppNotificationToGRPC(n)); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return status.Error(codes.Unavailable, "server shutting down")
+}
@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeBroker struct {
+	published []entity.InAppNotification
+	live      chan entity.InAppNotification
+}
+
+func (b *fakeBroker) Publish(_ context.Context, notification entity.InAppNotification) error {
+	b.published = append(b.published, notification)
+	return nil
+}
+
+func (b *fakeBroker) Subscribe(_ uint64) (<-chan entity.InAppNotification, func()) {
+	return b.live, func() {}
+}
+
+type fakeSubscribeStream struct {
+	grpclib.ServerStream
+	ctx  context.Context
+	sent []*types.InAppNotification
+}
+
+func (s *fakeSubscribeStream) Context() context.Context { return s.ctx }
+
+func (s *fakeSubscribeStream) Send(n *types.InAppNotification) error {
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func TestSendInAppNotificationSuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO inapp_notifications").
+		WithArgs("req-1", uint64(7), "comment", "title", "body", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	broker := &fakeBroker{}
+	server := NewServer(nil, service.NewInAppService(repository.NewInAppNotificationRepository(db), broker), nil, nil, nil, nil, nil, nil, nil)
+
+	resp, err := server.SendInAppNotification(context.Background(), &types.SendInAppNotificationRequest{
+		RequestId: "req-1",
+		UserId:    7,
+		Type:      "comment",
+		Title:     "title",
+		Body:      "body",
+	})
+	if err != nil {
+		t.Fatalf("SendInAppNotification: %v", err)
+	}
+	if resp.GetNotification().GetId() != 3 {
+		t.Fatalf("expected id 3, got %d", resp.GetNotification().GetId())
+	}
+	if len(broker.published) != 1 {
+		t.Fatalf("expected 1 published notification, got %d", len(broker.published))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestSendInAppNotificationInvalid(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	_, err := server.SendInAppNotification(context.Background(), &types.SendInAppNotificationRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestSubscribeNotificationsStreamsUntilShutdown(t *testing.T) {
+	t.Parallel()
+
+	broker := &fakeBroker{live: make(chan entity.InAppNotification, 2)}
+	broker.live <- entity.InAppNotification{ID: 1, UserID: 7}
+	broker.live <- entity.InAppNotification{ID: 2, UserID: 7}
+	close(broker.live)
+
+	server := NewServer(nil, service.NewInAppService(nil, broker), nil, nil, nil, nil, nil, nil, nil)
+	stream := &fakeSubscribeStream{ctx: context.Background()}
+
+	err := server.SubscribeNotifications(&types.SubscribeNotificationsRequest{UserId: 7}, stream)
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable after broker shutdown, got %v", err)
+	}
+	if len(stream.sent) != 2 || stream.sent[1].GetId() != 2 {
+		t.Fatalf("unexpected sent notifications: %v", stream.sent)
+	}
+}
+
+func TestSubscribeNotificationsRequiresUser(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	err := server.SubscribeNotifications(&types.SubscribeNotificationsRequest{}, &fakeSubscribeStream{ctx: context.Background()})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
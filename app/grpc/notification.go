@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/notify"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Notify validates a logical notification and sends it according to its channel policy.
+func (s *Server) Notify(ctx context.Context, req *types.NotifyRequest) (*types.NotifyResponse, error) {
+	msg := dto.NotifyFromGRPC(req)
+	if err := msg.Validate(); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"request_id": msg.RequestID,
+			"user_id":    msg.UserID,
+		}).Debug("Notify validation failed (grpc)")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"request_id": msg.RequestID,
+		"user_id":    msg.UserID,
+	}).Info("Received notify request (grpc)")
+
+	notification := msg.ToEntity()
+	deliveries, err := s.notifyService.Notify(ctx, notification)
+	if err != nil {
+		switch {
+		case errors.Is(err, notify.ErrUnsupportedChannel):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, service.ErrDuplicateRequestID):
+			logrus.WithField("request_id", msg.RequestID).Warn("Duplicate request_id")
+			return nil, status.Error(codes.AlreadyExists, "duplicate request_id")
+		}
+		logrus.WithError(err).WithField("request_id", msg.RequestID).Error("Failed to process notification")
+		return nil, status.Error(codes.Internal, "failed to process notification")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"request_id": msg.RequestID,
+		"status":     notification.Status,
+	}).Info("Notification processed (grpc)")
+	return &types.NotifyResponse{Notification: dto.NotificationToGRPC(*notification, deliveries)}, nil
+}
+
+// GetNotification returns a notification and the status of each channel delivery.
+func (s *Server) GetNotification(ctx context.Context, req *types.GetNotificationRequest) (*types.GetNotificationResponse, error) {
+	requestID := strings.TrimSpace(req.GetRequestId())
+	if requestID == "" {
+		return nil, status.Error(codes.InvalidArgument, dto.ErrMissingNotificationID.Error())
+	}
+
+	notification, deliveries, err := s.notifyService.Get(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, notify.ErrNotificationNotFound) {
+			return nil, status.Error(codes.NotFound, "notification not found")
+		}
+		logrus.WithError(err).WithField("request_id", requestID).Error("Failed to load notification")
+		return nil, status.Error(codes.Internal, "failed to load notification")
+	}
+
+	return &types.GetNotificationResponse{Notification: dto.NotificationToGRPC(*notification, deliveries)}, nil
+}
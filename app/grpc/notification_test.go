@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/notify"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type stubChannel struct {
+	name string
+}
+
+func (c stubChannel) Name() string { return c.name }
+
+func (c stubChannel) Send(_ context.Context, n entity.Notification) (string, error) {
+	return n.RequestID + ":" + c.name, nil
+}
+
+func TestNotifySuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO notifications").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO notification_deliveries").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE notifications").
+		WithArgs(entity.NotificationStatusCompleted, "n-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	svc := notify.NewService(repository.NewNotificationRepository(db), nil, stubChannel{name: entity.ChannelInApp})
+	server := NewServer(nil, nil, svc, nil, nil, nil, nil, nil, nil)
+
+	resp, err := server.Notify(context.Background(), &types.NotifyRequest{
+		RequestId: "n-1",
+		UserId:    7,
+		Type:      "comment",
+		Payload:   &types.NotificationPayload{Title: "t", Body: "b"},
+		Policy:    &types.ChannelPolicy{Fallback: []string{"in_app"}},
+	})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if len(resp.GetNotification().GetDeliveries()) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(resp.GetNotification().GetDeliveries()))
+	}
+	if resp.GetNotification().GetDeliveries()[0].GetReference() != "n-1:in_app" {
+		t.Fatalf("unexpected reference: %s", resp.GetNotification().GetDeliveries()[0].GetReference())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestNotifyUnsupportedChannel(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(nil, nil, notify.NewService(nil, nil, stubChannel{name: entity.ChannelEmail}), nil, nil, nil, nil, nil, nil)
+
+	_, err := server.Notify(context.Background(), &types.NotifyRequest{
+		RequestId: "n-1",
+		UserId:    7,
+		Type:      "comment",
+		Payload:   &types.NotificationPayload{Title: "t", Body: "b"},
+		Policy:    &types.ChannelPolicy{Always: []string{"push"}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestGetNotificationNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT request_id").WithArgs("missing").WillReturnError(sql.ErrNoRows)
+
+	server := NewServer(nil, nil, notify.NewService(repository.NewNotificationRepository(db), nil), nil, nil, nil, nil, nil, nil)
+
+	_, err = server.GetNotification(context.Background(), &types.GetNotificationRequest{RequestId: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
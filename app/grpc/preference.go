@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UpsertNotificationCategory validates and stores a notification category.
+func (s *Server) UpsertNotificationCategory(ctx context.Context, req *types.UpsertNotificationCategoryRequest) (*types.UpsertNotificationCategoryResponse, error) {
+	msg := dto.UpsertCategoryFromGRPC(req)
+	if err := msg.Validate(); err != nil {
+		logrus.WithError(err).WithField("category", msg.Name).Debug("Category upsert validation failed (grpc)")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	category := msg.ToEntity()
+	if err := s.preferenceService.UpsertCategory(ctx, category); err != nil {
+		logrus.WithError(err).WithField("category", msg.Name).Error("Failed to store notification category")
+		return nil, status.Error(codes.Internal, "failed to store notification category")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"category":      category.Name,
+		"transactional": category.Transactional,
+	}).Info("Notification category stored (grpc)")
+	return &types.UpsertNotificationCategoryResponse{Category: dto.CategoryToGRPC(*category)}, nil
+}
+
+// ListNotificationCategories returns all notification categories.
+func (s *Server) ListNotificationCategories(ctx context.Context, _ *types.ListNotificationCategoriesRequest) (*types.ListNotificationCategoriesResponse, error) {
+	categories, err := s.preferenceService.ListCategories(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list notification categories")
+		return nil, status.Error(codes.Internal, "failed to list notification categories")
+	}
+
+	resp := &types.ListNotificationCategoriesResponse{}
+	for _, category := range categories {
+		resp.Categories = append(resp.Categories, dto.CategoryToGRPC(category))
+	}
+	return resp, nil
+}
+
+// GetNotificationPreferences returns a user's effective preferences for every category.
+func (s *Server) GetNotificationPreferences(ctx context.Context, req *types.GetNotificationPreferencesRequest) (*types.GetNotificationPreferencesResponse, error) {
+	if req.GetUserId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, dto.ErrMissingUserID.Error())
+	}
+
+	preferences, err := s.preferenceService.Get(ctx, req.GetUserId())
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", req.GetUserId()).Error("Failed to load notification preferences")
+		return nil, status.Error(codes.Internal, "failed to load notification preferences")
+	}
+
+	return &types.GetNotificationPreferencesResponse{
+		UserId:     req.GetUserId(),
+		Categories: dto.PreferencesToGRPC(preferences),
+	}, nil
+}
+
+// UpdateNotificationPreferences stores a user's preferences and returns the resulting effective preferences.
+func (s *Server) UpdateNotificationPreferences(ctx context.Context, req *types.UpdateNotificationPreferencesRequest) (*types.UpdateNotificationPreferencesResponse, error) {
+	msg := dto.UpdatePreferencesFromGRPC(req)
+	if err := msg.Validate(); err != nil {
+		logrus.WithError(err).WithField("user_id", msg.UserID).Debug("Preferences update validation failed (grpc)")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.preferenceService.Update(ctx, msg.UserID, msg.ToEntities()); err != nil {
+		if errors.Is(err, service.ErrUnknownCategory) || errors.Is(err, service.ErrTransactionalCategory) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		logrus.WithError(err).WithField("user_id", msg.UserID).Error("Failed to store notification preferences")
+		return nil, status.Error(codes.Internal, "failed to store notification preferences")
+	}
+	logrus.WithField("user_id", msg.UserID).Info("Notification preferences updated (grpc)")
+
+	preferences, err := s.preferenceService.Get(ctx, msg.UserID)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", msg.UserID).Error("Failed to load notification preferences")
+		return nil, status.Error(codes.Internal, "failed to load notification preferences")
+	}
+
+	return &types.UpdateNotificationPreferencesResponse{
+		UserId:     msg.UserID,
+		Categories: dto.PreferencesToGRPC(preferences),
+	}, nil
+}
@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetNotificationPreferencesDefaults(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM notification_categories").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "description", "transactional", "updated_at"}).
+			AddRow("marketing", "", false, time.Now()).
+			AddRow("security", "", true, time.Now()))
+	mock.ExpectQuery("FROM notification_preferences").WithArgs(uint64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "category", "channel", "enabled"}).
+			AddRow(uint64(7), "security", entity.ChannelEmail, false))
+
+	server := NewServer(nil, nil, nil, nil, service.NewPreferenceService(repository.NewPreferenceRepository(db)), nil, nil, nil, nil)
+
+	resp, err := server.GetNotificationPreferences(context.Background(), &types.GetNotificationPreferencesRequest{UserId: 7})
+	if err != nil {
+		t.Fatalf("GetNotificationPreferences: %v", err)
+	}
+	if len(resp.GetCategories()) != 2 {
+		t.Fatalf("expected 2 categories, got %d", len(resp.GetCategories()))
+	}
+	for _, category := range resp.GetCategories() {
+		if !category.GetChannels()[entity.ChannelEmail] || !category.GetChannels()[entity.ChannelInApp] {
+			t.Fatalf("expected all channels enabled for %s, got %v", category.GetCategory(), category.GetChannels())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestUpdateNotificationPreferencesTransactional(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM notification_categories").WithArgs("security").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "description", "transactional", "updated_at"}).
+			AddRow("security", "", true, time.Now()))
+
+	server := NewServer(nil, nil, nil, nil, service.NewPreferenceService(repository.NewPreferenceRepository(db)), nil, nil, nil, nil)
+
+	_, err = server.UpdateNotificationPreferences(context.Background(), &types.UpdateNotificationPreferencesRequest{
+		UserId: 7,
+		Preferences: []*types.NotificationPreference{
+			{Category: "security", Channel: entity.ChannelEmail, Enabled: false},
+		},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestUpsertNotificationCategoryValidationError(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	_, err := server.UpsertNotificationCategory(context.Background(), &types.UpsertNotificationCategoryRequest{
+		Category: &types.NotificationCategory{Name: "Bad Name"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UpsertRecipientProfile validates and stores a user's recipient profile.
+func (s *Server) UpsertRecipientProfile(ctx context.Context, req *types.UpsertRecipientProfileRequest) (*types.UpsertRecipientProfileResponse, error) {
+	msg := dto.UpsertProfileFromGRPC(req)
+	if err := msg.Validate(); err != nil {
+		logrus.WithError(err).WithField("user_id", msg.UserID).Debug("Profile upsert validation failed (grpc)")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	profile := msg.ToEntity()
+	if err := s.profileService.Upsert(ctx, profile); err != nil {
+		logrus.WithError(err).WithField("user_id", msg.UserID).Error("Failed to store recipient profile")
+		return nil, status.Error(codes.Internal, "failed to store recipient profile")
+	}
+
+	logrus.WithField("user_id", msg.UserID).Info("Recipient profile stored (grpc)")
+	return &types.UpsertRecipientProfileResponse{Profile: dto.ProfileToGRPC(*profile)}, nil
+}
+
+// GetRecipientProfile returns a user's recipient profile.
+func (s *Server) GetRecipientProfile(ctx context.Context, req *types.GetRecipientProfileRequest) (*types.GetRecipientProfileResponse, error) {
+	if req.GetUserId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, dto.ErrMissingUserID.Error())
+	}
+
+	profile, err := s.profileService.Get(ctx, req.GetUserId())
+	if err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			return nil, status.Error(codes.NotFound, "recipient profile not found")
+		}
+		logrus.WithError(err).WithField("user_id", req.GetUserId()).Error("Failed to load recipient profile")
+		return nil, status.Error(codes.Internal, "failed to load recipient profile")
+	}
+
+	return &types.GetRecipientProfileResponse{Profile: dto.ProfileToGRPC(*profile)}, nil
+}
+
+// DeleteRecipientProfile removes a user's recipient profile.
+func (s *Server) DeleteRecipientProfile(ctx context.Context, req *types.DeleteRecipientProfileRequest) (*types.DeleteRecipientProfileResponse, error) {
+	if req.GetUserId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, dto.ErrMissingUserID.Error())
+	}
+
+	if err := s.profileService.Delete(ctx, req.GetUserId()); err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			return nil, status.Error(codes.NotFound, "recipient profile not found")
+		}
+		logrus.WithError(err).WithField("user_id", req.GetUserId()).Error("Failed to delete recipient profile")
+		return nil, status.Error(codes.Internal, "failed to delete recipient profile")
+	}
+
+	logrus.WithField("user_id", req.GetUserId()).Info("Recipient profile deleted (grpc)")
+	return &types.DeleteRecipientProfileResponse{}, nil
+}
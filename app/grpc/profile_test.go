@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUpsertRecipientProfileSuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO recipient_profiles").
+		WithArgs(uint64(7), "a@b.com", "+40700000000", "", "", `["tok"]`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	server := NewServer(nil, nil, nil, service.NewProfileService(repository.NewRecipientProfileRepository(db)), nil, nil, nil, nil, nil)
+
+	resp, err := server.UpsertRecipientProfile(context.Background(), &types.UpsertRecipientProfileRequest{
+		UserId:       7,
+		Email:        "a@b.com",
+		Phone:        "+40700000000",
+		DeviceTokens: []string{"tok"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertRecipientProfile: %v", err)
+	}
+	if resp.GetProfile().GetEmail() != "a@b.com" || resp.GetProfile().GetUpdatedAt() == nil {
+		t.Fatalf("unexpected profile: %+v", resp.GetProfile())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestUpsertRecipientProfileValidationError(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	_, err := server.UpsertRecipientProfile(context.Background(), &types.UpsertRecipientProfileRequest{UserId: 7, Email: "bad"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestGetRecipientProfileNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id").WithArgs(uint64(7)).WillReturnError(sql.ErrNoRows)
+
+	server := NewServer(nil, nil, nil, service.NewProfileService(repository.NewRecipientProfileRepository(db)), nil, nil, nil, nil, nil)
+
+	_, err = server.GetRecipientProfile(context.Background(), &types.GetRecipientProfileRequest{UserId: 7})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DryRunEmailRoute shows which provider an email would be sent through, without sending it.
+func (s *Server) DryRunEmailRoute(_ context.Context, req *types.DryRunEmailRouteRequest) (*types.DryRunEmailRouteResponse, error) {
+	msg := dto.DryRunRouteFromGRPC(req)
+	if err := msg.Validate(); err != nil {
+		logrus.WithError(err).WithField("recipient", msg.Recipient).Debug("Dry-run route validation failed (grpc)")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	decision := s.routeService.DryRun(provider.Route{
+		Recipient: msg.Recipient,
+		Sender:    msg.Sender,
+		Category:  msg.Category,
+		Tenant:    msg.Tenant,
+	})
+	return &types.DryRunEmailRouteResponse{
+		Provider: decision.Provider,
+		Rule:     decision.Rule,
+		Matched:  decision.Matched,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDryRunEmailRoute(t *testing.T) {
+	t.Parallel()
+
+	router, err := provider.NewRouter([]provider.RoutingRule{
+		{Match: provider.RouteMatch{Tenants: []string{"acme"}}, Provider: "noop"},
+	}, "ses", "noreply@example.com")
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, service.NewRouteService(router))
+
+	resp, err := server.DryRunEmailRoute(context.Background(), &types.DryRunEmailRouteRequest{Recipient: "a@b.com", Tenant: "acme"})
+	if err != nil {
+		t.Fatalf("DryRunEmailRoute: %v", err)
+	}
+	if resp.GetProvider() != "noop" || resp.GetRule() != "rule-1" || !resp.GetMatched() {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	_, err = server.DryRunEmailRoute(context.Background(), &types.DryRunEmailRouteRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
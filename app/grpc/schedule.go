@@ -0,0 +1,87 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UpsertEmailSchedule validates and stores a recurring email schedule.
+func (s *Server) UpsertEmailSchedule(ctx context.Context, req *types.UpsertEmailScheduleRequest) (*types.UpsertEmailScheduleResponse, error) {
+	msg := dto.UpsertScheduleFromGRPC(req)
+	if err := msg.Validate(); err != nil {
+		logrus.WithError(err).WithField("schedule", msg.Name).Debug("Schedule upsert validation failed (grpc)")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	schedule, err := s.scheduleService.Upsert(ctx, msg.ToEntity())
+	if err != nil {
+		logrus.WithError(err).WithField("schedule", msg.Name).Error("Failed to store email schedule")
+		return nil, status.Error(codes.Internal, "failed to store email schedule")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"schedule":    schedule.Name,
+		"next_run_at": schedule.NextRunAt,
+	}).Info("Email schedule stored (grpc)")
+	return &types.UpsertEmailScheduleResponse{Schedule: dto.ScheduleToGRPC(*schedule)}, nil
+}
+
+// GetEmailSchedule returns a recurring email schedule.
+func (s *Server) GetEmailSchedule(ctx context.Context, req *types.GetEmailScheduleRequest) (*types.GetEmailScheduleResponse, error) {
+	name, err := dto.ValidateScheduleName(req.GetName())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	schedule, err := s.scheduleService.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, service.ErrScheduleNotFound) {
+			return nil, status.Error(codes.NotFound, "email schedule not found")
+		}
+		logrus.WithError(err).WithField("schedule", name).Error("Failed to load email schedule")
+		return nil, status.Error(codes.Internal, "failed to load email schedule")
+	}
+
+	return &types.GetEmailScheduleResponse{Schedule: dto.ScheduleToGRPC(*schedule)}, nil
+}
+
+// ListEmailSchedules returns all recurring email schedules.
+func (s *Server) ListEmailSchedules(ctx context.Context, _ *types.ListEmailSchedulesRequest) (*types.ListEmailSchedulesResponse, error) {
+	schedules, err := s.scheduleService.List(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list email schedules")
+		return nil, status.Error(codes.Internal, "failed to list email schedules")
+	}
+
+	resp := &types.ListEmailSchedulesResponse{}
+	for _, schedule := range schedules {
+		resp.Schedules = append(resp.Schedules, dto.ScheduleToGRPC(schedule))
+	}
+	return resp, nil
+}
+
+// DeleteEmailSchedule removes a recurring email schedule.
+func (s *Server) DeleteEmailSchedule(ctx context.Context, req *types.DeleteEmailScheduleRequest) (*types.DeleteEmailScheduleResponse, error) {
+	name, err := dto.ValidateScheduleName(req.GetName())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.scheduleService.Delete(ctx, name); err != nil {
+		if errors.Is(err, service.ErrScheduleNotFound) {
+			return nil, status.Error(codes.NotFound, "email schedule not found")
+		}
+		logrus.WithError(err).WithField("schedule", name).Error("Failed to delete email schedule")
+		return nil, status.Error(codes.Internal, "failed to delete email schedule")
+	}
+
+	logrus.WithField("schedule", name).Info("Email schedule deleted (grpc)")
+	return &types.DeleteEmailScheduleResponse{}, nil
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	types "github.com/vibast-solutions/ms-go-notifications/app/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetEmailScheduleSuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	lastRun := time.Date(2030, 1, 6, 8, 0, 0, 0, time.UTC)
+	nextRun := lastRun.Add(24 * time.Hour)
+	mock.ExpectQuery("FROM email_schedules WHERE name").WithArgs("daily").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "cron_expr", "timezone", "category", "priority", "subject", "content",
+			"recipients", "enabled", "last_run_at", "next_run_at", "updated_at",
+		}).AddRow(uint64(1), "daily", "@daily", "UTC", "", entity.PriorityNormal, "Daily digest", "What happened today.",
+			`{"all_profiles":true}`, true, lastRun, nextRun, time.Now()))
+
+	server := NewServer(nil, nil, nil, nil, nil, service.NewScheduleService(repository.NewEmailScheduleRepository(db)), nil, nil, nil)
+
+	resp, err := server.GetEmailSchedule(context.Background(), &types.GetEmailScheduleRequest{Name: " Daily "})
+	if err != nil {
+		t.Fatalf("GetEmailSchedule: %v", err)
+	}
+	schedule := resp.GetSchedule()
+	if !schedule.GetRecipients().GetAllProfiles() || !schedule.GetEnabled() {
+		t.Fatalf("unexpected schedule: %+v", schedule)
+	}
+	if !schedule.GetLastRunAt().AsTime().Equal(lastRun) || !schedule.GetNextRunAt().AsTime().Equal(nextRun) {
+		t.Fatalf("unexpected run times: %v %v", schedule.GetLastRunAt().AsTime(), schedule.GetNextRunAt().AsTime())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestGetEmailScheduleNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM email_schedules WHERE name").WithArgs("daily").WillReturnError(sql.ErrNoRows)
+
+	server := NewServer(nil, nil, nil, nil, nil, service.NewScheduleService(repository.NewEmailScheduleRepository(db)), nil, nil, nil)
+
+	_, err = server.GetEmailSchedule(context.Background(), &types.GetEmailScheduleRequest{Name: "daily"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestUpsertEmailScheduleValidationError(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	_, err := server.UpsertEmailSchedule(context.Background(), &types.UpsertEmailScheduleRequest{Schedule: &types.EmailSchedule{
+		Name:    "daily",
+		Cron:    "@daily",
+		Subject: "Daily digest",
+		Content: "What happened today.",
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
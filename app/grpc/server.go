@@ -3,10 +3,12 @@ package grpc
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/vibast-solutions/ms-go-notifications/app/dto"
-	"github.com/vibast-solutions/ms-go-notifications/app/queue"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/notify"
 	"github.com/vibast-solutions/ms-go-notifications/app/service"
 	types "github.com/vibast-solutions/ms-go-notifications/app/types"
 	"google.golang.org/grpc/codes"
@@ -15,22 +17,50 @@ import (
 
 type Server struct {
 	types.UnimplementedNotificationsServiceServer
-	emailService *service.EmailService
-	producer     queue.EmailPublisher
+	emailService      *service.EmailService
+	inAppService      *service.InAppService
+	notifyService     *notify.Service
+	profileService    *service.ProfileService
+	preferenceService *service.PreferenceService
+	scheduleService   *service.ScheduleService
+	bulkService       *service.BulkEmailService
+	campaignService   *service.CampaignService
+	routeService      *service.RouteService
 }
 
 // NewServer constructs a gRPC server handler.
-func NewServer(emailService *service.EmailService, producer queue.EmailPublisher) *Server {
-	return &Server{emailService: emailService, producer: producer}
+func NewServer(
+	emailService *service.EmailService,
+	inAppService *service.InAppService,
+	notifyService *notify.Service,
+	profileService *service.ProfileService,
+	preferenceService *service.PreferenceService,
+	scheduleService *service.ScheduleService,
+	bulkService *service.BulkEmailService,
+	campaignService *service.CampaignService,
+	routeService *service.RouteService,
+) *Server {
+	return &Server{
+		emailService:      emailService,
+		inAppService:      inAppService,
+		notifyService:     notifyService,
+		profileService:    profileService,
+		preferenceService: preferenceService,
+		scheduleService:   scheduleService,
+		bulkService:       bulkService,
+		campaignService:   campaignService,
+		routeService:      routeService,
+	}
 }
 
-// SendRawEmail validates the request, stores history, and enqueues for delivery.
+// SendRawEmail validates the request and stores history; the outbox relay enqueues it for delivery.
 func (s *Server) SendRawEmail(ctx context.Context, req *types.SendRawEmailRequest) (*types.SendRawEmailResponse, error) {
 	msg := dto.FromGRPC(req)
 	if err := msg.Validate(); err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
 			"request_id": msg.RequestID,
 			"recipient":  msg.Recipient,
+			"user_id":    msg.UserID,
 		}).Debug("Send raw validation failed (grpc)")
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
@@ -38,9 +68,23 @@ func (s *Server) SendRawEmail(ctx context.Context, req *types.SendRawEmailReques
 	logrus.WithFields(logrus.Fields{
 		"request_id": msg.RequestID,
 		"recipient":  msg.Recipient,
+		"user_id":    msg.UserID,
 	}).Info("Received send raw request (grpc)")
 
-	if err := s.emailService.CreateRequest(ctx, msg.RequestID, msg.Recipient, msg.Subject, msg.Content); err != nil {
+	email := service.RawEmail{
+		Recipient: msg.Recipient,
+		UserID:    msg.UserID,
+		Category:  msg.Category,
+		Priority:  msg.Priority,
+		Timezone:  msg.Timezone,
+		Subject:   msg.Subject,
+		Content:   msg.Content,
+		SendAt:    msg.SendAt,
+		DigestKey: msg.DigestKey,
+		Tenant:    msg.Tenant,
+		Template:  msg.Template,
+	}
+	if err := s.emailService.CreateRequest(ctx, msg.RequestID, email); err != nil {
 		if errors.Is(err, service.ErrDuplicateRequestID) {
 			logrus.WithField("request_id", msg.RequestID).Warn("Duplicate request_id")
 			return nil, status.Error(codes.AlreadyExists, "duplicate request_id")
@@ -49,17 +93,55 @@ func (s *Server) SendRawEmail(ctx context.Context, req *types.SendRawEmailReques
 		return nil, status.Error(codes.Internal, "failed to create email history")
 	}
 
-	if err := s.producer.Publish(ctx, queue.EmailMessage{
-		RequestID: msg.RequestID,
-		Recipient: msg.Recipient,
-		Subject:   msg.Subject,
-		Content:   msg.Content,
-	}); err != nil {
-		_ = s.emailService.DeleteRequest(ctx, msg.RequestID)
-		logrus.WithError(err).WithField("request_id", msg.RequestID).Error("Failed to queue email")
-		return nil, status.Error(codes.Internal, "failed to queue email")
+	if email.Digested() {
+		logrus.WithFields(logrus.Fields{
+			"request_id": msg.RequestID,
+			"digest_key": msg.DigestKey,
+		}).Info("Email request held for digest (grpc)")
+		return &types.SendRawEmailResponse{Success: true}, nil
 	}
 
 	logrus.WithField("request_id", msg.RequestID).Info("Email request queued (grpc)")
 	return &types.SendRawEmailResponse{Success: true}, nil
 }
+
+// ListEmailAttempts returns the status of an email and the timeline of its send attempts.
+func (s *Server) ListEmailAttempts(ctx context.Context, req *types.ListEmailAttemptsRequest) (*types.ListEmailAttemptsResponse, error) {
+	requestID := strings.TrimSpace(req.GetRequestId())
+	if requestID == "" {
+		return nil, status.Error(codes.InvalidArgument, dto.ErrMissingRequestID.Error())
+	}
+
+	emailStatus, attempts, err := s.emailService.Attempts(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, service.ErrEmailNotFound) {
+			return nil, status.Error(codes.NotFound, "email not found")
+		}
+		logrus.WithError(err).WithField("request_id", requestID).Error("Failed to load email attempts")
+		return nil, status.Error(codes.Internal, "failed to load email attempts")
+	}
+
+	return dto.EmailAttemptsToGRPC(requestID, emailStatus, attempts), nil
+}
+
+// CancelEmail stops a scheduled, deferred, or not yet processed email.
+func (s *Server) CancelEmail(ctx context.Context, req *types.CancelEmailRequest) (*types.CancelEmailResponse, error) {
+	requestID := strings.TrimSpace(req.GetRequestId())
+	if requestID == "" {
+		return nil, status.Error(codes.InvalidArgument, dto.ErrMissingRequestID.Error())
+	}
+
+	if err := s.emailService.Cancel(ctx, requestID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailNotFound):
+			return nil, status.Error(codes.NotFound, "email not found")
+		case errors.Is(err, service.ErrEmailNotCancellable):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		logrus.WithError(err).WithField("request_id", requestID).Error("Failed to cancel email")
+		return nil, status.Error(codes.Internal, "failed to cancel email")
+	}
+
+	logrus.WithField("request_id", requestID).Info("Email cancelled (grpc)")
+	return &types.CancelEmailResponse{RequestId: requestID, Status: int32(entity.EmailStatusCancelled)}, nil
+}
@@ -2,14 +2,14 @@ package grpc
 
 import (
 	"context"
-	"errors"
+	"database/sql"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-sql-driver/mysql"
 	"github.com/vibast-solutions/ms-go-notifications/app/entity"
-	"github.com/vibast-solutions/ms-go-notifications/app/queue"
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
 	"github.com/vibast-solutions/ms-go-notifications/app/repository"
 	"github.com/vibast-solutions/ms-go-notifications/app/service"
 	types "github.com/vibast-solutions/ms-go-notifications/app/types"
@@ -30,25 +30,14 @@ func (p noopPreparer) Prepare(_ context.Context, _ string, _ string, _ string) (
 
 type noopProvider struct{}
 
-func (p noopProvider) SendRaw(_ context.Context, _ string, _ []byte) error { return nil }
-
-type mockPublisher struct {
-	err      error
-	messages []queue.EmailMessage
-}
-
-func (p *mockPublisher) Publish(_ context.Context, msg queue.EmailMessage) error {
-	if p.err != nil {
-		return p.err
-	}
-	p.messages = append(p.messages, msg)
-	return nil
+func (p noopProvider) SendRaw(_ context.Context, _ string, _ []byte) (provider.SendResult, error) {
+	return provider.SendResult{}, nil
 }
 
 func TestSendRawEmailInvalid(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(nil, nil)
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	_, err := server.SendRawEmail(context.Background(), &types.SendRawEmailRequest{})
 	if status.Code(err) != codes.InvalidArgument {
 		t.Fatalf("expected InvalidArgument, got %v", err)
@@ -64,13 +53,17 @@ func TestSendRawEmailSuccess(t *testing.T) {
 	}
 	defer db.Close()
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO email_history").
-		WithArgs("req-1", "a@b.com", "subj", "content-long", entity.EmailStatusNew).
+		WithArgs("req-1", uint64(0), "a@b.com", "", entity.PriorityNormal, "subj", "content-long", entity.EmailStatusNew, nil, "", "", "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("req-1", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), noopLocker{})
-	pub := &mockPublisher{}
-	server := NewServer(emailService, pub)
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	server := NewServer(emailService, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	resp, err := server.SendRawEmail(context.Background(), &types.SendRawEmailRequest{
 		RequestId: "req-1",
@@ -85,10 +78,6 @@ func TestSendRawEmailSuccess(t *testing.T) {
 		t.Fatalf("expected success=true")
 	}
 
-	if len(pub.messages) != 1 {
-		t.Fatalf("expected 1 published message, got %d", len(pub.messages))
-	}
-
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("expectations: %v", err)
 	}
@@ -104,13 +93,14 @@ func TestSendRawEmailDuplicate(t *testing.T) {
 	defer db.Close()
 
 	mysqlErr := &mysql.MySQLError{Number: 1062}
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO email_history").
-		WithArgs("req-dup", "a@b.com", "subj", "content-long", entity.EmailStatusNew).
+		WithArgs("req-dup", uint64(0), "a@b.com", "", entity.PriorityNormal, "subj", "content-long", entity.EmailStatusNew, nil, "", "", "").
 		WillReturnError(mysqlErr)
+	mock.ExpectRollback()
 
-	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), noopLocker{})
-	pub := &mockPublisher{}
-	server := NewServer(emailService, pub)
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	server := NewServer(emailService, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	_, err = server.SendRawEmail(context.Background(), &types.SendRawEmailRequest{
 		RequestId: "req-dup",
@@ -122,16 +112,12 @@ func TestSendRawEmailDuplicate(t *testing.T) {
 		t.Fatalf("expected AlreadyExists, got %v", err)
 	}
 
-	if len(pub.messages) != 0 {
-		t.Fatalf("expected 0 published messages, got %d", len(pub.messages))
-	}
-
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("expectations: %v", err)
 	}
 }
 
-func TestSendRawEmailPublishFailureDeletesRequest(t *testing.T) {
+func TestSendRawEmailOutboxFailureRollsBack(t *testing.T) {
 	t.Parallel()
 
 	db, mock, err := sqlmock.New()
@@ -140,16 +126,17 @@ func TestSendRawEmailPublishFailureDeletesRequest(t *testing.T) {
 	}
 	defer db.Close()
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO email_history").
-		WithArgs("req-1", "a@b.com", "subj", "content-long", entity.EmailStatusNew).
+		WithArgs("req-1", uint64(0), "a@b.com", "", entity.PriorityNormal, "subj", "content-long", entity.EmailStatusNew, nil, "", "", "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectExec("DELETE FROM email_history").
-		WithArgs("req-1").
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("req-1", "").
+		WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectRollback()
 
-	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), noopLocker{})
-	pub := &mockPublisher{err: errors.New("publish failed")}
-	server := NewServer(emailService, pub)
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	server := NewServer(emailService, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	_, err = server.SendRawEmail(context.Background(), &types.SendRawEmailRequest{
 		RequestId: "req-1",
@@ -165,3 +152,89 @@ func TestSendRawEmailPublishFailureDeletesRequest(t *testing.T) {
 		t.Fatalf("expectations: %v", err)
 	}
 }
+
+func TestCancelEmailSuccess(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(entity.EmailStatusCancelled, "req-1", entity.EmailStatusNew, entity.EmailStatusDeferred, entity.EmailStatusScheduled, entity.EmailStatusDigestPending, entity.EmailStatusCapDeferred, entity.EmailStatusPaused).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	server := NewServer(emailService, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	resp, err := server.CancelEmail(context.Background(), &types.CancelEmailRequest{RequestId: "req-1"})
+	if err != nil {
+		t.Fatalf("CancelEmail: %v", err)
+	}
+	if resp.GetStatus() != int32(entity.EmailStatusCancelled) {
+		t.Fatalf("unexpected status: %d", resp.GetStatus())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestCancelEmailNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE email_history").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-1").WillReturnError(sql.ErrNoRows)
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	server := NewServer(emailService, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	_, err = server.CancelEmail(context.Background(), &types.CancelEmailRequest{RequestId: "req-1"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestListEmailAttempts(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	startedAt := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusTemporaryFailure))
+	mock.ExpectQuery("FROM email_attempts").WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "request_id", "worker", "provider", "started_at", "finished_at", "error_class", "error"}).
+			AddRow(uint64(1), "req-1", "worker-1", "", startedAt, startedAt.Add(time.Second), "unavailable", "no email provider available"))
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), repository.NewEmailAttemptRepository(db), nil, nil, noopLocker{})
+	server := NewServer(emailService, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	resp, err := server.ListEmailAttempts(context.Background(), &types.ListEmailAttemptsRequest{RequestId: "req-1"})
+	if err != nil {
+		t.Fatalf("ListEmailAttempts: %v", err)
+	}
+	if resp.GetStatus() != int32(entity.EmailStatusTemporaryFailure) || len(resp.GetAttempts()) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if attempt := resp.GetAttempts()[0]; attempt.GetErrorClass() != "unavailable" || !attempt.GetStartedAt().AsTime().Equal(startedAt) {
+		t.Fatalf("unexpected attempt: %+v", attempt)
+	}
+
+	_, err = server.ListEmailAttempts(context.Background(), &types.ListEmailAttemptsRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,370 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/lock"
+)
+
+// ErrSchemaOutdated is returned when migrations known to this build are not applied yet.
+var ErrSchemaOutdated = errors.New("database schema is out of date")
+
+// lockKey is the MySQL named lock held while migrations run, so concurrent runs apply or
+// revert each migration once.
+const lockKey = "notifications:schema:migrations"
+
+// lockTimeout is how long a run waits for a concurrent run to finish.
+const lockTimeout = time.Minute
+
+// fileName matches migration files: <version>_<name>.up.sql and <version>_<name>.down.sql.
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// nameSeparators matches the runs of characters replaced by underscores in new migration names.
+var nameSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Migration is one versioned schema change with the SQL applying and reverting it.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is the state of a migration: known to this build, applied to the database, or
+// both. AppliedAt is zero for pending migrations.
+type Status struct {
+	Version   uint64
+	Name      string
+	Known     bool
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Load reads the migrations in fsys, ordered by version. Every version needs an up file;
+// a missing down file leaves the migration irreversible.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Create writes an empty up and down file for a new migration into dir, numbered after
+// the latest migration there, and returns their paths.
+func Create(dir string, name string) (string, string, error) {
+	name = strings.Trim(nameSeparators.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", errors.New("migration name is required")
+	}
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	var version uint64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", version, name))
+	up, down := base+".up.sql", base+".down.sql"
+	if err := os.WriteFile(up, []byte("-- Applied by `migrate up`.\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- Applied by `migrate down`; reverts the up file.\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	locker     *lock.MySQLLocker
+	migrations []Migration
+}
+
+// NewMigrator builds a migrator applying migrations to db and recording them in the
+// schema_migrations table.
+func NewMigrator(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, locker: lock.NewMySQLLocker(db), migrations: migrations}
+}
+
+// Up applies the pending migrations in version order, at most limit of them when limit
+// is positive, and returns the applied ones. MySQL commits schema changes as they run, so
+// a failing migration is left partly applied and not recorded.
+func (m *Migrator) Up(ctx context.Context, limit int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func() error {
+		if err := m.createTable(ctx); err != nil {
+			return err
+		}
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if limit > 0 && len(done) == limit {
+				break
+			}
+			if err := m.exec(ctx, migration.Up); err != nil {
+				return fmt.Errorf("apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			const query = `
+				INSERT INTO schema_migrations (version, name)
+				VALUES (?, ?)
+			`
+			if _, err := m.db.ExecContext(ctx, query, migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("record migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the steps latest applied migrations, newest first, and returns the
+// reverted ones.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func() error {
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+			status := statuses[i]
+			if !status.Applied {
+				continue
+			}
+			migration, ok := m.find(status.Version)
+			if !ok {
+				return fmt.Errorf("migration %d_%s is not known to this build", status.Version, status.Name)
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+			if err := m.exec(ctx, migration.Down); err != nil {
+				return fmt.Errorf("revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			const query = `
+				DELETE FROM schema_migrations
+				WHERE version = ?
+			`
+			if _, err := m.db.ExecContext(ctx, query, migration.Version); err != nil {
+				return fmt.Errorf("unrecord migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status returns every migration known to this build or applied to the database, ordered
+// by version. It does not create the schema_migrations table.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations)+len(applied))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name, Known: true}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, record)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Check returns ErrSchemaOutdated when a migration known to this build is not applied.
+// Applied migrations this build does not know, from a newer build, are allowed, so older
+// replicas keep running while a deployment rolls out.
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, status := range statuses {
+		if status.Known && !status.Applied {
+			pending = append(pending, fmt.Sprintf("%d_%s", status.Version, status.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %s", ErrSchemaOutdated, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// locked runs fn while holding the migrations lock.
+func (m *Migrator) locked(ctx context.Context, fn func() error) error {
+	if err := m.locker.Acquire(ctx, lockKey, lockTimeout); err != nil {
+		return fmt.Errorf("acquire migrations lock: %w", err)
+	}
+	defer func() {
+		_ = m.locker.Release(context.Background(), lockKey)
+	}()
+	return fn()
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	const query = `
+		CREATE TABLE IF NOT EXISTS schema_migrations
+		(
+			version    BIGINT UNSIGNED PRIMARY KEY,
+			name       VARCHAR(255)                       NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL
+		)
+	`
+	_, err := m.db.ExecContext(ctx, query)
+	return err
+}
+
+// applied returns the applied migrations by version; none when the schema_migrations
+// table does not exist yet.
+func (m *Migrator) applied(ctx context.Context) (map[uint64]Status, error) {
+	const tableQuery = `
+		SELECT COUNT(*)
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'
+	`
+	var tables int
+	if err := m.db.QueryRowContext(ctx, tableQuery).Scan(&tables); err != nil {
+		return nil, err
+	}
+	applied := make(map[uint64]Status)
+	if tables == 0 {
+		return applied, nil
+	}
+
+	const query = `
+		SELECT version, name, applied_at
+		FROM schema_migrations
+	`
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		status := Status{Applied: true}
+		if err := rows.Scan(&status.Version, &status.Name, &status.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[status.Version] = status
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) find(version uint64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// exec runs the statements of a migration file one by one. Statements end with a
+// semicolon at the end of a line; comment-only statements are skipped.
+func (m *Migrator) exec(ctx context.Context, script string) error {
+	for _, statement := range statements(script) {
+		if _, err := m.db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statements splits a migration file into its statements.
+func statements(script string) []string {
+	var result []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(script, "\n") {
+		current.WriteString(line)
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			result = appendStatement(result, current.String())
+			current.Reset()
+		}
+	}
+	return appendStatement(result, current.String())
+}
+
+// appendStatement appends statement without its semicolon unless it only holds comments.
+func appendStatement(result []string, statement string) []string {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return append(result, strings.TrimSuffix(strings.TrimSpace(statement), ";"))
+		}
+	}
+	return result
+}
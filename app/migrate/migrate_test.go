@@ -0,0 +1,335 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	gmssql "github.com/dolthub/go-mysql-server/sql"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/migrations"
+)
+
+// newTestDB starts an in-process MySQL-compatible server with an empty notifications
+// database and connects to it.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	database := memory.NewDatabase("notifications")
+	database.BaseDatabase.EnablePrimaryKeyIndexes()
+	provider := memory.NewDBProvider(database)
+	srv, err := server.NewServer(
+		server.Config{Protocol: "tcp", Address: "127.0.0.1:0"},
+		sqle.NewDefault(provider),
+		gmssql.NewContext,
+		memory.NewSessionBuilder(provider),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	go func() {
+		_ = srv.Start()
+	}()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	db, err := sql.Open("mysql", "root@tcp("+srv.Listener.Addr().String()+")/notifications?parseTime=true")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	return db
+}
+
+// tables returns the tables of the connected database.
+func tables(t *testing.T, db *sql.DB) map[string]bool {
+	t.Helper()
+	rows, err := db.Query("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()")
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("scan table: %v", err)
+		}
+		found[name] = true
+	}
+	return found
+}
+
+func TestMigratorEmbeddedMigrations(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t)
+	embedded, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	m := NewMigrator(db, embedded)
+	ctx := context.Background()
+
+	if err := m.Check(ctx); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("expected ErrSchemaOutdated on an empty database, got %v", err)
+	}
+
+	applied, err := m.Up(ctx, 0)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(embedded) {
+		t.Fatalf("expected %d applied migrations, got %d", len(embedded), len(applied))
+	}
+	found := tables(t, db)
+	for _, table := range []string{"schema_migrations", "email_history", "email_attempts", "email_outbox", "email_queue", "email_schedules", "notification_categories"} {
+		if !found[table] {
+			t.Fatalf("expected table %s after Up, got %v", table, found)
+		}
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("Check after Up: %v", err)
+	}
+	if applied, err := m.Up(ctx, 0); err != nil || len(applied) != 0 {
+		t.Fatalf("expected no migrations to apply twice, got %d: %v", len(applied), err)
+	}
+
+	reverted, err := m.Down(ctx, len(embedded))
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if len(reverted) != len(embedded) {
+		t.Fatalf("expected %d reverted migrations, got %d", len(embedded), len(reverted))
+	}
+	if found := tables(t, db); len(found) != 1 || !found["schema_migrations"] {
+		t.Fatalf("expected only schema_migrations after Down, got %v", found)
+	}
+	if err := m.Check(ctx); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("expected ErrSchemaOutdated after Down, got %v", err)
+	}
+}
+
+// baselineSchema is the hand-run schema.sql the service shipped with before migrations.
+var baselineSchema = []string{
+	`CREATE TABLE email_history
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    request_id VARCHAR(64)                        NOT NULL,
+    recipient  VARCHAR(255)                       NOT NULL,
+    subject    VARCHAR(255)                       NOT NULL,
+    content    TEXT                               NOT NULL,
+    status     SMALLINT DEFAULT 0                 NOT NULL,
+    retries    INT      DEFAULT 0                 NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT idx_email_history_request_id
+        UNIQUE (request_id)
+)`,
+	`CREATE INDEX idx_email_history_created_at ON email_history (created_at)`,
+	`CREATE INDEX idx_email_history_recipient ON email_history (recipient)`,
+	`CREATE INDEX idx_email_history_status ON email_history (status)`,
+}
+
+func TestMigratorUpgradesBaselineSchema(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t)
+	for _, statement := range baselineSchema {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("create baseline schema: %v", err)
+		}
+	}
+	if _, err := db.Exec("INSERT INTO email_history (request_id, recipient, subject, content, status) VALUES ('req-1', 'a@b.com', 'subj', 'content', 10)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	embedded, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	m := NewMigrator(db, embedded)
+	ctx := context.Background()
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("Check after Up: %v", err)
+	}
+
+	// The existing email keeps its data and gets the defaults of the columns added since.
+	var (
+		userID   uint64
+		priority string
+		digest   string
+		tenant   string
+		template string
+		status   int16
+	)
+	err = db.QueryRow("SELECT user_id, priority, digest_key, tenant, template, status FROM email_history WHERE request_id = 'req-1'").
+		Scan(&userID, &priority, &digest, &tenant, &template, &status)
+	if err != nil {
+		t.Fatalf("select upgraded email: %v", err)
+	}
+	if userID != 0 || priority != "normal" || digest != "" || tenant != "" || template != "" || status != 10 {
+		t.Fatalf("unexpected upgraded email: user_id=%d priority=%q digest_key=%q tenant=%q template=%q status=%d", userID, priority, digest, tenant, template, status)
+	}
+	if _, err := db.Exec("INSERT INTO email_history (request_id, user_id, recipient, category, subject, content, send_at, batch_id, provider, provider_message_id) VALUES ('req-2', 7, '', 'marketing', 'subj', 'content', NOW(), 'b-1', 'ses', 'ses-1')"); err != nil {
+		t.Fatalf("insert with upgraded columns: %v", err)
+	}
+
+	indexes := make(map[string]bool)
+	rows, err := db.Query("SELECT index_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'email_history'")
+	if err != nil {
+		t.Fatalf("list indexes: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("scan index: %v", err)
+		}
+		indexes[name] = true
+	}
+	for _, index := range []string{"idx_email_history_status", "idx_email_history_digest", "idx_email_history_batch", "idx_email_history_provider_message_id"} {
+		if !indexes[index] {
+			t.Fatalf("expected index %s after Up, got %v", index, indexes)
+		}
+	}
+
+	found := tables(t, db)
+	for _, table := range []string{"email_attempts", "email_batches", "email_outbox", "email_queue", "inapp_notifications", "notifications", "notification_deliveries", "recipient_profiles", "notification_categories", "notification_preferences", "email_schedules"} {
+		if !found[table] {
+			t.Fatalf("expected table %s after Up, got %v", table, found)
+		}
+	}
+}
+
+func TestMigratorStatusAndLimit(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t)
+	fsys := fstest.MapFS{
+		"0001_create_widgets.up.sql":   {Data: []byte("-- Widgets.\nCREATE TABLE widgets (id INT PRIMARY KEY);\n")},
+		"0001_create_widgets.down.sql": {Data: []byte("DROP TABLE widgets;\n")},
+		"0002_add_widget_name.up.sql":  {Data: []byte("ALTER TABLE widgets ADD COLUMN name VARCHAR(32) NOT NULL DEFAULT '';\nCREATE INDEX idx_widgets_name ON widgets (name);\n")},
+		"0003_irreversible.up.sql":     {Data: []byte("INSERT INTO widgets (id, name) VALUES (1, 'a;b');\n")},
+		"README.md":                    {Data: []byte("not a migration")},
+	}
+	all, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	m := NewMigrator(db, all)
+	ctx := context.Background()
+
+	applied, err := m.Up(ctx, 1)
+	if err != nil || len(applied) != 1 || applied[0].Version != 1 {
+		t.Fatalf("expected migration 1 applied, got %+v: %v", applied, err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if len(statuses) != 3 || !statuses[0].Applied || statuses[0].AppliedAt.IsZero() || statuses[1].Applied || statuses[2].Applied {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+	if err := m.Check(ctx); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("expected ErrSchemaOutdated, got %v", err)
+	}
+
+	if applied, err := m.Up(ctx, 0); err != nil || len(applied) != 2 {
+		t.Fatalf("expected migrations 2 and 3 applied, got %+v: %v", applied, err)
+	}
+	var name string
+	if err := db.QueryRow("SELECT name FROM widgets WHERE id = 1").Scan(&name); err != nil || name != "a;b" {
+		t.Fatalf("unexpected widget %q: %v", name, err)
+	}
+	if _, err := m.Down(ctx, 1); err == nil {
+		t.Fatal("expected an error reverting a migration without a down file")
+	}
+
+	// A newer build applied a migration this build does not know.
+	if _, err := db.Exec("INSERT INTO schema_migrations (version, name) VALUES (4, 'newer')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("expected unknown applied migrations to pass the check, got %v", err)
+	}
+	statuses, err = m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if len(statuses) != 4 || statuses[3].Known || !statuses[3].Applied || statuses[3].Name != "newer" {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+}
+
+func TestLoadRejectsInvalidMigrations(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]fstest.MapFS{
+		"missing up": {"0001_a.down.sql": {Data: []byte("DROP TABLE a;")}},
+		"two names": {
+			"0001_a.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+			"0001_b.up.sql": {Data: []byte("CREATE TABLE b (id INT);")},
+		},
+	}
+	for name, fsys := range tests {
+		if _, err := Load(fsys); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestStatements(t *testing.T) {
+	t.Parallel()
+
+	got := statements("-- header\n\nCREATE TABLE a\n(\n    id INT\n);\n-- between\nINSERT INTO a VALUES (1);\n-- trailing\n")
+	want := []string{"-- header\n\nCREATE TABLE a\n(\n    id INT\n)", "-- between\nINSERT INTO a VALUES (1)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements: %q", got)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "0007_existing.up.sql"), []byte("SELECT 1;"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	up, down, err := Create(dir, "Add Widget-Names")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if filepath.Base(up) != "0008_add_widget_names.up.sql" || filepath.Base(down) != "0008_add_widget_names.down.sql" {
+		t.Fatalf("unexpected files %s %s", up, down)
+	}
+	loaded, err := Load(os.DirFS(dir))
+	if err != nil || len(loaded) != 2 || loaded[1].Name != "add_widget_names" {
+		t.Fatalf("unexpected migrations %+v: %v", loaded, err)
+	}
+	if _, _, err := Create(dir, "!!"); err == nil {
+		t.Fatal("expected error for an empty name")
+	}
+}
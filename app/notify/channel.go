@@ -0,0 +1,32 @@
+package notify
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+var ErrNoEmailAddress = errors.New("no email address for recipient")
+
+// Channel delivers a logical notification through one transport.
+type Channel interface {
+	// Name returns the channel identifier used in policies.
+	Name() string
+	// Send hands the notification to the channel and returns a channel-specific reference.
+	Send(ctx context.Context, notification entity.Notification) (string, error)
+}
+
+// channelRequestID derives the idempotency key of a channel send from its parent notification.
+func channelRequestID(notification entity.Notification, channel string) string {
+	return notification.RequestID + ":" + channel
+}
+
+// QueuedChannel is a channel that only queues notifications in Send. Its deliveries stay
+// pending until Outcome reports how the queued send ended.
+type QueuedChannel interface {
+	Channel
+	// Outcome returns the delivery status and error of the queued send with reference,
+	// and false while it has not finished.
+	Outcome(ctx context.Context, reference string) (int16, string, bool, error)
+}
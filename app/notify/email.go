@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type EmailChannel struct {
+	emailService *service.EmailService
+}
+
+// NewEmailChannel builds a channel that queues notifications as emails.
+func NewEmailChannel(emailService *service.EmailService) *EmailChannel {
+	return &EmailChannel{emailService: emailService}
+}
+
+// Name returns the email channel identifier.
+func (c *EmailChannel) Name() string {
+	return entity.ChannelEmail
+}
+
+// Send records and enqueues the email; the reference is the email request ID.
+// Without an explicit address the email is resolved from the user's profile at send time.
+// Emails with a digest key are only recorded; the digest flusher sends them later. An
+// email already recorded by an interrupted dispatch of the notification is not queued again.
+func (c *EmailChannel) Send(ctx context.Context, notification entity.Notification) (string, error) {
+	if notification.Email == "" && notification.UserID == 0 {
+		return "", ErrNoEmailAddress
+	}
+
+	requestID := channelRequestID(notification, entity.ChannelEmail)
+	email := service.RawEmail{
+		Recipient: notification.Email,
+		UserID:    notification.UserID,
+		Category:  notification.Category,
+		Priority:  notification.Priority,
+		Subject:   notification.Title,
+		Content:   notification.Body,
+		DigestKey: notification.DigestKey,
+	}
+	if err := c.emailService.CreateRequest(ctx, requestID, email); err != nil && !errors.Is(err, service.ErrDuplicateRequestID) {
+		return "", err
+	}
+	return requestID, nil
+}
+
+// Outcome maps the status of the email with request ID reference to a delivery status
+// once the email is finished: sent or digested emails are accepted, and emails dropped
+// or failed permanently are failed, so the next fallback channel is tried.
+func (c *EmailChannel) Outcome(ctx context.Context, reference string) (int16, string, bool, error) {
+	status, err := c.emailService.Status(ctx, reference)
+	if errors.Is(err, service.ErrEmailNotFound) {
+		return entity.DeliveryStatusFailed, "email not found", true, nil
+	}
+	if err != nil {
+		return 0, "", false, err
+	}
+
+	switch status {
+	case entity.EmailStatusSuccess, entity.EmailStatusDigested:
+		return entity.DeliveryStatusAccepted, "", true, nil
+	case entity.EmailStatusSkippedByPreference:
+		return entity.DeliveryStatusSkippedByPreference, "skipped_by_preference", true, nil
+	case entity.EmailStatusCapped:
+		return entity.DeliveryStatusFailed, "dropped by frequency cap", true, nil
+	case entity.EmailStatusCancelled:
+		return entity.DeliveryStatusFailed, "email cancelled", true, nil
+	case entity.EmailStatusPermanentFailure, entity.EmailStatusUnknownFailure:
+		return entity.DeliveryStatusFailed, fmt.Sprintf("email failed with status %d", status), true, nil
+	}
+	return 0, "", false, nil
+}
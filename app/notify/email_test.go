@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type noopLocker struct{}
+
+func (l noopLocker) Acquire(_ context.Context, _ string, _ time.Duration) error { return nil }
+func (l noopLocker) Release(_ context.Context, _ string) error                  { return nil }
+
+func TestEmailChannelSendQueuesEmail(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("n-1:email", uint64(0), "a@b.com", "", "", "title", "body", entity.EmailStatusNew, nil, "", "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("n-1:email", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ch := NewEmailChannel(service.NewEmailService(nil, nil, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{}))
+
+	ref, err := ch.Send(context.Background(), entity.Notification{RequestID: "n-1", Email: "a@b.com", Title: "title", Body: "body"})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if ref != "n-1:email" {
+		t.Fatalf("unexpected reference %q", ref)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailChannelSendOutboxFailureRollsBack(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").WillReturnError(errors.New("mysql down"))
+	mock.ExpectRollback()
+
+	ch := NewEmailChannel(service.NewEmailService(nil, nil, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{}))
+
+	if _, err := ch.Send(context.Background(), entity.Notification{RequestID: "n-1", Email: "a@b.com", Title: "title", Body: "body"}); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailChannelSendRequiresAddress(t *testing.T) {
+	t.Parallel()
+
+	ch := NewEmailChannel(nil)
+	if _, err := ch.Send(context.Background(), entity.Notification{RequestID: "n-1"}); !errors.Is(err, ErrNoEmailAddress) {
+		t.Fatalf("expected ErrNoEmailAddress, got %v", err)
+	}
+}
+
+func TestEmailChannelSendAddressesUserWithoutEmail(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("n-1:email", uint64(7), "", "", "", "title", "body", entity.EmailStatusNew, nil, "", "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("n-1:email", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ch := NewEmailChannel(service.NewEmailService(nil, nil, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{}))
+
+	if _, err := ch.Send(context.Background(), entity.Notification{RequestID: "n-1", UserID: 7, Title: "title", Body: "body"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailChannelSendToleratesAlreadyQueuedEmail(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").WillReturnError(&mysql.MySQLError{Number: 1062})
+	mock.ExpectRollback()
+
+	ch := NewEmailChannel(service.NewEmailService(nil, nil, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{}))
+
+	ref, err := ch.Send(context.Background(), entity.Notification{RequestID: "n-1", Email: "a@b.com", Title: "title", Body: "body"})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if ref != "n-1:email" {
+		t.Fatalf("unexpected reference %q", ref)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailChannelOutcome(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		status   int16
+		err      error
+		want     int16
+		finished bool
+	}{
+		{name: "sent", status: entity.EmailStatusSuccess, want: entity.DeliveryStatusAccepted, finished: true},
+		{name: "digested", status: entity.EmailStatusDigested, want: entity.DeliveryStatusAccepted, finished: true},
+		{name: "opted out", status: entity.EmailStatusSkippedByPreference, want: entity.DeliveryStatusSkippedByPreference, finished: true},
+		{name: "capped", status: entity.EmailStatusCapped, want: entity.DeliveryStatusFailed, finished: true},
+		{name: "cancelled", status: entity.EmailStatusCancelled, want: entity.DeliveryStatusFailed, finished: true},
+		{name: "failed", status: entity.EmailStatusPermanentFailure, want: entity.DeliveryStatusFailed, finished: true},
+		{name: "missing", err: sql.ErrNoRows, want: entity.DeliveryStatusFailed, finished: true},
+		{name: "retrying", status: entity.EmailStatusTemporaryFailure, finished: false},
+		{name: "queued", status: entity.EmailStatusNew, finished: false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New: %v", err)
+			}
+			defer db.Close()
+
+			query := mock.ExpectQuery("SELECT status").WithArgs("n-1:email")
+			if tc.err != nil {
+				query.WillReturnError(tc.err)
+			} else {
+				query.WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(tc.status))
+			}
+
+			ch := NewEmailChannel(service.NewEmailService(nil, nil, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{}))
+
+			status, _, finished, err := ch.Outcome(context.Background(), "n-1:email")
+			if err != nil {
+				t.Fatalf("Outcome: %v", err)
+			}
+			if finished != tc.finished || status != tc.want {
+				t.Fatalf("expected status %d finished %t, got %d %t", tc.want, tc.finished, status, finished)
+			}
+		})
+	}
+}
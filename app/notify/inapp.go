@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type InAppChannel struct {
+	inAppService *service.InAppService
+}
+
+// NewInAppChannel builds a channel that stores and broadcasts in-app notifications.
+func NewInAppChannel(inAppService *service.InAppService) *InAppChannel {
+	return &InAppChannel{inAppService: inAppService}
+}
+
+// Name returns the in-app channel identifier.
+func (c *InAppChannel) Name() string {
+	return entity.ChannelInApp
+}
+
+// Send stores the in-app notification; the reference is its notification ID. A
+// notification already stored by an interrupted dispatch is not stored again.
+func (c *InAppChannel) Send(ctx context.Context, notification entity.Notification) (string, error) {
+	n := &entity.InAppNotification{
+		RequestID: channelRequestID(notification, entity.ChannelInApp),
+		UserID:    notification.UserID,
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Body:      notification.Body,
+	}
+	err := c.inAppService.Send(ctx, n)
+	if errors.Is(err, service.ErrDuplicateRequestID) {
+		n, err = c.inAppService.FindByRequestID(ctx, n.RequestID)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(n.ID, 10), nil
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/lock"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+const (
+	// ReconcileLockKey is held by the replica that reconciles notifications.
+	ReconcileLockKey = "notifications:notify:reconcile"
+
+	reconcileBatchSize = 100
+)
+
+// Reconciler finishes notifications that are still processing: it settles notifications
+// whose queued emails finished, running the remaining fallback channels when the email
+// was not sent, and re-dispatches notifications left without deliveries for stuckAfter
+// because the replica dispatching them stopped.
+type Reconciler struct {
+	service    *Service
+	repo       *repository.NotificationRepository
+	locker     lock.Locker
+	interval   time.Duration
+	stuckAfter time.Duration
+}
+
+// NewReconciler constructs a reconciler that checks for notifications to finish every interval.
+func NewReconciler(
+	service *Service,
+	repo *repository.NotificationRepository,
+	locker lock.Locker,
+	interval time.Duration,
+	stuckAfter time.Duration,
+) *Reconciler {
+	return &Reconciler{
+		service:    service,
+		repo:       repo,
+		locker:     locker,
+		interval:   interval,
+		stuckAfter: stuckAfter,
+	}
+}
+
+// Run reconciles notifications every interval until the context is cancelled.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.Reconcile(ctx); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Warn("Notification reconcile failed")
+		}
+	}
+}
+
+// Reconcile settles and re-dispatches one batch of notifications if no other replica is
+// reconciling.
+func (r *Reconciler) Reconcile(ctx context.Context) error {
+	if err := r.locker.Acquire(ctx, ReconcileLockKey, 5*r.interval); err != nil {
+		if errors.Is(err, lock.ErrNotAcquired) || errors.Is(err, lock.ErrAlreadyHeld) {
+			return nil
+		}
+		return fmt.Errorf("acquire reconcile lock: %w", err)
+	}
+	defer func() {
+		_ = r.locker.Release(context.Background(), ReconcileLockKey)
+	}()
+
+	finished, err := r.repo.ListWithFinishedEmails(ctx, reconcileBatchSize)
+	if err != nil {
+		return fmt.Errorf("list notifications with finished emails: %w", err)
+	}
+	for _, requestID := range finished {
+		if err := r.service.Settle(ctx, requestID); err != nil {
+			logrus.WithError(err).WithField("request_id", requestID).Warn("Failed to settle notification")
+		}
+	}
+
+	stuck, err := r.repo.ListStuck(ctx, r.stuckAfter, reconcileBatchSize)
+	if err != nil {
+		return fmt.Errorf("list stuck notifications: %w", err)
+	}
+	for _, requestID := range stuck {
+		logrus.WithField("request_id", requestID).Info("Re-dispatching interrupted notification")
+		if err := r.service.Redispatch(ctx, requestID); err != nil {
+			logrus.WithError(err).WithField("request_id", requestID).Warn("Failed to re-dispatch notification")
+		}
+	}
+	return nil
+}
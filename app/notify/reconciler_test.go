@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/lock"
+)
+
+type heldLocker struct{}
+
+func (l heldLocker) Acquire(_ context.Context, _ string, _ time.Duration) error {
+	return lock.ErrNotAcquired
+}
+func (l heldLocker) Release(_ context.Context, _ string) error { return nil }
+
+func TestReconcilerSettlesAndRedispatches(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	email := &fakeQueuedChannel{fakeChannel: fakeChannel{name: entity.ChannelEmail}, status: entity.DeliveryStatusAccepted, finished: true}
+	inApp := &fakeChannel{name: entity.ChannelInApp}
+	svc := NewService(repo, nil, email, inApp)
+
+	mock.ExpectQuery("JOIN email_history").
+		WillReturnRows(sqlmock.NewRows([]string{"notification_request_id"}).AddRow("n-1"))
+	mock.ExpectQuery("SELECT request_id").WithArgs("n-1").
+		WillReturnRows(notificationRows("n-1", "", `{"fallback":["email"],"always":null}`))
+	mock.ExpectQuery("FROM notification_deliveries").WithArgs("n-1").
+		WillReturnRows(sqlmock.NewRows([]string{"channel", "tier", "status", "reference", "error"}).
+			AddRow(entity.ChannelEmail, entity.PolicyTierFallback, entity.DeliveryStatusPending, "n-1:email", ""))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE notification_deliveries").
+		WithArgs(entity.DeliveryStatusAccepted, "n-1:email", "", "n-1", entity.ChannelEmail).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE notifications").
+		WithArgs(entity.NotificationStatusCompleted, "n-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectQuery("NOT EXISTS").
+		WithArgs(entity.NotificationStatusProcessing, int64(60), reconcileBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"request_id"}).AddRow("n-2"))
+	mock.ExpectQuery("SELECT request_id").WithArgs("n-2").
+		WillReturnRows(notificationRows("n-2", "", `{"fallback":["in_app"],"always":null}`))
+	expectComplete(mock, "n-2", entity.NotificationStatusCompleted, 1)
+
+	r := NewReconciler(svc, repo, noopLocker{}, time.Second, time.Minute)
+	if err := r.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if inApp.calls != 1 {
+		t.Fatalf("expected the interrupted notification to be sent once, got %d", inApp.calls)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestReconcilerSkipsWhenLockHeld(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	r := NewReconciler(NewService(repo, nil), repo, heldLocker{}, time.Second, time.Minute)
+	if err := r.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+var (
+	ErrUnsupportedChannel   = errors.New("unsupported channel")
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+type Service struct {
+	repo        *repository.NotificationRepository
+	preferences *service.PreferenceService
+	channels    map[string]Channel
+}
+
+// NewService builds the notification orchestrator over the given channels. When
+// preferences is set, channels the recipient opted out of for the category are skipped.
+func NewService(repo *repository.NotificationRepository, preferences *service.PreferenceService, channels ...Channel) *Service {
+	registry := make(map[string]Channel, len(channels))
+	for _, ch := range channels {
+		registry[ch.Name()] = ch
+	}
+	return &Service{repo: repo, preferences: preferences, channels: registry}
+}
+
+// ValidatePolicy ensures every channel in the policy is available.
+func (s *Service) ValidatePolicy(policy entity.ChannelPolicy) error {
+	for _, names := range [][]string{policy.Fallback, policy.Always} {
+		for _, name := range names {
+			if _, ok := s.channels[name]; !ok {
+				return fmt.Errorf("%w: %s", ErrUnsupportedChannel, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Notify records the parent notification, sends it through the policy's channels,
+// and stores the per-channel outcome under the parent request ID. Deliveries queued by a
+// QueuedChannel stay pending, and the notification processing, until the reconciler
+// records how the queued send ended.
+func (s *Service) Notify(ctx context.Context, notification *entity.Notification) ([]entity.NotificationDelivery, error) {
+	if err := s.ValidatePolicy(notification.Policy); err != nil {
+		return nil, err
+	}
+
+	notification.Status = entity.NotificationStatusProcessing
+	if err := s.repo.Create(ctx, notification); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return nil, service.ErrDuplicateRequestID
+		}
+		return nil, err
+	}
+
+	deliveries := s.dispatch(ctx, *notification)
+	notification.Status = overallStatus(notification.Policy, deliveries)
+
+	if err := s.repo.Complete(ctx, notification.RequestID, notification.Status, deliveries); err != nil {
+		return nil, fmt.Errorf("store deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Get loads a notification together with its per-channel deliveries.
+func (s *Service) Get(ctx context.Context, requestID string) (*entity.Notification, []entity.NotificationDelivery, error) {
+	notification, err := s.repo.FindByRequestID(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrNotificationNotFound
+		}
+		return nil, nil, err
+	}
+	deliveries, err := s.repo.ListDeliveries(ctx, requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return notification, deliveries, nil
+}
+
+// Settle records the outcome of the notification's finished queued sends and updates its
+// status. When a queued fallback send was not accepted, the fallback channels after it
+// are tried.
+func (s *Service) Settle(ctx context.Context, requestID string) error {
+	notification, deliveries, err := s.Get(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	fallbacks := len(notification.Policy.Fallback)
+	if len(deliveries) != fallbacks+len(notification.Policy.Always) {
+		return fmt.Errorf("notification %s has %d deliveries for a policy of %d channels", requestID, len(deliveries), fallbacks+len(notification.Policy.Always))
+	}
+
+	settled := false
+	for i := range deliveries {
+		d := &deliveries[i]
+		queued, ok := s.channels[d.Channel].(QueuedChannel)
+		if d.Status != entity.DeliveryStatusPending || !ok {
+			continue
+		}
+		status, reason, finished, err := queued.Outcome(ctx, d.Reference)
+		if err != nil {
+			return fmt.Errorf("%s outcome: %w", d.Channel, err)
+		}
+		if !finished {
+			continue
+		}
+		d.Status = status
+		d.Error = truncate(reason, 255)
+		settled = true
+		if i < fallbacks && status != entity.DeliveryStatusAccepted {
+			s.fallback(ctx, *notification, deliveries[:fallbacks], i+1)
+		}
+	}
+	if !settled {
+		return nil
+	}
+
+	status := overallStatus(notification.Policy, deliveries)
+	if err := s.repo.UpdateDeliveries(ctx, requestID, status, deliveries); err != nil {
+		return fmt.Errorf("store deliveries: %w", err)
+	}
+	return nil
+}
+
+// Redispatch sends a notification whose dispatch was interrupted before its deliveries
+// were stored. Channels that already sent it return the earlier reference instead of
+// sending again.
+func (s *Service) Redispatch(ctx context.Context, requestID string) error {
+	notification, err := s.repo.FindByRequestID(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if err := s.ValidatePolicy(notification.Policy); err != nil {
+		return err
+	}
+
+	deliveries := s.dispatch(ctx, *notification)
+	status := overallStatus(notification.Policy, deliveries)
+	if err := s.repo.Complete(ctx, requestID, status, deliveries); err != nil {
+		return fmt.Errorf("store deliveries: %w", err)
+	}
+	return nil
+}
+
+// dispatch tries fallback channels in order until one accepts, then sends to every always channel.
+func (s *Service) dispatch(ctx context.Context, notification entity.Notification) []entity.NotificationDelivery {
+	deliveries := make([]entity.NotificationDelivery, 0, len(notification.Policy.Fallback)+len(notification.Policy.Always))
+
+	for _, name := range notification.Policy.Fallback {
+		deliveries = append(deliveries, entity.NotificationDelivery{Channel: name, Tier: entity.PolicyTierFallback})
+	}
+	s.fallback(ctx, notification, deliveries, 0)
+
+	for _, name := range notification.Policy.Always {
+		deliveries = append(deliveries, s.send(ctx, notification, name, entity.PolicyTierAlways))
+	}
+	return deliveries
+}
+
+// fallback sends through the fallback deliveries from position from until one channel
+// accepts or queues the notification, and marks the channels after it skipped. A fallback
+// channel skipped by preference passes on to the next one.
+func (s *Service) fallback(ctx context.Context, notification entity.Notification, deliveries []entity.NotificationDelivery, from int) {
+	handled := false
+	for i := from; i < len(deliveries); i++ {
+		name := deliveries[i].Channel
+		if handled {
+			deliveries[i] = entity.NotificationDelivery{Channel: name, Tier: entity.PolicyTierFallback, Status: entity.DeliveryStatusSkipped}
+			continue
+		}
+		deliveries[i] = s.send(ctx, notification, name, entity.PolicyTierFallback)
+		handled = deliveries[i].Status == entity.DeliveryStatusAccepted || deliveries[i].Status == entity.DeliveryStatusPending
+	}
+}
+
+// send delivers through a single channel and converts the outcome into a delivery record.
+func (s *Service) send(ctx context.Context, notification entity.Notification, name string, tier string) entity.NotificationDelivery {
+	d := entity.NotificationDelivery{Channel: name, Tier: tier}
+
+	if s.preferences != nil {
+		allowed, err := s.preferences.Allowed(ctx, notification.UserID, notification.Category, name)
+		if err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"request_id": notification.RequestID,
+				"channel":    name,
+			}).Warn("Preference check failed")
+			d.Status = entity.DeliveryStatusFailed
+			d.Error = truncate(err.Error(), 255)
+			return d
+		}
+		if !allowed {
+			d.Status = entity.DeliveryStatusSkippedByPreference
+			d.Error = "skipped_by_preference"
+			return d
+		}
+	}
+
+	reference, err := s.channels[name].Send(ctx, notification)
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"request_id": notification.RequestID,
+			"channel":    name,
+		}).Warn("Channel send failed")
+		d.Status = entity.DeliveryStatusFailed
+		d.Error = truncate(err.Error(), 255)
+		return d
+	}
+
+	d.Status = entity.DeliveryStatusAccepted
+	if _, queued := s.channels[name].(QueuedChannel); queued {
+		d.Status = entity.DeliveryStatusPending
+	}
+	d.Reference = reference
+	return d
+}
+
+// overallStatus summarizes channel outcomes: processing while a queued send is pending,
+// completed when the fallback tier and all always channels succeeded, failed when nothing
+// was accepted, partial otherwise. Channels skipped by preference count as handled: a
+// notification the user opted out of everywhere is completed, not failed.
+func overallStatus(policy entity.ChannelPolicy, deliveries []entity.NotificationDelivery) int16 {
+	for _, d := range deliveries {
+		if d.Status == entity.DeliveryStatusPending {
+			return entity.NotificationStatusProcessing
+		}
+	}
+
+	fallbackAccepted := false
+	fallbackFailed := false
+	anyHandled := false
+	alwaysFailed := false
+	for _, d := range deliveries {
+		switch {
+		case d.Status == entity.DeliveryStatusAccepted:
+			anyHandled = true
+			if d.Tier == entity.PolicyTierFallback {
+				fallbackAccepted = true
+			}
+		case d.Status == entity.DeliveryStatusSkippedByPreference:
+			anyHandled = true
+		case d.Status == entity.DeliveryStatusFailed && d.Tier == entity.PolicyTierFallback:
+			fallbackFailed = true
+		case d.Status == entity.DeliveryStatusFailed && d.Tier == entity.PolicyTierAlways:
+			alwaysFailed = true
+		}
+	}
+	fallbackSatisfied := fallbackAccepted || !fallbackFailed
+
+	switch {
+	case !anyHandled:
+		return entity.NotificationStatusFailed
+	case fallbackSatisfied && !alwaysFailed:
+		return entity.NotificationStatusCompleted
+	default:
+		return entity.NotificationStatusPartial
+	}
+}
+
+// truncate limits a string to max bytes for storage.
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return value[:max]
+}
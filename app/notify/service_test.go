@@ -0,0 +1,434 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type fakeChannel struct {
+	name  string
+	err   error
+	calls int
+	last  entity.Notification
+}
+
+func (c *fakeChannel) Name() string { return c.name }
+
+func (c *fakeChannel) Send(_ context.Context, n entity.Notification) (string, error) {
+	c.calls++
+	c.last = n
+	if c.err != nil {
+		return "", c.err
+	}
+	return n.RequestID + "-" + c.name, nil
+}
+
+func newNotificationRepo(t *testing.T) (*repository.NotificationRepository, sqlmock.Sqlmock, func()) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	return repository.NewNotificationRepository(db), mock, func() { _ = db.Close() }
+}
+
+func expectComplete(mock sqlmock.Sqlmock, requestID string, status int16, deliveries int) {
+	mock.ExpectBegin()
+	for i := 0; i < deliveries; i++ {
+		mock.ExpectExec("INSERT INTO notification_deliveries").WillReturnResult(sqlmock.NewResult(int64(i+1), 1))
+	}
+	mock.ExpectExec("UPDATE notifications").
+		WithArgs(status, requestID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestServiceNotifyFallsOverToNextChannel(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	push := &fakeChannel{name: "push", err: errors.New("no device")}
+	inApp := &fakeChannel{name: entity.ChannelInApp}
+	email := &fakeChannel{name: entity.ChannelEmail}
+	svc := NewService(repo, nil, push, inApp, email)
+
+	mock.ExpectExec("INSERT INTO notifications").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectComplete(mock, "n-1", entity.NotificationStatusCompleted, 3)
+
+	n := &entity.Notification{
+		RequestID: "n-1",
+		UserID:    7,
+		Type:      "comment",
+		Title:     "title",
+		Body:      "body",
+		Policy:    entity.ChannelPolicy{Fallback: []string{"push", entity.ChannelInApp}, Always: []string{entity.ChannelEmail}},
+	}
+	deliveries, err := svc.Notify(context.Background(), n)
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if len(deliveries) != 3 {
+		t.Fatalf("expected 3 deliveries, got %+v", deliveries)
+	}
+	if deliveries[0].Status != entity.DeliveryStatusFailed || deliveries[0].Error != "no device" {
+		t.Fatalf("expected failed push delivery, got %+v", deliveries[0])
+	}
+	if deliveries[1].Status != entity.DeliveryStatusAccepted || deliveries[1].Reference != "n-1-in_app" {
+		t.Fatalf("expected accepted in-app delivery, got %+v", deliveries[1])
+	}
+	if deliveries[2].Tier != entity.PolicyTierAlways || deliveries[2].Status != entity.DeliveryStatusAccepted {
+		t.Fatalf("expected accepted email delivery, got %+v", deliveries[2])
+	}
+	if n.Status != entity.NotificationStatusCompleted {
+		t.Fatalf("expected completed status, got %d", n.Status)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestServiceNotifySkipsRemainingFallbacks(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	inApp := &fakeChannel{name: entity.ChannelInApp}
+	email := &fakeChannel{name: entity.ChannelEmail}
+	svc := NewService(repo, nil, inApp, email)
+
+	mock.ExpectExec("INSERT INTO notifications").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectComplete(mock, "n-2", entity.NotificationStatusCompleted, 2)
+
+	deliveries, err := svc.Notify(context.Background(), &entity.Notification{
+		RequestID: "n-2",
+		Policy:    entity.ChannelPolicy{Fallback: []string{entity.ChannelInApp, entity.ChannelEmail}},
+	})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if email.calls != 0 {
+		t.Fatalf("expected email channel not to be called")
+	}
+	if deliveries[1].Status != entity.DeliveryStatusSkipped {
+		t.Fatalf("expected skipped email delivery, got %+v", deliveries[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestServiceNotifyStatuses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		fallback error
+		always   error
+		want     int16
+	}{
+		{name: "all failed", fallback: errors.New("down"), always: errors.New("down"), want: entity.NotificationStatusFailed},
+		{name: "always failed", fallback: nil, always: errors.New("down"), want: entity.NotificationStatusPartial},
+		{name: "fallback failed", fallback: errors.New("down"), always: nil, want: entity.NotificationStatusPartial},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, mock, cleanup := newNotificationRepo(t)
+			defer cleanup()
+
+			svc := NewService(repo, nil,
+				&fakeChannel{name: entity.ChannelInApp, err: tc.fallback},
+				&fakeChannel{name: entity.ChannelEmail, err: tc.always},
+			)
+
+			mock.ExpectExec("INSERT INTO notifications").WillReturnResult(sqlmock.NewResult(1, 1))
+			expectComplete(mock, "n-3", tc.want, 2)
+
+			n := &entity.Notification{
+				RequestID: "n-3",
+				Policy:    entity.ChannelPolicy{Fallback: []string{entity.ChannelInApp}, Always: []string{entity.ChannelEmail}},
+			}
+			if _, err := svc.Notify(context.Background(), n); err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			if n.Status != tc.want {
+				t.Fatalf("expected status %d, got %d", tc.want, n.Status)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestServiceNotifyUnsupportedChannel(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	svc := NewService(repo, nil, &fakeChannel{name: entity.ChannelEmail})
+
+	_, err := svc.Notify(context.Background(), &entity.Notification{
+		RequestID: "n-4",
+		Policy:    entity.ChannelPolicy{Fallback: []string{"sms"}},
+	})
+	if !errors.Is(err, ErrUnsupportedChannel) {
+		t.Fatalf("expected ErrUnsupportedChannel, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestServiceNotifyDuplicate(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	svc := NewService(repo, nil, &fakeChannel{name: entity.ChannelEmail})
+
+	mock.ExpectExec("INSERT INTO notifications").WillReturnError(&mysql.MySQLError{Number: 1062})
+
+	_, err := svc.Notify(context.Background(), &entity.Notification{
+		RequestID: "n-5",
+		Policy:    entity.ChannelPolicy{Always: []string{entity.ChannelEmail}},
+	})
+	if !errors.Is(err, service.ErrDuplicateRequestID) {
+		t.Fatalf("expected ErrDuplicateRequestID, got %v", err)
+	}
+}
+
+func TestServiceGetNotFound(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	svc := NewService(repo, nil)
+
+	mock.ExpectQuery("SELECT request_id").WithArgs("missing").WillReturnError(sql.ErrNoRows)
+
+	if _, _, err := svc.Get(context.Background(), "missing"); !errors.Is(err, ErrNotificationNotFound) {
+		t.Fatalf("expected ErrNotificationNotFound, got %v", err)
+	}
+}
+
+func TestServiceNotifySkipsChannelsByPreference(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	prefDB, prefMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer prefDB.Close()
+
+	categoryRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"name", "description", "transactional", "updated_at"}).
+			AddRow("marketing", "", false, time.Now())
+	}
+	prefMock.ExpectQuery("FROM notification_categories").WithArgs("marketing").WillReturnRows(categoryRows())
+	prefMock.ExpectQuery("FROM notification_preferences").WithArgs(uint64(7), "marketing", entity.ChannelInApp).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "category", "channel", "enabled"}).
+			AddRow(uint64(7), "marketing", entity.ChannelInApp, false))
+	prefMock.ExpectQuery("FROM notification_categories").WithArgs("marketing").WillReturnRows(categoryRows())
+	prefMock.ExpectQuery("FROM notification_preferences").WithArgs(uint64(7), "marketing", entity.ChannelEmail).
+		WillReturnError(sql.ErrNoRows)
+
+	inApp := &fakeChannel{name: entity.ChannelInApp}
+	email := &fakeChannel{name: entity.ChannelEmail}
+	svc := NewService(repo, service.NewPreferenceService(repository.NewPreferenceRepository(prefDB)), inApp, email)
+
+	mock.ExpectExec("INSERT INTO notifications").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectComplete(mock, "n-9", entity.NotificationStatusCompleted, 2)
+
+	deliveries, err := svc.Notify(context.Background(), &entity.Notification{
+		RequestID: "n-9",
+		UserID:    7,
+		Category:  "marketing",
+		Policy:    entity.ChannelPolicy{Fallback: []string{entity.ChannelInApp, entity.ChannelEmail}},
+	})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if inApp.calls != 0 || email.calls != 1 {
+		t.Fatalf("expected only email to be called, got in_app=%d email=%d", inApp.calls, email.calls)
+	}
+	if deliveries[0].Status != entity.DeliveryStatusSkippedByPreference || deliveries[1].Status != entity.DeliveryStatusAccepted {
+		t.Fatalf("unexpected deliveries: %+v", deliveries)
+	}
+
+	if err := prefMock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("preference expectations: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+type fakeQueuedChannel struct {
+	fakeChannel
+	status   int16
+	reason   string
+	finished bool
+}
+
+func (c *fakeQueuedChannel) Outcome(_ context.Context, _ string) (int16, string, bool, error) {
+	return c.status, c.reason, c.finished, nil
+}
+
+func notificationRows(requestID string, digestKey string, policy string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"request_id", "user_id", "type", "category", "priority", "title", "body", "email", "digest_key", "policy", "status", "created_at"}).
+		AddRow(requestID, uint64(7), "comment", "", entity.PriorityNormal, "title", "body", "", digestKey, policy, entity.NotificationStatusProcessing, time.Now())
+}
+
+func TestServiceNotifyKeepsQueuedDeliveryPending(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	email := &fakeQueuedChannel{fakeChannel: fakeChannel{name: entity.ChannelEmail}}
+	inApp := &fakeChannel{name: entity.ChannelInApp}
+	svc := NewService(repo, nil, email, inApp)
+
+	mock.ExpectExec("INSERT INTO notifications").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectComplete(mock, "n-10", entity.NotificationStatusProcessing, 2)
+
+	n := &entity.Notification{
+		RequestID: "n-10",
+		Policy:    entity.ChannelPolicy{Fallback: []string{entity.ChannelEmail, entity.ChannelInApp}},
+	}
+	deliveries, err := svc.Notify(context.Background(), n)
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if deliveries[0].Status != entity.DeliveryStatusPending || deliveries[1].Status != entity.DeliveryStatusSkipped {
+		t.Fatalf("unexpected deliveries: %+v", deliveries)
+	}
+	if inApp.calls != 0 {
+		t.Fatalf("expected in-app channel not to be called while the email is pending")
+	}
+	if n.Status != entity.NotificationStatusProcessing {
+		t.Fatalf("expected processing status, got %d", n.Status)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestServiceSettleFallsOverWhenQueuedSendFails(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	email := &fakeQueuedChannel{fakeChannel: fakeChannel{name: entity.ChannelEmail}, status: entity.DeliveryStatusFailed, reason: "email failed", finished: true}
+	inApp := &fakeChannel{name: entity.ChannelInApp}
+	svc := NewService(repo, nil, email, inApp)
+
+	mock.ExpectQuery("SELECT request_id").WithArgs("n-11").
+		WillReturnRows(notificationRows("n-11", "", `{"fallback":["email","in_app"],"always":null}`))
+	mock.ExpectQuery("FROM notification_deliveries").WithArgs("n-11").
+		WillReturnRows(sqlmock.NewRows([]string{"channel", "tier", "status", "reference", "error"}).
+			AddRow(entity.ChannelEmail, entity.PolicyTierFallback, entity.DeliveryStatusPending, "n-11:email", "").
+			AddRow(entity.ChannelInApp, entity.PolicyTierFallback, entity.DeliveryStatusSkipped, "", ""))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE notification_deliveries").
+		WithArgs(entity.DeliveryStatusFailed, "n-11:email", "email failed", "n-11", entity.ChannelEmail).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE notification_deliveries").
+		WithArgs(entity.DeliveryStatusAccepted, "n-11-in_app", "", "n-11", entity.ChannelInApp).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE notifications").
+		WithArgs(entity.NotificationStatusCompleted, "n-11").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := svc.Settle(context.Background(), "n-11"); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if inApp.calls != 1 {
+		t.Fatalf("expected in-app fallback to be sent once, got %d", inApp.calls)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestServiceSettleWaitsForUnfinishedSend(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	svc := NewService(repo, nil, &fakeQueuedChannel{fakeChannel: fakeChannel{name: entity.ChannelEmail}})
+
+	mock.ExpectQuery("SELECT request_id").WithArgs("n-12").
+		WillReturnRows(notificationRows("n-12", "", `{"fallback":null,"always":["email"]}`))
+	mock.ExpectQuery("FROM notification_deliveries").WithArgs("n-12").
+		WillReturnRows(sqlmock.NewRows([]string{"channel", "tier", "status", "reference", "error"}).
+			AddRow(entity.ChannelEmail, entity.PolicyTierAlways, entity.DeliveryStatusPending, "n-12:email", ""))
+
+	if err := svc.Settle(context.Background(), "n-12"); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestServiceRedispatchCompletesInterruptedNotification(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newNotificationRepo(t)
+	defer cleanup()
+
+	email := &fakeChannel{name: entity.ChannelEmail}
+	svc := NewService(repo, nil, email)
+
+	mock.ExpectQuery("SELECT request_id").WithArgs("n-13").
+		WillReturnRows(notificationRows("n-13", "weekly", `{"fallback":["email"],"always":null}`))
+	expectComplete(mock, "n-13", entity.NotificationStatusCompleted, 1)
+
+	if err := svc.Redispatch(context.Background(), "n-13"); err != nil {
+		t.Fatalf("Redispatch: %v", err)
+	}
+	if email.calls != 1 {
+		t.Fatalf("expected email channel to be called once, got %d", email.calls)
+	}
+	if email.last.DigestKey != "weekly" {
+		t.Fatalf("expected the stored digest key to be passed on, got %q", email.last.DigestKey)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
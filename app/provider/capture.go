@@ -0,0 +1,300 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCapturedEmailNotFound is returned by capture stores for unknown email IDs.
+var ErrCapturedEmailNotFound = errors.New("captured email not found")
+
+// deliveredToHeader is prepended to the files of the Maildir store, as mail delivery
+// agents do, so the recipient survives alongside the raw email.
+const deliveredToHeader = "Delivered-To: "
+
+// capturedEmailID matches the IDs the capture provider assigns; anything else could
+// escape the Maildir store's directory.
+var capturedEmailID = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+$`)
+
+// CapturedEmail is an email kept by the capture provider instead of being sent. Raw is
+// the MIME message exactly as the provider received it.
+type CapturedEmail struct {
+	ID         string
+	Recipient  string
+	CapturedAt time.Time
+	Raw        []byte
+}
+
+// CapturedContent is the readable part of a captured email.
+type CapturedContent struct {
+	From    string
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+	Headers map[string]string
+}
+
+// Content parses the sender, subject, bodies, and custom headers of the email.
+func (e CapturedEmail) Content() (CapturedContent, error) {
+	parsed, err := parseRawEmail(e.Raw)
+	if err != nil {
+		return CapturedContent{}, err
+	}
+	return CapturedContent{
+		From:    parsed.From,
+		To:      parsed.To,
+		Subject: parsed.Subject,
+		HTML:    parsed.HTML,
+		Text:    parsed.Text,
+		Headers: parsed.Headers,
+	}, nil
+}
+
+// CaptureStore keeps the emails of the capture provider. List returns them oldest first.
+type CaptureStore interface {
+	Save(email CapturedEmail) error
+	List() ([]CapturedEmail, error)
+	Get(id string) (CapturedEmail, error)
+	Clear() error
+}
+
+// CaptureProvider stores every email in a CaptureStore instead of sending it, so local
+// development and e2e tests can inspect the MIME output.
+type CaptureProvider struct {
+	store CaptureStore
+	now   func() time.Time
+	seq   atomic.Uint64
+}
+
+// NewCaptureProvider constructs a provider capturing emails into store.
+func NewCaptureProvider(store CaptureStore) *CaptureProvider {
+	return &CaptureProvider{store: store, now: time.Now}
+}
+
+// SendRaw stores the email and reports the ID it was stored under as its message ID.
+func (p *CaptureProvider) SendRaw(_ context.Context, recipient string, raw []byte) (SendResult, error) {
+	if recipient == "" {
+		return SendResult{}, Permanent(fmt.Errorf("recipient is required"))
+	}
+	if len(raw) == 0 {
+		return SendResult{}, Permanent(fmt.Errorf("raw content is required"))
+	}
+
+	now := p.now()
+	// The process ID keeps IDs unique when several processes share a Maildir store.
+	id := fmt.Sprintf("%d.%d.%d", now.UnixNano(), os.Getpid(), p.seq.Add(1))
+	email := CapturedEmail{
+		ID:         id,
+		Recipient:  recipient,
+		CapturedAt: now.UTC(),
+		Raw:        bytes.Clone(raw),
+	}
+	if err := p.store.Save(email); err != nil {
+		return SendResult{}, fmt.Errorf("capture email: %w", err)
+	}
+
+	return SendResult{Provider: "capture", MessageID: id, Recipients: []string{recipient}}, nil
+}
+
+// MemoryCaptureStore keeps captured emails in memory, dropping the oldest beyond limit.
+// It is only visible to the process that captured the emails.
+type MemoryCaptureStore struct {
+	mu     sync.Mutex
+	emails []CapturedEmail
+	limit  int
+}
+
+// NewMemoryCaptureStore constructs a memory store keeping at most limit emails; a limit
+// of zero or less keeps every email.
+func NewMemoryCaptureStore(limit int) *MemoryCaptureStore {
+	return &MemoryCaptureStore{limit: limit}
+}
+
+// Save appends the email, dropping the oldest one when the store is full.
+func (s *MemoryCaptureStore) Save(email CapturedEmail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emails = append(s.emails, email)
+	if s.limit > 0 && len(s.emails) > s.limit {
+		s.emails = append([]CapturedEmail(nil), s.emails[len(s.emails)-s.limit:]...)
+	}
+	return nil
+}
+
+// List returns the stored emails, oldest first.
+func (s *MemoryCaptureStore) List() ([]CapturedEmail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CapturedEmail(nil), s.emails...), nil
+}
+
+// Get returns the email stored under id.
+func (s *MemoryCaptureStore) Get(id string) (CapturedEmail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, email := range s.emails {
+		if email.ID == id {
+			return email, nil
+		}
+	}
+	return CapturedEmail{}, ErrCapturedEmailNotFound
+}
+
+// Clear removes every stored email.
+func (s *MemoryCaptureStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emails = nil
+	return nil
+}
+
+// MaildirCaptureStore keeps captured emails as <id>.eml files in a Maildir, so they are
+// shared between processes and can be opened by mail clients. Each file starts with a
+// Delivered-To header naming the recipient, followed by the raw email.
+type MaildirCaptureStore struct {
+	dir string
+}
+
+// NewMaildirCaptureStore constructs a store in dir, creating its tmp, new, and cur
+// subdirectories when missing.
+func NewMaildirCaptureStore(dir string) (*MaildirCaptureStore, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("create maildir: %w", err)
+		}
+	}
+	return &MaildirCaptureStore{dir: dir}, nil
+}
+
+// Save writes the email into tmp and moves it into new, so readers never see a partial
+// file.
+func (s *MaildirCaptureStore) Save(email CapturedEmail) error {
+	if !capturedEmailID.MatchString(email.ID) {
+		return fmt.Errorf("invalid captured email id %q", email.ID)
+	}
+	var content bytes.Buffer
+	content.WriteString(deliveredToHeader)
+	content.WriteString(email.Recipient)
+	content.WriteString("\r\n")
+	content.Write(email.Raw)
+
+	name := email.ID + ".eml"
+	tmp := filepath.Join(s.dir, "tmp", name)
+	if err := os.WriteFile(tmp, content.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmp, email.CapturedAt, email.CapturedAt); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, "new", name))
+}
+
+// List returns the emails in new and cur, oldest first.
+func (s *MaildirCaptureStore) List() ([]CapturedEmail, error) {
+	var emails []CapturedEmail
+	for _, sub := range []string{"new", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(s.dir, sub))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			id, ok := capturedIDFromFile(entry.Name())
+			if !ok {
+				continue
+			}
+			email, err := s.read(filepath.Join(s.dir, sub, entry.Name()), id)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			emails = append(emails, email)
+		}
+	}
+	sort.SliceStable(emails, func(i, j int) bool {
+		return emails[i].CapturedAt.Before(emails[j].CapturedAt)
+	})
+	return emails, nil
+}
+
+// Get returns the email stored under id, looking in new and then cur.
+func (s *MaildirCaptureStore) Get(id string) (CapturedEmail, error) {
+	if !capturedEmailID.MatchString(id) {
+		return CapturedEmail{}, ErrCapturedEmailNotFound
+	}
+	for _, sub := range []string{"new", "cur"} {
+		// Mail clients move read emails into cur, appending Maildir flags to the name.
+		files, err := filepath.Glob(filepath.Join(s.dir, sub, id+".eml*"))
+		if err != nil {
+			return CapturedEmail{}, err
+		}
+		for _, file := range files {
+			email, err := s.read(file, id)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return email, err
+		}
+	}
+	return CapturedEmail{}, ErrCapturedEmailNotFound
+}
+
+// Clear removes the email files from tmp, new, and cur.
+func (s *MaildirCaptureStore) Clear() error {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(s.dir, sub))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if _, ok := capturedIDFromFile(entry.Name()); !ok {
+				continue
+			}
+			if err := os.Remove(filepath.Join(s.dir, sub, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *MaildirCaptureStore) read(file string, id string) (CapturedEmail, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return CapturedEmail{}, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return CapturedEmail{}, err
+	}
+
+	email := CapturedEmail{ID: id, CapturedAt: info.ModTime().UTC(), Raw: content}
+	if rest, ok := bytes.CutPrefix(content, []byte(deliveredToHeader)); ok {
+		if line, raw, found := bytes.Cut(rest, []byte("\r\n")); found {
+			email.Recipient = string(line)
+			email.Raw = raw
+		}
+	}
+	return email, nil
+}
+
+// capturedIDFromFile returns the email ID of a Maildir file name written by Save, with or
+// without the flags mail clients append.
+func capturedIDFromFile(name string) (string, bool) {
+	name, _, _ = strings.Cut(name, ":")
+	id, ok := strings.CutSuffix(name, ".eml")
+	return id, ok && capturedEmailID.MatchString(id)
+}
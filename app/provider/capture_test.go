@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCaptureProviderSendRawStoresEmail(t *testing.T) {
+	store := NewMemoryCaptureStore(0)
+	p := NewCaptureProvider(store)
+	result, err := p.SendRaw(context.Background(), "user@example.com", []byte(testRawEmail))
+	if err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+
+	emails, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(emails) != 1 {
+		t.Fatalf("expected 1 captured email, got %d", len(emails))
+	}
+	email := emails[0]
+	if email.Recipient != "user@example.com" || string(email.Raw) != testRawEmail {
+		t.Fatalf("unexpected captured email: %+v", email)
+	}
+	if result.Provider != "capture" || result.MessageID != email.ID {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	content, err := email.Content()
+	if err != nil {
+		t.Fatalf("Content: %v", err)
+	}
+	if content.Subject != "Hello" || content.To != "a@b.com" || content.HTML != "<p>Hi</p>" {
+		t.Fatalf("unexpected content: %+v", content)
+	}
+}
+
+func TestCaptureProviderSendRawRejectsEmptyEmail(t *testing.T) {
+	p := NewCaptureProvider(NewMemoryCaptureStore(0))
+	if _, err := p.SendRaw(context.Background(), "user@example.com", nil); !IsPermanent(err) {
+		t.Fatalf("expected permanent error, got %v", err)
+	}
+}
+
+func TestMemoryCaptureStoreDropsOldest(t *testing.T) {
+	store := NewMemoryCaptureStore(2)
+	for _, id := range []string{"1", "2", "3"} {
+		_ = store.Save(CapturedEmail{ID: id})
+	}
+
+	emails, _ := store.List()
+	if len(emails) != 2 || emails[0].ID != "2" || emails[1].ID != "3" {
+		t.Fatalf("unexpected emails: %+v", emails)
+	}
+	if _, err := store.Get("1"); !errors.Is(err, ErrCapturedEmailNotFound) {
+		t.Fatalf("expected ErrCapturedEmailNotFound, got %v", err)
+	}
+	_ = store.Clear()
+	if emails, _ := store.List(); len(emails) != 0 {
+		t.Fatalf("expected no emails after Clear, got %d", len(emails))
+	}
+}
+
+func TestMaildirCaptureStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewMaildirCaptureStore(dir)
+	if err != nil {
+		t.Fatalf("NewMaildirCaptureStore: %v", err)
+	}
+	first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	emails := []CapturedEmail{
+		{ID: "200.1.2", Recipient: "b@example.com", CapturedAt: first.Add(time.Minute), Raw: []byte(testRawEmail)},
+		{ID: "100.1.1", Recipient: "a@example.com", CapturedAt: first, Raw: []byte(testRawEmail)},
+	}
+	for _, email := range emails {
+		if err := store.Save(email); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	file, err := os.ReadFile(filepath.Join(dir, "new", "100.1.1.eml"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(file) != "Delivered-To: a@example.com\r\n"+testRawEmail {
+		t.Fatalf("unexpected maildir file: %q", file)
+	}
+
+	listed, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(listed) != 2 || listed[0].ID != "100.1.1" || listed[1].ID != "200.1.2" {
+		t.Fatalf("expected emails oldest first, got %+v", listed)
+	}
+	if listed[0].Recipient != "a@example.com" || string(listed[0].Raw) != testRawEmail || !listed[0].CapturedAt.Equal(first) {
+		t.Fatalf("unexpected listed email: %+v", listed[0])
+	}
+
+	// A mail client marking the email as seen moves it into cur with flags.
+	if err := os.Rename(filepath.Join(dir, "new", "200.1.2.eml"), filepath.Join(dir, "cur", "200.1.2.eml:2,S")); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	got, err := store.Get("200.1.2")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Recipient != "b@example.com" {
+		t.Fatalf("unexpected email: %+v", got)
+	}
+	if _, err := store.Get("../100.1.1"); !errors.Is(err, ErrCapturedEmailNotFound) {
+		t.Fatalf("expected ErrCapturedEmailNotFound, got %v", err)
+	}
+
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if listed, _ := store.List(); len(listed) != 0 {
+		t.Fatalf("expected no emails after Clear, got %d", len(listed))
+	}
+}
@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerBuckets is how many slices the failure-rate window is counted in.
+const breakerBuckets = 10
+
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// BreakerConfig configures a CircuitBreaker. The circuit opens when at least MinRequests
+// sends were made within Window and FailureRate of them failed. After OpenTimeout it lets
+// HalfOpenProbes sends through; it closes when they all succeed and opens again on the
+// first failure.
+type BreakerConfig struct {
+	Window         time.Duration
+	MinRequests    int
+	FailureRate    float64
+	OpenTimeout    time.Duration
+	HalfOpenProbes int
+}
+
+type breakerBucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+// CircuitBreaker tracks the failure rate of one provider in this process and stops sends
+// to it while it is failing.
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	cfg      BreakerConfig
+	state    int
+	buckets  [breakerBuckets]breakerBucket
+	openedAt time.Time
+	probes   int
+	probed   int
+}
+
+// NewCircuitBreaker constructs a closed circuit breaker.
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.HalfOpenProbes < 1 {
+		cfg.HalfOpenProbes = 1
+	}
+	return &CircuitBreaker{cfg: cfg}
+}
+
+// Allow reports whether a send may be attempted at now. In the half-open state it admits
+// at most HalfOpenProbes sends until their results are recorded.
+func (b *CircuitBreaker) Allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if now.Sub(b.openedAt) < b.cfg.OpenTimeout {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probes = 0
+		b.probed = 0
+		fallthrough
+	case breakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return false
+		}
+		b.probes++
+		return true
+	default:
+		return true
+	}
+}
+
+// Record counts the result of an allowed send at now.
+func (b *CircuitBreaker) Record(now time.Time, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerHalfOpen:
+		if failed {
+			b.open(now)
+			return
+		}
+		b.probed++
+		if b.probed >= b.cfg.HalfOpenProbes {
+			b.state = breakerClosed
+			b.buckets = [breakerBuckets]breakerBucket{}
+		}
+	case breakerClosed:
+		bucket := b.bucket(now)
+		bucket.requests++
+		if failed {
+			bucket.failures++
+		}
+		requests, failures := b.counts(now)
+		if requests >= b.cfg.MinRequests && float64(failures) >= b.cfg.FailureRate*float64(requests) {
+			b.open(now)
+		}
+	}
+}
+
+// Open reports whether the circuit currently refuses sends.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == breakerOpen
+}
+
+func (b *CircuitBreaker) open(now time.Time) {
+	b.state = breakerOpen
+	b.openedAt = now
+	b.buckets = [breakerBuckets]breakerBucket{}
+}
+
+// bucket returns the bucket counting sends at now, resetting it when it last counted an
+// earlier slice of the window.
+func (b *CircuitBreaker) bucket(now time.Time) *breakerBucket {
+	width := b.cfg.Window / breakerBuckets
+	if width <= 0 {
+		width = time.Millisecond
+	}
+	start := now.Truncate(width)
+	bucket := &b.buckets[(start.UnixNano()/int64(width))%breakerBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+	return bucket
+}
+
+// counts sums the sends and failures counted within the window ending at now.
+func (b *CircuitBreaker) counts(now time.Time) (int, int) {
+	var requests, failures int
+	for _, bucket := range b.buckets {
+		if bucket.start.IsZero() || now.Sub(bucket.start) >= b.cfg.Window {
+			continue
+		}
+		requests += bucket.requests
+		failures += bucket.failures
+	}
+	return requests, failures
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensOnFailureRate(t *testing.T) {
+	t.Parallel()
+
+	b := NewCircuitBreaker(BreakerConfig{
+		Window:         time.Minute,
+		MinRequests:    4,
+		FailureRate:    0.6,
+		OpenTimeout:    30 * time.Second,
+		HalfOpenProbes: 2,
+	})
+	start := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	// Three results are below MinRequests, so two failures out of three keep it closed.
+	for i, failed := range []bool{true, false, true} {
+		if !b.Allow(start) {
+			t.Fatalf("send %d: expected closed circuit", i)
+		}
+		b.Record(start, failed)
+	}
+	if b.Open() {
+		t.Fatal("expected the circuit to stay closed below MinRequests")
+	}
+
+	b.Record(start, false)
+	if b.Open() {
+		t.Fatal("expected 2 of 4 failures to stay below the failure rate")
+	}
+	b.Record(start, true)
+	if !b.Open() {
+		t.Fatal("expected 3 of 5 failures to open the circuit")
+	}
+	if b.Allow(start.Add(10 * time.Second)) {
+		t.Fatal("expected an open circuit to refuse sends")
+	}
+
+	// After OpenTimeout two probes are let through, and no more until they report.
+	probeAt := start.Add(30 * time.Second)
+	if !b.Allow(probeAt) || !b.Allow(probeAt) {
+		t.Fatal("expected two half-open probes")
+	}
+	if b.Allow(probeAt) {
+		t.Fatal("expected a third probe to be refused")
+	}
+	b.Record(probeAt, false)
+	b.Record(probeAt, false)
+	if b.Open() || !b.Allow(probeAt) {
+		t.Fatal("expected successful probes to close the circuit")
+	}
+}
+
+func TestCircuitBreakerReopensOnFailedProbe(t *testing.T) {
+	t.Parallel()
+
+	b := NewCircuitBreaker(BreakerConfig{Window: time.Minute, MinRequests: 1, FailureRate: 1, OpenTimeout: time.Second})
+	start := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	b.Record(start, true)
+	if !b.Open() {
+		t.Fatal("expected the circuit to open")
+	}
+	probeAt := start.Add(time.Second)
+	if !b.Allow(probeAt) {
+		t.Fatal("expected a half-open probe")
+	}
+	b.Record(probeAt, true)
+	if !b.Open() || b.Allow(probeAt.Add(500*time.Millisecond)) {
+		t.Fatal("expected a failed probe to reopen the circuit")
+	}
+}
+
+func TestCircuitBreakerForgetsFailuresOutsideWindow(t *testing.T) {
+	t.Parallel()
+
+	b := NewCircuitBreaker(BreakerConfig{Window: 10 * time.Second, MinRequests: 2, FailureRate: 1, OpenTimeout: time.Second})
+	start := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	b.Record(start, true)
+	b.Record(start.Add(11*time.Second), true)
+	if b.Open() {
+		t.Fatal("expected a failure outside the window not to count")
+	}
+	b.Record(start.Add(12*time.Second), true)
+	if !b.Open() {
+		t.Fatal("expected two failures within the window to open the circuit")
+	}
+}
@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ErrNoProviderAvailable is returned by FailoverProvider when every circuit is open.
+var ErrNoProviderAvailable = errors.New("no email provider available")
+
+// Classes of send errors, as reported by ClassifyError.
+const (
+	ErrorClassPermanent   = "permanent"
+	ErrorClassThrottled   = "throttled"
+	ErrorClassTimeout     = "timeout"
+	ErrorClassUnavailable = "unavailable"
+	ErrorClassTemporary   = "temporary"
+)
+
+// throttlingCodes are the AWS error codes of requests refused for the account's send rate.
+var throttlingCodes = map[string]bool{
+	"TooManyRequestsException": true,
+	"LimitExceededException":   true,
+	"ThrottlingException":      true,
+	"Throttling":               true,
+}
+
+// PermanentError marks a send failure that no retry or other provider can fix, such as a
+// message the provider rejected. Failures not marked permanent are retryable.
+type PermanentError struct {
+	Err error
+}
+
+// Permanent marks err as a permanent send failure.
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	return fmt.Sprintf("permanent: %v", e.Err)
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether err is a permanent send failure.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// ProviderError attributes a send failure to the provider it happened at.
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Provider, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// withProvider attributes err to the named provider unless it is already attributed.
+func withProvider(name string, err error) error {
+	if FailedProvider(err) != "" {
+		return err
+	}
+	return &ProviderError{Provider: name, Err: err}
+}
+
+// FailedProvider returns the name of the provider err happened at, or "" when unknown.
+func FailedProvider(err error) string {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Provider
+	}
+	return ""
+}
+
+// ClassifyError returns the class of a send error: permanent, throttled by the provider,
+// timed out, unavailable because every provider's circuit is open, or another temporary
+// failure. It returns "" for a nil error.
+func ClassifyError(err error) string {
+	var apiErr *APIError
+	var coded interface{ ErrorCode() string }
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case IsPermanent(err):
+		return ErrorClassPermanent
+	case errors.Is(err, ErrNoProviderAvailable):
+		return ErrorClassUnavailable
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests,
+		errors.As(err, &coded) && throttlingCodes[coded.ErrorCode()]:
+		return ErrorClassThrottled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	default:
+		return ErrorClassTemporary
+	}
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "success", err: nil, want: ""},
+		{name: "permanent", err: Permanent(errors.New("rejected")), want: ErrorClassPermanent},
+		{name: "api throttled", err: &APIError{Provider: "sendgrid", StatusCode: 429}, want: ErrorClassThrottled},
+		{name: "ses throttled", err: fmt.Errorf("ses send raw email: %w", &types.TooManyRequestsException{}), want: ErrorClassThrottled},
+		{name: "timeout", err: fmt.Errorf("smtp: %w", context.DeadlineExceeded), want: ErrorClassTimeout},
+		{name: "circuits open", err: ErrNoProviderAvailable, want: ErrorClassUnavailable},
+		{name: "server error", err: &APIError{Provider: "postmark", StatusCode: 503}, want: ErrorClassTemporary},
+	}
+	for _, tc := range tests {
+		if got := ClassifyError(tc.err); got != tc.want {
+			t.Fatalf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestNamedProviderAttributesFailures(t *testing.T) {
+	failing := NewNamedProvider("ses", &stubProvider{err: Permanent(errors.New("rejected"))})
+	_, err := failing.SendRaw(context.Background(), "a@b.com", []byte("raw"))
+	if FailedProvider(err) != "ses" || !IsPermanent(err) || err.Error() != "ses: permanent: rejected" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// An error already attributed, for example by a failover member, keeps its provider.
+	nested := NewNamedProvider("ses,noop", &stubProvider{err: &ProviderError{Provider: "noop", Err: errors.New("down")}})
+	if _, err := nested.SendRaw(context.Background(), "a@b.com", []byte("raw")); FailedProvider(err) != "noop" {
+		t.Fatalf("expected noop, got %v", err)
+	}
+
+	if FailedProvider(errors.New("plain")) != "" {
+		t.Fatal("expected no provider for an unattributed error")
+	}
+}
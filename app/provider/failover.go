@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FailoverMember is one provider of a FailoverProvider.
+type FailoverMember struct {
+	Name     string
+	Provider EmailProvider
+}
+
+type failoverMember struct {
+	FailoverMember
+	breaker *CircuitBreaker
+}
+
+// FailoverProvider sends through an ordered list of providers. A retryable failure moves
+// on to the next provider; a permanent one is returned as is. Each provider has its own
+// circuit breaker, and providers whose circuit is open are skipped.
+type FailoverProvider struct {
+	members []failoverMember
+	now     func() time.Time
+}
+
+// NewFailoverProvider constructs a failover provider trying members in order.
+func NewFailoverProvider(members []FailoverMember, breaker BreakerConfig) *FailoverProvider {
+	p := &FailoverProvider{now: time.Now}
+	for _, member := range members {
+		p.members = append(p.members, failoverMember{
+			FailoverMember: member,
+			breaker:        NewCircuitBreaker(breaker),
+		})
+	}
+	return p
+}
+
+// SendRaw sends through the first provider that accepts the email. It returns
+// ErrNoProviderAvailable when every circuit is open, else the last provider's error. It
+// stops as soon as the context is done.
+func (p *FailoverProvider) SendRaw(ctx context.Context, recipient string, raw []byte) (SendResult, error) {
+	var lastErr error
+	for _, member := range p.members {
+		if !member.breaker.Allow(p.now()) {
+			logrus.WithField("provider", member.Name).Debug("Provider circuit open; skipping")
+			continue
+		}
+
+		result, err := member.Provider.SendRaw(ctx, recipient, raw)
+		if err == nil {
+			member.breaker.Record(p.now(), false)
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			// A cancelled or expired send, such as a pacer wait that outlasted the
+			// deadline, says nothing about the provider's health either.
+			return SendResult{}, withProvider(member.Name, err)
+		}
+		if IsPermanent(err) {
+			// A rejected message says nothing about the provider's health.
+			member.breaker.Record(p.now(), false)
+			return SendResult{}, withProvider(member.Name, err)
+		}
+
+		member.breaker.Record(p.now(), true)
+		if member.breaker.Open() {
+			logrus.WithField("provider", member.Name).Warn("Provider circuit opened")
+		}
+		logrus.WithError(err).WithField("provider", member.Name).Warn("Provider send failed; trying next provider")
+		lastErr = withProvider(member.Name, err)
+	}
+	if lastErr == nil {
+		return SendResult{}, ErrNoProviderAvailable
+	}
+	return SendResult{}, lastErr
+}
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (p *stubProvider) SendRaw(_ context.Context, recipient string, _ []byte) (SendResult, error) {
+	p.calls++
+	if p.err != nil {
+		return SendResult{}, p.err
+	}
+	return SendResult{Provider: p.name, Recipients: []string{recipient}}, nil
+}
+
+func newTestFailover(members ...*stubProvider) *FailoverProvider {
+	list := make([]FailoverMember, 0, len(members))
+	for _, member := range members {
+		list = append(list, FailoverMember{Name: member.name, Provider: member})
+	}
+	return NewFailoverProvider(list, BreakerConfig{
+		Window:         time.Minute,
+		MinRequests:    2,
+		FailureRate:    0.5,
+		OpenTimeout:    time.Minute,
+		HalfOpenProbes: 1,
+	})
+}
+
+func TestFailoverProviderFallsOverOnRetryableFailure(t *testing.T) {
+	t.Parallel()
+
+	primary := &stubProvider{name: "ses", err: errors.New("throttled")}
+	secondary := &stubProvider{name: "noop"}
+	p := newTestFailover(primary, secondary)
+
+	result, err := p.SendRaw(context.Background(), "a@b.com", []byte("raw"))
+	if err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if result.Provider != "noop" {
+		t.Fatalf("expected delivery through noop, got %q", result.Provider)
+	}
+
+	// The second failure opens the primary's circuit; later sends skip it.
+	if _, err := p.SendRaw(context.Background(), "a@b.com", []byte("raw")); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if _, err := p.SendRaw(context.Background(), "a@b.com", []byte("raw")); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if primary.calls != 2 || secondary.calls != 3 {
+		t.Fatalf("expected the open circuit to skip the primary, got primary=%d secondary=%d", primary.calls, secondary.calls)
+	}
+}
+
+func TestFailoverProviderStopsOnPermanentFailure(t *testing.T) {
+	t.Parallel()
+
+	primary := &stubProvider{name: "ses", err: Permanent(errors.New("message rejected"))}
+	secondary := &stubProvider{name: "noop"}
+
+	_, err := newTestFailover(primary, secondary).SendRaw(context.Background(), "a@b.com", []byte("raw"))
+	if !IsPermanent(err) {
+		t.Fatalf("expected a permanent error, got %v", err)
+	}
+	if secondary.calls != 0 {
+		t.Fatal("expected a permanent failure not to fall over")
+	}
+}
+
+func TestFailoverProviderAllUnavailable(t *testing.T) {
+	t.Parallel()
+
+	primary := &stubProvider{name: "ses", err: errors.New("unavailable")}
+	p := newTestFailover(primary)
+
+	for i := 0; i < 2; i++ {
+		_, err := p.SendRaw(context.Background(), "a@b.com", []byte("raw"))
+		if err == nil || errors.Is(err, ErrNoProviderAvailable) || IsPermanent(err) {
+			t.Fatalf("send %d: expected the provider's retryable error, got %v", i, err)
+		}
+	}
+	if _, err := p.SendRaw(context.Background(), "a@b.com", []byte("raw")); !errors.Is(err, ErrNoProviderAvailable) {
+		t.Fatalf("expected ErrNoProviderAvailable, got %v", err)
+	}
+}
+
+func TestFailoverProviderStopsWithoutTrippingOnContextError(t *testing.T) {
+	t.Parallel()
+
+	primary := &stubProvider{name: "ses"}
+	secondary := &stubProvider{name: "smtp"}
+	pacer := &recordingPacer{err: context.DeadlineExceeded}
+	p := NewFailoverProvider([]FailoverMember{
+		{Name: "ses", Provider: NewPacedProvider("ses", primary, pacer, "noreply@example.com")},
+		{Name: "smtp", Provider: secondary},
+	}, BreakerConfig{Window: time.Minute, MinRequests: 2, FailureRate: 0.5, OpenTimeout: time.Minute, HalfOpenProbes: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		if _, err := p.SendRaw(ctx, "a@b.com", []byte("raw")); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("send %d: expected the pacer's context error, got %v", i, err)
+		}
+	}
+	if secondary.calls != 0 {
+		t.Fatalf("expected no fallback after a context error, got %d calls", secondary.calls)
+	}
+	if p.members[0].breaker.Open() {
+		t.Fatal("expected the circuit to stay closed after context errors")
+	}
+
+	pacer.err = nil
+	if _, err := p.SendRaw(context.Background(), "a@b.com", []byte("raw")); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if primary.calls != 1 {
+		t.Fatalf("expected the primary to send once its context is live, got %d calls", primary.calls)
+	}
+}
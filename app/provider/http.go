@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxAPIErrorBody bounds how much of a failed API response is kept in the error.
+const maxAPIErrorBody = 512
+
+// APIError is a send rejected by a provider's HTTP API.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s api: status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// newAPIError reads the start of a failed response into an APIError.
+func newAPIError(provider string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxAPIErrorBody))
+	return &APIError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+	}
+}
+
+// classifyAPIError marks errors of malformed or oversized messages as permanent. Other
+// statuses, including authentication errors and throttling, are retryable, so failover
+// can move to another provider.
+func classifyAPIError(err *APIError) error {
+	switch err.StatusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return Permanent(err)
+	default:
+		return err
+	}
+}
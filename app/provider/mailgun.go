@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// MailgunProvider sends raw MIME email through the Mailgun messages.mime endpoint of one
+// sending domain.
+type MailgunProvider struct {
+	client  *http.Client
+	baseURL string
+	domain  string
+	apiKey  string
+}
+
+// NewMailgunProvider builds a provider sending through Mailgun. baseURL is the API host,
+// for example https://api.mailgun.net or https://api.eu.mailgun.net for EU domains.
+func NewMailgunProvider(baseURL, domain, apiKey string) *MailgunProvider {
+	return &MailgunProvider{
+		client:  &http.Client{},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		domain:  domain,
+		apiKey:  apiKey,
+	}
+}
+
+// SendRaw uploads the raw MIME email for recipient. Malformed and oversized messages fail
+// permanently; authentication, throttling, and server errors are retryable.
+func (p *MailgunProvider) SendRaw(ctx context.Context, recipient string, raw []byte) (SendResult, error) {
+	if recipient == "" {
+		return SendResult{}, Permanent(fmt.Errorf("recipient is required"))
+	}
+	if len(raw) == 0 {
+		return SendResult{}, Permanent(fmt.Errorf("raw content is required"))
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("to", recipient); err != nil {
+		return SendResult{}, fmt.Errorf("mailgun build request: %w", err)
+	}
+	message, err := form.CreateFormFile("message", "message.eml")
+	if err != nil {
+		return SendResult{}, fmt.Errorf("mailgun build request: %w", err)
+	}
+	if _, err := message.Write(raw); err != nil {
+		return SendResult{}, fmt.Errorf("mailgun build request: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return SendResult{}, fmt.Errorf("mailgun build request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v3/%s/messages.mime", p.baseURL, p.domain)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return SendResult{}, fmt.Errorf("mailgun build request: %w", err)
+	}
+	req.SetBasicAuth("api", p.apiKey)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return SendResult{}, fmt.Errorf("mailgun send raw email: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return SendResult{}, fmt.Errorf("mailgun send raw email: %w", classifyAPIError(newAPIError("mailgun", resp)))
+	}
+
+	var result struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return SendResult{}, fmt.Errorf("mailgun decode response: %w", err)
+	}
+	return SendResult{
+		Provider:   "mailgun",
+		MessageID:  strings.Trim(result.ID, "<>"),
+		Recipients: []string{recipient},
+		Metadata:   map[string]string{"message": result.Message},
+	}, nil
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMailgunProviderSendRaw(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, key, _ := r.BasicAuth()
+		if r.URL.Path != "/v3/mg.example.com/messages.mime" || user != "api" || key != "key-1" {
+			t.Errorf("unexpected request %s with auth %s:%s", r.URL.Path, user, key)
+		}
+		if r.FormValue("to") != "a@b.com" {
+			t.Errorf("unexpected to: %q", r.FormValue("to"))
+		}
+		file, _, err := r.FormFile("message")
+		if err != nil {
+			t.Errorf("FormFile: %v", err)
+		} else if message, _ := io.ReadAll(file); string(message) != "raw mime" {
+			t.Errorf("unexpected message: %q", message)
+		}
+		_, _ = w.Write([]byte(`{"id":"<20300107.1@mg.example.com>","message":"Queued. Thank you."}`))
+	}))
+	defer server.Close()
+
+	result, err := NewMailgunProvider(server.URL, "mg.example.com", "key-1").SendRaw(context.Background(), "a@b.com", []byte("raw mime"))
+	if err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if result.Provider != "mailgun" || result.MessageID != "20300107.1@mg.example.com" || result.Metadata["message"] != "Queued. Thank you." {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestMailgunProviderClassifiesErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{status: http.StatusBadRequest, permanent: true},
+		{status: http.StatusUnauthorized, permanent: false},
+		{status: http.StatusTooManyRequests, permanent: false},
+		{status: http.StatusInternalServerError, permanent: false},
+	}
+	for _, tc := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(tc.status)
+			_, _ = w.Write([]byte(`{"message":"failed"}`))
+		}))
+		_, err := NewMailgunProvider(server.URL, "mg.example.com", "key-1").SendRaw(context.Background(), "a@b.com", []byte("raw mime"))
+		server.Close()
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tc.status {
+			t.Fatalf("status %d: expected an APIError, got %v", tc.status, err)
+		}
+		if IsPermanent(err) != tc.permanent {
+			t.Fatalf("status %d: expected permanent=%v, got %v", tc.status, tc.permanent, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// parsedEmail is the part of a raw MIME email that JSON send APIs take. Headers holds the
+// custom X- headers, which those APIs pass through.
+type parsedEmail struct {
+	From    string
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+	Headers map[string]string
+}
+
+// parseRawEmail extracts the sender, subject, bodies, and custom headers of a raw email.
+// The first text/html and text/plain parts are used; attachments are not supported.
+func parseRawEmail(raw []byte) (parsedEmail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return parsedEmail{}, fmt.Errorf("parse raw email: %w", err)
+	}
+
+	var decoder mime.WordDecoder
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	email := parsedEmail{
+		From:    msg.Header.Get("From"),
+		To:      msg.Header.Get("To"),
+		Subject: subject,
+	}
+	for key := range msg.Header {
+		if strings.HasPrefix(key, "X-") {
+			if email.Headers == nil {
+				email.Headers = make(map[string]string)
+			}
+			email.Headers[key] = msg.Header.Get(key)
+		}
+	}
+
+	if err := readMIMEBody(&email, textproto.MIMEHeader(msg.Header), msg.Body); err != nil {
+		return parsedEmail{}, fmt.Errorf("parse raw email: %w", err)
+	}
+	if email.HTML == "" && email.Text == "" {
+		return parsedEmail{}, fmt.Errorf("parse raw email: no text or html body")
+	}
+	return email, nil
+}
+
+// readMIMEBody stores the first text/html and text/plain bodies of a part, descending
+// into multipart parts.
+func readMIMEBody(email *parsedEmail, header textproto.MIMEHeader, body io.Reader) error {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := readMIMEBody(email, part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	if mediaType != "text/html" && mediaType != "text/plain" {
+		return nil
+	}
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, newLineStripper(body))
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if mediaType == "text/html" && email.HTML == "" {
+		email.HTML = string(content)
+	}
+	if mediaType == "text/plain" && email.Text == "" {
+		email.Text = string(content)
+	}
+	return nil
+}
+
+// lineStripper drops line breaks, which base64 bodies are wrapped with.
+type lineStripper struct {
+	r io.Reader
+}
+
+func newLineStripper(r io.Reader) io.Reader {
+	return &lineStripper{r: r}
+}
+
+func (s *lineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
@@ -0,0 +1,48 @@
+package provider
+
+import "testing"
+
+func TestParseRawEmailMultipart(t *testing.T) {
+	t.Parallel()
+
+	raw := "From: Notifications <noreply@example.com>\r\n" +
+		"To: a@b.com\r\n" +
+		"Subject: =?UTF-8?Q?Bun=C4=83_ziua?=\r\n" +
+		"X-Tenant: acme\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=b1\r\n" +
+		"\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Hello =C8=99i bun=C4=83\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/html; charset=UTF-8\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"PHA+SGVs\r\nbG88L3A+\r\n" +
+		"--b1--\r\n"
+
+	email, err := parseRawEmail([]byte(raw))
+	if err != nil {
+		t.Fatalf("parseRawEmail: %v", err)
+	}
+	if email.Subject != "Bună ziua" || email.From != "Notifications <noreply@example.com>" {
+		t.Fatalf("unexpected headers: %+v", email)
+	}
+	if email.Text != "Hello și bună" || email.HTML != "<p>Hello</p>" {
+		t.Fatalf("unexpected bodies: text=%q html=%q", email.Text, email.HTML)
+	}
+	if email.Headers["X-Tenant"] != "acme" || len(email.Headers) != 1 {
+		t.Fatalf("unexpected custom headers: %v", email.Headers)
+	}
+}
+
+func TestParseRawEmailRequiresBody(t *testing.T) {
+	t.Parallel()
+
+	if _, err := parseRawEmail([]byte("From: a@b.com\r\nContent-Type: image/png\r\n\r\nxx")); err == nil {
+		t.Fatal("expected an email without text or html body to be rejected")
+	}
+}
@@ -0,0 +1,24 @@
+package provider
+
+import "context"
+
+// NamedProvider attributes the send failures of a provider to its name with a
+// ProviderError, so callers can tell which provider failed.
+type NamedProvider struct {
+	name     string
+	provider EmailProvider
+}
+
+// NewNamedProvider wraps provider under name.
+func NewNamedProvider(name string, provider EmailProvider) *NamedProvider {
+	return &NamedProvider{name: name, provider: provider}
+}
+
+// SendRaw sends through the wrapped provider.
+func (p *NamedProvider) SendRaw(ctx context.Context, recipient string, raw []byte) (SendResult, error) {
+	result, err := p.provider.SendRaw(ctx, recipient, raw)
+	if err != nil {
+		return result, withProvider(p.name, err)
+	}
+	return result, nil
+}
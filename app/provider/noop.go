@@ -10,7 +10,7 @@ func NewNoopProvider() *NoopProvider {
 	return &NoopProvider{}
 }
 
-// SendRaw returns nil without sending.
-func (p *NoopProvider) SendRaw(_ context.Context, _ string, _ []byte) error {
-	return nil
+// SendRaw reports the email as accepted without sending it.
+func (p *NoopProvider) SendRaw(_ context.Context, recipient string, _ []byte) (SendResult, error) {
+	return SendResult{Provider: "noop", Recipients: []string{recipient}}, nil
 }
@@ -0,0 +1,37 @@
+package provider
+
+import "context"
+
+// Pacer holds sends back to the rate allowed for a provider and the address they are sent
+// from.
+type Pacer interface {
+	Wait(ctx context.Context, provider string, sender string) error
+}
+
+// PacedProvider waits for its pacer before every send, so the sends of each provider are
+// paced by that provider's rate and the domain of the email's sender, whichever provider
+// failover or routing picked.
+type PacedProvider struct {
+	name     string
+	sender   string
+	provider EmailProvider
+	pacer    Pacer
+}
+
+// NewPacedProvider wraps the provider named name. sender is the address of emails whose
+// route has no sender.
+func NewPacedProvider(name string, provider EmailProvider, pacer Pacer, sender string) *PacedProvider {
+	return &PacedProvider{name: name, sender: sender, provider: provider, pacer: pacer}
+}
+
+// SendRaw waits for the pacer and sends through the wrapped provider.
+func (p *PacedProvider) SendRaw(ctx context.Context, recipient string, raw []byte) (SendResult, error) {
+	sender := routeFromContext(ctx).Sender
+	if sender == "" {
+		sender = p.sender
+	}
+	if err := p.pacer.Wait(ctx, p.name, sender); err != nil {
+		return SendResult{}, err
+	}
+	return p.provider.SendRaw(ctx, recipient, raw)
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type recordingPacer struct {
+	waits []string
+	err   error
+}
+
+func (p *recordingPacer) Wait(_ context.Context, provider string, sender string) error {
+	p.waits = append(p.waits, provider+" "+sender)
+	return p.err
+}
+
+func TestPacedProviderPacesTheProviderThatSends(t *testing.T) {
+	t.Parallel()
+
+	pacer := &recordingPacer{}
+	primary := &stubProvider{name: "ses", err: errors.New("throttled")}
+	secondary := &stubProvider{name: "smtp"}
+	p := NewFailoverProvider([]FailoverMember{
+		{Name: "ses", Provider: NewPacedProvider("ses", primary, pacer, "noreply@example.com")},
+		{Name: "smtp", Provider: NewPacedProvider("smtp", secondary, pacer, "noreply@example.com")},
+	}, BreakerConfig{MinRequests: 10})
+
+	if _, err := p.SendRaw(context.Background(), "a@b.com", []byte("raw")); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	ctx := WithRoute(context.Background(), Route{Sender: "news@news.example.com"})
+	if _, err := p.SendRaw(ctx, "a@b.com", []byte("raw")); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+
+	want := []string{
+		"ses noreply@example.com",
+		"smtp noreply@example.com",
+		"ses news@news.example.com",
+		"smtp news@news.example.com",
+	}
+	if !reflect.DeepEqual(pacer.waits, want) {
+		t.Fatalf("unexpected waits: %q", pacer.waits)
+	}
+}
+
+func TestPacedProviderDoesNotSendWhenWaitFails(t *testing.T) {
+	t.Parallel()
+
+	inner := &stubProvider{name: "ses"}
+	p := NewPacedProvider("ses", inner, &recordingPacer{err: context.Canceled}, "noreply@example.com")
+
+	if _, err := p.SendRaw(context.Background(), "a@b.com", []byte("raw")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if inner.calls != 0 {
+		t.Fatalf("expected no send, got %d", inner.calls)
+	}
+}
@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// postmarkPermanentCodes are the Postmark API error codes of a message no retry can fix:
+// an invalid email request and an inactive (bounced or unsubscribed) recipient.
+var postmarkPermanentCodes = map[int]bool{300: true, 406: true}
+
+// PostmarkProvider sends email through the Postmark email API of one server. The API takes
+// JSON rather than MIME, so the raw email's sender, subject, bodies, and X- headers are sent.
+type PostmarkProvider struct {
+	client        *http.Client
+	baseURL       string
+	serverToken   string
+	messageStream string
+}
+
+type postmarkHeader struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+type postmarkRequest struct {
+	From          string           `json:"From"`
+	To            string           `json:"To"`
+	Subject       string           `json:"Subject"`
+	HtmlBody      string           `json:"HtmlBody,omitempty"`
+	TextBody      string           `json:"TextBody,omitempty"`
+	Headers       []postmarkHeader `json:"Headers,omitempty"`
+	MessageStream string           `json:"MessageStream,omitempty"`
+}
+
+type postmarkResponse struct {
+	To          string `json:"To"`
+	SubmittedAt string `json:"SubmittedAt"`
+	MessageID   string `json:"MessageID"`
+	ErrorCode   int    `json:"ErrorCode"`
+	Message     string `json:"Message"`
+}
+
+// NewPostmarkProvider builds a provider sending through Postmark; baseURL is normally
+// https://api.postmarkapp.com and an empty messageStream uses the server's default stream.
+func NewPostmarkProvider(baseURL, serverToken, messageStream string) *PostmarkProvider {
+	return &PostmarkProvider{
+		client:        &http.Client{},
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		serverToken:   serverToken,
+		messageStream: messageStream,
+	}
+}
+
+// SendRaw sends the email to recipient. Unparseable and invalid messages and inactive
+// recipients fail permanently; token, account, throttling, and server errors are retryable.
+func (p *PostmarkProvider) SendRaw(ctx context.Context, recipient string, raw []byte) (SendResult, error) {
+	if recipient == "" {
+		return SendResult{}, Permanent(fmt.Errorf("recipient is required"))
+	}
+	email, err := parseRawEmail(raw)
+	if err != nil {
+		return SendResult{}, Permanent(err)
+	}
+
+	request := postmarkRequest{
+		From:          email.From,
+		To:            recipient,
+		Subject:       email.Subject,
+		HtmlBody:      email.HTML,
+		TextBody:      email.Text,
+		MessageStream: p.messageStream,
+	}
+	names := make([]string, 0, len(email.Headers))
+	for name := range email.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		request.Headers = append(request.Headers, postmarkHeader{Name: name, Value: email.Headers[name]})
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return SendResult{}, fmt.Errorf("postmark build request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/email", bytes.NewReader(payload))
+	if err != nil {
+		return SendResult{}, fmt.Errorf("postmark build request: %w", err)
+	}
+	req.Header.Set("X-Postmark-Server-Token", p.serverToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return SendResult{}, fmt.Errorf("postmark send email: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		apiErr := newAPIError("postmark", resp)
+		return SendResult{}, fmt.Errorf("postmark send email: %w", classifyPostmarkError(apiErr))
+	}
+
+	var result postmarkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return SendResult{}, fmt.Errorf("postmark decode response: %w", err)
+	}
+	accepted := recipient
+	if result.To != "" {
+		accepted = result.To
+	}
+	return SendResult{
+		Provider:   "postmark",
+		MessageID:  result.MessageID,
+		Recipients: []string{accepted},
+		Metadata:   map[string]string{"submitted_at": result.SubmittedAt, "message": result.Message},
+	}, nil
+}
+
+// classifyPostmarkError marks rejected messages as permanent. Postmark reports most errors
+// with status 422 and an ErrorCode telling message problems from account problems.
+func classifyPostmarkError(err *APIError) error {
+	if err.StatusCode != http.StatusUnprocessableEntity {
+		return classifyAPIError(err)
+	}
+	var body postmarkResponse
+	if json.Unmarshal([]byte(err.Body), &body) == nil && postmarkPermanentCodes[body.ErrorCode] {
+		return Permanent(err)
+	}
+	return err
+}
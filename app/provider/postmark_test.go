@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostmarkProviderSendRaw(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/email" || r.Header.Get("X-Postmark-Server-Token") != "pm-token" {
+			t.Errorf("unexpected request %s with token %q", r.URL.Path, r.Header.Get("X-Postmark-Server-Token"))
+		}
+		var body postmarkRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if body.From != "Notifications <noreply@example.com>" || body.To != "a@b.com" || body.Subject != "Hello" ||
+			body.HtmlBody != "<p>Hi</p>" || body.MessageStream != "broadcast" {
+			t.Errorf("unexpected body: %+v", body)
+		}
+		if len(body.Headers) != 1 || body.Headers[0] != (postmarkHeader{Name: "X-Category", Value: "marketing"}) {
+			t.Errorf("unexpected headers: %+v", body.Headers)
+		}
+		_, _ = w.Write([]byte(`{"To":"a@b.com","SubmittedAt":"2026-01-02T03:04:05Z","MessageID":"pm-msg-1","ErrorCode":0,"Message":"OK"}`))
+	}))
+	defer server.Close()
+
+	result, err := NewPostmarkProvider(server.URL, "pm-token", "broadcast").SendRaw(context.Background(), "a@b.com", []byte(testRawEmail))
+	if err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if result.Provider != "postmark" || result.MessageID != "pm-msg-1" || len(result.Recipients) != 1 || result.Recipients[0] != "a@b.com" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Metadata["submitted_at"] != "2026-01-02T03:04:05Z" {
+		t.Fatalf("unexpected metadata: %+v", result.Metadata)
+	}
+}
+
+func TestPostmarkProviderClassifiesErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		permanent bool
+	}{
+		{name: "inactive recipient", status: http.StatusUnprocessableEntity, body: `{"ErrorCode":406,"Message":"inactive"}`, permanent: true},
+		{name: "invalid request", status: http.StatusUnprocessableEntity, body: `{"ErrorCode":300,"Message":"invalid"}`, permanent: true},
+		{name: "bad token", status: http.StatusUnprocessableEntity, body: `{"ErrorCode":10,"Message":"bad token"}`, permanent: false},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{}`, permanent: false},
+		{name: "server error", status: http.StatusInternalServerError, body: `{}`, permanent: false},
+	}
+	for _, tc := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(tc.status)
+			_, _ = w.Write([]byte(tc.body))
+		}))
+		_, err := NewPostmarkProvider(server.URL, "pm-token", "").SendRaw(context.Background(), "a@b.com", []byte(testRawEmail))
+		server.Close()
+		if err == nil || IsPermanent(err) != tc.permanent {
+			t.Fatalf("%s: expected permanent=%v, got %v", tc.name, tc.permanent, err)
+		}
+	}
+}
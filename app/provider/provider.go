@@ -2,6 +2,16 @@ package provider
 
 import "context"
 
+// SendResult describes an email a provider accepted: the provider's name, the ID it
+// assigned to the message when it reports one, the recipients it accepted, and response
+// details worth keeping for support, such as request IDs.
+type SendResult struct {
+	Provider   string            `json:"provider"`
+	MessageID  string            `json:"message_id,omitempty"`
+	Recipients []string          `json:"recipients"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
 type EmailProvider interface {
-	SendRaw(ctx context.Context, recipient string, raw []byte) error
+	SendRaw(ctx context.Context, recipient string, raw []byte) (SendResult, error)
 }
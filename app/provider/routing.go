@@ -0,0 +1,204 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"os"
+	"path"
+	"strings"
+)
+
+type routeKey struct{}
+
+// Route describes the email being sent, for providers that choose by it or tag it. An
+// empty Sender stands for the configured source address.
+type Route struct {
+	Recipient string
+	Sender    string
+	Category  string
+	Tenant    string
+	Template  string
+	RequestID string
+}
+
+// WithRoute returns a context carrying the route of the email sent with it.
+func WithRoute(ctx context.Context, route Route) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// routeFromContext returns the route stored by WithRoute, if any.
+func routeFromContext(ctx context.Context) Route {
+	route, _ := ctx.Value(routeKey{}).(Route)
+	return route
+}
+
+// RouteMatch is the condition of a routing rule. Domains and Senders are glob patterns
+// (as in path.Match) on the recipient domain and the sender address; Categories and
+// Tenants are exact values. Every non-empty list must contain a match; empty lists match
+// any email. All comparisons are case-insensitive.
+type RouteMatch struct {
+	Domains    []string `json:"domains"`
+	Senders    []string `json:"senders"`
+	Categories []string `json:"categories"`
+	Tenants    []string `json:"tenants"`
+}
+
+// RoutingRule sends the emails matching Match through the provider named Provider.
+type RoutingRule struct {
+	Name     string     `json:"name"`
+	Match    RouteMatch `json:"match"`
+	Provider string     `json:"provider"`
+}
+
+// Router picks the provider of an email from an ordered list of rules; the first matching
+// rule wins and emails matching no rule go to the fallback provider.
+type Router struct {
+	rules    []RoutingRule
+	fallback string
+	sender   string
+}
+
+// NewRouter constructs a router. sender is the address routes without a sender are matched
+// with. Rule patterns are lowercased and unnamed rules are named after their position.
+func NewRouter(rules []RoutingRule, fallback string, sender string) (*Router, error) {
+	if address, err := mail.ParseAddress(sender); err == nil {
+		sender = address.Address
+	}
+	r := &Router{fallback: fallback, sender: strings.ToLower(sender)}
+	for i, rule := range rules {
+		rule.Provider = strings.ToLower(strings.TrimSpace(rule.Provider))
+		if rule.Provider == "" {
+			return nil, fmt.Errorf("routing rule %d: provider is required", i+1)
+		}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		rule.Match = RouteMatch{
+			Domains:    lowerAll(rule.Match.Domains),
+			Senders:    lowerAll(rule.Match.Senders),
+			Categories: lowerAll(rule.Match.Categories),
+			Tenants:    lowerAll(rule.Match.Tenants),
+		}
+		for _, pattern := range append(append([]string{}, rule.Match.Domains...), rule.Match.Senders...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("routing rule %q: invalid pattern %q", rule.Name, pattern)
+			}
+		}
+		r.rules = append(r.rules, rule)
+	}
+	return r, nil
+}
+
+// LoadRoutingRules reads routing rules from a JSON file holding a list of RoutingRule.
+func LoadRoutingRules(file string) ([]RoutingRule, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read routing rules: %w", err)
+	}
+	var rules []RoutingRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse routing rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Rules returns the router's rules in evaluation order.
+func (r *Router) Rules() []RoutingRule {
+	return r.rules
+}
+
+// Fallback returns the name of the provider for emails matching no rule.
+func (r *Router) Fallback() string {
+	return r.fallback
+}
+
+// Select returns the provider for route and the rule that chose it, or the fallback
+// provider and false when no rule matches.
+func (r *Router) Select(route Route) (string, RoutingRule, bool) {
+	sender := strings.ToLower(route.Sender)
+	if sender == "" {
+		sender = r.sender
+	}
+	recipient := strings.ToLower(route.Recipient)
+	domain := recipient[strings.LastIndex(recipient, "@")+1:]
+	category := strings.ToLower(route.Category)
+	tenant := strings.ToLower(route.Tenant)
+
+	for _, rule := range r.rules {
+		if matchesGlob(rule.Match.Domains, domain) &&
+			matchesGlob(rule.Match.Senders, sender) &&
+			matchesValue(rule.Match.Categories, category) &&
+			matchesValue(rule.Match.Tenants, tenant) {
+			return rule.Provider, rule, true
+		}
+	}
+	return r.fallback, RoutingRule{}, false
+}
+
+// RoutingProvider sends every email through the provider its Router selects, using the
+// route from WithRoute and the recipient passed to SendRaw.
+type RoutingProvider struct {
+	router    *Router
+	providers map[string]EmailProvider
+}
+
+// NewRoutingProvider constructs a routing provider. providers must contain the fallback
+// provider and the provider of every rule.
+func NewRoutingProvider(router *Router, providers map[string]EmailProvider) (*RoutingProvider, error) {
+	if _, ok := providers[router.Fallback()]; !ok {
+		return nil, fmt.Errorf("routing fallback provider %q is not configured", router.Fallback())
+	}
+	for _, rule := range router.Rules() {
+		if _, ok := providers[rule.Provider]; !ok {
+			return nil, fmt.Errorf("routing rule %q: provider %q is not configured", rule.Name, rule.Provider)
+		}
+	}
+	return &RoutingProvider{router: router, providers: providers}, nil
+}
+
+// SendRaw sends through the provider selected for the email.
+func (p *RoutingProvider) SendRaw(ctx context.Context, recipient string, raw []byte) (SendResult, error) {
+	route := routeFromContext(ctx)
+	route.Recipient = recipient
+	name, _, _ := p.router.Select(route)
+	return p.providers[name].SendRaw(ctx, recipient, raw)
+}
+
+// matchesGlob reports whether value matches one of patterns; no patterns match anything.
+func matchesGlob(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesValue reports whether value is one of values; no values match anything.
+func matchesValue(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerAll(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	lowered := make([]string, 0, len(values))
+	for _, value := range values {
+		lowered = append(lowered, strings.ToLower(strings.TrimSpace(value)))
+	}
+	return lowered
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestRouter(t *testing.T) *Router {
+	t.Helper()
+	router, err := NewRouter([]RoutingRule{
+		{
+			Name:     "microsoft",
+			Match:    RouteMatch{Domains: []string{"outlook.com", "hotmail.com", "*.outlook.com"}},
+			Provider: "smtp",
+		},
+		{
+			Match:    RouteMatch{Categories: []string{"marketing"}, Tenants: []string{"Acme"}},
+			Provider: "noop",
+		},
+		{
+			Name:     "billing-sender",
+			Match:    RouteMatch{Senders: []string{"billing@*"}},
+			Provider: "noop",
+		},
+	}, "ses", "Notifications <noreply@example.com>")
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return router
+}
+
+func TestRouterSelect(t *testing.T) {
+	t.Parallel()
+
+	router := newTestRouter(t)
+	tests := []struct {
+		name     string
+		route    Route
+		provider string
+		rule     string
+	}{
+		{name: "domain", route: Route{Recipient: "Ana@Outlook.com"}, provider: "smtp", rule: "microsoft"},
+		{name: "subdomain glob", route: Route{Recipient: "ana@eu.outlook.com"}, provider: "smtp", rule: "microsoft"},
+		{name: "category and tenant", route: Route{Recipient: "ana@b.com", Category: "marketing", Tenant: "acme"}, provider: "noop", rule: "rule-2"},
+		{name: "category without tenant", route: Route{Recipient: "ana@b.com", Category: "marketing"}, provider: "ses"},
+		{name: "sender", route: Route{Recipient: "ana@b.com", Sender: "billing@example.com"}, provider: "noop", rule: "billing-sender"},
+		{name: "configured sender", route: Route{Recipient: "ana@b.com"}, provider: "ses"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			name, rule, matched := router.Select(tc.route)
+			if name != tc.provider || rule.Name != tc.rule || matched != (tc.rule != "") {
+				t.Fatalf("expected %s via %q, got %s via %q (matched=%v)", tc.provider, tc.rule, name, rule.Name, matched)
+			}
+		})
+	}
+}
+
+func TestNewRouterRejectsInvalidRules(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewRouter([]RoutingRule{{Match: RouteMatch{Domains: []string{"b.com"}}}}, "ses", ""); err == nil {
+		t.Fatal("expected a rule without provider to be rejected")
+	}
+	if _, err := NewRouter([]RoutingRule{{Match: RouteMatch{Domains: []string{"[b.com"}}, Provider: "noop"}}, "ses", ""); err == nil {
+		t.Fatal("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestRoutingProviderSendsThroughSelectedProvider(t *testing.T) {
+	t.Parallel()
+
+	ses := &stubProvider{name: "ses"}
+	smtp := &stubProvider{name: "smtp"}
+	noop := &stubProvider{name: "noop"}
+	p, err := NewRoutingProvider(newTestRouter(t), map[string]EmailProvider{"ses": ses, "smtp": smtp, "noop": noop})
+	if err != nil {
+		t.Fatalf("NewRoutingProvider: %v", err)
+	}
+
+	result, err := p.SendRaw(context.Background(), "ana@hotmail.com", []byte("raw"))
+	if err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if result.Provider != "smtp" {
+		t.Fatalf("expected delivery through smtp, got %q", result.Provider)
+	}
+
+	ctx := WithRoute(context.Background(), Route{Category: "marketing", Tenant: "acme"})
+	if _, err := p.SendRaw(ctx, "ana@b.com", []byte("raw")); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if _, err := p.SendRaw(context.Background(), "ana@b.com", []byte("raw")); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if ses.calls != 1 || smtp.calls != 1 || noop.calls != 1 {
+		t.Fatalf("unexpected calls: ses=%d smtp=%d noop=%d", ses.calls, smtp.calls, noop.calls)
+	}
+}
+
+func TestNewRoutingProviderRequiresRuleProviders(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewRoutingProvider(newTestRouter(t), map[string]EmailProvider{"ses": &stubProvider{}, "noop": &stubProvider{}}); err == nil {
+		t.Fatal("expected a missing rule provider to be rejected")
+	}
+}
+
+func TestLoadRoutingRules(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "routing.json")
+	content := `[{"name": "microsoft", "match": {"domains": ["outlook.com", "*.outlook.com"]}, "provider": "smtp"}]`
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	rules, err := LoadRoutingRules(file)
+	if err != nil {
+		t.Fatalf("LoadRoutingRules: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Provider != "smtp" || len(rules[0].Match.Domains) != 2 {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+}
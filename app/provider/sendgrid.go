@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+// SendGridProvider sends email through the SendGrid v3 mail send API. The API takes JSON
+// rather than MIME, so the raw email's sender, subject, bodies, and X- headers are sent.
+type SendGridProvider struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+}
+
+// NewSendGridProvider builds a provider sending through SendGrid; baseURL is normally
+// https://api.sendgrid.com.
+func NewSendGridProvider(baseURL, apiKey string) *SendGridProvider {
+	return &SendGridProvider{
+		client:  &http.Client{},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+	}
+}
+
+// SendRaw sends the email to recipient. Unparseable, malformed, and oversized messages
+// fail permanently; authentication, throttling, and server errors are retryable.
+func (p *SendGridProvider) SendRaw(ctx context.Context, recipient string, raw []byte) (SendResult, error) {
+	if recipient == "" {
+		return SendResult{}, Permanent(fmt.Errorf("recipient is required"))
+	}
+	email, err := parseRawEmail(raw)
+	if err != nil {
+		return SendResult{}, Permanent(err)
+	}
+	from, err := mail.ParseAddress(email.From)
+	if err != nil {
+		return SendResult{}, Permanent(fmt.Errorf("parse raw email from: %w", err))
+	}
+
+	// SendGrid requires text/plain content before text/html.
+	var content []sendGridContent
+	if email.Text != "" {
+		content = append(content, sendGridContent{Type: "text/plain", Value: email.Text})
+	}
+	if email.HTML != "" {
+		content = append(content, sendGridContent{Type: "text/html", Value: email.HTML})
+	}
+	payload, err := json.Marshal(sendGridRequest{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: recipient}}}},
+		From:             sendGridAddress{Email: from.Address, Name: from.Name},
+		Subject:          email.Subject,
+		Content:          content,
+		Headers:          email.Headers,
+	})
+	if err != nil {
+		return SendResult{}, fmt.Errorf("sendgrid build request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v3/mail/send", bytes.NewReader(payload))
+	if err != nil {
+		return SendResult{}, fmt.Errorf("sendgrid build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return SendResult{}, fmt.Errorf("sendgrid send email: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return SendResult{}, fmt.Errorf("sendgrid send email: %w", classifyAPIError(newAPIError("sendgrid", resp)))
+	}
+
+	// SendGrid answers without a body; the message ID is a response header.
+	return SendResult{
+		Provider:   "sendgrid",
+		MessageID:  resp.Header.Get("X-Message-Id"),
+		Recipients: []string{recipient},
+		Metadata:   map[string]string{"status": strconv.Itoa(resp.StatusCode)},
+	}, nil
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testRawEmail = "From: Notifications <noreply@example.com>\r\n" +
+	"To: a@b.com\r\n" +
+	"Subject: Hello\r\n" +
+	"X-Category: marketing\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: text/html; charset=UTF-8\r\n" +
+	"\r\n" +
+	"<p>Hi</p>"
+
+func TestSendGridProviderSendRaw(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/mail/send" || r.Header.Get("Authorization") != "Bearer sg-key" {
+			t.Errorf("unexpected request %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body sendGridRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if body.From.Email != "noreply@example.com" || body.From.Name != "Notifications" || body.Subject != "Hello" ||
+			len(body.Personalizations) != 1 || body.Personalizations[0].To[0].Email != "a@b.com" {
+			t.Errorf("unexpected body: %+v", body)
+		}
+		if len(body.Content) != 1 || body.Content[0].Type != "text/html" || body.Content[0].Value != "<p>Hi</p>" {
+			t.Errorf("unexpected content: %+v", body.Content)
+		}
+		if body.Headers["X-Category"] != "marketing" {
+			t.Errorf("unexpected headers: %v", body.Headers)
+		}
+		w.Header().Set("X-Message-Id", "sg-msg-1")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	result, err := NewSendGridProvider(server.URL, "sg-key").SendRaw(context.Background(), "a@b.com", []byte(testRawEmail))
+	if err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if result.Provider != "sendgrid" || result.MessageID != "sg-msg-1" || result.Metadata["status"] != "202" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestSendGridProviderClassifiesErrors(t *testing.T) {
+	t.Parallel()
+
+	for status, permanent := range map[int]bool{
+		http.StatusBadRequest:            true,
+		http.StatusForbidden:             false,
+		http.StatusTooManyRequests:       false,
+		http.StatusServiceUnavailable:    false,
+		http.StatusRequestEntityTooLarge: true,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(status)
+		}))
+		_, err := NewSendGridProvider(server.URL, "sg-key").SendRaw(context.Background(), "a@b.com", []byte(testRawEmail))
+		server.Close()
+		if err == nil || IsPermanent(err) != permanent {
+			t.Fatalf("status %d: expected permanent=%v, got %v", status, permanent, err)
+		}
+	}
+
+	if _, err := NewSendGridProvider("http://127.0.0.1:0", "sg-key").SendRaw(context.Background(), "a@b.com", []byte("not mime")); !IsPermanent(err) {
+		t.Fatalf("expected an unparseable email to fail permanently, got %v", err)
+	}
+}
@@ -2,47 +2,204 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/mail"
+	"os"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
 	"github.com/aws/aws-sdk-go-v2/service/sesv2"
 	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
 )
 
+// sesTagValueMaxLength is the longest message tag value SES accepts.
+const sesTagValueMaxLength = 256
+
+// SESOptions are the SES sending options of a sender identity. ConfigurationSet names
+// the configuration set publishing the email's events; FeedbackForwardingAddress receives
+// bounces and complaints. ContactList and ContactListTopic make SES add the list
+// management headers and skip contacts that unsubscribed from the list or topic.
+type SESOptions struct {
+	ConfigurationSet          string `json:"configuration_set"`
+	FeedbackForwardingAddress string `json:"feedback_forwarding_address"`
+	ContactList               string `json:"contact_list"`
+	ContactListTopic          string `json:"contact_list_topic"`
+}
+
+// merge returns o with its empty fields taken from defaults.
+func (o SESOptions) merge(defaults SESOptions) SESOptions {
+	if o.ConfigurationSet == "" {
+		o.ConfigurationSet = defaults.ConfigurationSet
+	}
+	if o.FeedbackForwardingAddress == "" {
+		o.FeedbackForwardingAddress = defaults.FeedbackForwardingAddress
+	}
+	if o.ContactList == "" {
+		o.ContactList = defaults.ContactList
+		if o.ContactListTopic == "" {
+			o.ContactListTopic = defaults.ContactListTopic
+		}
+	}
+	return o
+}
+
 type SESProvider struct {
-	client *sesv2.Client
-	source string
+	client     *sesv2.Client
+	source     string
+	defaults   SESOptions
+	identities map[string]SESOptions
 }
 
-// NewSESProvider builds a provider that sends email via AWS SES.
-func NewSESProvider(cfg aws.Config, source string) *SESProvider {
+// NewSESProvider builds a provider that sends email via AWS SES. identities overrides the
+// defaults per sender identity, keyed by address or by domain; empty fields of an
+// identity's options fall back to defaults.
+func NewSESProvider(cfg aws.Config, source string, defaults SESOptions, identities map[string]SESOptions) *SESProvider {
+	lowered := make(map[string]SESOptions, len(identities))
+	for identity, options := range identities {
+		lowered[strings.ToLower(strings.TrimSpace(identity))] = options
+	}
 	return &SESProvider{
-		client: sesv2.NewFromConfig(cfg),
-		source: source,
+		client:     sesv2.NewFromConfig(cfg),
+		source:     source,
+		defaults:   defaults,
+		identities: lowered,
 	}
 }
 
-// SendRaw sends a raw MIME email via SES.
-func (p *SESProvider) SendRaw(ctx context.Context, recipient string, raw []byte) error {
+// LoadSESIdentityOptions reads the per-identity SES options from a JSON file holding an
+// object of SESOptions keyed by sender address or domain.
+func LoadSESIdentityOptions(file string) (map[string]SESOptions, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read ses identity options: %w", err)
+	}
+	var identities map[string]SESOptions
+	if err := json.Unmarshal(data, &identities); err != nil {
+		return nil, fmt.Errorf("parse ses identity options: %w", err)
+	}
+	return identities, nil
+}
+
+// SendRaw sends a raw MIME email via SES, with the options of its sender identity and the
+// route from WithRoute as message tags. Rejected messages and invalid requests fail
+// permanently; throttling, service, and network errors are retryable.
+func (p *SESProvider) SendRaw(ctx context.Context, recipient string, raw []byte) (SendResult, error) {
 	if recipient == "" {
-		return fmt.Errorf("recipient is required")
+		return SendResult{}, Permanent(fmt.Errorf("recipient is required"))
 	}
 	if len(raw) == 0 {
-		return fmt.Errorf("raw content is required")
+		return SendResult{}, Permanent(fmt.Errorf("raw content is required"))
 	}
 
-	_, err := p.client.SendEmail(ctx, &sesv2.SendEmailInput{
-		FromEmailAddress: aws.String(p.source),
+	out, err := p.client.SendEmail(ctx, p.sendEmailInput(routeFromContext(ctx), recipient, raw))
+	if err != nil {
+		var rejected *types.MessageRejected
+		var badRequest *types.BadRequestException
+		if errors.As(err, &rejected) || errors.As(err, &badRequest) {
+			return SendResult{}, Permanent(fmt.Errorf("ses send raw email: %w", err))
+		}
+		return SendResult{}, fmt.Errorf("ses send raw email: %w", err)
+	}
+
+	result := SendResult{
+		Provider:   "ses",
+		MessageID:  aws.ToString(out.MessageId),
+		Recipients: []string{recipient},
+	}
+	if requestID, ok := awsmiddleware.GetRequestIDMetadata(out.ResultMetadata); ok {
+		result.Metadata = map[string]string{"request_id": requestID}
+	}
+	return result, nil
+}
+
+// sendEmailInput builds the SES request sending raw to recipient from the route's sender,
+// or the configured source when the route has none.
+func (p *SESProvider) sendEmailInput(route Route, recipient string, raw []byte) *sesv2.SendEmailInput {
+	sender := route.Sender
+	if sender == "" {
+		sender = p.source
+	}
+	options := p.options(sender)
+
+	input := &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(sender),
 		Destination: &types.Destination{
 			ToAddresses: []string{recipient},
 		},
 		Content: &types.EmailContent{
 			Raw: &types.RawMessage{Data: raw},
 		},
-	})
-	if err != nil {
-		return fmt.Errorf("ses send raw email: %w", err)
+		EmailTags: sesMessageTags(route),
+	}
+	if options.ConfigurationSet != "" {
+		input.ConfigurationSetName = aws.String(options.ConfigurationSet)
+	}
+	if options.FeedbackForwardingAddress != "" {
+		input.FeedbackForwardingEmailAddress = aws.String(options.FeedbackForwardingAddress)
+	}
+	if options.ContactList != "" {
+		input.ListManagementOptions = &types.ListManagementOptions{ContactListName: aws.String(options.ContactList)}
+		if options.ContactListTopic != "" {
+			input.ListManagementOptions.TopicName = aws.String(options.ContactListTopic)
+		}
+	}
+	return input
+}
+
+// options returns the options of the sender's identity: those of its address, else of its
+// domain, else the defaults.
+func (p *SESProvider) options(sender string) SESOptions {
+	if address, err := mail.ParseAddress(sender); err == nil {
+		sender = address.Address
+	}
+	sender = strings.ToLower(sender)
+	if options, ok := p.identities[sender]; ok {
+		return options.merge(p.defaults)
 	}
+	if at := strings.LastIndex(sender, "@"); at >= 0 {
+		if options, ok := p.identities[sender[at+1:]]; ok {
+			return options.merge(p.defaults)
+		}
+	}
+	return p.defaults
+}
 
-	return nil
+// sesMessageTags returns the message tags of the route's non-empty fields, so the events
+// SES publishes can be attributed to them.
+func sesMessageTags(route Route) []types.MessageTag {
+	var tags []types.MessageTag
+	for _, tag := range []struct{ name, value string }{
+		{"category", route.Category},
+		{"tenant", route.Tenant},
+		{"template", route.Template},
+		{"request_id", route.RequestID},
+	} {
+		if tag.value == "" {
+			continue
+		}
+		tags = append(tags, types.MessageTag{
+			Name:  aws.String(tag.name),
+			Value: aws.String(sesTagValue(tag.value)),
+		})
+	}
+	return tags
+}
+
+// sesTagValue makes value a valid tag value: SES accepts only ASCII letters, digits,
+// underscores, and dashes, up to 256 characters. Other characters become underscores.
+func sesTagValue(value string) string {
+	if len(value) > sesTagValueMaxLength {
+		value = value[:sesTagValueMaxLength]
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
 }
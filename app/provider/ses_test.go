@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+func TestSESProviderSendEmailInput(t *testing.T) {
+	p := NewSESProvider(aws.Config{}, "Notifications <noreply@example.com>", SESOptions{
+		ConfigurationSet:          "default-set",
+		FeedbackForwardingAddress: "bounces@example.com",
+	}, map[string]SESOptions{
+		"Billing@Example.com": {ConfigurationSet: "billing-set", ContactList: "customers", ContactListTopic: "invoices"},
+		"news.example.com":    {ContactList: "newsletter"},
+	})
+
+	tests := []struct {
+		name           string
+		route          Route
+		sender         string
+		configSet      string
+		feedback       string
+		listManagement *types.ListManagementOptions
+	}{
+		{
+			name:      "defaults for the source",
+			route:     Route{},
+			sender:    "Notifications <noreply@example.com>",
+			configSet: "default-set",
+			feedback:  "bounces@example.com",
+		},
+		{
+			name:      "identity address",
+			route:     Route{Sender: "billing@example.com"},
+			sender:    "billing@example.com",
+			configSet: "billing-set",
+			feedback:  "bounces@example.com",
+			listManagement: &types.ListManagementOptions{
+				ContactListName: aws.String("customers"),
+				TopicName:       aws.String("invoices"),
+			},
+		},
+		{
+			name:           "identity domain",
+			route:          Route{Sender: "Weekly <weekly@news.example.com>"},
+			sender:         "Weekly <weekly@news.example.com>",
+			configSet:      "default-set",
+			feedback:       "bounces@example.com",
+			listManagement: &types.ListManagementOptions{ContactListName: aws.String("newsletter")},
+		},
+	}
+	for _, tc := range tests {
+		input := p.sendEmailInput(tc.route, "a@b.com", []byte(testRawEmail))
+		if aws.ToString(input.FromEmailAddress) != tc.sender {
+			t.Fatalf("%s: unexpected sender %q", tc.name, aws.ToString(input.FromEmailAddress))
+		}
+		if aws.ToString(input.ConfigurationSetName) != tc.configSet || aws.ToString(input.FeedbackForwardingEmailAddress) != tc.feedback {
+			t.Fatalf("%s: unexpected options %q %q", tc.name, aws.ToString(input.ConfigurationSetName), aws.ToString(input.FeedbackForwardingEmailAddress))
+		}
+		if !reflect.DeepEqual(input.ListManagementOptions, tc.listManagement) {
+			t.Fatalf("%s: unexpected list management options %+v", tc.name, input.ListManagementOptions)
+		}
+		if !reflect.DeepEqual(input.Destination.ToAddresses, []string{"a@b.com"}) || string(input.Content.Raw.Data) != testRawEmail {
+			t.Fatalf("%s: unexpected email %+v", tc.name, input)
+		}
+	}
+}
+
+func TestSESProviderSendEmailInputWithoutOptions(t *testing.T) {
+	p := NewSESProvider(aws.Config{}, "noreply@example.com", SESOptions{}, nil)
+	input := p.sendEmailInput(Route{}, "a@b.com", []byte(testRawEmail))
+	if input.ConfigurationSetName != nil || input.FeedbackForwardingEmailAddress != nil || input.ListManagementOptions != nil || input.EmailTags != nil {
+		t.Fatalf("expected no SES options, got %+v", input)
+	}
+}
+
+func TestSESMessageTags(t *testing.T) {
+	tags := sesMessageTags(Route{
+		Category:  "marketing",
+		Template:  "welcome.v2",
+		RequestID: "batch-1:" + strings.Repeat("x", 300),
+	})
+
+	got := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		got[aws.ToString(tag.Name)] = aws.ToString(tag.Value)
+	}
+	want := map[string]string{
+		"category":   "marketing",
+		"template":   "welcome_v2",
+		"request_id": "batch-1_" + strings.Repeat("x", 248),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected tags: %+v", got)
+	}
+}
+
+func TestLoadSESIdentityOptions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "identities.json")
+	data := `{"billing@example.com": {"configuration_set": "billing-set", "feedback_forwarding_address": "billing-bounces@example.com", "contact_list": "customers", "contact_list_topic": "invoices"}}`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	identities, err := LoadSESIdentityOptions(file)
+	if err != nil {
+		t.Fatalf("LoadSESIdentityOptions: %v", err)
+	}
+	want := SESOptions{
+		ConfigurationSet:          "billing-set",
+		FeedbackForwardingAddress: "billing-bounces@example.com",
+		ContactList:               "customers",
+		ContactListTopic:          "invoices",
+	}
+	if len(identities) != 1 || identities["billing@example.com"] != want {
+		t.Fatalf("unexpected identities: %+v", identities)
+	}
+
+	if err := os.WriteFile(file, []byte("["), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := LoadSESIdentityOptions(file); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SMTPProvider relays email through an SMTP server, using STARTTLS when the server
+// offers it and PLAIN authentication when a username is set.
+type SMTPProvider struct {
+	addr     string
+	username string
+	password string
+	source   string
+}
+
+// NewSMTPProvider builds a provider that relays email through the SMTP server at addr.
+func NewSMTPProvider(addr, username, password, source string) *SMTPProvider {
+	return &SMTPProvider{
+		addr:     addr,
+		username: username,
+		password: password,
+		source:   source,
+	}
+}
+
+// SendRaw relays a raw MIME email. Only a rejection of the recipient or the message fails
+// permanently; other replies, including authentication and sender failures that fixing the
+// relay configuration resolves, and network errors are retryable.
+func (p *SMTPProvider) SendRaw(ctx context.Context, recipient string, raw []byte) (SendResult, error) {
+	if recipient == "" {
+		return SendResult{}, Permanent(fmt.Errorf("recipient is required"))
+	}
+	if len(raw) == 0 {
+		return SendResult{}, Permanent(fmt.Errorf("raw content is required"))
+	}
+
+	if err := p.send(ctx, recipient, raw); err != nil {
+		return SendResult{}, classifySMTPError(fmt.Errorf("smtp send raw email: %w", err))
+	}
+
+	return SendResult{
+		Provider:   "smtp",
+		Recipients: []string{recipient},
+		Metadata:   map[string]string{"relay": p.addr},
+	}, nil
+}
+
+func (p *SMTPProvider) send(ctx context.Context, recipient string, raw []byte) error {
+	host, _, err := net.SplitHostPort(p.addr)
+	if err != nil {
+		return err
+	}
+	from := p.source
+	if address, err := mail.ParseAddress(p.source); err == nil {
+		from = address.Address
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if p.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", p.username, p.password, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(recipient); err != nil {
+		return &smtpCommandError{command: "RCPT", err: err}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return &smtpCommandError{command: "DATA", err: err}
+	}
+	if _, err := w.Write(raw); err != nil {
+		return &smtpCommandError{command: "DATA", err: err}
+	}
+	if err := w.Close(); err != nil {
+		return &smtpCommandError{command: "DATA", err: err}
+	}
+
+	// The relay accepted the message with its reply to the data; failing now would send it twice.
+	if err := client.Quit(); err != nil {
+		logrus.WithError(err).WithField("relay", p.addr).Debug("SMTP QUIT failed after the message was accepted")
+	}
+	return nil
+}
+
+// smtpCommandError records the SMTP command whose reply failed the send.
+type smtpCommandError struct {
+	command string
+	err     error
+}
+
+func (e *smtpCommandError) Error() string {
+	return e.command + ": " + e.err.Error()
+}
+
+func (e *smtpCommandError) Unwrap() error {
+	return e.err
+}
+
+// classifySMTPError marks the replies that reject this recipient or message as permanent:
+// 550 (mailbox unavailable), 551 (user not local), 553 (mailbox name not allowed), and 554
+// (transaction failed) to RCPT TO or DATA.
+func classifySMTPError(err error) error {
+	var command *smtpCommandError
+	var reply *textproto.Error
+	if !errors.As(err, &command) || !errors.As(err, &reply) {
+		return err
+	}
+	if command.command != "RCPT" && command.command != "DATA" {
+		return err
+	}
+	switch reply.Code {
+	case 550, 551, 553, 554:
+		return Permanent(err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// serveSMTP accepts one connection on a fake SMTP server and returns the DATA it received.
+// replies overrides the reply to a command; the DATA reply answers the end of the message,
+// and an empty QUIT reply closes the connection without answering.
+func serveSMTP(t *testing.T, replies map[string]string) (string, <-chan string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { _ = lis.Close() })
+
+	reply := func(verb string, fallback string) string {
+		if r, ok := replies[verb]; ok {
+			return r
+		}
+		return fallback
+	}
+
+	data := make(chan string, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		_ = text.PrintfLine("220 localhost ESMTP")
+		var body strings.Builder
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				data <- body.String()
+				return
+			}
+			switch verb := strings.ToUpper(strings.Fields(line + " x")[0]); verb {
+			case "EHLO", "HELO":
+				_ = text.PrintfLine("250-localhost")
+				_ = text.PrintfLine("250 AUTH PLAIN")
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				lines, _ := text.ReadDotLines()
+				body.WriteString(strings.Join(lines, "\n"))
+				_ = text.PrintfLine("%s", reply(verb, "250 queued"))
+			case "QUIT":
+				if r := reply(verb, "221 bye"); r != "" {
+					_ = text.PrintfLine("%s", r)
+				}
+				data <- body.String()
+				return
+			case "AUTH":
+				_ = text.PrintfLine("%s", reply(verb, "235 authenticated"))
+			default:
+				_ = text.PrintfLine("%s", reply(verb, "250 ok"))
+			}
+		}
+	}()
+	return lis.Addr().String(), data
+}
+
+func TestSMTPProviderSendRaw(t *testing.T) {
+	t.Parallel()
+
+	addr, data := serveSMTP(t, nil)
+	p := NewSMTPProvider(addr, "", "", "Notifications <noreply@example.com>")
+
+	result, err := p.SendRaw(context.Background(), "a@b.com", []byte("Subject: hi\r\n\r\nhello\r\n"))
+	if err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if body := <-data; !strings.Contains(body, "hello") {
+		t.Fatalf("unexpected data: %q", body)
+	}
+	if result.Provider != "smtp" || result.Metadata["relay"] != addr {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestSMTPProviderClassifiesReplies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		username  string
+		replies   map[string]string
+		code      int
+		permanent bool
+	}{
+		{name: "mailbox unavailable", replies: map[string]string{"RCPT": "550 mailbox unavailable"}, code: 550, permanent: true},
+		{name: "user not local", replies: map[string]string{"RCPT": "551 user not local"}, code: 551, permanent: true},
+		{name: "message rejected", replies: map[string]string{"DATA": "554 message rejected as spam"}, code: 554, permanent: true},
+		{name: "greylisted", replies: map[string]string{"RCPT": "451 try again later"}, code: 451},
+		{name: "authentication failed", username: "user", replies: map[string]string{"AUTH": "535 authentication failed"}, code: 535},
+		{name: "authentication required", replies: map[string]string{"MAIL": "530 authentication required"}, code: 530},
+		{name: "sender rejected", replies: map[string]string{"MAIL": "550 sender not allowed"}, code: 550},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			addr, _ := serveSMTP(t, tc.replies)
+			_, err := NewSMTPProvider(addr, tc.username, "secret", "noreply@example.com").SendRaw(context.Background(), "a@b.com", []byte("raw"))
+			var reply *textproto.Error
+			if !errors.As(err, &reply) || reply.Code != tc.code {
+				t.Fatalf("expected a %d reply, got %v", tc.code, err)
+			}
+			if IsPermanent(err) != tc.permanent {
+				t.Fatalf("expected permanent=%t, got %v", tc.permanent, err)
+			}
+		})
+	}
+}
+
+func TestSMTPProviderIgnoresQuitFailureAfterAcceptance(t *testing.T) {
+	t.Parallel()
+
+	addr, data := serveSMTP(t, map[string]string{"QUIT": ""})
+	if _, err := NewSMTPProvider(addr, "", "", "noreply@example.com").SendRaw(context.Background(), "a@b.com", []byte("hello")); err != nil {
+		t.Fatalf("SendRaw: %v", err)
+	}
+	if body := <-data; body != "hello" {
+		t.Fatalf("unexpected data: %q", body)
+	}
+}
@@ -2,41 +2,54 @@ package queue
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
 	"github.com/vibast-solutions/ms-go-notifications/app/service"
 )
 
+// campaignPauseRecheck is how long a message of a paused batch is held before its batch
+// status is checked again.
+const campaignPauseRecheck = time.Minute
+
 type EmailConsumer struct {
-	client       *redis.Client
+	receiver     EmailReceiver
 	emailService *service.EmailService
-	consumerName string
+	quietHours   *service.QuietHoursService
+	caps         *service.FrequencyCapService
+	campaigns    *service.CampaignService
 }
 
-// NewEmailConsumer constructs a Redis stream consumer.
-func NewEmailConsumer(client *redis.Client, emailService *service.EmailService, consumerName string) *EmailConsumer {
+// NewEmailConsumer constructs a consumer for the given queue backend. A nil quietHours
+// disables quiet-hours deferral, a nil caps disables frequency capping, and a nil campaigns
+// sends batch emails regardless of their batch's status.
+func NewEmailConsumer(
+	receiver EmailReceiver,
+	emailService *service.EmailService,
+	quietHours *service.QuietHoursService,
+	caps *service.FrequencyCapService,
+	campaigns *service.CampaignService,
+) *EmailConsumer {
 	return &EmailConsumer{
-		client:       client,
+		receiver:     receiver,
 		emailService: emailService,
-		consumerName: consumerName,
+		quietHours:   quietHours,
+		caps:         caps,
+		campaigns:    campaigns,
 	}
 }
 
 // Run starts the consumer loop and blocks until context cancellation.
 func (c *EmailConsumer) Run(ctx context.Context) error {
-	if err := c.ensureGroup(ctx); err != nil {
+	if err := c.receiver.Open(ctx); err != nil {
 		return err
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"consumer": c.consumerName,
-		"stream":   StreamName,
-	}).Info("Consumer started")
+	logrus.Info("Consumer started")
 
-	// First drain pending messages, then switch to reading new ones.
-	startID := "0"
 	for {
 		select {
 		case <-ctx.Done():
@@ -45,79 +58,248 @@ func (c *EmailConsumer) Run(ctx context.Context) error {
 		default:
 		}
 
-		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
-			Group:    ConsumerGroup,
-			Consumer: c.consumerName,
-			Streams:  []string{StreamName, startID},
-			Count:    1,
-			Block:    5 * time.Second,
-		}).Result()
+		delivery, err := c.receiver.Receive(ctx)
 		if err != nil {
-			if err == redis.Nil {
-				// No messages available within block timeout.
-				if startID == "0" {
-					// Finished draining pending messages, switch to new.
-					startID = ">"
-				}
-				continue
-			}
 			if ctx.Err() != nil {
 				logrus.Info("Consumer shutting down")
 				return nil
 			}
-			logrus.WithError(err).Warn("XReadGroup error")
+			logrus.WithError(err).Warn("Receive error")
 			time.Sleep(time.Second)
 			continue
 		}
-
-		for _, stream := range streams {
-			if len(stream.Messages) == 0 && startID == "0" {
-				// No more pending messages, switch to reading new.
-				startID = ">"
-				continue
-			}
-			for _, msg := range stream.Messages {
-				c.processMessage(ctx, msg)
-			}
+		if delivery == nil {
+			continue
 		}
+		c.processMessage(ctx, delivery)
 	}
 }
 
-// processMessage handles a single message and acks on success.
-func (c *EmailConsumer) processMessage(ctx context.Context, msg redis.XMessage) {
-	requestID, _ := msg.Values["request_id"].(string)
-	recipient, _ := msg.Values["recipient"].(string)
-	subject, _ := msg.Values["subject"].(string)
-	content, _ := msg.Values["content"].(string)
+// processMessage handles a single delivery and acks on success.
+func (c *EmailConsumer) processMessage(ctx context.Context, delivery *Delivery) {
+	message := delivery.Message
+	requestID := message.RequestID
 
 	logrus.WithFields(logrus.Fields{
-		"message_id": msg.ID,
+		"message_id": delivery.ID,
+		"lane":       delivery.Lane(),
 		"request_id": requestID,
-		"recipient":  recipient,
+		"recipient":  message.Recipient,
+		"user_id":    message.UserID,
 	}).Info("Processing message")
 
+	email := service.RawEmail{
+		Recipient: message.Recipient,
+		UserID:    message.UserID,
+		Category:  message.Category,
+		Priority:  message.Priority,
+		Timezone:  message.Timezone,
+		Subject:   message.Subject,
+		Content:   message.Content,
+		Tenant:    message.Tenant,
+		Template:  message.Template,
+	}
+
+	if c.campaigns != nil && message.BatchID != "" {
+		handled, err := c.applyCampaignStatus(ctx, delivery)
+		if err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"request_id": requestID,
+				"message_id": delivery.ID,
+			}).Warn("Campaign status check failed; message will be retried")
+			c.nack(ctx, delivery)
+			return
+		}
+		if handled {
+			return
+		}
+	}
+
+	if c.quietHours != nil {
+		handled, err := c.deferForQuietHours(ctx, delivery, email)
+		if err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"request_id": requestID,
+				"message_id": delivery.ID,
+			}).Warn("Quiet hours check failed; message will be retried")
+			c.nack(ctx, delivery)
+			return
+		}
+		if handled {
+			return
+		}
+	}
+
 	sendCtx := service.WithRequestID(ctx, requestID)
 	sendCtx, cancel := context.WithTimeout(sendCtx, 30*time.Second)
 	defer cancel()
 
-	if err := c.emailService.SendRaw(sendCtx, recipient, subject, content); err != nil {
+	// The frequency cap is reserved only once the email service knows the email goes out,
+	// so opted-out, cancelled and already finished emails do not count against it.
+	var gate service.SendGate
+	var capUntil time.Time
+	if c.caps != nil {
+		gate = func(ctx context.Context) (bool, error) {
+			until, capped, err := c.caps.Reserve(ctx, requestID, email, time.Now())
+			capUntil = until
+			return !capped, err
+		}
+	}
+
+	err := c.emailService.SendRawGated(sendCtx, email, gate)
+	if errors.Is(err, service.ErrEmailHeld) {
+		if err := c.holdForFrequencyCap(ctx, delivery, capUntil); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"request_id": requestID,
+				"message_id": delivery.ID,
+			}).Warn("Frequency capped email could not be held; message will be retried")
+			c.nack(ctx, delivery)
+		}
+		return
+	}
+	if err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
 			"request_id": requestID,
-			"message_id": msg.ID,
-		}).Warn("SendRaw failed; message stays pending")
+			"message_id": delivery.ID,
+		}).Warn("SendRaw failed; message will be retried")
+		c.nack(ctx, delivery)
 		return
 	}
 
-	if err := c.client.XAck(ctx, StreamName, ConsumerGroup, msg.ID).Err(); err != nil {
-		logrus.WithError(err).WithField("message_id", msg.ID).Warn("XAck failed")
+	c.ack(ctx, delivery)
+}
+
+// applyCampaignStatus holds a message of a paused batch for campaignPauseRecheck and drops
+// one of a cancelled batch. It reports true once the delivery has been deferred or acked.
+func (c *EmailConsumer) applyCampaignStatus(ctx context.Context, delivery *Delivery) (bool, error) {
+	message := delivery.Message
+	status, err := c.campaigns.Status(ctx, message.BatchID)
+	if err != nil {
+		if errors.Is(err, service.ErrBatchNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	switch status {
+	case entity.EmailBatchStatusCancelled:
+		err := c.emailService.Cancel(ctx, message.RequestID)
+		if err != nil && !errors.Is(err, service.ErrEmailNotCancellable) && !errors.Is(err, service.ErrEmailNotFound) {
+			return false, fmt.Errorf("cancel email: %w", err)
+		}
+		logrus.WithFields(logrus.Fields{
+			"request_id": message.RequestID,
+			"batch_id":   message.BatchID,
+		}).Info("Email batch was cancelled; dropping message")
+		c.ack(ctx, delivery)
+		return true, nil
+	case entity.EmailBatchStatusPaused:
+		live, err := c.emailService.MarkPaused(ctx, message.RequestID)
+		if err != nil {
+			return false, fmt.Errorf("mark paused: %w", err)
+		}
+		if !live {
+			logrus.WithField("request_id", message.RequestID).Info("Email was cancelled or already finished; dropping message")
+			c.ack(ctx, delivery)
+			return true, nil
+		}
+		if err := c.receiver.Defer(ctx, delivery, time.Now().Add(campaignPauseRecheck)); err != nil {
+			return false, err
+		}
+		logrus.WithFields(logrus.Fields{
+			"request_id": message.RequestID,
+			"batch_id":   message.BatchID,
+		}).Debug("Email held while its batch is paused")
+		return true, nil
+	}
+	return false, nil
+}
+
+// deferForQuietHours queues a message that falls into the recipient's quiet hours again for
+// when they end. It reports true once the delivery has been deferred or acked.
+func (c *EmailConsumer) deferForQuietHours(ctx context.Context, delivery *Delivery, email service.RawEmail) (bool, error) {
+	message := delivery.Message
+	until, deferred, err := c.quietHours.DeferUntil(ctx, email, time.Now())
+	if err != nil || !deferred {
+		return false, err
+	}
+	live, err := c.emailService.MarkDeferred(ctx, message.RequestID)
+	if err != nil {
+		return false, fmt.Errorf("mark deferred: %w", err)
 	}
+	if !live {
+		logrus.WithField("request_id", message.RequestID).Info("Email was cancelled or already finished; dropping message")
+		c.ack(ctx, delivery)
+		return true, nil
+	}
+	if err := c.receiver.Defer(ctx, delivery, until); err != nil {
+		return false, err
+	}
+	logrus.WithFields(logrus.Fields{
+		"request_id": message.RequestID,
+		"until":      until.UTC().Format(time.RFC3339),
+	}).Info("Email deferred until quiet hours end")
+	return true, nil
 }
 
-// ensureGroup creates the stream and consumer group if missing.
-func (c *EmailConsumer) ensureGroup(ctx context.Context) error {
-	err := c.client.XGroupCreateMkStream(ctx, StreamName, ConsumerGroup, "0").Err()
-	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
+// holdForFrequencyCap drops a capped email or queues it again for when the recipient has
+// room, depending on the cap policy. The delivery has been deferred or acked once it
+// returns nil.
+func (c *EmailConsumer) holdForFrequencyCap(ctx context.Context, delivery *Delivery, until time.Time) error {
+	message := delivery.Message
+	if c.caps.Policy() == service.FrequencyCapPolicyDrop {
+		if err := c.emailService.MarkCapped(ctx, message.RequestID); err != nil {
+			return fmt.Errorf("mark capped: %w", err)
+		}
+		logrus.WithFields(logrus.Fields{
+			"request_id": message.RequestID,
+			"category":   message.Category,
+		}).Info("Email dropped by frequency cap")
+		c.ack(ctx, delivery)
+		return nil
+	}
+
+	live, err := c.emailService.MarkCapDeferred(ctx, message.RequestID)
+	if err != nil {
+		return fmt.Errorf("mark cap deferred: %w", err)
+	}
+	if !live {
+		logrus.WithField("request_id", message.RequestID).Info("Email was cancelled or already finished; dropping message")
+		c.ack(ctx, delivery)
+		return nil
+	}
+	if err := c.receiver.Defer(ctx, delivery, until); err != nil {
 		return err
 	}
+	logrus.WithFields(logrus.Fields{
+		"request_id": message.RequestID,
+		"category":   message.Category,
+		"until":      until.UTC().Format(time.RFC3339),
+	}).Info("Email deferred by frequency cap")
 	return nil
 }
+
+// ack removes a handled delivery from the queue.
+func (c *EmailConsumer) ack(ctx context.Context, delivery *Delivery) {
+	if err := c.receiver.Ack(ctx, delivery); err != nil {
+		logrus.WithError(err).WithField("message_id", delivery.ID).Warn("Ack failed")
+	}
+}
+
+// nack hands a failed delivery back to the queue for a later retry. A failed last delivery
+// is not retried, so its request is marked as failed and the message removed instead.
+func (c *EmailConsumer) nack(ctx context.Context, delivery *Delivery) {
+	if delivery.Last() {
+		requestID := delivery.Message.RequestID
+		if err := c.emailService.MarkUndeliverable(ctx, requestID); err != nil {
+			logrus.WithError(err).WithField("request_id", requestID).Warn("Failed to mark email as undeliverable")
+		} else {
+			logrus.WithField("request_id", requestID).Warn("Email failed on its last delivery; giving up")
+			c.ack(ctx, delivery)
+			return
+		}
+	}
+	if err := c.receiver.Nack(ctx, delivery); err != nil {
+		logrus.WithError(err).WithField("message_id", delivery.ID).Warn("Nack failed")
+	}
+}
@@ -2,6 +2,8 @@ package queue
 
 import (
 	"context"
+	"database/sql/driver"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -10,10 +12,30 @@ import (
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
+	"github.com/vibast-solutions/ms-go-notifications/app/ratelimit"
 	"github.com/vibast-solutions/ms-go-notifications/app/repository"
 	"github.com/vibast-solutions/ms-go-notifications/app/service"
 )
 
+// statusUpdateArgs returns the arguments of the conditional update moving a request to status.
+func statusUpdateArgs(status int16, requestID string) []driver.Value {
+	args := []driver.Value{status, requestID}
+	for _, source := range entity.EmailStatusSources(status) {
+		args = append(args, source)
+	}
+	return args
+}
+
+// deliveredArgs returns the arguments of the conditional update marking a request as sent.
+func deliveredArgs(provider string, messageID string, response string, requestID string) []driver.Value {
+	args := []driver.Value{entity.EmailStatusSuccess, provider, messageID, response, requestID}
+	for _, source := range entity.EmailStatusSources(entity.EmailStatusSuccess) {
+		args = append(args, source)
+	}
+	return args
+}
+
 type noopLocker struct{}
 
 func (l noopLocker) Acquire(_ context.Context, _ string, _ time.Duration) error { return nil }
@@ -27,7 +49,15 @@ func (p noopPreparer) Prepare(_ context.Context, _ string, _ string, _ string) (
 
 type noopProvider struct{}
 
-func (p noopProvider) SendRaw(_ context.Context, _ string, _ []byte) error { return nil }
+func (p noopProvider) SendRaw(_ context.Context, _ string, _ []byte) (provider.SendResult, error) {
+	return provider.SendResult{}, nil
+}
+
+type failingProvider struct{}
+
+func (p failingProvider) SendRaw(_ context.Context, _ string, _ []byte) (provider.SendResult, error) {
+	return provider.SendResult{}, errors.New("provider unavailable")
+}
 
 func TestEmailConsumerProcessMessageAcks(t *testing.T) {
 	t.Parallel()
@@ -42,11 +72,12 @@ func TestEmailConsumerProcessMessageAcks(t *testing.T) {
 	defer client.Close()
 
 	ctx := context.Background()
-	if err := client.XGroupCreateMkStream(ctx, StreamName, ConsumerGroup, "0").Err(); err != nil {
+	receiver := NewRedisReceiver(client, "c1", nil)
+	if err := receiver.ensureGroups(ctx); err != nil {
 		if strings.Contains(err.Error(), "unknown command") {
 			t.Skipf("streams not supported by miniredis: %v", err)
 		}
-		t.Fatalf("XGroupCreateMkStream: %v", err)
+		t.Fatalf("ensureGroups: %v", err)
 	}
 
 	msgID, err := client.XAdd(ctx, &redis.XAddArgs{
@@ -62,20 +93,15 @@ func TestEmailConsumerProcessMessageAcks(t *testing.T) {
 		t.Fatalf("XAdd: %v", err)
 	}
 
-	streams, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
-		Group:    ConsumerGroup,
-		Consumer: "c1",
-		Streams:  []string{StreamName, ">"},
-		Count:    1,
-	}).Result()
+	delivery, err := receiver.Receive(ctx)
 	if err != nil {
 		if strings.Contains(err.Error(), "unknown command") {
 			t.Skipf("streams not supported by miniredis: %v", err)
 		}
-		t.Fatalf("XReadGroup: %v", err)
+		t.Fatalf("Receive: %v", err)
 	}
-	if len(streams) == 0 || len(streams[0].Messages) == 0 || streams[0].Messages[0].ID != msgID {
-		t.Fatalf("expected message %s to be read", msgID)
+	if delivery == nil || delivery.ID != msgID {
+		t.Fatalf("expected message %s to be received, got %+v", msgID, delivery)
 	}
 
 	db, mock, err := sqlmock.New()
@@ -85,18 +111,18 @@ func TestEmailConsumerProcessMessageAcks(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(entity.EmailStatusProcessing, "req-1").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, "req-1")...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec("UPDATE email_history").
 		WithArgs("raw", "req-1").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(entity.EmailStatusSuccess, "req-1").
+		WithArgs(deliveredArgs("", "", `{"provider":"","recipients":null}`, "req-1")...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), noopLocker{})
-	consumer := NewEmailConsumer(client, emailService, "c1")
-	consumer.processMessage(ctx, streams[0].Messages[0])
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	consumer := NewEmailConsumer(receiver, emailService, nil, nil, nil)
+	consumer.processMessage(ctx, delivery)
 
 	pending, err := client.XPending(ctx, StreamName, ConsumerGroup).Result()
 	if err != nil {
@@ -113,3 +139,424 @@ func TestEmailConsumerProcessMessageAcks(t *testing.T) {
 		t.Fatalf("expectations: %v", err)
 	}
 }
+
+func TestEmailConsumerProcessMessageDefersDuringQuietHours(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	receiver := NewRedisReceiver(client, "c1", nil)
+	if err := receiver.ensureGroups(ctx); err != nil {
+		t.Fatalf("ensureGroups: %v", err)
+	}
+	if err := NewEmailProducer(client).Publish(ctx, EmailMessage{
+		RequestID: "req-1",
+		Recipient: "a@b.com",
+		Subject:   "subj",
+		Content:   "content",
+	}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	delivery, err := receiver.Receive(ctx)
+	if err != nil || delivery == nil {
+		t.Fatalf("Receive: %+v, %v", delivery, err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusDeferred, "req-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// A window around the current time guarantees the message is inside quiet hours.
+	now := time.Now().UTC()
+	quietHours, err := service.NewQuietHoursService(
+		now.Add(-time.Hour).Format("15:04"),
+		now.Add(time.Hour).Format("15:04"),
+		"UTC",
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewQuietHoursService: %v", err)
+	}
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	consumer := NewEmailConsumer(receiver, emailService, quietHours, nil, nil)
+	consumer.processMessage(ctx, delivery)
+
+	pending, err := client.XPending(ctx, StreamName, ConsumerGroup).Result()
+	if err != nil {
+		t.Fatalf("XPending: %v", err)
+	}
+	if pending.Count != 0 {
+		t.Fatalf("expected 0 pending, got %d", pending.Count)
+	}
+	if got := client.ZCard(ctx, DelayedSetName).Val(); got != 1 {
+		t.Fatalf("expected 1 delayed message, got %d", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailConsumerProcessMessageHonorsCampaignStatus(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	receiver := NewRedisReceiver(client, "c1", nil)
+	if err := receiver.ensureGroups(ctx); err != nil {
+		t.Fatalf("ensureGroups: %v", err)
+	}
+	producer := NewEmailProducer(client)
+	for _, msg := range []EmailMessage{
+		{RequestID: "paused-1", Recipient: "a@b.com", Subject: "subj", Content: "content", BatchID: "paused"},
+		{RequestID: "cancelled-1", Recipient: "c@d.com", Subject: "subj", Content: "content", BatchID: "cancelled"},
+	} {
+		if err := producer.Publish(ctx, msg); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM email_batches").WithArgs("paused").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailBatchStatusPaused))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusPaused, "paused-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM email_batches").WithArgs("cancelled").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailBatchStatusCancelled))
+	mock.ExpectExec("UPDATE email_history").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM email_history").WithArgs("cancelled-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusCancelled))
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	campaigns := service.NewCampaignService(repository.NewEmailBatchRepository(db))
+	consumer := NewEmailConsumer(receiver, emailService, nil, nil, campaigns)
+	for i := 0; i < 2; i++ {
+		delivery, err := receiver.Receive(ctx)
+		if err != nil || delivery == nil {
+			t.Fatalf("Receive: %+v, %v", delivery, err)
+		}
+		consumer.processMessage(ctx, delivery)
+	}
+
+	pending, err := client.XPending(ctx, StreamName, ConsumerGroup).Result()
+	if err != nil {
+		t.Fatalf("XPending: %v", err)
+	}
+	if pending.Count != 0 {
+		t.Fatalf("expected 0 pending, got %d", pending.Count)
+	}
+	if got := client.ZCard(ctx, DelayedSetName).Val(); got != 1 {
+		t.Fatalf("expected only the paused message to be delayed, got %d", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailConsumerProcessMessageAppliesFrequencyCap(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	receiver := NewRedisReceiver(client, "c1", nil)
+	if err := receiver.ensureGroups(ctx); err != nil {
+		t.Fatalf("ensureGroups: %v", err)
+	}
+	producer := NewEmailProducer(client)
+	for _, requestID := range []string{"req-1", "req-2", "req-3"} {
+		if err := producer.Publish(ctx, EmailMessage{
+			RequestID: requestID,
+			Recipient: "a@b.com",
+			Category:  "marketing",
+			Subject:   "subj",
+			Content:   "content",
+		}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	var deliveries []*Delivery
+	for len(deliveries) < 3 {
+		delivery, err := receiver.Receive(ctx)
+		if err != nil || delivery == nil {
+			t.Fatalf("Receive: %+v, %v", delivery, err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, "req-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs("raw", "req-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(deliveredArgs("", "", `{"provider":"","recipients":null}`, "req-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, "req-2")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusCapDeferred, "req-2")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, "req-3")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusCapped, "req-3")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	windows := ratelimit.NewSlidingWindow(client)
+	deferCaps, err := service.NewFrequencyCapService("marketing=1/24h", service.FrequencyCapPolicyDefer, windows)
+	if err != nil {
+		t.Fatalf("NewFrequencyCapService: %v", err)
+	}
+	dropCaps, err := service.NewFrequencyCapService("marketing=1/24h", service.FrequencyCapPolicyDrop, windows)
+	if err != nil {
+		t.Fatalf("NewFrequencyCapService: %v", err)
+	}
+
+	NewEmailConsumer(receiver, emailService, nil, deferCaps, nil).processMessage(ctx, deliveries[0])
+	NewEmailConsumer(receiver, emailService, nil, deferCaps, nil).processMessage(ctx, deliveries[1])
+	NewEmailConsumer(receiver, emailService, nil, dropCaps, nil).processMessage(ctx, deliveries[2])
+
+	pending, err := client.XPending(ctx, StreamName, ConsumerGroup).Result()
+	if err != nil {
+		t.Fatalf("XPending: %v", err)
+	}
+	if pending.Count != 0 {
+		t.Fatalf("expected 0 pending, got %d", pending.Count)
+	}
+	if got := client.ZCard(ctx, DelayedSetName).Val(); got != 1 {
+		t.Fatalf("expected 1 delayed message, got %d", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailConsumerFrequencyCapIgnoresFinishedEmails(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	receiver := NewRedisReceiver(client, "c1", nil)
+	if err := receiver.ensureGroups(ctx); err != nil {
+		t.Fatalf("ensureGroups: %v", err)
+	}
+	producer := NewEmailProducer(client)
+	for _, requestID := range []string{"req-1", "req-2"} {
+		if err := producer.Publish(ctx, EmailMessage{
+			RequestID: requestID,
+			Recipient: "a@b.com",
+			Category:  "marketing",
+			Subject:   "subj",
+			Content:   "content",
+		}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	// req-1 was cancelled after it was queued, so it must not use up the recipient's cap.
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, "req-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT status").
+		WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusCancelled))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, "req-2")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs("raw", "req-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(deliveredArgs("", "", `{"provider":"","recipients":null}`, "req-2")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	caps, err := service.NewFrequencyCapService("marketing=1/24h", service.FrequencyCapPolicyDrop, ratelimit.NewSlidingWindow(client))
+	if err != nil {
+		t.Fatalf("NewFrequencyCapService: %v", err)
+	}
+	consumer := NewEmailConsumer(receiver, emailService, nil, caps, nil)
+	for i := 0; i < 2; i++ {
+		delivery, err := receiver.Receive(ctx)
+		if err != nil || delivery == nil {
+			t.Fatalf("Receive: %+v, %v", delivery, err)
+		}
+		consumer.processMessage(ctx, delivery)
+	}
+
+	pending, err := client.XPending(ctx, StreamName, ConsumerGroup).Result()
+	if err != nil {
+		t.Fatalf("XPending: %v", err)
+	}
+	if pending.Count != 0 {
+		t.Fatalf("expected 0 pending, got %d", pending.Count)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailConsumerGivesUpOnLastDelivery(t *testing.T) {
+	t.Parallel()
+
+	q := newTestNATSQueue(t, 1)
+	ctx := context.Background()
+	if err := q.Publish(ctx, EmailMessage{RequestID: "req-1", Recipient: "a@b.com", Subject: "subj", Content: "content"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	delivery := receiveWithin(t, q, 3*time.Second)
+	if delivery == nil || !delivery.Last() {
+		t.Fatalf("expected the last delivery, got %+v", delivery)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, "req-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs("raw", "req-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusTemporaryFailure, "req-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusUnknownFailure, "req-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	emailService := service.NewEmailService(noopPreparer{}, failingProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	NewEmailConsumer(q, emailService, nil, nil, nil).processMessage(ctx, delivery)
+
+	info, err := q.js.Stream(ctx, NATSStreamName)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if msgs := info.CachedInfo().State.Msgs; msgs != 0 {
+		t.Fatalf("expected the given up message to be removed, got %d messages", msgs)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailConsumerRunWithMemoryQueue(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, "req-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs("raw", "req-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(deliveredArgs("", "", `{"provider":"","recipients":null}`, "req-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	memoryQueue := NewMemoryQueue(nil)
+	if err := memoryQueue.Publish(ctx, EmailMessage{
+		RequestID: "req-1",
+		Recipient: "a@b.com",
+		Subject:   "subj",
+		Content:   "content",
+	}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	emailService := service.NewEmailService(noopPreparer{}, noopProvider{}, repository.NewEmailHistoryRepository(db), nil, nil, nil, noopLocker{})
+	done := make(chan error, 1)
+	go func() {
+		done <- NewEmailConsumer(memoryQueue, emailService, nil, nil, nil).Run(ctx)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for memoryQueue.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("message was not consumed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+const DelayedSetName = "notifications:email:delayed"
+
+// moveDueScript atomically moves due members of the delay set back onto the stream of their
+// priority lane (KEYS[2] high, KEYS[3] normal, KEYS[4] low), so concurrent movers on several
+// consumers never republish a message twice.
+var moveDueScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+for _, member in ipairs(due) do
+	local fields = cjson.decode(member)
+	local args = {}
+	for k, v in pairs(fields) do
+		table.insert(args, k)
+		table.insert(args, v)
+	end
+	local stream = KEYS[3]
+	if fields['priority'] == 'high' then
+		stream = KEYS[2]
+	elseif fields['priority'] == 'low' then
+		stream = KEYS[4]
+	end
+	redis.call('XADD', stream, '*', unpack(args))
+	redis.call('ZREM', KEYS[1], member)
+end
+return #due
+`)
+
+// DelayQueue holds email messages in a Redis sorted set scored by their due time
+// and republishes them to their lane's stream once due.
+type DelayQueue struct {
+	client *redis.Client
+}
+
+// NewDelayQueue constructs a Redis sorted-set delay queue.
+func NewDelayQueue(client *redis.Client) *DelayQueue {
+	return &DelayQueue{client: client}
+}
+
+// Schedule stores the message until at.
+func (q *DelayQueue) Schedule(ctx context.Context, msg EmailMessage, at time.Time) error {
+	member, err := delayedMember(msg, at)
+	if err != nil {
+		return err
+	}
+	if err := q.client.ZAdd(ctx, DelayedSetName, member).Err(); err != nil {
+		return fmt.Errorf("zadd to %s: %w", DelayedSetName, err)
+	}
+	return nil
+}
+
+// delayedMember encodes a message as a delay set member due at at.
+func delayedMember(msg EmailMessage, at time.Time) (redis.Z, error) {
+	member, err := encodeEmailMessage(msg)
+	if err != nil {
+		return redis.Z{}, err
+	}
+	return redis.Z{Score: float64(at.UnixMilli()), Member: member}, nil
+}
+
+// MoveDue republishes up to limit messages due at or before now and returns how many moved.
+func (q *DelayQueue) MoveDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	moved, err := moveDueScript.Run(ctx, q.client,
+		[]string{DelayedSetName, StreamFor(entity.PriorityHigh), StreamFor(entity.PriorityNormal), StreamFor(entity.PriorityLow)},
+		strconv.FormatInt(now.UnixMilli(), 10), limit,
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("move due messages: %w", err)
+	}
+	return moved, nil
+}
+
+// Run moves due messages every interval until the context is cancelled.
+func (q *DelayQueue) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		moved, err := q.MoveDue(ctx, time.Now(), 100)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logrus.WithError(err).Warn("Failed to move delayed messages")
+			continue
+		}
+		if moved > 0 {
+			logrus.WithField("count", moved).Info("Republished delayed messages")
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestDelayQueueMoveDue(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	q := NewDelayQueue(client)
+	now := time.Now()
+
+	if err := q.Schedule(ctx, EmailMessage{RequestID: "due", UserID: 7, Subject: "subj", Content: "content"}, now.Add(-time.Second)); err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	if err := q.Schedule(ctx, EmailMessage{RequestID: "urgent", Priority: "high", Subject: "subj", Content: "content"}, now.Add(-time.Second)); err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	if err := q.Schedule(ctx, EmailMessage{RequestID: "later", Subject: "subj", Content: "content"}, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+
+	moved, err := q.MoveDue(ctx, now, 100)
+	if err != nil {
+		t.Fatalf("MoveDue: %v", err)
+	}
+	if moved != 2 {
+		t.Fatalf("expected 2 moved, got %d", moved)
+	}
+
+	entries, err := client.XRange(ctx, StreamName, "-", "+").Result()
+	if err != nil {
+		t.Fatalf("XRange: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 stream entry, got %d", len(entries))
+	}
+	msg := emailMessageFromValues(entries[0].Values)
+	if msg.RequestID != "due" || msg.UserID != 7 || msg.Subject != "subj" {
+		t.Fatalf("unexpected republished message: %+v", msg)
+	}
+	if got := client.XLen(ctx, StreamFor("high")).Val(); got != 1 {
+		t.Fatalf("expected 1 entry in the high lane, got %d", got)
+	}
+	if got := client.ZCard(ctx, DelayedSetName).Val(); got != 1 {
+		t.Fatalf("expected 1 delayed message left, got %d", got)
+	}
+}
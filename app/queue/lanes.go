@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+const (
+	// LaneStrategyStrict always reads the highest-priority lane that has a message.
+	LaneStrategyStrict = "strict"
+	// LaneStrategyWeighted shares reads between lanes by weight, so lower lanes keep
+	// progressing while higher lanes have a backlog.
+	LaneStrategyWeighted = "weighted"
+)
+
+// lanes lists the priority lanes from highest to lowest priority.
+var lanes = []string{entity.PriorityHigh, entity.PriorityNormal, entity.PriorityLow}
+
+// laneFor returns the lane an email of the given priority is queued in. Messages without a
+// priority, such as those queued before priorities existed, go to the normal lane.
+func laneFor(priority string) string {
+	switch priority {
+	case entity.PriorityHigh, entity.PriorityLow:
+		return priority
+	default:
+		return entity.PriorityNormal
+	}
+}
+
+// LaneScheduler decides in which order a consumer reads the priority lanes. A nil scheduler
+// reads them in strict priority order.
+type LaneScheduler struct {
+	weights map[string]int
+
+	mu      sync.Mutex
+	current map[string]int
+}
+
+// NewLaneScheduler constructs a scheduler for the strategy. Weighted scheduling takes weights
+// in the form "high=6,normal=3,low=1"; lanes left out get weight 1.
+func NewLaneScheduler(strategy, weights string) (*LaneScheduler, error) {
+	switch strategy {
+	case LaneStrategyStrict:
+		return nil, nil
+	case LaneStrategyWeighted:
+	default:
+		return nil, fmt.Errorf("lane strategy must be %q or %q", LaneStrategyStrict, LaneStrategyWeighted)
+	}
+
+	parsed, err := ParseLaneWeights(weights)
+	if err != nil {
+		return nil, err
+	}
+	return &LaneScheduler{weights: parsed, current: make(map[string]int)}, nil
+}
+
+// ParseLaneWeights parses a comma-separated list of "lane=weight" pairs.
+func ParseLaneWeights(value string) (map[string]int, error) {
+	weights := make(map[string]int, len(lanes))
+	for _, lane := range lanes {
+		weights[lane] = 1
+	}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lane, weightValue, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("lane weight %q must be lane=weight", part)
+		}
+		lane = strings.ToLower(strings.TrimSpace(lane))
+		if _, known := weights[lane]; !known {
+			return nil, fmt.Errorf("lane weight %q names an unknown lane", part)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(weightValue))
+		if err != nil || weight < 1 {
+			return nil, fmt.Errorf("lane weight %q must be a positive integer", part)
+		}
+		weights[lane] = weight
+	}
+	return weights, nil
+}
+
+// Order returns the lanes to read for the next message. The first lane is picked by smooth
+// weighted round robin; the remaining lanes follow in priority order, so a consumer never
+// idles while any lane has messages.
+func (s *LaneScheduler) Order() []string {
+	if s == nil {
+		return lanes
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := 0
+	first := ""
+	for _, lane := range lanes {
+		s.current[lane] += s.weights[lane]
+		total += s.weights[lane]
+		if first == "" || s.current[lane] > s.current[first] {
+			first = lane
+		}
+	}
+	s.current[first] -= total
+
+	order := make([]string, 0, len(lanes))
+	order = append(order, first)
+	for _, lane := range lanes {
+		if lane != first {
+			order = append(order, lane)
+		}
+	}
+	return order
+}
@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLaneSchedulerStrict(t *testing.T) {
+	t.Parallel()
+
+	scheduler, err := NewLaneScheduler(LaneStrategyStrict, "")
+	if err != nil {
+		t.Fatalf("NewLaneScheduler: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if got := scheduler.Order(); !reflect.DeepEqual(got, []string{"high", "normal", "low"}) {
+			t.Fatalf("unexpected strict order: %v", got)
+		}
+	}
+}
+
+func TestLaneSchedulerWeightedSharesReads(t *testing.T) {
+	t.Parallel()
+
+	scheduler, err := NewLaneScheduler(LaneStrategyWeighted, "high=3,normal=2")
+	if err != nil {
+		t.Fatalf("NewLaneScheduler: %v", err)
+	}
+
+	firsts := map[string]int{}
+	for i := 0; i < 60; i++ {
+		order := scheduler.Order()
+		if len(order) != 3 {
+			t.Fatalf("expected every lane in the order, got %v", order)
+		}
+		firsts[order[0]]++
+	}
+	if firsts["high"] != 30 || firsts["normal"] != 20 || firsts["low"] != 10 {
+		t.Fatalf("unexpected share of first reads: %v", firsts)
+	}
+}
+
+func TestNewLaneSchedulerRejectsInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		strategy string
+		weights  string
+	}{
+		{strategy: "random", weights: ""},
+		{strategy: LaneStrategyWeighted, weights: "high"},
+		{strategy: LaneStrategyWeighted, weights: "urgent=2"},
+		{strategy: LaneStrategyWeighted, weights: "high=0"},
+	}
+	for _, tc := range tests {
+		if _, err := NewLaneScheduler(tc.strategy, tc.weights); err == nil {
+			t.Fatalf("expected error for %q %q", tc.strategy, tc.weights)
+		}
+	}
+}
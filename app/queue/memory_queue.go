@@ -0,0 +1,140 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	id          uint64
+	lane        string
+	message     EmailMessage
+	availableAt time.Time
+}
+
+// MemoryQueue keeps email messages in process memory. It is meant for tests and for
+// running the API and the consumer in one process; messages are lost on restart.
+type MemoryQueue struct {
+	scheduler *LaneScheduler
+
+	mu           sync.Mutex
+	nextID       uint64
+	entries      []*memoryEntry
+	published    chan struct{}
+	pollInterval time.Duration
+}
+
+// NewMemoryQueue constructs an in-memory queue that reads the lanes in the order the
+// scheduler picks.
+func NewMemoryQueue(scheduler *LaneScheduler) *MemoryQueue {
+	return &MemoryQueue{
+		scheduler:    scheduler,
+		published:    make(chan struct{}, 1),
+		pollInterval: pollInterval,
+	}
+}
+
+// Publish appends the message, due at SendAt when it is in the future and immediately otherwise.
+func (q *MemoryQueue) Publish(_ context.Context, msg EmailMessage) error {
+	availableAt := time.Now()
+	if msg.SendAt.After(availableAt) {
+		availableAt = msg.SendAt
+	}
+
+	q.mu.Lock()
+	q.nextID++
+	q.entries = append(q.entries, &memoryEntry{id: q.nextID, lane: laneFor(msg.Priority), message: msg, availableAt: availableAt})
+	q.mu.Unlock()
+
+	select {
+	case q.published <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Open is a no-op.
+func (q *MemoryQueue) Open(_ context.Context) error {
+	return nil
+}
+
+// Receive claims the first due message of the first lane in scheduler order that has one
+// and hides it for receiveVisibility. When none is due it waits until a message is
+// published or pollInterval passes and returns nil.
+func (q *MemoryQueue) Receive(ctx context.Context) (*Delivery, error) {
+	if delivery := q.claim(time.Now()); delivery != nil {
+		return delivery, nil
+	}
+
+	timer := time.NewTimer(q.pollInterval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	case <-timer.C:
+	case <-q.published:
+	}
+	return q.claim(time.Now()), nil
+}
+
+// claim returns the first due entry in publish order of the first lane that has one and
+// hides it.
+func (q *MemoryQueue) claim(now time.Time) *Delivery {
+	order := q.scheduler.Order()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, lane := range order {
+		for _, entry := range q.entries {
+			if entry.lane != lane || entry.availableAt.After(now) {
+				continue
+			}
+			entry.availableAt = now.Add(receiveVisibility)
+			return &Delivery{ID: strconv.FormatUint(entry.id, 10), Message: entry.message, lane: lane}
+		}
+	}
+	return nil
+}
+
+// Ack removes the entry.
+func (q *MemoryQueue) Ack(_ context.Context, delivery *Delivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, entry := range q.entries {
+		if strconv.FormatUint(entry.id, 10) == delivery.ID {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// Defer moves the entry's due time to until.
+func (q *MemoryQueue) Defer(_ context.Context, delivery *Delivery, until time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, entry := range q.entries {
+		if strconv.FormatUint(entry.id, 10) == delivery.ID {
+			entry.availableAt = until
+			return nil
+		}
+	}
+	return nil
+}
+
+// Nack leaves the entry hidden; it is delivered again once the visibility timeout passes.
+func (q *MemoryQueue) Nack(_ context.Context, _ *Delivery) error {
+	return nil
+}
+
+// Len returns how many messages are queued, including received but unacked ones.
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryQueueDeliversDueMessagesInOrder(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q := NewMemoryQueue(nil)
+	q.pollInterval = time.Millisecond
+
+	for _, msg := range []EmailMessage{
+		{RequestID: "req-1", SendAt: time.Now().Add(time.Hour)},
+		{RequestID: "req-2"},
+		{RequestID: "req-3"},
+	} {
+		if err := q.Publish(ctx, msg); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	first, err := q.Receive(ctx)
+	if err != nil || first == nil || first.Message.RequestID != "req-2" {
+		t.Fatalf("expected req-2, got %+v, %v", first, err)
+	}
+	if err := q.Defer(ctx, first, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Defer: %v", err)
+	}
+
+	second, err := q.Receive(ctx)
+	if err != nil || second == nil || second.Message.RequestID != "req-3" {
+		t.Fatalf("expected req-3, got %+v, %v", second, err)
+	}
+	if err := q.Ack(ctx, second); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+
+	// req-1 is scheduled and req-2 deferred, so nothing is due.
+	if delivery, err := q.Receive(ctx); err != nil || delivery != nil {
+		t.Fatalf("expected no delivery, got %+v, %v", delivery, err)
+	}
+	if got := q.Len(); got != 2 {
+		t.Fatalf("expected 2 queued messages, got %d", got)
+	}
+}
+
+func TestMemoryQueueReadsHigherLanesFirst(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q := NewMemoryQueue(nil)
+
+	for _, msg := range []EmailMessage{
+		{RequestID: "newsletter", Priority: "low"},
+		{RequestID: "otp", Priority: "high"},
+	} {
+		if err := q.Publish(ctx, msg); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	for _, want := range []string{"otp", "newsletter"} {
+		delivery, err := q.Receive(ctx)
+		if err != nil || delivery == nil || delivery.Message.RequestID != want {
+			t.Fatalf("expected %s, got %+v, %v", want, delivery, err)
+		}
+		if err := q.Ack(ctx, delivery); err != nil {
+			t.Fatalf("Ack: %v", err)
+		}
+	}
+}
@@ -1,18 +1,144 @@
 package queue
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
 
 const StreamName = "notifications:email:send-raw"
 const ConsumerGroup = "email-consumers"
 
-// EmailPublisher abstracts message publishing to the email stream.
+// StreamFor returns the Redis stream of a priority lane. The normal lane keeps the original
+// stream name, so messages queued before lanes existed are still read.
+func StreamFor(lane string) string {
+	if lane == entity.PriorityNormal {
+		return StreamName
+	}
+	return StreamName + ":" + lane
+}
+
+// EmailPublisher abstracts message publishing to the email queue.
 type EmailPublisher interface {
 	Publish(ctx context.Context, msg EmailMessage) error
 }
 
+// EmailBatchPublisher is implemented by publishers that can push several messages in one
+// round trip. A failed batch may have been published in part.
+type EmailBatchPublisher interface {
+	PublishBatch(ctx context.Context, msgs []EmailMessage) error
+}
+
+// EmailReceiver abstracts the consuming side of an email queue backend. A delivery that
+// is neither acked nor deferred is delivered again later.
+type EmailReceiver interface {
+	// Open prepares the backend and starts its background work until ctx is cancelled.
+	Open(ctx context.Context) error
+	// Receive waits for the next due delivery; it returns nil when none arrived in time.
+	Receive(ctx context.Context) (*Delivery, error)
+	// Ack removes a handled delivery from the queue.
+	Ack(ctx context.Context, delivery *Delivery) error
+	// Defer removes the delivery and queues its message again, due at until.
+	Defer(ctx context.Context, delivery *Delivery, until time.Time) error
+	// Nack hands back a delivery that failed, to be delivered again later.
+	Nack(ctx context.Context, delivery *Delivery) error
+}
+
+// Delivery is a message handed to a consumer; ID identifies it within its backend.
+type Delivery struct {
+	ID      string
+	Message EmailMessage
+	// lane is the priority lane the delivery was read from.
+	lane string
+	// last is set when the backend will not deliver the message again after this delivery.
+	last bool
+}
+
+// Lane returns the priority lane the delivery was read from.
+func (d *Delivery) Lane() string {
+	return d.lane
+}
+
+// Last reports whether this is the message's final delivery, so a failure is not retried.
+func (d *Delivery) Last() bool {
+	return d.last
+}
+
+// EmailMessage is a queued email. SendAt is not part of the stream entry; a future
+// SendAt holds the message in the delay queue until it is due.
 type EmailMessage struct {
 	RequestID string
 	Recipient string
+	UserID    uint64
+	Category  string
+	Priority  string
+	Timezone  string
 	Subject   string
 	Content   string
+	BatchID   string
+	Tenant    string
+	Template  string
+	SendAt    time.Time
+}
+
+// fields returns the stream entry fields for the message.
+func (m EmailMessage) fields() map[string]string {
+	return map[string]string{
+		"request_id": m.RequestID,
+		"recipient":  m.Recipient,
+		"user_id":    strconv.FormatUint(m.UserID, 10),
+		"category":   m.Category,
+		"priority":   m.Priority,
+		"timezone":   m.Timezone,
+		"subject":    m.Subject,
+		"content":    m.Content,
+		"batch_id":   m.BatchID,
+		"tenant":     m.Tenant,
+		"template":   m.Template,
+	}
+}
+
+// emailMessageFromValues decodes stream entry fields. Fields added after a message
+// was queued are missing from it and decode as zero values.
+func emailMessageFromValues(values map[string]interface{}) EmailMessage {
+	str := func(key string) string {
+		v, _ := values[key].(string)
+		return v
+	}
+	userID, _ := strconv.ParseUint(str("user_id"), 10, 64)
+	return EmailMessage{
+		RequestID: str("request_id"),
+		Recipient: str("recipient"),
+		UserID:    userID,
+		Category:  str("category"),
+		Priority:  str("priority"),
+		Timezone:  str("timezone"),
+		Subject:   str("subject"),
+		Content:   str("content"),
+		BatchID:   str("batch_id"),
+		Tenant:    str("tenant"),
+		Template:  str("template"),
+	}
+}
+
+// encodeEmailMessage serializes the message fields for backends that store messages as text.
+func encodeEmailMessage(msg EmailMessage) (string, error) {
+	payload, err := json.Marshal(msg.fields())
+	if err != nil {
+		return "", fmt.Errorf("encode email message: %w", err)
+	}
+	return string(payload), nil
+}
+
+// decodeEmailMessage parses a payload written by encodeEmailMessage.
+func decodeEmailMessage(payload string) (EmailMessage, error) {
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &values); err != nil {
+		return EmailMessage{}, fmt.Errorf("decode email message: %w", err)
+	}
+	return emailMessageFromValues(values), nil
 }
@@ -0,0 +1,135 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// receiveVisibility is how long a received message stays hidden from other consumers of the
+// MySQL and memory queues; a message neither acked nor deferred by then is delivered again.
+const receiveVisibility = 2 * time.Minute
+
+// pollInterval is how long the MySQL and memory queues wait for a due message per Receive.
+const pollInterval = time.Second
+
+// MySQLQueue stores email messages in the email_queue table. Consumers claim due rows with
+// SELECT ... FOR UPDATE SKIP LOCKED, so several of them can share the table without Redis.
+type MySQLQueue struct {
+	db           *sql.DB
+	scheduler    *LaneScheduler
+	pollInterval time.Duration
+}
+
+// NewMySQLQueue constructs a MySQL table queue that reads the lanes in the order the
+// scheduler picks.
+func NewMySQLQueue(db *sql.DB, scheduler *LaneScheduler) *MySQLQueue {
+	return &MySQLQueue{db: db, scheduler: scheduler, pollInterval: pollInterval}
+}
+
+// Publish inserts the message, due at SendAt when it is in the future and immediately otherwise.
+func (q *MySQLQueue) Publish(ctx context.Context, msg EmailMessage) error {
+	payload, err := encodeEmailMessage(msg)
+	if err != nil {
+		return err
+	}
+	availableAt := time.Now().UTC()
+	if msg.SendAt.After(availableAt) {
+		availableAt = msg.SendAt.UTC()
+	}
+
+	query := `INSERT INTO email_queue (request_id, lane, payload, available_at) VALUES (?, ?, ?, ?)`
+	if _, err := q.db.ExecContext(ctx, query, msg.RequestID, laneFor(msg.Priority), payload, availableAt); err != nil {
+		return fmt.Errorf("insert into email_queue: %w", err)
+	}
+	return nil
+}
+
+// Open is a no-op; the table is created by the schema.
+func (q *MySQLQueue) Open(_ context.Context) error {
+	return nil
+}
+
+// Receive claims the oldest due message of the first lane in scheduler order that has one
+// and hides it for receiveVisibility. When none is due it waits pollInterval and returns nil.
+func (q *MySQLQueue) Receive(ctx context.Context) (*Delivery, error) {
+	now := time.Now().UTC()
+	for _, lane := range q.scheduler.Order() {
+		delivery, err := q.claim(ctx, lane, now)
+		if err != nil || delivery != nil {
+			return delivery, err
+		}
+	}
+
+	timer := time.NewTimer(q.pollInterval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	return nil, nil
+}
+
+// claim locks one due row of the lane, skipping rows other consumers hold, and moves its due
+// time past the visibility timeout in the same transaction.
+func (q *MySQLQueue) claim(ctx context.Context, lane string, now time.Time) (*Delivery, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var (
+		id      uint64
+		payload string
+	)
+	query := `SELECT id, payload FROM email_queue WHERE lane = ? AND available_at <= ? ORDER BY available_at, id LIMIT 1 FOR UPDATE SKIP LOCKED`
+	if err := tx.QueryRowContext(ctx, query, lane, now).Scan(&id, &payload); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("claim from email_queue: %w", err)
+	}
+
+	query = `UPDATE email_queue SET available_at = ? WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, now.Add(receiveVisibility), id); err != nil {
+		return nil, fmt.Errorf("claim from email_queue: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	message, err := decodeEmailMessage(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Delivery{ID: strconv.FormatUint(id, 10), Message: message, lane: lane}, nil
+}
+
+// Ack deletes the row.
+func (q *MySQLQueue) Ack(ctx context.Context, delivery *Delivery) error {
+	query := `DELETE FROM email_queue WHERE id = ?`
+	if _, err := q.db.ExecContext(ctx, query, delivery.ID); err != nil {
+		return fmt.Errorf("delete from email_queue: %w", err)
+	}
+	return nil
+}
+
+// Defer moves the row's due time to until.
+func (q *MySQLQueue) Defer(ctx context.Context, delivery *Delivery, until time.Time) error {
+	query := `UPDATE email_queue SET available_at = ? WHERE id = ?`
+	if _, err := q.db.ExecContext(ctx, query, until.UTC(), delivery.ID); err != nil {
+		return fmt.Errorf("defer in email_queue: %w", err)
+	}
+	return nil
+}
+
+// Nack leaves the row hidden; it is delivered again once the visibility timeout passes.
+func (q *MySQLQueue) Nack(_ context.Context, _ *Delivery) error {
+	return nil
+}
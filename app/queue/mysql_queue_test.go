@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMySQLQueuePublishAndReceive(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	q := NewMySQLQueue(db, nil)
+
+	mock.ExpectExec("INSERT INTO email_queue").
+		WithArgs("req-1", "high", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	if err := q.Publish(ctx, EmailMessage{RequestID: "req-1", Recipient: "a@b.com", UserID: 42, Priority: "high", Subject: "subj", Content: "content"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, payload FROM email_queue .* FOR UPDATE SKIP LOCKED").
+		WithArgs("high", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}).
+			AddRow(uint64(7), `{"request_id":"req-1","recipient":"a@b.com","user_id":"42","priority":"high","subject":"subj","content":"content"}`))
+	mock.ExpectExec("UPDATE email_queue SET available_at").
+		WithArgs(sqlmock.AnyArg(), uint64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	delivery, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if delivery == nil || delivery.ID != "7" || delivery.Lane() != "high" || delivery.Message.RequestID != "req-1" || delivery.Message.UserID != 42 {
+		t.Fatalf("unexpected delivery: %+v", delivery)
+	}
+
+	until := time.Date(2030, 1, 2, 7, 0, 0, 0, time.UTC)
+	mock.ExpectExec("UPDATE email_queue SET available_at").
+		WithArgs(until, "7").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := q.Defer(ctx, delivery, until); err != nil {
+		t.Fatalf("Defer: %v", err)
+	}
+
+	mock.ExpectExec("DELETE FROM email_queue").
+		WithArgs("7").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := q.Ack(ctx, delivery); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestMySQLQueueReceiveEmpty(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	q := NewMySQLQueue(db, nil)
+	q.pollInterval = time.Millisecond
+
+	// Every lane is tried once, highest priority first.
+	for _, lane := range []string{"high", "normal", "low"} {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id, payload FROM email_queue").
+			WithArgs(lane, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}))
+		mock.ExpectRollback()
+	}
+
+	delivery, err := q.Receive(context.Background())
+	if err != nil || delivery != nil {
+		t.Fatalf("expected no delivery, got %+v, %v", delivery, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
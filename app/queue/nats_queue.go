@@ -0,0 +1,389 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+const (
+	NATSStreamName   = "NOTIFICATIONS_EMAIL"
+	NATSSubject      = "notifications.email.send-raw"
+	NATSConsumerName = "email-consumers"
+
+	// NATSDelayedStreamName holds scheduled and deferred messages until they are due, apart
+	// from the lanes, so waiting does not count as a delivery of the lane consumers.
+	NATSDelayedStreamName   = "NOTIFICATIONS_EMAIL_DELAYED"
+	NATSDelayedSubject      = "notifications.email.delayed"
+	NATSDelayedConsumerName = "email-delayed"
+)
+
+// NATSSubjectFor returns the subject of a priority lane. The normal lane keeps the original
+// subject, so messages queued before lanes existed are still read.
+func NATSSubjectFor(lane string) string {
+	if lane == entity.PriorityNormal {
+		return NATSSubject
+	}
+	return NATSSubject + "." + lane
+}
+
+// natsConsumerFor returns the durable consumer name of a priority lane.
+func natsConsumerFor(lane string) string {
+	return NATSConsumerName + "-" + lane
+}
+
+// natsSendAtHeader carries the due time of scheduled and deferred messages. JetStream has no
+// delayed publish, so these messages are published to the delayed stream, whose consumer nacks
+// them until they are due and then moves them to their lane.
+const natsSendAtHeader = "Notifications-Send-At"
+
+const (
+	// natsAckWait is how long a delivery may stay unacknowledged before it is redelivered.
+	natsAckWait = time.Minute
+	// natsFetchWait is how long Receive waits on the high lane when every lane is empty.
+	natsFetchWait = time.Second
+	// natsRetryBackoff is the first redelivery delay after a failed delivery; it doubles
+	// with every further delivery up to natsMaxRetryBackoff.
+	natsRetryBackoff    = 10 * time.Second
+	natsMaxRetryBackoff = 10 * time.Minute
+	// natsPromoteInterval is how often Receive moves due messages from the delayed stream.
+	natsPromoteInterval = time.Second
+	// natsPromoteBatch is how many delayed messages one promotion fetches at most.
+	natsPromoteBatch = 100
+)
+
+// NATSQueue queues email messages on a JetStream work-queue stream with one subject per
+// priority lane, each consumed through a durable pull consumer shared by every worker.
+type NATSQueue struct {
+	js           jetstream.JetStream
+	scheduler    *LaneScheduler
+	maxDeliver   int
+	consumers    map[string]jetstream.Consumer
+	delayed      jetstream.Consumer
+	fetchWait    time.Duration
+	retryBackoff time.Duration
+
+	mu          sync.Mutex
+	inflight    map[string]jetstream.Msg
+	nextPromote time.Time
+}
+
+// NewNATSQueue creates or updates the email and delayed streams and constructs a JetStream
+// queue that reads the lanes in the order the scheduler picks. A message is delivered from
+// its lane at most maxDeliver times; zero or less means no limit.
+func NewNATSQueue(ctx context.Context, js jetstream.JetStream, maxDeliver int, scheduler *LaneScheduler) (*NATSQueue, error) {
+	streams := []jetstream.StreamConfig{
+		{Name: NATSStreamName, Subjects: []string{NATSSubject, NATSSubject + ".*"}, Retention: jetstream.WorkQueuePolicy},
+		{Name: NATSDelayedStreamName, Subjects: []string{NATSDelayedSubject}, Retention: jetstream.WorkQueuePolicy},
+	}
+	for _, stream := range streams {
+		if _, err := js.CreateOrUpdateStream(ctx, stream); err != nil {
+			return nil, fmt.Errorf("create stream %s: %w", stream.Name, err)
+		}
+	}
+	if maxDeliver <= 0 {
+		maxDeliver = -1
+	}
+	return &NATSQueue{
+		js:           js,
+		scheduler:    scheduler,
+		maxDeliver:   maxDeliver,
+		consumers:    make(map[string]jetstream.Consumer, len(lanes)),
+		fetchWait:    natsFetchWait,
+		retryBackoff: natsRetryBackoff,
+		inflight:     make(map[string]jetstream.Msg),
+	}, nil
+}
+
+// Publish adds the message to its lane, or to the delayed stream when it is not due yet. The
+// request ID is the JetStream message ID, so the server drops a republish of the same message
+// within its duplicate window.
+func (q *NATSQueue) Publish(ctx context.Context, msg EmailMessage) error {
+	natsMsg, err := newNATSMsg(msg, msg.SendAt)
+	if err != nil {
+		return err
+	}
+	if _, err := q.js.PublishMsg(ctx, natsMsg, jetstream.WithMsgID(msg.RequestID)); err != nil {
+		return fmt.Errorf("publish to %s: %w", natsMsg.Subject, err)
+	}
+	return nil
+}
+
+// Open creates or updates one durable pull consumer per lane and the consumer of the delayed
+// stream. The unfiltered consumer used before lanes existed is deleted first, since consumers
+// of a work-queue stream must not overlap.
+func (q *NATSQueue) Open(ctx context.Context) error {
+	if err := q.js.DeleteConsumer(ctx, NATSStreamName, NATSConsumerName); err != nil && !errors.Is(err, jetstream.ErrConsumerNotFound) {
+		return fmt.Errorf("delete consumer %s: %w", NATSConsumerName, err)
+	}
+	for _, lane := range lanes {
+		consumer, err := q.js.CreateOrUpdateConsumer(ctx, NATSStreamName, jetstream.ConsumerConfig{
+			Durable:       natsConsumerFor(lane),
+			FilterSubject: NATSSubjectFor(lane),
+			AckPolicy:     jetstream.AckExplicitPolicy,
+			AckWait:       natsAckWait,
+			MaxDeliver:    q.maxDeliver,
+		})
+		if err != nil {
+			return fmt.Errorf("create consumer %s: %w", natsConsumerFor(lane), err)
+		}
+		q.consumers[lane] = consumer
+	}
+
+	// Delayed messages are nacked until due however long that is, so their consumer has no
+	// delivery limit. A nacked message stays pending until it is redelivered, so the consumer
+	// has no ack pending limit either: far-future messages must not keep due ones back.
+	delayed, err := q.js.CreateOrUpdateConsumer(ctx, NATSDelayedStreamName, jetstream.ConsumerConfig{
+		Durable:       NATSDelayedConsumerName,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       natsAckWait,
+		MaxDeliver:    -1,
+		MaxAckPending: -1,
+	})
+	if err != nil {
+		return fmt.Errorf("create consumer %s: %w", NATSDelayedConsumerName, err)
+	}
+	q.delayed = delayed
+
+	logrus.WithFields(logrus.Fields{
+		"consumer": NATSConsumerName,
+		"stream":   NATSStreamName,
+	}).Info("NATS receiver opened")
+	return nil
+}
+
+// Receive moves due delayed messages to their lanes, then pulls the next message from the
+// first lane in scheduler order that has one. When every lane is empty it waits on the high
+// lane for a while. A lane message that is not due yet, published there before the delayed
+// stream existed, is moved to the delayed stream.
+func (q *NATSQueue) Receive(ctx context.Context) (*Delivery, error) {
+	if err := q.promote(ctx); err != nil {
+		return nil, err
+	}
+	for {
+		msg, lane, err := q.next(ctx)
+		if err != nil || msg == nil {
+			return nil, err
+		}
+
+		if at, ok := natsSendAt(msg); ok && at.After(time.Now()) {
+			if err := q.hold(ctx, msg); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		meta, err := msg.Metadata()
+		if err != nil {
+			return nil, fmt.Errorf("read message metadata: %w", err)
+		}
+		message, err := decodeEmailMessage(string(msg.Data()))
+		if err != nil {
+			// A message that cannot be decoded never succeeds; terminate it instead of retrying.
+			_ = msg.Term()
+			return nil, err
+		}
+
+		id := strconv.FormatUint(meta.Sequence.Stream, 10)
+		q.mu.Lock()
+		q.inflight[id] = msg
+		q.mu.Unlock()
+		last := q.maxDeliver > 0 && meta.NumDelivered >= uint64(q.maxDeliver)
+		return &Delivery{ID: id, Message: message, lane: lane, last: last}, nil
+	}
+}
+
+// promote moves the delayed messages that are due to their lanes, at most once per
+// natsPromoteInterval across the queue's callers. A message that is not due is nacked until its
+// due time, so the consumer receives it again when it is.
+func (q *NATSQueue) promote(ctx context.Context) error {
+	q.mu.Lock()
+	if time.Now().Before(q.nextPromote) {
+		q.mu.Unlock()
+		return nil
+	}
+	q.nextPromote = time.Now().Add(natsPromoteInterval)
+	q.mu.Unlock()
+
+	batch, err := q.delayed.FetchNoWait(natsPromoteBatch)
+	if err != nil {
+		return fmt.Errorf("fetch from %s: %w", NATSDelayedConsumerName, err)
+	}
+	for msg := range batch.Messages() {
+		if at, ok := natsSendAt(msg); ok && at.After(time.Now()) {
+			if err := msg.NakWithDelay(time.Until(at)); err != nil {
+				return fmt.Errorf("nak delayed message: %w", err)
+			}
+			continue
+		}
+
+		message, err := decodeEmailMessage(string(msg.Data()))
+		if err != nil {
+			logrus.WithError(err).Warn("Dropping delayed message that cannot be decoded")
+			_ = msg.Term()
+			continue
+		}
+		natsMsg, err := newNATSMsg(message, time.Time{})
+		if err != nil {
+			return err
+		}
+		// The ID includes the due time, so a redelivered promotion is dropped as a duplicate
+		// while a later deferral of the same request is not.
+		msgID := message.RequestID + "@" + msg.Headers().Get(natsSendAtHeader)
+		if _, err := q.js.PublishMsg(ctx, natsMsg, jetstream.WithMsgID(msgID)); err != nil {
+			return fmt.Errorf("publish to %s: %w", natsMsg.Subject, err)
+		}
+		if err := msg.DoubleAck(ctx); err != nil {
+			return fmt.Errorf("ack delayed message: %w", err)
+		}
+	}
+	if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) {
+		return fmt.Errorf("fetch from %s: %w", NATSDelayedConsumerName, err)
+	}
+	return nil
+}
+
+// hold moves a lane message that is not due yet to the delayed stream.
+func (q *NATSQueue) hold(ctx context.Context, msg jetstream.Msg) error {
+	delayed := nats.NewMsg(NATSDelayedSubject)
+	delayed.Header = msg.Headers()
+	delayed.Data = msg.Data()
+	if _, err := q.js.PublishMsg(ctx, delayed); err != nil {
+		return fmt.Errorf("publish to %s: %w", NATSDelayedSubject, err)
+	}
+	if err := msg.DoubleAck(ctx); err != nil {
+		return fmt.Errorf("ack scheduled message: %w", err)
+	}
+	return nil
+}
+
+// natsSendAt returns the due time of a scheduled or deferred message.
+func natsSendAt(msg jetstream.Msg) (time.Time, bool) {
+	sendAt := msg.Headers().Get(natsSendAtHeader)
+	if sendAt == "" {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(time.RFC3339Nano, sendAt)
+	return at, err == nil
+}
+
+// next fetches one message without waiting from the lanes in scheduler order, then waits on
+// the high lane. It returns nil when no message arrived.
+func (q *NATSQueue) next(ctx context.Context) (jetstream.Msg, string, error) {
+	for _, lane := range q.scheduler.Order() {
+		batch, err := q.consumers[lane].FetchNoWait(1)
+		if err != nil {
+			return nil, "", fmt.Errorf("fetch from %s: %w", natsConsumerFor(lane), err)
+		}
+		if msg := <-batch.Messages(); msg != nil {
+			return msg, lane, nil
+		}
+		if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) {
+			return nil, "", fmt.Errorf("fetch from %s: %w", natsConsumerFor(lane), err)
+		}
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, q.fetchWait)
+	defer cancel()
+	msg, err := q.consumers[entity.PriorityHigh].Next(jetstream.FetchContext(fetchCtx))
+	if err != nil {
+		if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("fetch from %s: %w", natsConsumerFor(entity.PriorityHigh), err)
+	}
+	return msg, entity.PriorityHigh, nil
+}
+
+// Ack acknowledges the message, which removes it from the work-queue stream.
+func (q *NATSQueue) Ack(ctx context.Context, delivery *Delivery) error {
+	msg, err := q.take(delivery)
+	if err != nil {
+		return err
+	}
+	if err := msg.DoubleAck(ctx); err != nil {
+		return fmt.Errorf("ack message %s: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+// Defer publishes the message to the delayed stream, due at until, and acknowledges the
+// delivery. The copy starts with a fresh delivery count once it is back in its lane.
+func (q *NATSQueue) Defer(ctx context.Context, delivery *Delivery, until time.Time) error {
+	natsMsg, err := newNATSMsg(delivery.Message, until)
+	if err != nil {
+		return err
+	}
+	if _, err := q.js.PublishMsg(ctx, natsMsg); err != nil {
+		return fmt.Errorf("publish to %s: %w", natsMsg.Subject, err)
+	}
+	return q.Ack(ctx, delivery)
+}
+
+// Nack asks the server to redeliver the message after a backoff that doubles with every
+// delivery. Once the consumer's max deliver is reached the message is not delivered again;
+// such a delivery is marked Last.
+func (q *NATSQueue) Nack(_ context.Context, delivery *Delivery) error {
+	msg, err := q.take(delivery)
+	if err != nil {
+		return err
+	}
+	meta, err := msg.Metadata()
+	if err != nil {
+		return fmt.Errorf("read message metadata: %w", err)
+	}
+	if err := msg.NakWithDelay(natsBackoff(q.retryBackoff, meta.NumDelivered)); err != nil {
+		return fmt.Errorf("nak message %s: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+// take removes the delivery's message from the in-flight set.
+func (q *NATSQueue) take(delivery *Delivery) (jetstream.Msg, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	msg, ok := q.inflight[delivery.ID]
+	if !ok {
+		return nil, fmt.Errorf("message %s is not in flight", delivery.ID)
+	}
+	delete(q.inflight, delivery.ID)
+	return msg, nil
+}
+
+// natsBackoff returns the redelivery delay after the given number of deliveries, doubling
+// base for every delivery after the first.
+func natsBackoff(base time.Duration, delivered uint64) time.Duration {
+	backoff := base
+	for i := uint64(1); i < delivered && backoff < natsMaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > natsMaxRetryBackoff {
+		return natsMaxRetryBackoff
+	}
+	return backoff
+}
+
+// newNATSMsg encodes the message for its lane's subject, or for the delayed stream due at
+// dueAt when that is in the future.
+func newNATSMsg(msg EmailMessage, dueAt time.Time) (*nats.Msg, error) {
+	payload, err := encodeEmailMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	natsMsg := nats.NewMsg(NATSSubjectFor(laneFor(msg.Priority)))
+	natsMsg.Data = []byte(payload)
+	if dueAt.After(time.Now()) {
+		natsMsg.Subject = NATSDelayedSubject
+		natsMsg.Header.Set(natsSendAtHeader, dueAt.UTC().Format(time.RFC3339Nano))
+	}
+	return natsMsg, nil
+}
@@ -0,0 +1,291 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// newTestNATSQueue starts an embedded JetStream server and opens a queue on it.
+func newTestNATSQueue(t *testing.T, maxDeliver int) *NATSQueue {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("server.NewServer: %v", err)
+	}
+	srv.Start()
+	t.Cleanup(srv.Shutdown)
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatalf("nats server not ready")
+	}
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("nats.Connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatalf("jetstream.New: %v", err)
+	}
+
+	ctx := context.Background()
+	q, err := NewNATSQueue(ctx, js, maxDeliver, nil)
+	if err != nil {
+		t.Fatalf("NewNATSQueue: %v", err)
+	}
+	q.fetchWait = time.Second
+	q.retryBackoff = 50 * time.Millisecond
+	if err := q.Open(ctx); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return q
+}
+
+// receiveWithin calls Receive until it returns a delivery or timeout passes.
+func receiveWithin(t *testing.T, q *NATSQueue, timeout time.Duration) *Delivery {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		delivery, err := q.Receive(context.Background())
+		if err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+		if delivery != nil {
+			return delivery
+		}
+	}
+	return nil
+}
+
+func TestNATSQueuePublishReceiveAck(t *testing.T) {
+	t.Parallel()
+
+	q := newTestNATSQueue(t, 5)
+	ctx := context.Background()
+
+	msg := EmailMessage{RequestID: "req-1", Recipient: "a@b.com", UserID: 42, Subject: "subj", Content: "content"}
+	// The second publish has the same message ID and is dropped as a duplicate.
+	for i := 0; i < 2; i++ {
+		if err := q.Publish(ctx, msg); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	delivery, err := q.Receive(ctx)
+	if err != nil || delivery == nil {
+		t.Fatalf("Receive: %+v, %v", delivery, err)
+	}
+	if delivery.Message.RequestID != "req-1" || delivery.Message.UserID != 42 || delivery.Message.Recipient != "a@b.com" {
+		t.Fatalf("unexpected message: %+v", delivery.Message)
+	}
+	if err := q.Ack(ctx, delivery); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+
+	if delivery, err := q.Receive(ctx); err != nil || delivery != nil {
+		t.Fatalf("expected no delivery, got %+v, %v", delivery, err)
+	}
+}
+
+func TestNATSQueueHoldsScheduledAndDeferredMessages(t *testing.T) {
+	t.Parallel()
+
+	q := newTestNATSQueue(t, 5)
+	ctx := context.Background()
+
+	start := time.Now()
+	if err := q.Publish(ctx, EmailMessage{RequestID: "req-1", SendAt: start.Add(300 * time.Millisecond)}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	delivery := receiveWithin(t, q, 3*time.Second)
+	if delivery == nil {
+		t.Fatalf("scheduled message was not delivered")
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Fatalf("scheduled message delivered after %v", elapsed)
+	}
+
+	deferredAt := time.Now()
+	if err := q.Defer(ctx, delivery, deferredAt.Add(300*time.Millisecond)); err != nil {
+		t.Fatalf("Defer: %v", err)
+	}
+	delivery = receiveWithin(t, q, 3*time.Second)
+	if delivery == nil || delivery.Message.RequestID != "req-1" {
+		t.Fatalf("deferred message was not delivered: %+v", delivery)
+	}
+	if elapsed := time.Since(deferredAt); elapsed < 250*time.Millisecond {
+		t.Fatalf("deferred message delivered after %v", elapsed)
+	}
+	if err := q.Ack(ctx, delivery); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+}
+
+func TestNATSQueueWaitingDoesNotCountAgainstMaxDeliver(t *testing.T) {
+	t.Parallel()
+
+	q := newTestNATSQueue(t, 1)
+	ctx := context.Background()
+
+	dueAt := time.Now().Add(300 * time.Millisecond)
+	if err := q.Publish(ctx, EmailMessage{RequestID: "scheduled", SendAt: dueAt}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	// A message queued on its lane with a due time, as before the delayed stream existed.
+	legacy, err := newNATSMsg(EmailMessage{RequestID: "legacy"}, time.Time{})
+	if err != nil {
+		t.Fatalf("newNATSMsg: %v", err)
+	}
+	legacy.Header.Set(natsSendAtHeader, dueAt.UTC().Format(time.RFC3339Nano))
+	if _, err := q.js.PublishMsg(ctx, legacy); err != nil {
+		t.Fatalf("PublishMsg: %v", err)
+	}
+
+	received := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		delivery := receiveWithin(t, q, 5*time.Second)
+		if delivery == nil {
+			t.Fatalf("expected both messages once due, got %v", received)
+		}
+		if time.Now().Before(dueAt) {
+			t.Fatalf("%s delivered before it was due", delivery.Message.RequestID)
+		}
+		received[delivery.Message.RequestID] = true
+		if err := q.Ack(ctx, delivery); err != nil {
+			t.Fatalf("Ack: %v", err)
+		}
+	}
+	if !received["scheduled"] || !received["legacy"] {
+		t.Fatalf("unexpected deliveries: %v", received)
+	}
+}
+
+func TestNATSQueueNackStopsAtMaxDeliver(t *testing.T) {
+	t.Parallel()
+
+	q := newTestNATSQueue(t, 2)
+	ctx := context.Background()
+
+	if err := q.Publish(ctx, EmailMessage{RequestID: "req-1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	for attempt := 1; attempt <= 2; attempt++ {
+		delivery := receiveWithin(t, q, 3*time.Second)
+		if delivery == nil {
+			t.Fatalf("attempt %d: message was not delivered", attempt)
+		}
+		if delivery.Last() != (attempt == 2) {
+			t.Fatalf("attempt %d: expected Last() %v, got %v", attempt, attempt == 2, delivery.Last())
+		}
+		if err := q.Nack(ctx, delivery); err != nil {
+			t.Fatalf("attempt %d: Nack: %v", attempt, err)
+		}
+	}
+
+	if delivery, err := q.Receive(ctx); err != nil || delivery != nil {
+		t.Fatalf("expected no delivery after max deliver, got %+v, %v", delivery, err)
+	}
+}
+
+func TestNATSQueueFutureMessagesDoNotHoldBackDueOnes(t *testing.T) {
+	t.Parallel()
+
+	q := newTestNATSQueue(t, 5)
+	q.fetchWait = 10 * time.Millisecond
+	ctx := context.Background()
+
+	// More far-future messages than JetStream lets a consumer have pending by default.
+	farFuture := time.Now().Add(time.Hour)
+	for i := 0; i < 1000; i++ {
+		if err := q.Publish(ctx, EmailMessage{RequestID: fmt.Sprintf("future-%d", i), SendAt: farFuture}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	if err := q.Publish(ctx, EmailMessage{RequestID: "due", SendAt: time.Now().Add(300 * time.Millisecond)}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		// Promote on every call instead of once per natsPromoteInterval.
+		q.mu.Lock()
+		q.nextPromote = time.Time{}
+		q.mu.Unlock()
+
+		delivery, err := q.Receive(ctx)
+		if err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+		if delivery == nil {
+			continue
+		}
+		if delivery.Message.RequestID != "due" {
+			t.Fatalf("expected only the due message, got %s", delivery.Message.RequestID)
+		}
+		if err := q.Ack(ctx, delivery); err != nil {
+			t.Fatalf("Ack: %v", err)
+		}
+		return
+	}
+	t.Fatal("due message was held back by the future ones")
+}
+
+func TestNATSBackoff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		delivered uint64
+		want      time.Duration
+	}{
+		{delivered: 1, want: 10 * time.Second},
+		{delivered: 2, want: 20 * time.Second},
+		{delivered: 4, want: 80 * time.Second},
+		{delivered: 12, want: natsMaxRetryBackoff},
+	}
+	for _, tc := range tests {
+		if got := natsBackoff(natsRetryBackoff, tc.delivered); got != tc.want {
+			t.Fatalf("natsBackoff(%d): expected %v, got %v", tc.delivered, tc.want, got)
+		}
+	}
+}
+
+func TestNATSQueueReadsHigherLanesFirst(t *testing.T) {
+	t.Parallel()
+
+	q := newTestNATSQueue(t, 5)
+	ctx := context.Background()
+
+	for _, msg := range []EmailMessage{
+		{RequestID: "newsletter", Priority: "low"},
+		{RequestID: "otp", Priority: "high"},
+	} {
+		if err := q.Publish(ctx, msg); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	for _, want := range []string{"otp", "newsletter"} {
+		delivery := receiveWithin(t, q, 3*time.Second)
+		if delivery == nil || delivery.Message.RequestID != want || delivery.Lane() != delivery.Message.Priority {
+			t.Fatalf("expected %s, got %+v", want, delivery)
+		}
+		if err := q.Ack(ctx, delivery); err != nil {
+			t.Fatalf("Ack: %v", err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/lock"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+const (
+	// OutboxRelayLockKey is held by the process that relays the outbox.
+	OutboxRelayLockKey = "notifications:outbox:relay"
+
+	outboxBatchSize = 100
+)
+
+// OutboxRelay publishes outbox entries to the email queue in insertion order and deletes
+// each entry once published. Publishers that implement EmailBatchPublisher get each batch
+// of entries in one call. An entry whose deletion fails is published again on the next
+// pass, so delivery is at least once.
+type OutboxRelay struct {
+	outbox   *repository.EmailOutboxRepository
+	producer EmailPublisher
+	locker   lock.Locker
+	interval time.Duration
+}
+
+// NewOutboxRelay constructs a relay that polls the outbox every interval.
+func NewOutboxRelay(outbox *repository.EmailOutboxRepository, producer EmailPublisher, locker lock.Locker, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:   outbox,
+		producer: producer,
+		locker:   locker,
+		interval: interval,
+	}
+}
+
+// Run relays the outbox every interval until the context is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := r.Relay(ctx); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Warn("Outbox relay failed")
+		}
+	}
+}
+
+// Relay publishes pending outbox entries until the outbox is drained or half of the lock TTL
+// has passed, and returns how many were published. Stopping early keeps a long backlog from
+// outliving the lock, which another process could then take while this one still publishes;
+// the next pass re-acquires the lock and carries on. It does nothing while another process
+// holds the relay lock.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	lockTTL := 5 * r.interval
+	drainUntil := time.Now().Add(lockTTL / 2)
+	if err := r.locker.Acquire(ctx, OutboxRelayLockKey, lockTTL); err != nil {
+		if errors.Is(err, lock.ErrNotAcquired) || errors.Is(err, lock.ErrAlreadyHeld) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("acquire outbox lock: %w", err)
+	}
+	defer func() {
+		_ = r.locker.Release(context.Background(), OutboxRelayLockKey)
+	}()
+
+	published := 0
+	for {
+		entries, err := r.outbox.ListPending(ctx, outboxBatchSize)
+		if err != nil {
+			return published, fmt.Errorf("list outbox: %w", err)
+		}
+		n, err := r.publish(ctx, entries)
+		published += n
+		if err != nil {
+			return published, err
+		}
+		if len(entries) < outboxBatchSize || !time.Now().Before(drainUntil) {
+			return published, nil
+		}
+	}
+}
+
+// publish publishes entries and deletes them from the outbox, returning how many were published.
+func (r *OutboxRelay) publish(ctx context.Context, entries []entity.EmailOutboxEntry) (int, error) {
+	if batch, ok := r.producer.(EmailBatchPublisher); ok && len(entries) > 0 {
+		msgs := make([]EmailMessage, 0, len(entries))
+		ids := make([]uint64, 0, len(entries))
+		for _, entry := range entries {
+			msgs = append(msgs, outboxMessage(entry))
+			ids = append(ids, entry.ID)
+		}
+		if err := batch.PublishBatch(ctx, msgs); err != nil {
+			return 0, fmt.Errorf("publish %d outbox entries: %w", len(entries), err)
+		}
+		if err := r.outbox.DeleteMany(ctx, ids); err != nil {
+			return 0, fmt.Errorf("delete %d outbox entries: %w", len(ids), err)
+		}
+		return len(entries), nil
+	}
+
+	published := 0
+	for _, entry := range entries {
+		// Entries are published in order; a failure stops the pass so later
+		// entries do not overtake it.
+		if err := r.producer.Publish(ctx, outboxMessage(entry)); err != nil {
+			return published, fmt.Errorf("publish %s: %w", entry.Email.RequestID, err)
+		}
+		if err := r.outbox.Delete(ctx, entry.ID); err != nil {
+			return published, fmt.Errorf("delete outbox entry %d: %w", entry.ID, err)
+		}
+		published++
+	}
+	return published, nil
+}
+
+// outboxMessage builds the queue message of an outbox entry.
+func outboxMessage(entry entity.EmailOutboxEntry) EmailMessage {
+	return EmailMessage{
+		RequestID: entry.Email.RequestID,
+		Recipient: entry.Email.Recipient,
+		UserID:    entry.Email.UserID,
+		Category:  entry.Email.Category,
+		Priority:  entry.Email.Priority,
+		Timezone:  entry.Timezone,
+		Subject:   entry.Email.Subject,
+		Content:   entry.Email.Content,
+		BatchID:   entry.Email.BatchID,
+		Tenant:    entry.Email.Tenant,
+		Template:  entry.Email.Template,
+		SendAt:    entry.Email.SendAt,
+	}
+}
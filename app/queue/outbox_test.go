@@ -0,0 +1,205 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/lock"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+type stubLocker struct {
+	err error
+}
+
+func (l stubLocker) Acquire(_ context.Context, _ string, _ time.Duration) error { return l.err }
+func (l stubLocker) Release(_ context.Context, _ string) error                  { return nil }
+
+type mockPublisher struct {
+	err      error
+	messages []EmailMessage
+}
+
+func (p *mockPublisher) Publish(_ context.Context, msg EmailMessage) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+type mockBatchPublisher struct {
+	mockPublisher
+	batches [][]EmailMessage
+}
+
+func (p *mockBatchPublisher) PublishBatch(_ context.Context, msgs []EmailMessage) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.batches = append(p.batches, msgs)
+	return nil
+}
+
+var outboxColumns = []string{
+	"id", "timezone", "request_id", "user_id", "recipient", "category", "priority", "subject", "content", "batch_id", "tenant", "template", "send_at",
+}
+
+func TestOutboxRelayPublishesAndDeletes(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	sendAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	mock.ExpectQuery("FROM email_outbox o").
+		WithArgs(outboxBatchSize).
+		WillReturnRows(sqlmock.NewRows(outboxColumns).
+			AddRow(uint64(1), "Europe/Bucharest", "req-1", uint64(7), "", "marketing", entity.PriorityLow, "subj", "content", "", "", "", nil).
+			AddRow(uint64(2), "", "req-2", uint64(0), "a@b.com", "", entity.PriorityNormal, "subj", "content", "batch-1", "acme", "welcome", sendAt))
+	mock.ExpectExec("DELETE FROM email_outbox").WithArgs(uint64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM email_outbox").WithArgs(uint64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	pub := &mockPublisher{}
+	relay := NewOutboxRelay(repository.NewEmailOutboxRepository(db), pub, stubLocker{}, time.Second)
+
+	published, err := relay.Relay(context.Background())
+	if err != nil {
+		t.Fatalf("Relay: %v", err)
+	}
+	if published != 2 || len(pub.messages) != 2 {
+		t.Fatalf("expected 2 published messages, got %d (%d)", published, len(pub.messages))
+	}
+	if pub.messages[0].RequestID != "req-1" || pub.messages[0].Timezone != "Europe/Bucharest" || pub.messages[0].UserID != 7 {
+		t.Fatalf("unexpected first message: %+v", pub.messages[0])
+	}
+	if !pub.messages[1].SendAt.Equal(sendAt) || pub.messages[1].BatchID != "batch-1" || pub.messages[1].Tenant != "acme" || pub.messages[1].Template != "welcome" {
+		t.Fatalf("expected second message scheduled at %s, got %+v", sendAt, pub.messages[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestOutboxRelayPublishesBatches(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM email_outbox o").
+		WithArgs(outboxBatchSize).
+		WillReturnRows(sqlmock.NewRows(outboxColumns).
+			AddRow(uint64(1), "", "req-1", uint64(7), "", "", entity.PriorityNormal, "subj", "content", "", "", "", nil).
+			AddRow(uint64(2), "", "req-2", uint64(0), "a@b.com", "", entity.PriorityHigh, "subj", "content", "", "", "", nil))
+	mock.ExpectExec(`DELETE FROM email_outbox\s+WHERE id IN \(\?, \?\)`).WithArgs(uint64(1), uint64(2)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	pub := &mockBatchPublisher{}
+	relay := NewOutboxRelay(repository.NewEmailOutboxRepository(db), pub, stubLocker{}, time.Second)
+
+	published, err := relay.Relay(context.Background())
+	if err != nil {
+		t.Fatalf("Relay: %v", err)
+	}
+	if published != 2 || len(pub.batches) != 1 || len(pub.batches[0]) != 2 || len(pub.messages) != 0 {
+		t.Fatalf("expected one batch of 2 messages, got %d published, %v", published, pub.batches)
+	}
+	if pub.batches[0][1].RequestID != "req-2" || pub.batches[0][1].Priority != entity.PriorityHigh {
+		t.Fatalf("unexpected second message: %+v", pub.batches[0][1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestOutboxRelayStopsDrainingBeforeLockExpires(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows(outboxColumns)
+	for i := 1; i <= outboxBatchSize; i++ {
+		rows.AddRow(uint64(i), "", "req", uint64(7), "", "", entity.PriorityNormal, "subj", "content", "", "", "", nil)
+	}
+	mock.ExpectQuery("FROM email_outbox o").WithArgs(outboxBatchSize).WillReturnRows(rows)
+	mock.ExpectExec("DELETE FROM email_outbox").WillReturnResult(sqlmock.NewResult(0, outboxBatchSize))
+
+	// The lock of a relay polling every nanosecond expires at once, so it publishes one batch
+	// and leaves the rest of the full outbox to the next pass.
+	pub := &mockBatchPublisher{}
+	relay := NewOutboxRelay(repository.NewEmailOutboxRepository(db), pub, stubLocker{}, time.Nanosecond)
+
+	published, err := relay.Relay(context.Background())
+	if err != nil {
+		t.Fatalf("Relay: %v", err)
+	}
+	if published != outboxBatchSize || len(pub.batches) != 1 {
+		t.Fatalf("expected one batch of %d messages, got %d published in %d batches", outboxBatchSize, published, len(pub.batches))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestOutboxRelayKeepsEntryWhenPublishFails(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM email_outbox o").
+		WithArgs(outboxBatchSize).
+		WillReturnRows(sqlmock.NewRows(outboxColumns).
+			AddRow(uint64(1), "", "req-1", uint64(7), "", "", entity.PriorityNormal, "subj", "content", "", "", "", nil))
+
+	relay := NewOutboxRelay(repository.NewEmailOutboxRepository(db), &mockPublisher{err: errors.New("redis down")}, stubLocker{}, time.Second)
+
+	if _, err := relay.Relay(context.Background()); err == nil {
+		t.Fatal("expected publish error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestOutboxRelaySkipsWithoutLock(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	relay := NewOutboxRelay(repository.NewEmailOutboxRepository(db), &mockPublisher{}, stubLocker{err: lock.ErrNotAcquired}, time.Second)
+
+	published, err := relay.Relay(context.Background())
+	if err != nil || published != 0 {
+		t.Fatalf("expected no-op, got %d, %v", published, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
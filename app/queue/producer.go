@@ -3,32 +3,62 @@ package queue
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type EmailProducer struct {
-	client *redis.Client
+	client  *redis.Client
+	delayed *DelayQueue
 }
 
 // NewEmailProducer constructs a Redis stream producer.
 func NewEmailProducer(client *redis.Client) *EmailProducer {
-	return &EmailProducer{client: client}
+	return &EmailProducer{client: client, delayed: NewDelayQueue(client)}
 }
 
-// Publish pushes an email message onto the stream.
+// Publish pushes an email message onto its priority lane's stream, or into the delay queue when it
+// is scheduled for later; consumers move it to the stream once due.
 func (p *EmailProducer) Publish(ctx context.Context, msg EmailMessage) error {
+	if msg.SendAt.After(time.Now()) {
+		return p.delayed.Schedule(ctx, msg, msg.SendAt)
+	}
+
+	stream := StreamFor(laneFor(msg.Priority))
 	_, err := p.client.XAdd(ctx, &redis.XAddArgs{
-		Stream: StreamName,
-		Values: map[string]interface{}{
-			"request_id": msg.RequestID,
-			"recipient":  msg.Recipient,
-			"subject":    msg.Subject,
-			"content":    msg.Content,
-		},
+		Stream: stream,
+		Values: msg.fields(),
 	}).Result()
 	if err != nil {
-		return fmt.Errorf("xadd to %s: %w", StreamName, err)
+		return fmt.Errorf("xadd to %s: %w", stream, err)
+	}
+	return nil
+}
+
+// PublishBatch pushes several messages like Publish, sending all XADDs and delay-queue
+// ZADDs in one pipeline.
+func (p *EmailProducer) PublishBatch(ctx context.Context, msgs []EmailMessage) error {
+	now := time.Now()
+	_, err := p.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, msg := range msgs {
+			if msg.SendAt.After(now) {
+				member, err := delayedMember(msg, msg.SendAt)
+				if err != nil {
+					return err
+				}
+				pipe.ZAdd(ctx, DelayedSetName, member)
+				continue
+			}
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: StreamFor(laneFor(msg.Priority)),
+				Values: msg.fields(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("publish %d messages: %w", len(msgs), err)
 	}
 	return nil
 }
@@ -3,6 +3,7 @@ package queue
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
@@ -34,3 +35,69 @@ func TestEmailProducerPublish(t *testing.T) {
 		t.Fatalf("expected 1 message, got %d", got)
 	}
 }
+
+func TestEmailProducerPublishScheduled(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	producer := NewEmailProducer(client)
+	if err := producer.Publish(ctx, EmailMessage{
+		RequestID: "req-1",
+		Recipient: "a@b.com",
+		Subject:   "subj",
+		Content:   "content",
+		SendAt:    time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if got := client.XLen(ctx, StreamName).Val(); got != 0 {
+		t.Fatalf("expected no stream messages, got %d", got)
+	}
+	if got := client.ZCard(ctx, DelayedSetName).Val(); got != 1 {
+		t.Fatalf("expected 1 scheduled message, got %d", got)
+	}
+}
+
+func TestEmailProducerPublishBatch(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	producer := NewEmailProducer(client)
+	if err := producer.PublishBatch(ctx, []EmailMessage{
+		{RequestID: "req-1", Recipient: "a@b.com", Subject: "subj", Content: "content"},
+		{RequestID: "req-2", Recipient: "a@b.com", Priority: "high", Subject: "subj", Content: "content"},
+		{RequestID: "req-3", Recipient: "a@b.com", Subject: "subj", Content: "content", SendAt: time.Now().Add(time.Hour)},
+		{RequestID: "req-4", Recipient: "a@b.com", Subject: "subj", Content: "content"},
+	}); err != nil {
+		t.Fatalf("PublishBatch: %v", err)
+	}
+
+	if got := client.XLen(ctx, StreamName).Val(); got != 2 {
+		t.Fatalf("expected 2 normal lane messages, got %d", got)
+	}
+	if got := client.XLen(ctx, StreamFor("high")).Val(); got != 1 {
+		t.Fatalf("expected 1 high lane message, got %d", got)
+	}
+	if got := client.ZCard(ctx, DelayedSetName).Val(); got != 1 {
+		t.Fatalf("expected 1 scheduled message, got %d", got)
+	}
+}
@@ -0,0 +1,178 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+// RedisReceiver reads the lane streams as one consumer of the consumer group. Deferred
+// messages go to the delay queue, which every receiver moves back to the streams once due.
+type RedisReceiver struct {
+	client       *redis.Client
+	delayed      *DelayQueue
+	scheduler    *LaneScheduler
+	consumerName string
+	// startIDs holds "0" for lanes whose pending entries are still being drained, else ">".
+	startIDs map[string]string
+	// buffered holds deliveries read together with the one returned by the last Receive.
+	buffered []*Delivery
+}
+
+// NewRedisReceiver constructs a Redis stream receiver for the named consumer that reads the
+// lanes in the order the scheduler picks.
+func NewRedisReceiver(client *redis.Client, consumerName string, scheduler *LaneScheduler) *RedisReceiver {
+	startIDs := make(map[string]string, len(lanes))
+	for _, lane := range lanes {
+		startIDs[lane] = "0"
+	}
+	return &RedisReceiver{
+		client:       client,
+		delayed:      NewDelayQueue(client),
+		scheduler:    scheduler,
+		consumerName: consumerName,
+		startIDs:     startIDs,
+	}
+}
+
+// Open creates the lane streams and consumer groups if missing and starts the delay queue mover.
+func (r *RedisReceiver) Open(ctx context.Context) error {
+	if err := r.ensureGroups(ctx); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"consumer": r.consumerName,
+		"stream":   StreamName,
+	}).Info("Redis receiver opened")
+
+	// Every receiver runs the mover; the move is atomic, so replicas do not duplicate messages.
+	go r.delayed.Run(ctx, time.Second)
+	return nil
+}
+
+// ensureGroups creates every lane stream and its consumer group if missing.
+func (r *RedisReceiver) ensureGroups(ctx context.Context) error {
+	for _, lane := range lanes {
+		err := r.client.XGroupCreateMkStream(ctx, StreamFor(lane), ConsumerGroup, "0").Err()
+		if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
+			return err
+		}
+	}
+	return nil
+}
+
+// Receive reads the lanes without blocking in scheduler order, first draining messages left
+// pending for this consumer. When every lane is empty it blocks on all of them at once.
+func (r *RedisReceiver) Receive(ctx context.Context) (*Delivery, error) {
+	if len(r.buffered) > 0 {
+		delivery := r.buffered[0]
+		r.buffered = r.buffered[1:]
+		return delivery, nil
+	}
+
+	for _, lane := range r.scheduler.Order() {
+		delivery, err := r.readLane(ctx, lane)
+		if err != nil || delivery != nil {
+			return delivery, err
+		}
+	}
+
+	streams := make([]string, 0, 2*len(lanes))
+	for _, lane := range lanes {
+		streams = append(streams, StreamFor(lane))
+	}
+	for range lanes {
+		streams = append(streams, ">")
+	}
+	result, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    ConsumerGroup,
+		Consumer: r.consumerName,
+		Streams:  streams,
+		Count:    1,
+		Block:    5 * time.Second,
+	}).Result()
+	if err == redis.Nil {
+		// No messages available within block timeout.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("xreadgroup on %s: %w", StreamName, err)
+	}
+
+	// Streams come back in request order, which is priority order.
+	for _, stream := range result {
+		for _, msg := range stream.Messages {
+			r.buffered = append(r.buffered, &Delivery{ID: msg.ID, Message: emailMessageFromValues(msg.Values), lane: laneOfStream(stream.Stream)})
+		}
+	}
+	if len(r.buffered) == 0 {
+		return nil, nil
+	}
+	delivery := r.buffered[0]
+	r.buffered = r.buffered[1:]
+	return delivery, nil
+}
+
+// readLane reads one message from the lane without blocking, or nil when it has none.
+func (r *RedisReceiver) readLane(ctx context.Context, lane string) (*Delivery, error) {
+	stream := StreamFor(lane)
+	for {
+		result, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    ConsumerGroup,
+			Consumer: r.consumerName,
+			Streams:  []string{stream, r.startIDs[lane]},
+			Count:    1,
+			Block:    -1,
+		}).Result()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("xreadgroup on %s: %w", stream, err)
+		}
+		for _, s := range result {
+			for _, msg := range s.Messages {
+				return &Delivery{ID: msg.ID, Message: emailMessageFromValues(msg.Values), lane: lane}, nil
+			}
+		}
+		if r.startIDs[lane] != "0" {
+			return nil, nil
+		}
+		// No more pending messages, switch to reading new.
+		r.startIDs[lane] = ">"
+	}
+}
+
+// laneOfStream returns the lane whose stream is named stream.
+func laneOfStream(stream string) string {
+	for _, lane := range lanes {
+		if StreamFor(lane) == stream {
+			return lane
+		}
+	}
+	return entity.PriorityNormal
+}
+
+// Ack acknowledges the stream entry.
+func (r *RedisReceiver) Ack(ctx context.Context, delivery *Delivery) error {
+	stream := StreamFor(delivery.lane)
+	if err := r.client.XAck(ctx, stream, ConsumerGroup, delivery.ID).Err(); err != nil {
+		return fmt.Errorf("xack on %s: %w", stream, err)
+	}
+	return nil
+}
+
+// Defer stores the message in the delay queue and acknowledges the stream entry.
+func (r *RedisReceiver) Defer(ctx context.Context, delivery *Delivery, until time.Time) error {
+	if err := r.delayed.Schedule(ctx, delivery.Message, until); err != nil {
+		return err
+	}
+	return r.Ack(ctx, delivery)
+}
+
+// Nack leaves the stream entry pending; it is delivered again when this consumer restarts.
+func (r *RedisReceiver) Nack(_ context.Context, _ *Delivery) error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisReceiverReadsHigherLanesFirst(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	receiver := NewRedisReceiver(client, "c1", nil)
+	if err := receiver.ensureGroups(ctx); err != nil {
+		t.Fatalf("ensureGroups: %v", err)
+	}
+
+	producer := NewEmailProducer(client)
+	for _, msg := range []EmailMessage{
+		{RequestID: "newsletter", Priority: "low"},
+		{RequestID: "receipt", Priority: "normal"},
+		{RequestID: "otp", Priority: "high"},
+	} {
+		if err := producer.Publish(ctx, msg); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	for _, want := range []string{"otp", "receipt", "newsletter"} {
+		delivery, err := receiver.Receive(ctx)
+		if err != nil || delivery == nil {
+			t.Fatalf("Receive: %+v, %v", delivery, err)
+		}
+		if delivery.Message.RequestID != want || delivery.Lane() != delivery.Message.Priority {
+			t.Fatalf("expected %s, got %+v in lane %s", want, delivery.Message, delivery.Lane())
+		}
+		if err := receiver.Ack(ctx, delivery); err != nil {
+			t.Fatalf("Ack: %v", err)
+		}
+	}
+
+	for _, lane := range lanes {
+		pending, err := client.XPending(ctx, StreamFor(lane), ConsumerGroup).Result()
+		if err != nil {
+			t.Fatalf("XPending: %v", err)
+		}
+		if pending.Count != 0 {
+			t.Fatalf("expected 0 pending in %s, got %d", lane, pending.Count)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// reserveScript checks every window first and only records the member when all of them
+// have room, so a capped send never consumes a slot. A member that is already counted
+// in a window (a retried send) does not count again. It returns 0 when the member was
+// recorded, otherwise the millisecond timestamp at which the first slot frees up.
+var reserveScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local member = ARGV[2]
+local retry = 0
+for i, key in ipairs(KEYS) do
+	local max = tonumber(ARGV[1 + i * 2])
+	local window = tonumber(ARGV[2 + i * 2])
+	redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+	if not redis.call('ZSCORE', key, member) then
+		local count = redis.call('ZCARD', key)
+		if count >= max then
+			local oldest = redis.call('ZRANGE', key, count - max, count - max, 'WITHSCORES')
+			local at = tonumber(oldest[2]) + window
+			if at > retry then
+				retry = at
+			end
+		end
+	end
+end
+if retry > 0 then
+	return retry
+end
+for i, key in ipairs(KEYS) do
+	redis.call('ZADD', key, 'NX', now, member)
+	redis.call('PEXPIRE', key, tonumber(ARGV[2 + i * 2]))
+end
+return 0
+`)
+
+// Limit allows at most Max members under Key within any Window-long period.
+type Limit struct {
+	Key    string
+	Max    int
+	Window time.Duration
+}
+
+// SlidingWindow counts members in Redis sorted sets scored by the time they were recorded.
+type SlidingWindow struct {
+	client *redis.Client
+}
+
+// NewSlidingWindow constructs a Redis sliding-window counter.
+func NewSlidingWindow(client *redis.Client) *SlidingWindow {
+	return &SlidingWindow{client: client}
+}
+
+// Reserve records member at now in every limit when all of them have room. Otherwise it
+// records nothing and reports false with the time at which all limits have room again.
+func (w *SlidingWindow) Reserve(ctx context.Context, member string, limits []Limit, now time.Time) (bool, time.Time, error) {
+	if len(limits) == 0 {
+		return true, time.Time{}, nil
+	}
+	keys := make([]string, 0, len(limits))
+	args := []interface{}{strconv.FormatInt(now.UnixMilli(), 10), member}
+	for _, limit := range limits {
+		keys = append(keys, limit.Key)
+		args = append(args, limit.Max, limit.Window.Milliseconds())
+	}
+
+	retry, err := reserveScript.Run(ctx, w.client, keys, args...).Int64()
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("reserve sliding window: %w", err)
+	}
+	if retry == 0 {
+		return true, time.Time{}, nil
+	}
+	return false, time.UnixMilli(retry), nil
+}
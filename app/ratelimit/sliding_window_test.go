@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSlidingWindowReserve(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	w := NewSlidingWindow(client)
+	limits := []Limit{
+		{Key: "hour", Max: 2, Window: time.Hour},
+		{Key: "day", Max: 5, Window: 24 * time.Hour},
+	}
+	start := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	for i, member := range []string{"a", "b"} {
+		allowed, _, err := w.Reserve(ctx, member, limits, start.Add(time.Duration(i)*time.Minute))
+		if err != nil || !allowed {
+			t.Fatalf("Reserve(%s): allowed=%v err=%v", member, allowed, err)
+		}
+	}
+
+	// A member already counted passes again without using another slot.
+	if allowed, _, err := w.Reserve(ctx, "a", limits, start.Add(2*time.Minute)); err != nil || !allowed {
+		t.Fatalf("Reserve(a again): allowed=%v err=%v", allowed, err)
+	}
+
+	allowed, until, err := w.Reserve(ctx, "c", limits, start.Add(3*time.Minute))
+	if err != nil {
+		t.Fatalf("Reserve(c): %v", err)
+	}
+	if allowed {
+		t.Fatal("expected c to be capped")
+	}
+	if !until.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected retry at %s, got %s", start.Add(time.Hour), until)
+	}
+	if got := client.ZCard(ctx, "day").Val(); got != 2 {
+		t.Fatalf("expected capped member not to be counted, got %d in day window", got)
+	}
+
+	// Once the oldest entry leaves the window there is room again.
+	if allowed, _, err := w.Reserve(ctx, "c", limits, start.Add(time.Hour)); err != nil || !allowed {
+		t.Fatalf("Reserve(c later): allowed=%v err=%v", allowed, err)
+	}
+}
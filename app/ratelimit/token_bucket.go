@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills every bucket for the time passed since it was last updated and only
+// takes a token when all of them have one, so a send held back by one bucket does not
+// drain the others. A bucket that does not exist yet starts full. It returns 0 when the
+// tokens were taken, otherwise the millisecond timestamp at which every bucket has a
+// token again.
+var takeScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local tokens = {}
+local updated = {}
+local retry = 0
+for i, key in ipairs(KEYS) do
+	local capacity = tonumber(ARGV[i * 2])
+	local period = tonumber(ARGV[1 + i * 2])
+	local state = redis.call('HMGET', key, 'tokens', 'updated')
+	local available = tonumber(state[1]) or capacity
+	local at = tonumber(state[2]) or now
+	if now > at then
+		available = math.min(capacity, available + (now - at) * capacity / period)
+		at = now
+	end
+	tokens[i] = available
+	updated[i] = at
+	if available < 1 then
+		local ready = at + math.ceil((1 - available) * period / capacity)
+		if ready > retry then
+			retry = ready
+		end
+	end
+end
+if retry > 0 then
+	return retry
+end
+for i, key in ipairs(KEYS) do
+	redis.call('HSET', key, 'tokens', tostring(tokens[i] - 1), 'updated', tostring(updated[i]))
+	redis.call('PEXPIRE', key, tonumber(ARGV[1 + i * 2]))
+end
+return 0
+`)
+
+// Bucket holds at most Capacity tokens under Key and refills Capacity tokens every Period.
+type Bucket struct {
+	Key      string
+	Capacity int
+	Period   time.Duration
+}
+
+// TokenBucket keeps token buckets in Redis hashes, so every process taking from a bucket
+// shares its rate.
+type TokenBucket struct {
+	client *redis.Client
+}
+
+// NewTokenBucket constructs a Redis token bucket limiter.
+func NewTokenBucket(client *redis.Client) *TokenBucket {
+	return &TokenBucket{client: client}
+}
+
+// Take removes one token from every bucket at now when all of them have one. Otherwise it
+// takes nothing and reports false with the time at which all buckets have a token again.
+func (b *TokenBucket) Take(ctx context.Context, buckets []Bucket, now time.Time) (bool, time.Time, error) {
+	if len(buckets) == 0 {
+		return true, time.Time{}, nil
+	}
+	keys := make([]string, 0, len(buckets))
+	args := []interface{}{strconv.FormatInt(now.UnixMilli(), 10)}
+	for _, bucket := range buckets {
+		keys = append(keys, bucket.Key)
+		args = append(args, bucket.Capacity, bucket.Period.Milliseconds())
+	}
+
+	retry, err := takeScript.Run(ctx, b.client, keys, args...).Int64()
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("take token bucket: %w", err)
+	}
+	if retry == 0 {
+		return true, time.Time{}, nil
+	}
+	return false, time.UnixMilli(retry), nil
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestTokenBucketTake(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	b := NewTokenBucket(client)
+	buckets := []Bucket{
+		{Key: "provider", Capacity: 2, Period: time.Second},
+		{Key: "domain", Capacity: 10, Period: time.Second},
+	}
+	start := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if allowed, _, err := b.Take(ctx, buckets, start); err != nil || !allowed {
+			t.Fatalf("Take(%d): allowed=%v err=%v", i, allowed, err)
+		}
+	}
+
+	allowed, until, err := b.Take(ctx, buckets, start)
+	if err != nil {
+		t.Fatalf("Take(empty): %v", err)
+	}
+	if allowed {
+		t.Fatal("expected the provider bucket to be empty")
+	}
+	if !until.Equal(start.Add(500 * time.Millisecond)) {
+		t.Fatalf("expected a token at %s, got %s", start.Add(500*time.Millisecond), until)
+	}
+	if got := client.HGet(ctx, "domain", "tokens").Val(); got != "8" {
+		t.Fatalf("expected the refused take to leave the domain bucket at 8 tokens, got %s", got)
+	}
+
+	// Half a period refills one token of the provider bucket.
+	if allowed, _, err := b.Take(ctx, buckets, start.Add(500*time.Millisecond)); err != nil || !allowed {
+		t.Fatalf("Take(refilled): allowed=%v err=%v", allowed, err)
+	}
+	if allowed, _, err := b.Take(ctx, buckets, start.Add(500*time.Millisecond)); err != nil || allowed {
+		t.Fatalf("Take(empty again): allowed=%v err=%v", allowed, err)
+	}
+}
+
+func TestTokenBucketTakeWithoutBuckets(t *testing.T) {
+	t.Parallel()
+
+	allowed, _, err := NewTokenBucket(nil).Take(context.Background(), nil, time.Now())
+	if err != nil || !allowed {
+		t.Fatalf("expected no buckets to allow, got allowed=%v err=%v", allowed, err)
+	}
+}
@@ -0,0 +1,188 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+const ChannelName = "notifications:inapp:events"
+
+// subscriberBuffer bounds how many events may queue up for a slow subscriber.
+const subscriberBuffer = 16
+
+type event struct {
+	ID        uint64    `json:"id"`
+	RequestID string    `json:"request_id"`
+	UserID    uint64    `json:"user_id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Hub fans out in-app notifications to local subscribers through Redis pub/sub,
+// so every replica receives events regardless of where they were created. Without a
+// Redis client events only reach the subscribers of this process.
+type Hub struct {
+	client      *redis.Client
+	mu          sync.Mutex
+	closed      bool
+	subscribers map[uint64]map[chan entity.InAppNotification]struct{}
+}
+
+// NewHub constructs a Redis pub/sub backed hub; a nil client builds an in-process hub.
+func NewHub(client *redis.Client) *Hub {
+	return &Hub{
+		client:      client,
+		subscribers: make(map[uint64]map[chan entity.InAppNotification]struct{}),
+	}
+}
+
+// Publish broadcasts a notification to all replicas.
+func (h *Hub) Publish(ctx context.Context, notification entity.InAppNotification) error {
+	if h.client == nil {
+		h.dispatch(notification)
+		return nil
+	}
+	payload, err := json.Marshal(event{
+		ID:        notification.ID,
+		RequestID: notification.RequestID,
+		UserID:    notification.UserID,
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Body:      notification.Body,
+		CreatedAt: notification.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if err := h.client.Publish(ctx, ChannelName, payload).Err(); err != nil {
+		return fmt.Errorf("publish to %s: %w", ChannelName, err)
+	}
+	return nil
+}
+
+// Subscribe registers a local subscriber for a user and returns its event channel
+// together with a function that unregisters it.
+func (h *Hub) Subscribe(userID uint64) (<-chan entity.InAppNotification, func()) {
+	ch := make(chan entity.InAppNotification, subscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan entity.InAppNotification]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[userID][ch]; !ok {
+			return
+		}
+		delete(h.subscribers[userID], ch)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+		close(ch)
+	}
+}
+
+// Run listens on the Redis channel and dispatches events until the context is cancelled.
+// All subscriber channels are closed when Run returns.
+func (h *Hub) Run(ctx context.Context) error {
+	if h.client == nil {
+		defer h.closeAll()
+		logrus.Info("Realtime hub started without Redis; events reach this process only")
+		<-ctx.Done()
+		return nil
+	}
+
+	pubsub := h.client.Subscribe(ctx, ChannelName)
+	defer pubsub.Close()
+	defer h.closeAll()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("subscribe to %s: %w", ChannelName, err)
+	}
+
+	logrus.WithField("channel", ChannelName).Info("Realtime hub started")
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Realtime hub shutting down")
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var evt event
+			if err := json.Unmarshal([]byte(msg.Payload), &evt); err != nil {
+				logrus.WithError(err).Warn("Failed to decode realtime event")
+				continue
+			}
+			h.dispatch(entity.InAppNotification{
+				ID:        evt.ID,
+				RequestID: evt.RequestID,
+				UserID:    evt.UserID,
+				Type:      evt.Type,
+				Title:     evt.Title,
+				Body:      evt.Body,
+				CreatedAt: evt.CreatedAt,
+			})
+		}
+	}
+}
+
+// dispatch delivers a notification to the user's local subscribers without blocking. A
+// subscriber whose buffer is full is closed and removed instead of losing the event, so
+// its stream ends and the client reconnects and replays what it missed.
+func (h *Hub) dispatch(notification entity.InAppNotification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subs := h.subscribers[notification.UserID]
+	for ch := range subs {
+		select {
+		case ch <- notification:
+		default:
+			logrus.WithFields(logrus.Fields{
+				"user_id":         notification.UserID,
+				"notification_id": notification.ID,
+			}).Warn("Realtime subscriber is too slow; closing its stream")
+			delete(subs, ch)
+			close(ch)
+		}
+	}
+	if len(subs) == 0 {
+		delete(h.subscribers, notification.UserID)
+	}
+}
+
+// closeAll closes every subscriber channel and rejects new subscriptions.
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for userID, subs := range h.subscribers {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(h.subscribers, userID)
+	}
+}
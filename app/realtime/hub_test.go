@@ -0,0 +1,152 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+func TestHubPublishDeliversToSubscriber(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	hub := NewHub(client)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = hub.Run(ctx)
+		close(done)
+	}()
+
+	events, unsubscribe := hub.Subscribe(7)
+	defer unsubscribe()
+	other, unsubscribeOther := hub.Subscribe(8)
+	defer unsubscribeOther()
+
+	// Wait until the hub's Redis subscription is active before publishing.
+	deadline := time.Now().Add(2 * time.Second)
+	for mr.PubSubNumSub(ChannelName)[ChannelName] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("hub did not subscribe in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := hub.Publish(context.Background(), entity.InAppNotification{ID: 1, UserID: 7, Type: "comment", Title: "t", Body: "b"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	select {
+	case n := <-events:
+		if n.ID != 1 || n.Title != "t" {
+			t.Fatalf("unexpected notification: %+v", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting for notification")
+	}
+
+	select {
+	case n := <-other:
+		t.Fatalf("unexpected notification for other user: %+v", n)
+	default:
+	}
+
+	cancel()
+	<-done
+
+	if _, ok := <-events; ok {
+		t.Fatalf("expected subscriber channel to be closed after shutdown")
+	}
+}
+
+func TestHubUnsubscribeClosesChannel(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(nil)
+	events, unsubscribe := hub.Subscribe(7)
+	unsubscribe()
+	unsubscribe()
+
+	if _, ok := <-events; ok {
+		t.Fatalf("expected closed channel")
+	}
+}
+
+func TestHubWithoutRedisDeliversLocally(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = hub.Run(ctx)
+		close(done)
+	}()
+
+	events, unsubscribe := hub.Subscribe(7)
+	defer unsubscribe()
+
+	if err := hub.Publish(context.Background(), entity.InAppNotification{ID: 1, UserID: 7, Title: "t"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	select {
+	case n := <-events:
+		if n.ID != 1 {
+			t.Fatalf("unexpected notification: %+v", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting for notification")
+	}
+
+	cancel()
+	<-done
+	if _, ok := <-events; ok {
+		t.Fatalf("expected subscriber channel to be closed after shutdown")
+	}
+}
+
+func TestHubClosesSlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(nil)
+	slow, unsubscribeSlow := hub.Subscribe(7)
+	fast, unsubscribeFast := hub.Subscribe(7)
+	defer unsubscribeFast()
+
+	for id := uint64(1); id <= subscriberBuffer+1; id++ {
+		if err := hub.Publish(context.Background(), entity.InAppNotification{ID: id, UserID: 7}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		if n := <-fast; n.ID != id {
+			t.Fatalf("expected notification %d, got %+v", id, n)
+		}
+	}
+
+	received := 0
+	for range slow {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Fatalf("expected %d buffered notifications before the close, got %d", subscriberBuffer, received)
+	}
+	unsubscribeSlow()
+
+	if err := hub.Publish(context.Background(), entity.InAppNotification{ID: 100, UserID: 7}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if n := <-fast; n.ID != 100 {
+		t.Fatalf("expected the other subscriber to stay open, got %+v", n)
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+type EmailAttemptRepository struct {
+	db *sql.DB
+}
+
+// NewEmailAttemptRepository constructs a repository backed by MySQL.
+func NewEmailAttemptRepository(db *sql.DB) *EmailAttemptRepository {
+	return &EmailAttemptRepository{db: db}
+}
+
+// Create records a finished send attempt.
+func (r *EmailAttemptRepository) Create(ctx context.Context, attempt entity.EmailAttempt) error {
+	const query = `
+		INSERT INTO email_attempts (request_id, worker, provider, started_at, finished_at, error_class, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		attempt.RequestID,
+		attempt.Worker,
+		attempt.Provider,
+		attempt.StartedAt.UTC(),
+		attempt.FinishedAt.UTC(),
+		attempt.ErrorClass,
+		attempt.Error,
+	)
+	return err
+}
+
+// ListByRequestID returns the send attempts of a request, oldest first.
+func (r *EmailAttemptRepository) ListByRequestID(ctx context.Context, requestID string) ([]entity.EmailAttempt, error) {
+	const query = `
+		SELECT id, request_id, worker, provider, started_at, finished_at, error_class, error
+		FROM email_attempts
+		WHERE request_id = ?
+		ORDER BY id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []entity.EmailAttempt
+	for rows.Next() {
+		var attempt entity.EmailAttempt
+		if err := rows.Scan(
+			&attempt.ID,
+			&attempt.RequestID,
+			&attempt.Worker,
+			&attempt.Provider,
+			&attempt.StartedAt,
+			&attempt.FinishedAt,
+			&attempt.ErrorClass,
+			&attempt.Error,
+		); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+var emailAttemptColumns = []string{"id", "request_id", "worker", "provider", "started_at", "finished_at", "error_class", "error"}
+
+func TestEmailAttemptRepositoryCreateAndList(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailAttemptRepository(db)
+	startedAt := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	finishedAt := startedAt.Add(250 * time.Millisecond)
+
+	mock.ExpectExec("INSERT INTO email_attempts").
+		WithArgs("req-1", "worker-1", "ses", startedAt, finishedAt, "throttled", "ses: TooManyRequestsException").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	err = repo.Create(context.Background(), entity.EmailAttempt{
+		RequestID:  "req-1",
+		Worker:     "worker-1",
+		Provider:   "ses",
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		ErrorClass: "throttled",
+		Error:      "ses: TooManyRequestsException",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	mock.ExpectQuery("FROM email_attempts").
+		WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows(emailAttemptColumns).
+			AddRow(uint64(1), "req-1", "worker-1", "ses", startedAt, finishedAt, "throttled", "ses: TooManyRequestsException").
+			AddRow(uint64(2), "req-1", "worker-2", "ses", startedAt.Add(time.Minute), finishedAt.Add(time.Minute), "", ""))
+
+	attempts, err := repo.ListByRequestID(context.Background(), "req-1")
+	if err != nil {
+		t.Fatalf("ListByRequestID: %v", err)
+	}
+	if len(attempts) != 2 || attempts[0].ErrorClass != "throttled" || attempts[1].Worker != "worker-2" || attempts[1].Error != "" {
+		t.Fatalf("unexpected attempts: %+v", attempts)
+	}
+	if !attempts[0].FinishedAt.Equal(finishedAt) {
+		t.Fatalf("unexpected finished_at: %v", attempts[0].FinishedAt)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
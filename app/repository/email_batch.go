@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+type EmailBatchRepository struct {
+	db *sql.DB
+}
+
+// NewEmailBatchRepository constructs a repository backed by MySQL.
+func NewEmailBatchRepository(db *sql.DB) *EmailBatchRepository {
+	return &EmailBatchRepository{db: db}
+}
+
+// Create records a batch. Recording a batch that already exists does nothing, so an
+// interrupted bulk send can be resumed under the same batch ID.
+func (r *EmailBatchRepository) Create(ctx context.Context, batchID string) error {
+	const query = `
+		INSERT INTO email_batches (batch_id)
+		VALUES (?)
+		ON DUPLICATE KEY UPDATE batch_id = batch_id
+	`
+	_, err := r.db.ExecContext(ctx, query, batchID)
+	return err
+}
+
+// FindByBatchID returns a batch with its emails counted by status; it returns
+// sql.ErrNoRows when missing.
+func (r *EmailBatchRepository) FindByBatchID(ctx context.Context, batchID string) (*entity.EmailBatch, error) {
+	const batchQuery = `
+		SELECT id, batch_id, status, created_at
+		FROM email_batches
+		WHERE batch_id = ?
+	`
+	var batch entity.EmailBatch
+	if err := r.db.QueryRowContext(ctx, batchQuery, batchID).Scan(&batch.ID, &batch.BatchID, &batch.Status, &batch.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	const countQuery = `
+		SELECT status, COUNT(*)
+		FROM email_history
+		WHERE batch_id = ?
+		GROUP BY status
+	`
+	rows, err := r.db.QueryContext(ctx, countQuery, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status int16
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		batch.Count(status, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// FindStatus returns the status of a batch; it returns sql.ErrNoRows when missing.
+func (r *EmailBatchRepository) FindStatus(ctx context.Context, batchID string) (string, error) {
+	const query = `
+		SELECT status
+		FROM email_batches
+		WHERE batch_id = ?
+	`
+	var status string
+	if err := r.db.QueryRowContext(ctx, query, batchID).Scan(&status); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// UpdateStatus moves a batch from the status from to status; a batch in another status is
+// left unchanged.
+func (r *EmailBatchRepository) UpdateStatus(ctx context.Context, batchID string, from string, status string) error {
+	const query = `
+		UPDATE email_batches
+		SET status = ?
+		WHERE batch_id = ? AND status = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, status, batchID, from)
+	return err
+}
+
+// Cancel marks a batch as cancelled together with all of its emails that have not started
+// sending yet, in one transaction, and returns how many emails it cancelled. It returns
+// sql.ErrNoRows when the batch is missing.
+func (r *EmailBatchRepository) Cancel(ctx context.Context, batchID string) (int64, error) {
+	const lockQuery = `
+		SELECT id
+		FROM email_batches
+		WHERE batch_id = ?
+		FOR UPDATE
+	`
+	const batchQuery = `
+		UPDATE email_batches
+		SET status = ?
+		WHERE batch_id = ?
+	`
+	historyQuery := `
+		UPDATE email_history
+		SET status = ?
+		WHERE batch_id = ? AND status IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(cancellableStatuses)), ", ") + `)
+	`
+	args := []any{entity.EmailStatusCancelled, batchID}
+	for _, status := range cancellableStatuses {
+		args = append(args, status)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var id uint64
+	if err := tx.QueryRowContext(ctx, lockQuery, batchID).Scan(&id); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, batchQuery, entity.EmailBatchStatusCancelled, batchID); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, historyQuery, args...)
+	if err != nil {
+		return 0, err
+	}
+	cancelled, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return cancelled, nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+func TestEmailBatchRepositoryCreateAndFind(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailBatchRepository(db)
+
+	mock.ExpectExec("INSERT INTO email_batches").WithArgs("batch-1").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := repo.Create(context.Background(), "batch-1"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	createdAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM email_batches").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "batch_id", "status", "created_at"}).AddRow(uint64(1), "batch-1", entity.EmailBatchStatusPaused, createdAt))
+	mock.ExpectQuery("FROM email_history").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+			AddRow(entity.EmailStatusNew, 3).
+			AddRow(entity.EmailStatusPaused, 2).
+			AddRow(entity.EmailStatusCapDeferred, 1).
+			AddRow(entity.EmailStatusSuccess, 5).
+			AddRow(entity.EmailStatusSkippedByPreference, 2).
+			AddRow(entity.EmailStatusCancelled, 1).
+			AddRow(entity.EmailStatusPermanentFailure, 1))
+
+	batch, err := repo.FindByBatchID(context.Background(), "batch-1")
+	if err != nil {
+		t.Fatalf("FindByBatchID: %v", err)
+	}
+	if batch.Status != entity.EmailBatchStatusPaused {
+		t.Fatalf("unexpected status: %q", batch.Status)
+	}
+	if batch.Total != 15 || batch.Queued != 6 || batch.Sent != 5 || batch.Suppressed != 2 || batch.Cancelled != 1 || batch.Failed != 1 {
+		t.Fatalf("unexpected counts: %+v", batch)
+	}
+	if !batch.CreatedAt.Equal(createdAt) {
+		t.Fatalf("unexpected created_at: %v", batch.CreatedAt)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailBatchRepositoryFindMissing(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM email_batches").WithArgs("batch-1").WillReturnError(sql.ErrNoRows)
+
+	_, err = NewEmailBatchRepository(db).FindByBatchID(context.Background(), "batch-1")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestEmailBatchRepositoryCancel(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id").WithArgs("batch-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uint64(1)))
+	mock.ExpectExec("UPDATE email_batches").
+		WithArgs(entity.EmailBatchStatusCancelled, "batch-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(
+			entity.EmailStatusCancelled, "batch-1",
+			entity.EmailStatusNew, entity.EmailStatusDeferred, entity.EmailStatusScheduled,
+			entity.EmailStatusDigestPending, entity.EmailStatusCapDeferred, entity.EmailStatusPaused,
+		).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	cancelled, err := NewEmailBatchRepository(db).Cancel(context.Background(), "batch-1")
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if cancelled != 4 {
+		t.Fatalf("expected 4 cancelled emails, got %d", cancelled)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailBatchRepositoryCancelMissing(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id").WithArgs("batch-1").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = NewEmailBatchRepository(db).Cancel(context.Background(), "batch-1")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
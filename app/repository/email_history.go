@@ -3,8 +3,38 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
 )
 
+// ErrDigestChanged is returned when digest items changed while their digest was being built.
+var ErrDigestChanged = errors.New("digest items changed")
+
+// errBatchRaced is returned when another transaction inserted request IDs of a bulk chunk
+// after the chunk's owners were read.
+var errBatchRaced = errors.New("bulk chunk inserted concurrently")
+
+// batchInsertAttempts bounds how often a bulk chunk is inserted again after it raced with
+// another transaction inserting the same request IDs.
+const batchInsertAttempts = 3
+
+// StatusConflictError is returned when a request cannot move to Status from the status it
+// is in, for example because another worker already finished it.
+type StatusConflictError struct {
+	RequestID string
+	Current   int16
+	Status    int16
+}
+
+func (e *StatusConflictError) Error() string {
+	return fmt.Sprintf("email %s cannot move from status %d to %d", e.RequestID, e.Current, e.Status)
+}
+
 type EmailHistoryRepository struct {
 	db *sql.DB
 }
@@ -14,35 +44,314 @@ func NewEmailHistoryRepository(db *sql.DB) *EmailHistoryRepository {
 	return &EmailHistoryRepository{db: db}
 }
 
-// Create inserts a new email history record.
-func (r *EmailHistoryRepository) Create(ctx context.Context, requestID string, recipient string, subject string, content string, status int16) error {
-	const query = `
-		INSERT INTO email_history (request_id, recipient, subject, content, status, retries)
-		VALUES (?, ?, ?, ?, ?, 0)
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Create inserts a new email history record; a zero SendAt is stored as NULL.
+func (r *EmailHistoryRepository) Create(ctx context.Context, history entity.EmailHistory) error {
+	return createEmailHistory(ctx, r.db, history)
+}
+
+// CreateQueued inserts a new email history record together with its outbox entry in one
+// transaction, so the email is queued by the outbox relay exactly when the record exists.
+func (r *EmailHistoryRepository) CreateQueued(ctx context.Context, history entity.EmailHistory, timezone string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := createEmailHistory(ctx, tx, history); err != nil {
+		return err
+	}
+	if err := createOutboxEntry(ctx, tx, history.RequestID, timezone); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateBatchQueued inserts the history records of a bulk send chunk with their outbox
+// entries in one transaction, using one multi-row insert per table. It reports per
+// record whether it now belongs to the batch: a request ID already stored for the same
+// batch (a resumed send) counts as accepted and is not inserted again, one taken by
+// another request is rejected. A chunk that races with another transaction storing some
+// of its request IDs, such as a concurrent resume of the same batch, is rolled back and
+// inserted again, and then sees the other transaction's records.
+func (r *EmailHistoryRepository) CreateBatchQueued(ctx context.Context, batchID string, histories []entity.EmailHistory, timezones []string) ([]bool, error) {
+	if len(histories) == 0 {
+		return make([]bool, 0), nil
+	}
+	for attempt := 1; ; attempt++ {
+		accepted, err := r.createBatchQueued(ctx, batchID, histories, timezones)
+		if attempt == batchInsertAttempts || !(errors.Is(err, errBatchRaced) || isDeadlock(err)) {
+			return accepted, err
+		}
+	}
+}
+
+// createBatchQueued makes one attempt of CreateBatchQueued. It returns errBatchRaced when
+// fewer records than read as missing could be inserted.
+func (r *EmailHistoryRepository) createBatchQueued(ctx context.Context, batchID string, histories []entity.EmailHistory, timezones []string) ([]bool, error) {
+	accepted := make([]bool, len(histories))
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	requestIDs := make([]string, 0, len(histories))
+	for _, history := range histories {
+		requestIDs = append(requestIDs, history.RequestID)
+	}
+	owners, err := findBatchOwners(ctx, tx, requestIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var fresh []entity.EmailHistory
+	var freshTimezones []string
+	for i, history := range histories {
+		if owner, exists := owners[history.RequestID]; exists {
+			accepted[i] = owner == batchID
+			continue
+		}
+		accepted[i] = true
+		history.BatchID = batchID
+		fresh = append(fresh, history)
+		freshTimezones = append(freshTimezones, timezones[i])
+	}
+	if len(fresh) > 0 {
+		inserted, err := createEmailHistories(ctx, tx, fresh)
+		if err != nil {
+			return nil, err
+		}
+		if inserted != int64(len(fresh)) {
+			return nil, errBatchRaced
+		}
+		if err := createOutboxEntries(ctx, tx, fresh, freshTimezones); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return accepted, nil
+}
+
+// findBatchOwners returns the batch ID of every stored request among requestIDs; requests
+// outside any batch map to an empty string.
+func findBatchOwners(ctx context.Context, tx *sql.Tx, requestIDs []string) (map[string]string, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(requestIDs)), ", ")
+	query := `
+		SELECT request_id, batch_id
+		FROM email_history
+		WHERE request_id IN (` + placeholders + `)
 	`
-	_, err := r.db.ExecContext(ctx, query, requestID, recipient, subject, content, status)
-	return err
+	args := make([]any, 0, len(requestIDs))
+	for _, id := range requestIDs {
+		args = append(args, id)
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	owners := make(map[string]string)
+	for rows.Next() {
+		var requestID, batchID string
+		if err := rows.Scan(&requestID, &batchID); err != nil {
+			return nil, err
+		}
+		owners[requestID] = batchID
+	}
+	return owners, rows.Err()
+}
+
+// isDeadlock reports whether MySQL rolled the transaction back to break a deadlock.
+func isDeadlock(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1213
+}
+
+// createEmailHistories inserts several history records with one statement and returns how
+// many were inserted. Records whose request ID is already stored are skipped.
+func createEmailHistories(ctx context.Context, db execer, histories []entity.EmailHistory) (int64, error) {
+	query := `
+		INSERT INTO email_history (request_id, user_id, recipient, category, priority, subject, content, status, send_at, batch_id, tenant, template, retries)
+		VALUES ` + strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0), ", len(histories)), ", ") + `
+		ON DUPLICATE KEY UPDATE id = id`
+	args := make([]any, 0, 12*len(histories))
+	for _, history := range histories {
+		var sendAt any
+		if !history.SendAt.IsZero() {
+			sendAt = history.SendAt.UTC()
+		}
+		args = append(args,
+			history.RequestID,
+			history.UserID,
+			history.Recipient,
+			history.Category,
+			history.Priority,
+			history.Subject,
+			history.Content,
+			history.Status,
+			sendAt,
+			history.BatchID,
+			history.Tenant,
+			history.Template,
+		)
+	}
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-// DeleteByRequestID removes a history record by request ID.
-func (r *EmailHistoryRepository) DeleteByRequestID(ctx context.Context, requestID string) error {
+// createEmailHistory inserts a history record through db or a transaction.
+func createEmailHistory(ctx context.Context, db execer, history entity.EmailHistory) error {
 	const query = `
-		DELETE FROM email_history
-		WHERE request_id = ?
+		INSERT INTO email_history (request_id, user_id, recipient, category, priority, subject, content, status, send_at, digest_key, tenant, template, retries)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0)
 	`
-	_, err := r.db.ExecContext(ctx, query, requestID)
+	var sendAt any
+	if !history.SendAt.IsZero() {
+		sendAt = history.SendAt.UTC()
+	}
+	_, err := db.ExecContext(ctx, query,
+		history.RequestID,
+		history.UserID,
+		history.Recipient,
+		history.Category,
+		history.Priority,
+		history.Subject,
+		history.Content,
+		history.Status,
+		sendAt,
+		history.DigestKey,
+		history.Tenant,
+		history.Template,
+	)
 	return err
 }
 
-// UpdateStatus updates the status for a request ID.
+// UpdateStatus moves a request to status when its current status allows it (see
+// entity.EmailStatusSources). It returns a *StatusConflictError when it does not and
+// sql.ErrNoRows when the request is missing.
 func (r *EmailHistoryRepository) UpdateStatus(ctx context.Context, requestID string, status int16) error {
-	const query = `
+	sources := entity.EmailStatusSources(status)
+	if len(sources) == 0 {
+		return r.statusConflict(ctx, requestID, status)
+	}
+	query := `
 		UPDATE email_history
 		SET status = ?
+		WHERE request_id = ? AND status IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(sources)), ", ") + `)
+	`
+	args := []any{status, requestID}
+	for _, source := range sources {
+		args = append(args, source)
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return r.checkTransition(ctx, res, requestID, status)
+}
+
+// UpdateDelivered marks a request as sent and records the provider that delivered it, the
+// message ID the provider assigned, and the provider's response as JSON. Like UpdateStatus,
+// it returns a *StatusConflictError when the request can no longer be marked as sent.
+func (r *EmailHistoryRepository) UpdateDelivered(ctx context.Context, requestID string, provider string, messageID string, response string) error {
+	sources := entity.EmailStatusSources(entity.EmailStatusSuccess)
+	query := `
+		UPDATE email_history
+		SET status = ?, provider = ?, provider_message_id = ?, provider_response = ?
+		WHERE request_id = ? AND status IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(sources)), ", ") + `)
+	`
+	args := []any{entity.EmailStatusSuccess, provider, messageID, response, requestID}
+	for _, source := range sources {
+		args = append(args, source)
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return r.checkTransition(ctx, res, requestID, entity.EmailStatusSuccess)
+}
+
+// checkTransition returns nil when a conditional status update changed the request and
+// the conflict with its current status otherwise.
+func (r *EmailHistoryRepository) checkTransition(ctx context.Context, res sql.Result, requestID string, status int16) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+	return r.statusConflict(ctx, requestID, status)
+}
+
+// statusConflict returns the conflict of a request that was not moved to status. MySQL
+// reports zero affected rows when the row already had the new values, so a request that
+// is in status and may stay there is not a conflict.
+func (r *EmailHistoryRepository) statusConflict(ctx context.Context, requestID string, status int16) error {
+	current, err := r.FindStatus(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if current == status && entity.CanTransitionEmailStatus(current, status) {
+		return nil
+	}
+	return &StatusConflictError{RequestID: requestID, Current: current, Status: status}
+}
+
+// FindStatus returns the status of a request; it returns sql.ErrNoRows when missing.
+func (r *EmailHistoryRepository) FindStatus(ctx context.Context, requestID string) (int16, error) {
+	const query = `
+		SELECT status
+		FROM email_history
 		WHERE request_id = ?
 	`
-	_, err := r.db.ExecContext(ctx, query, status, requestID)
-	return err
+	var status int16
+	if err := r.db.QueryRowContext(ctx, query, requestID).Scan(&status); err != nil {
+		return 0, err
+	}
+	return status, nil
+}
+
+// cancellableStatuses lists the statuses of requests that have not started sending yet.
+var cancellableStatuses = entity.EmailStatusSources(entity.EmailStatusCancelled)
+
+// Cancel marks a scheduled, deferred (by quiet hours, a frequency cap, or a paused campaign), digest-pending,
+// or not yet processed request as cancelled and reports whether it did.
+func (r *EmailHistoryRepository) Cancel(ctx context.Context, requestID string) (bool, error) {
+	query := `
+		UPDATE email_history
+		SET status = ?
+		WHERE request_id = ? AND status IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(cancellableStatuses)), ", ") + `)
+	`
+	args := []any{entity.EmailStatusCancelled, requestID}
+	for _, status := range cancellableStatuses {
+		args = append(args, status)
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
 }
 
 // UpdateContent updates the stored raw content for a request ID.
@@ -55,3 +364,119 @@ func (r *EmailHistoryRepository) UpdateContent(ctx context.Context, requestID st
 	_, err := r.db.ExecContext(ctx, query, content, requestID)
 	return err
 }
+
+// UpdateRecipient stores the address a request was resolved to at send time.
+func (r *EmailHistoryRepository) UpdateRecipient(ctx context.Context, requestID string, recipient string) error {
+	const query = `
+		UPDATE email_history
+		SET recipient = ?
+		WHERE request_id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, recipient, requestID)
+	return err
+}
+
+// ListDueDigests returns the groups of pending digest items whose oldest item has
+// waited at least window. The window is applied by MySQL against created_at.
+func (r *EmailHistoryRepository) ListDueDigests(ctx context.Context, window time.Duration, limit int) ([]entity.DigestGroup, error) {
+	const query = `
+		SELECT digest_key, user_id, recipient, category, MIN(id)
+		FROM email_history
+		WHERE status = ?
+		GROUP BY digest_key, user_id, recipient, category
+		HAVING MIN(created_at) <= NOW() - INTERVAL ? SECOND
+		ORDER BY MIN(id) ASC
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, entity.EmailStatusDigestPending, int64(window/time.Second), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []entity.DigestGroup
+	for rows.Next() {
+		var g entity.DigestGroup
+		if err := rows.Scan(&g.DigestKey, &g.UserID, &g.Recipient, &g.Category, &g.FirstID); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// ListDigestItems returns up to limit pending items of a digest group, oldest first.
+func (r *EmailHistoryRepository) ListDigestItems(ctx context.Context, group entity.DigestGroup, limit int) ([]entity.EmailHistory, error) {
+	const query = `
+		SELECT id, request_id, subject, content, created_at
+		FROM email_history
+		WHERE status = ? AND digest_key = ? AND user_id = ? AND recipient = ? AND category = ?
+		ORDER BY id ASC
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query,
+		entity.EmailStatusDigestPending, group.DigestKey, group.UserID, group.Recipient, group.Category, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []entity.EmailHistory
+	for rows.Next() {
+		item := entity.EmailHistory{
+			UserID:    group.UserID,
+			Recipient: group.Recipient,
+			Category:  group.Category,
+			DigestKey: group.DigestKey,
+			Status:    entity.EmailStatusDigestPending,
+		}
+		if err := rows.Scan(&item.ID, &item.RequestID, &item.Subject, &item.Content, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// CreateDigest inserts the digest email with its outbox entry and marks its items as
+// digested into it in one transaction. It returns ErrDigestChanged when an item is no longer pending, for
+// example because it was cancelled meanwhile.
+func (r *EmailHistoryRepository) CreateDigest(ctx context.Context, parent entity.EmailHistory, itemRequestIDs []string) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(itemRequestIDs)), ", ")
+	query := `
+		UPDATE email_history
+		SET status = ?, digest_request_id = ?
+		WHERE status = ? AND request_id IN (` + placeholders + `)
+	`
+	args := []any{entity.EmailStatusDigested, parent.RequestID, entity.EmailStatusDigestPending}
+	for _, id := range itemRequestIDs {
+		args = append(args, id)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := createEmailHistory(ctx, tx, parent); err != nil {
+		return err
+	}
+	if err := createOutboxEntry(ctx, tx, parent.RequestID, ""); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != int64(len(itemRequestIDs)) {
+		return ErrDigestChanged
+	}
+	return tx.Commit()
+}
@@ -2,11 +2,61 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
 )
 
+func TestEmailHistoryRepositoryUpdateStatusConflict(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailHistoryRepository(db)
+
+	// A sent request is not moved back to processing.
+	mock.ExpectExec("UPDATE email_history").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusSuccess))
+	err = repo.UpdateStatus(context.Background(), "req-1", entity.EmailStatusProcessing)
+	var conflict *StatusConflictError
+	if !errors.As(err, &conflict) || conflict.Current != entity.EmailStatusSuccess || conflict.Status != entity.EmailStatusProcessing {
+		t.Fatalf("expected status conflict, got %v", err)
+	}
+
+	// MySQL reports no affected rows for a request already in the status it may stay in.
+	mock.ExpectExec("UPDATE email_history").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-2").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusProcessing))
+	if err := repo.UpdateStatus(context.Background(), "req-2", entity.EmailStatusProcessing); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+
+	mock.ExpectExec("UPDATE email_history").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-3").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusSuccess))
+	if err := repo.UpdateDelivered(context.Background(), "req-3", "ses", "msg-1", "{}"); !errors.As(err, &conflict) {
+		t.Fatalf("expected status conflict, got %v", err)
+	}
+
+	// Requests are only created as new; no status moves back to it.
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-4").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusProcessing))
+	if err := repo.UpdateStatus(context.Background(), "req-4", entity.EmailStatusNew); !errors.As(err, &conflict) {
+		t.Fatalf("expected status conflict, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
 func TestEmailHistoryRepositoryCRUD(t *testing.T) {
 	t.Parallel()
 
@@ -19,14 +69,27 @@ func TestEmailHistoryRepositoryCRUD(t *testing.T) {
 	repo := NewEmailHistoryRepository(db)
 
 	mock.ExpectExec("INSERT INTO email_history").
-		WithArgs("req-1", "a@b.com", "subj", "content", int16(0)).
+		WithArgs("req-1", uint64(7), "a@b.com", "marketing", entity.PriorityLow, "subj", "content", int16(0), nil, "", "", "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
-	if err := repo.Create(context.Background(), "req-1", "a@b.com", "subj", "content", 0); err != nil {
+	if err := repo.Create(context.Background(), entity.EmailHistory{
+		RequestID: "req-1",
+		UserID:    7,
+		Recipient: "a@b.com",
+		Category:  "marketing",
+		Priority:  entity.PriorityLow,
+		Subject:   "subj",
+		Content:   "content",
+		Status:    entity.EmailStatusNew,
+	}); err != nil {
 		t.Fatalf("Create: %v", err)
 	}
 
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(int16(1), "req-1").
+		WithArgs(
+			entity.EmailStatusProcessing, "req-1",
+			entity.EmailStatusNew, entity.EmailStatusScheduled, entity.EmailStatusDeferred, entity.EmailStatusCapDeferred,
+			entity.EmailStatusPaused, entity.EmailStatusTemporaryFailure, entity.EmailStatusProcessing,
+		).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	if err := repo.UpdateStatus(context.Background(), "req-1", 1); err != nil {
 		t.Fatalf("UpdateStatus: %v", err)
@@ -39,11 +102,166 @@ func TestEmailHistoryRepositoryCRUD(t *testing.T) {
 		t.Fatalf("UpdateContent: %v", err)
 	}
 
-	mock.ExpectExec("DELETE FROM email_history").
-		WithArgs("req-1").
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs("new@b.com", "req-1").
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	if err := repo.DeleteByRequestID(context.Background(), "req-1"); err != nil {
-		t.Fatalf("DeleteByRequestID: %v", err)
+	if err := repo.UpdateRecipient(context.Background(), "req-1", "new@b.com"); err != nil {
+		t.Fatalf("UpdateRecipient: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("req-2", uint64(0), "a@b.com", "", entity.PriorityNormal, "subj", "content", int16(0), nil, "", "", "").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("req-2", "Europe/Bucharest").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	if err := repo.CreateQueued(context.Background(), entity.EmailHistory{
+		RequestID: "req-2",
+		Recipient: "a@b.com",
+		Priority:  entity.PriorityNormal,
+		Subject:   "subj",
+		Content:   "content",
+		Status:    entity.EmailStatusNew,
+	}, "Europe/Bucharest"); err != nil {
+		t.Fatalf("CreateQueued: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailHistoryRepositoryCreateDigestRollsBackWhenItemsChanged(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailHistoryRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("digest-11", uint64(7), "", "", entity.PriorityNormal, "2 new notifications", "content", entity.EmailStatusNew, nil, "", "", "").
+		WillReturnResult(sqlmock.NewResult(13, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("digest-11", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(entity.EmailStatusDigested, "digest-11", entity.EmailStatusDigestPending, "c-1", "c-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	err = repo.CreateDigest(context.Background(), entity.EmailHistory{
+		RequestID: "digest-11",
+		UserID:    7,
+		Priority:  entity.PriorityNormal,
+		Subject:   "2 new notifications",
+		Content:   "content",
+		Status:    entity.EmailStatusNew,
+	}, []string{"c-1", "c-2"})
+	if !errors.Is(err, ErrDigestChanged) {
+		t.Fatalf("expected ErrDigestChanged, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailHistoryRepositoryCreateBatchQueued(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailHistoryRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT request_id, batch_id").
+		WithArgs("b:0", "b:1", "b:2", "b:3").
+		WillReturnRows(sqlmock.NewRows([]string{"request_id", "batch_id"}).
+			AddRow("b:1", "b").
+			AddRow("b:2", ""))
+	mock.ExpectExec(`INSERT INTO email_history .* VALUES \(.*\), \(.*\) ON DUPLICATE KEY UPDATE id = id$`).
+		WithArgs(
+			"b:0", uint64(0), "a@b.com", "news", entity.PriorityLow, "Hi Ana", "content", entity.EmailStatusNew, nil, "b", "", "",
+			"b:3", uint64(9), "", "news", entity.PriorityLow, "Hi Ion", "content", entity.EmailStatusNew, nil, "b", "", "",
+		).
+		WillReturnResult(sqlmock.NewResult(10, 2))
+	mock.ExpectExec(`INSERT INTO email_outbox .* VALUES \(\?, \?\), \(\?, \?\)$`).
+		WithArgs("b:0", "Europe/Bucharest", "b:3", "").
+		WillReturnResult(sqlmock.NewResult(5, 2))
+	mock.ExpectCommit()
+
+	histories := []entity.EmailHistory{
+		{RequestID: "b:0", Recipient: "a@b.com", Category: "news", Priority: entity.PriorityLow, Subject: "Hi Ana", Content: "content"},
+		{RequestID: "b:1", Recipient: "c@d.com", Category: "news", Priority: entity.PriorityLow, Subject: "Hi Eva", Content: "content"},
+		{RequestID: "b:2", Recipient: "e@f.com", Category: "news", Priority: entity.PriorityLow, Subject: "Hi Dan", Content: "content"},
+		{RequestID: "b:3", UserID: 9, Category: "news", Priority: entity.PriorityLow, Subject: "Hi Ion", Content: "content"},
+	}
+	accepted, err := repo.CreateBatchQueued(context.Background(), "b", histories, []string{"Europe/Bucharest", "", "", ""})
+	if err != nil {
+		t.Fatalf("CreateBatchQueued: %v", err)
+	}
+	if !accepted[0] || !accepted[1] || accepted[2] || !accepted[3] {
+		t.Fatalf("unexpected accepted flags: %v", accepted)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailHistoryRepositoryCreateBatchQueuedRetriesRacedChunk(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailHistoryRepository(db)
+
+	// Another stream of the same batch stores b:1 after the owners are read, so only b:0
+	// is inserted and the chunk is retried.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT request_id, batch_id").
+		WithArgs("b:0", "b:1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_id", "batch_id"}))
+	mock.ExpectExec("INSERT INTO email_history").WillReturnResult(sqlmock.NewResult(10, 1))
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT request_id, batch_id").
+		WithArgs("b:0", "b:1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_id", "batch_id"}).AddRow("b:1", "b"))
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("b:0", uint64(0), "a@b.com", "news", entity.PriorityLow, "Hi Ana", "content", entity.EmailStatusNew, nil, "b", "", "").
+		WillReturnResult(sqlmock.NewResult(11, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("b:0", "").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+
+	histories := []entity.EmailHistory{
+		{RequestID: "b:0", Recipient: "a@b.com", Category: "news", Priority: entity.PriorityLow, Subject: "Hi Ana", Content: "content"},
+		{RequestID: "b:1", Recipient: "c@d.com", Category: "news", Priority: entity.PriorityLow, Subject: "Hi Eva", Content: "content"},
+	}
+	accepted, err := repo.CreateBatchQueued(context.Background(), "b", histories, []string{"", ""})
+	if err != nil {
+		t.Fatalf("CreateBatchQueued: %v", err)
+	}
+	if !accepted[0] || !accepted[1] {
+		t.Fatalf("unexpected accepted flags: %v", accepted)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
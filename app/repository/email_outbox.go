@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+type EmailOutboxRepository struct {
+	db *sql.DB
+}
+
+// NewEmailOutboxRepository constructs a repository backed by MySQL.
+func NewEmailOutboxRepository(db *sql.DB) *EmailOutboxRepository {
+	return &EmailOutboxRepository{db: db}
+}
+
+// createOutboxEntry inserts an outbox entry through db or a transaction; callers write it
+// in the same transaction as the history record it queues.
+func createOutboxEntry(ctx context.Context, db execer, requestID string, timezone string) error {
+	const query = `
+		INSERT INTO email_outbox (request_id, timezone)
+		VALUES (?, ?)
+	`
+	_, err := db.ExecContext(ctx, query, requestID, timezone)
+	return err
+}
+
+// createOutboxEntries inserts the outbox entries of several history records with one
+// statement; timezones holds the entry timezone of each record.
+func createOutboxEntries(ctx context.Context, db execer, histories []entity.EmailHistory, timezones []string) error {
+	query := `
+		INSERT INTO email_outbox (request_id, timezone)
+		VALUES ` + strings.TrimSuffix(strings.Repeat("(?, ?), ", len(histories)), ", ")
+	args := make([]any, 0, 2*len(histories))
+	for i, history := range histories {
+		args = append(args, history.RequestID, timezones[i])
+	}
+	_, err := db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// ListPending returns up to limit outbox entries with their history records, oldest first.
+func (r *EmailOutboxRepository) ListPending(ctx context.Context, limit int) ([]entity.EmailOutboxEntry, error) {
+	const query = `
+		SELECT o.id, o.timezone, h.request_id, h.user_id, h.recipient, h.category, h.priority, h.subject, h.content, h.batch_id, h.tenant, h.template, h.send_at
+		FROM email_outbox o
+		JOIN email_history h ON h.request_id = o.request_id
+		ORDER BY o.id ASC
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []entity.EmailOutboxEntry
+	for rows.Next() {
+		var entry entity.EmailOutboxEntry
+		var sendAt sql.NullTime
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.Timezone,
+			&entry.Email.RequestID,
+			&entry.Email.UserID,
+			&entry.Email.Recipient,
+			&entry.Email.Category,
+			&entry.Email.Priority,
+			&entry.Email.Subject,
+			&entry.Email.Content,
+			&entry.Email.BatchID,
+			&entry.Email.Tenant,
+			&entry.Email.Template,
+			&sendAt,
+		); err != nil {
+			return nil, err
+		}
+		if sendAt.Valid {
+			entry.Email.SendAt = sendAt.Time
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Delete removes a published outbox entry.
+func (r *EmailOutboxRepository) Delete(ctx context.Context, id uint64) error {
+	const query = `
+		DELETE FROM email_outbox
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// DeleteMany removes several published outbox entries with one statement.
+func (r *EmailOutboxRepository) DeleteMany(ctx context.Context, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query := `
+		DELETE FROM email_outbox
+		WHERE id IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + `)
+	`
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+var emailOutboxColumns = []string{
+	"id", "timezone", "request_id", "user_id", "recipient", "category", "priority", "subject", "content", "batch_id", "tenant", "template", "send_at",
+}
+
+func TestEmailOutboxRepositoryListAndDelete(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailOutboxRepository(db)
+	sendAt := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM email_outbox o").
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows(emailOutboxColumns).
+			AddRow(uint64(1), "Europe/Bucharest", "req-1", uint64(7), "", "marketing", entity.PriorityLow, "subj", "content", "", "", "", nil).
+			AddRow(uint64(2), "", "req-2", uint64(0), "a@b.com", "", entity.PriorityNormal, "subj", "content", "batch-1", "", "", sendAt))
+	entries, err := repo.ListPending(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("ListPending: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Timezone != "Europe/Bucharest" || entries[0].Email.UserID != 7 || !entries[0].Email.SendAt.IsZero() {
+		t.Fatalf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Email.Recipient != "a@b.com" || entries[1].Email.BatchID != "batch-1" || !entries[1].Email.SendAt.Equal(sendAt) {
+		t.Fatalf("unexpected second entry: %+v", entries[1])
+	}
+
+	mock.ExpectExec("DELETE FROM email_outbox").
+		WithArgs(uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.Delete(context.Background(), 1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
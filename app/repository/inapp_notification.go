@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+type InAppNotificationRepository struct {
+	db *sql.DB
+}
+
+// NewInAppNotificationRepository constructs a repository backed by MySQL.
+func NewInAppNotificationRepository(db *sql.DB) *InAppNotificationRepository {
+	return &InAppNotificationRepository{db: db}
+}
+
+// Create inserts a new in-app notification and fills its ID and creation time.
+func (r *InAppNotificationRepository) Create(ctx context.Context, notification *entity.InAppNotification) error {
+	const query = `
+		INSERT INTO inapp_notifications (request_id, user_id, type, title, body, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	result, err := r.db.ExecContext(ctx, query,
+		notification.RequestID,
+		notification.UserID,
+		notification.Type,
+		notification.Title,
+		notification.Body,
+		createdAt,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	notification.ID = uint64(id)
+	notification.CreatedAt = createdAt
+	return nil
+}
+
+// FindByRequestID loads an in-app notification; it returns sql.ErrNoRows when missing.
+func (r *InAppNotificationRepository) FindByRequestID(ctx context.Context, requestID string) (*entity.InAppNotification, error) {
+	const query = `
+		SELECT id, request_id, user_id, type, title, body, created_at
+		FROM inapp_notifications
+		WHERE request_id = ?
+	`
+	var n entity.InAppNotification
+	err := r.db.QueryRowContext(ctx, query, requestID).Scan(&n.ID, &n.RequestID, &n.UserID, &n.Type, &n.Title, &n.Body, &n.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ListByUser returns a user's notifications with an ID greater than afterID, oldest first.
+func (r *InAppNotificationRepository) ListByUser(ctx context.Context, userID uint64, afterID uint64, limit int) ([]entity.InAppNotification, error) {
+	const query = `
+		SELECT id, request_id, user_id, type, title, body, created_at
+		FROM inapp_notifications
+		WHERE user_id = ? AND id > ?
+		ORDER BY id ASC
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []entity.InAppNotification
+	for rows.Next() {
+		var n entity.InAppNotification
+		if err := rows.Scan(&n.ID, &n.RequestID, &n.UserID, &n.Type, &n.Title, &n.Body, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+func TestInAppNotificationRepositoryCreate(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewInAppNotificationRepository(db)
+
+	mock.ExpectExec("INSERT INTO inapp_notifications").
+		WithArgs("req-1", uint64(7), "comment", "New comment", "Someone replied", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(42, 1))
+
+	n := &entity.InAppNotification{RequestID: "req-1", UserID: 7, Type: "comment", Title: "New comment", Body: "Someone replied"}
+	if err := repo.Create(context.Background(), n); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if n.ID != 42 {
+		t.Fatalf("expected id 42, got %d", n.ID)
+	}
+	if n.CreatedAt.IsZero() {
+		t.Fatalf("expected created_at to be set")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestInAppNotificationRepositoryListByUser(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewInAppNotificationRepository(db)
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery("SELECT id, request_id, user_id, type, title, body, created_at FROM inapp_notifications").
+		WithArgs(uint64(7), uint64(10), 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "request_id", "user_id", "type", "title", "body", "created_at"}).
+			AddRow(11, "req-11", 7, "comment", "t1", "b1", createdAt).
+			AddRow(12, "req-12", 7, "comment", "t2", "b2", createdAt))
+
+	got, err := repo.ListByUser(context.Background(), 7, 10, 50)
+	if err != nil {
+		t.Fatalf("ListByUser: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != 11 || got[1].RequestID != "req-12" {
+		t.Fatalf("unexpected notifications: %+v", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository constructs a repository backed by MySQL.
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create inserts a parent notification record.
+func (r *NotificationRepository) Create(ctx context.Context, notification *entity.Notification) error {
+	const query = `
+		INSERT INTO notifications (request_id, user_id, type, category, priority, title, body, email, digest_key, policy, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	policy, err := json.Marshal(notification.Policy)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query,
+		notification.RequestID,
+		notification.UserID,
+		notification.Type,
+		notification.Category,
+		notification.Priority,
+		notification.Title,
+		notification.Body,
+		notification.Email,
+		notification.DigestKey,
+		string(policy),
+		notification.Status,
+	)
+	return err
+}
+
+// Complete stores per-channel deliveries and the final parent status in one transaction.
+func (r *NotificationRepository) Complete(ctx context.Context, requestID string, status int16, deliveries []entity.NotificationDelivery) error {
+	const insertDelivery = `
+		INSERT INTO notification_deliveries (notification_request_id, channel, tier, position, status, reference, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	const updateStatus = `
+		UPDATE notifications
+		SET status = ?
+		WHERE request_id = ?
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for i, d := range deliveries {
+		if _, err := tx.ExecContext(ctx, insertDelivery, requestID, d.Channel, d.Tier, i, d.Status, d.Reference, d.Error); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, updateStatus, status, requestID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateDeliveries stores the new outcome of existing deliveries, matched by channel, and
+// the parent status in one transaction.
+func (r *NotificationRepository) UpdateDeliveries(ctx context.Context, requestID string, status int16, deliveries []entity.NotificationDelivery) error {
+	const updateDelivery = `
+		UPDATE notification_deliveries
+		SET status = ?, reference = ?, error = ?
+		WHERE notification_request_id = ? AND channel = ?
+	`
+	const updateStatus = `
+		UPDATE notifications
+		SET status = ?
+		WHERE request_id = ?
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, d := range deliveries {
+		if _, err := tx.ExecContext(ctx, updateDelivery, d.Status, d.Reference, d.Error, requestID, d.Channel); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, updateStatus, status, requestID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListWithFinishedEmails returns up to limit request IDs of notifications with a pending
+// email delivery whose email is finished, oldest first.
+func (r *NotificationRepository) ListWithFinishedEmails(ctx context.Context, limit int) ([]string, error) {
+	statuses := entity.TerminalEmailStatuses()
+	query := `
+		SELECT d.notification_request_id
+		FROM notification_deliveries d
+		JOIN email_history h ON h.request_id = d.reference
+		WHERE d.status = ? AND d.channel = ? AND h.status IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ") + `)
+		ORDER BY d.id ASC
+		LIMIT ?
+	`
+	args := make([]any, 0, len(statuses)+3)
+	args = append(args, entity.DeliveryStatusPending, entity.ChannelEmail)
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+	args = append(args, limit)
+	return r.listRequestIDs(ctx, query, args...)
+}
+
+// ListStuck returns up to limit request IDs of notifications still processing without any
+// deliveries after olderThan, oldest first: their dispatch was interrupted.
+func (r *NotificationRepository) ListStuck(ctx context.Context, olderThan time.Duration, limit int) ([]string, error) {
+	const query = `
+		SELECT n.request_id
+		FROM notifications n
+		WHERE n.status = ?
+			AND n.created_at <= NOW() - INTERVAL ? SECOND
+			AND NOT EXISTS (SELECT 1 FROM notification_deliveries d WHERE d.notification_request_id = n.request_id)
+		ORDER BY n.created_at ASC
+		LIMIT ?
+	`
+	return r.listRequestIDs(ctx, query, entity.NotificationStatusProcessing, int64(olderThan/time.Second), limit)
+}
+
+// listRequestIDs runs a query that selects a single request ID column.
+func (r *NotificationRepository) listRequestIDs(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requestIDs []string
+	for rows.Next() {
+		var requestID string
+		if err := rows.Scan(&requestID); err != nil {
+			return nil, err
+		}
+		requestIDs = append(requestIDs, requestID)
+	}
+	return requestIDs, rows.Err()
+}
+
+// FindByRequestID loads a parent notification; it returns sql.ErrNoRows when missing.
+func (r *NotificationRepository) FindByRequestID(ctx context.Context, requestID string) (*entity.Notification, error) {
+	const query = `
+		SELECT request_id, user_id, type, category, priority, title, body, email, digest_key, policy, status, created_at
+		FROM notifications
+		WHERE request_id = ?
+	`
+	var n entity.Notification
+	var policy string
+	err := r.db.QueryRowContext(ctx, query, requestID).Scan(
+		&n.RequestID, &n.UserID, &n.Type, &n.Category, &n.Priority, &n.Title, &n.Body, &n.Email, &n.DigestKey, &policy, &n.Status, &n.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(policy), &n.Policy); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ListDeliveries returns the per-channel deliveries of a notification in attempt order.
+func (r *NotificationRepository) ListDeliveries(ctx context.Context, requestID string) ([]entity.NotificationDelivery, error) {
+	const query = `
+		SELECT channel, tier, status, reference, error
+		FROM notification_deliveries
+		WHERE notification_request_id = ?
+		ORDER BY position ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []entity.NotificationDelivery
+	for rows.Next() {
+		var d entity.NotificationDelivery
+		if err := rows.Scan(&d.Channel, &d.Tier, &d.Status, &d.Reference, &d.Error); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+func TestNotificationRepositoryCreateAndComplete(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewNotificationRepository(db)
+
+	mock.ExpectExec("INSERT INTO notifications").
+		WithArgs("n-1", uint64(7), "comment", "social", entity.PriorityHigh, "title", "body", "a@b.com", "weekly", `{"fallback":["in_app","email"],"always":null}`, entity.NotificationStatusProcessing).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = repo.Create(context.Background(), &entity.Notification{
+		RequestID: "n-1",
+		UserID:    7,
+		Type:      "comment",
+		Category:  "social",
+		Priority:  entity.PriorityHigh,
+		Title:     "title",
+		Body:      "body",
+		Email:     "a@b.com",
+		DigestKey: "weekly",
+		Policy:    entity.ChannelPolicy{Fallback: []string{entity.ChannelInApp, entity.ChannelEmail}},
+		Status:    entity.NotificationStatusProcessing,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO notification_deliveries").
+		WithArgs("n-1", entity.ChannelInApp, entity.PolicyTierFallback, 0, entity.DeliveryStatusAccepted, "12", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO notification_deliveries").
+		WithArgs("n-1", entity.ChannelEmail, entity.PolicyTierFallback, 1, entity.DeliveryStatusSkipped, "", "").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("UPDATE notifications").
+		WithArgs(entity.NotificationStatusCompleted, "n-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Complete(context.Background(), "n-1", entity.NotificationStatusCompleted, []entity.NotificationDelivery{
+		{Channel: entity.ChannelInApp, Tier: entity.PolicyTierFallback, Status: entity.DeliveryStatusAccepted, Reference: "12"},
+		{Channel: entity.ChannelEmail, Tier: entity.PolicyTierFallback, Status: entity.DeliveryStatusSkipped},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestNotificationRepositoryCompleteRollsBackOnError(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewNotificationRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO notification_deliveries").
+		WillReturnError(errors.New("insert failed"))
+	mock.ExpectRollback()
+
+	err = repo.Complete(context.Background(), "n-1", entity.NotificationStatusCompleted, []entity.NotificationDelivery{
+		{Channel: entity.ChannelInApp, Tier: entity.PolicyTierFallback, Status: entity.DeliveryStatusAccepted},
+	})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestNotificationRepositoryFind(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewNotificationRepository(db)
+
+	mock.ExpectQuery("SELECT request_id, user_id, type, category, priority, title, body, email, digest_key, policy, status, created_at FROM notifications").
+		WithArgs("n-1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_id", "user_id", "type", "category", "priority", "title", "body", "email", "digest_key", "policy", "status", "created_at"}).
+			AddRow("n-1", 7, "comment", "social", entity.PriorityHigh, "title", "body", "", "weekly", `{"fallback":["in_app"],"always":["email"]}`, entity.NotificationStatusCompleted, time.Now()))
+
+	n, err := repo.FindByRequestID(context.Background(), "n-1")
+	if err != nil {
+		t.Fatalf("FindByRequestID: %v", err)
+	}
+	if len(n.Policy.Always) != 1 || n.Policy.Always[0] != entity.ChannelEmail {
+		t.Fatalf("unexpected policy: %+v", n.Policy)
+	}
+	if n.DigestKey != "weekly" {
+		t.Fatalf("unexpected digest key: %q", n.DigestKey)
+	}
+
+	mock.ExpectQuery("SELECT channel, tier, status, reference, error FROM notification_deliveries").
+		WithArgs("n-1").
+		WillReturnRows(sqlmock.NewRows([]string{"channel", "tier", "status", "reference", "error"}).
+			AddRow(entity.ChannelInApp, entity.PolicyTierFallback, entity.DeliveryStatusAccepted, "12", ""))
+
+	deliveries, err := repo.ListDeliveries(context.Background(), "n-1")
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Reference != "12" {
+		t.Fatalf("unexpected deliveries: %+v", deliveries)
+	}
+
+	mock.ExpectQuery("SELECT request_id").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+	if _, err := repo.FindByRequestID(context.Background(), "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestNotificationRepositoryListForReconcile(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewNotificationRepository(db)
+
+	args := []driver.Value{entity.DeliveryStatusPending, entity.ChannelEmail}
+	for _, status := range entity.TerminalEmailStatuses() {
+		args = append(args, status)
+	}
+	args = append(args, 100)
+	mock.ExpectQuery("FROM notification_deliveries d JOIN email_history h ON h.request_id = d.reference").
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"notification_request_id"}).AddRow("n-1").AddRow("n-2"))
+
+	finished, err := repo.ListWithFinishedEmails(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("ListWithFinishedEmails: %v", err)
+	}
+	if len(finished) != 2 || finished[0] != "n-1" || finished[1] != "n-2" {
+		t.Fatalf("unexpected request IDs: %v", finished)
+	}
+
+	mock.ExpectQuery("FROM notifications n WHERE n.status = \\? AND n.created_at <= NOW\\(\\) - INTERVAL \\? SECOND AND NOT EXISTS").
+		WithArgs(entity.NotificationStatusProcessing, int64(60), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"request_id"}).AddRow("n-3"))
+
+	stuck, err := repo.ListStuck(context.Background(), time.Minute, 100)
+	if err != nil {
+		t.Fatalf("ListStuck: %v", err)
+	}
+	if len(stuck) != 1 || stuck[0] != "n-3" {
+		t.Fatalf("unexpected request IDs: %v", stuck)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestNotificationRepositoryUpdateDeliveries(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewNotificationRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE notification_deliveries").
+		WithArgs(entity.DeliveryStatusFailed, "n-1:email", "email cancelled", "n-1", entity.ChannelEmail).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE notifications").
+		WithArgs(entity.NotificationStatusFailed, "n-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.UpdateDeliveries(context.Background(), "n-1", entity.NotificationStatusFailed, []entity.NotificationDelivery{
+		{Channel: entity.ChannelEmail, Tier: entity.PolicyTierFallback, Status: entity.DeliveryStatusFailed, Reference: "n-1:email", Error: "email cancelled"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateDeliveries: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+type PreferenceRepository struct {
+	db *sql.DB
+}
+
+// NewPreferenceRepository constructs a categories and preferences repository backed by MySQL.
+func NewPreferenceRepository(db *sql.DB) *PreferenceRepository {
+	return &PreferenceRepository{db: db}
+}
+
+// UpsertCategory creates or updates a notification category and fills its update time.
+func (r *PreferenceRepository) UpsertCategory(ctx context.Context, category *entity.NotificationCategory) error {
+	const query = `
+		INSERT INTO notification_categories (name, description, transactional, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			description = VALUES(description),
+			transactional = VALUES(transactional),
+			updated_at = VALUES(updated_at)
+	`
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	if _, err := r.db.ExecContext(ctx, query, category.Name, category.Description, category.Transactional, updatedAt); err != nil {
+		return err
+	}
+	category.UpdatedAt = updatedAt
+	return nil
+}
+
+// FindCategory loads a category by name; it returns sql.ErrNoRows when missing.
+func (r *PreferenceRepository) FindCategory(ctx context.Context, name string) (*entity.NotificationCategory, error) {
+	const query = `
+		SELECT name, description, transactional, updated_at
+		FROM notification_categories
+		WHERE name = ?
+	`
+	var c entity.NotificationCategory
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&c.Name, &c.Description, &c.Transactional, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListCategories returns all categories ordered by name.
+func (r *PreferenceRepository) ListCategories(ctx context.Context) ([]entity.NotificationCategory, error) {
+	const query = `
+		SELECT name, description, transactional, updated_at
+		FROM notification_categories
+		ORDER BY name ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []entity.NotificationCategory
+	for rows.Next() {
+		var c entity.NotificationCategory
+		if err := rows.Scan(&c.Name, &c.Description, &c.Transactional, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// FindPreference loads a stored preference; it returns sql.ErrNoRows when the user never set one.
+func (r *PreferenceRepository) FindPreference(ctx context.Context, userID uint64, category string, channel string) (*entity.NotificationPreference, error) {
+	const query = `
+		SELECT user_id, category, channel, enabled
+		FROM notification_preferences
+		WHERE user_id = ? AND category = ? AND channel = ?
+	`
+	var p entity.NotificationPreference
+	err := r.db.QueryRowContext(ctx, query, userID, category, channel).Scan(&p.UserID, &p.Category, &p.Channel, &p.Enabled)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListPreferences returns every preference a user has stored.
+func (r *PreferenceRepository) ListPreferences(ctx context.Context, userID uint64) ([]entity.NotificationPreference, error) {
+	const query = `
+		SELECT user_id, category, channel, enabled
+		FROM notification_preferences
+		WHERE user_id = ?
+		ORDER BY category ASC, channel ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var preferences []entity.NotificationPreference
+	for rows.Next() {
+		var p entity.NotificationPreference
+		if err := rows.Scan(&p.UserID, &p.Category, &p.Channel, &p.Enabled); err != nil {
+			return nil, err
+		}
+		preferences = append(preferences, p)
+	}
+	return preferences, rows.Err()
+}
+
+// UpsertPreferences stores a batch of preferences in one transaction.
+func (r *PreferenceRepository) UpsertPreferences(ctx context.Context, preferences []entity.NotificationPreference) error {
+	const query = `
+		INSERT INTO notification_preferences (user_id, category, channel, enabled)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE enabled = VALUES(enabled)
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, p := range preferences {
+		if _, err := tx.ExecContext(ctx, query, p.UserID, p.Category, p.Channel, p.Enabled); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+func TestPreferenceRepositoryCategories(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPreferenceRepository(db)
+
+	mock.ExpectExec("INSERT INTO notification_categories").
+		WithArgs("marketing", "Promotions", false, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	category := &entity.NotificationCategory{Name: "marketing", Description: "Promotions"}
+	if err := repo.UpsertCategory(context.Background(), category); err != nil {
+		t.Fatalf("UpsertCategory: %v", err)
+	}
+	if category.UpdatedAt.IsZero() {
+		t.Fatalf("expected UpdatedAt to be set")
+	}
+
+	mock.ExpectQuery("SELECT name, description, transactional, updated_at").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "description", "transactional", "updated_at"}).
+			AddRow("marketing", "Promotions", false, time.Now()).
+			AddRow("security", "Account security", true, time.Now()))
+	categories, err := repo.ListCategories(context.Background())
+	if err != nil {
+		t.Fatalf("ListCategories: %v", err)
+	}
+	if len(categories) != 2 || !categories[1].Transactional {
+		t.Fatalf("unexpected categories: %+v", categories)
+	}
+
+	mock.ExpectQuery("SELECT name, description, transactional, updated_at").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+	if _, err := repo.FindCategory(context.Background(), "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestPreferenceRepositoryPreferences(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPreferenceRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO notification_preferences").
+		WithArgs(uint64(7), "marketing", entity.ChannelEmail, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO notification_preferences").
+		WithArgs(uint64(7), "marketing", entity.ChannelInApp, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.UpsertPreferences(context.Background(), []entity.NotificationPreference{
+		{UserID: 7, Category: "marketing", Channel: entity.ChannelEmail, Enabled: false},
+		{UserID: 7, Category: "marketing", Channel: entity.ChannelInApp, Enabled: true},
+	}); err != nil {
+		t.Fatalf("UpsertPreferences: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT user_id, category, channel, enabled").
+		WithArgs(uint64(7), "marketing", entity.ChannelEmail).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "category", "channel", "enabled"}).
+			AddRow(uint64(7), "marketing", entity.ChannelEmail, false))
+	preference, err := repo.FindPreference(context.Background(), 7, "marketing", entity.ChannelEmail)
+	if err != nil {
+		t.Fatalf("FindPreference: %v", err)
+	}
+	if preference.Enabled {
+		t.Fatalf("expected preference to be disabled")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
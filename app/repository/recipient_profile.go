@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+type RecipientProfileRepository struct {
+	db *sql.DB
+}
+
+// NewRecipientProfileRepository constructs a repository backed by MySQL.
+func NewRecipientProfileRepository(db *sql.DB) *RecipientProfileRepository {
+	return &RecipientProfileRepository{db: db}
+}
+
+// Upsert creates or replaces the profile of a user and fills its update time.
+func (r *RecipientProfileRepository) Upsert(ctx context.Context, profile *entity.RecipientProfile) error {
+	const query = `
+		INSERT INTO recipient_profiles (user_id, email, phone, locale, timezone, device_tokens, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			email = VALUES(email),
+			phone = VALUES(phone),
+			locale = VALUES(locale),
+			timezone = VALUES(timezone),
+			device_tokens = VALUES(device_tokens),
+			updated_at = VALUES(updated_at)
+	`
+	tokens := profile.DeviceTokens
+	if tokens == nil {
+		tokens = []string{}
+	}
+	deviceTokens, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	if _, err := r.db.ExecContext(ctx, query,
+		profile.UserID,
+		profile.Email,
+		profile.Phone,
+		profile.Locale,
+		profile.Timezone,
+		string(deviceTokens),
+		updatedAt,
+	); err != nil {
+		return err
+	}
+	profile.UpdatedAt = updatedAt
+	return nil
+}
+
+// FindByUserID loads a profile; it returns sql.ErrNoRows when the user has none.
+func (r *RecipientProfileRepository) FindByUserID(ctx context.Context, userID uint64) (*entity.RecipientProfile, error) {
+	const query = `
+		SELECT user_id, email, phone, locale, timezone, device_tokens, updated_at
+		FROM recipient_profiles
+		WHERE user_id = ?
+	`
+	var (
+		profile      entity.RecipientProfile
+		deviceTokens string
+	)
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&profile.UserID,
+		&profile.Email,
+		&profile.Phone,
+		&profile.Locale,
+		&profile.Timezone,
+		&deviceTokens,
+		&profile.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if deviceTokens != "" {
+		if err := json.Unmarshal([]byte(deviceTokens), &profile.DeviceTokens); err != nil {
+			return nil, err
+		}
+	}
+	return &profile, nil
+}
+
+// Delete removes a profile; it returns sql.ErrNoRows when the user has none.
+func (r *RecipientProfileRepository) Delete(ctx context.Context, userID uint64) error {
+	const query = `
+		DELETE FROM recipient_profiles
+		WHERE user_id = ?
+	`
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListUserIDsWithEmail pages through users whose profile has an email address,
+// returning up to limit IDs greater than afterUserID in ascending order.
+func (r *RecipientProfileRepository) ListUserIDsWithEmail(ctx context.Context, afterUserID uint64, limit int) ([]uint64, error) {
+	const query = `
+		SELECT user_id
+		FROM recipient_profiles
+		WHERE user_id > ? AND email <> ''
+		ORDER BY user_id ASC
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, afterUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uint64
+	for rows.Next() {
+		var userID uint64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
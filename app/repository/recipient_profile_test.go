@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+func TestRecipientProfileRepositoryUpsert(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO recipient_profiles").
+		WithArgs(uint64(7), "a@b.com", "+40700000000", "ro-RO", "Europe/Bucharest", `["tok-1"]`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	profile := &entity.RecipientProfile{
+		UserID:       7,
+		Email:        "a@b.com",
+		Phone:        "+40700000000",
+		Locale:       "ro-RO",
+		Timezone:     "Europe/Bucharest",
+		DeviceTokens: []string{"tok-1"},
+	}
+	if err := NewRecipientProfileRepository(db).Upsert(context.Background(), profile); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if profile.UpdatedAt.IsZero() {
+		t.Fatalf("expected UpdatedAt to be set")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestRecipientProfileRepositoryFindByUserID(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery("SELECT user_id, email, phone, locale, timezone, device_tokens, updated_at").
+		WithArgs(uint64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "email", "phone", "locale", "timezone", "device_tokens", "updated_at"}).
+			AddRow(uint64(7), "a@b.com", "", "en", "UTC", `["tok-1","tok-2"]`, updatedAt))
+
+	profile, err := NewRecipientProfileRepository(db).FindByUserID(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("FindByUserID: %v", err)
+	}
+	if profile.Email != "a@b.com" || len(profile.DeviceTokens) != 2 || !profile.UpdatedAt.Equal(updatedAt) {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestRecipientProfileRepositoryDeleteMissing(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM recipient_profiles").
+		WithArgs(uint64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := NewRecipientProfileRepository(db).Delete(context.Background(), 7); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+const scheduleColumns = `id, name, cron_expr, timezone, category, priority, subject, content, recipients, enabled, last_run_at, next_run_at, updated_at`
+
+type EmailScheduleRepository struct {
+	db *sql.DB
+}
+
+// NewEmailScheduleRepository constructs a repository backed by MySQL.
+func NewEmailScheduleRepository(db *sql.DB) *EmailScheduleRepository {
+	return &EmailScheduleRepository{db: db}
+}
+
+// Upsert creates or replaces a schedule by name and fills its update time.
+func (r *EmailScheduleRepository) Upsert(ctx context.Context, schedule *entity.EmailSchedule) error {
+	const query = `
+		INSERT INTO email_schedules (name, cron_expr, timezone, category, priority, subject, content, recipients, enabled, next_run_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			cron_expr = VALUES(cron_expr),
+			timezone = VALUES(timezone),
+			category = VALUES(category),
+			priority = VALUES(priority),
+			subject = VALUES(subject),
+			content = VALUES(content),
+			recipients = VALUES(recipients),
+			enabled = VALUES(enabled),
+			next_run_at = VALUES(next_run_at),
+			updated_at = VALUES(updated_at)
+	`
+	recipients, err := json.Marshal(schedule.Recipients)
+	if err != nil {
+		return err
+	}
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	if _, err := r.db.ExecContext(ctx, query,
+		schedule.Name,
+		schedule.CronExpr,
+		schedule.Timezone,
+		schedule.Category,
+		schedule.Priority,
+		schedule.Subject,
+		schedule.Content,
+		string(recipients),
+		schedule.Enabled,
+		schedule.NextRunAt.UTC(),
+		updatedAt,
+	); err != nil {
+		return err
+	}
+	schedule.UpdatedAt = updatedAt
+	return nil
+}
+
+// FindByName loads a schedule; it returns sql.ErrNoRows when missing.
+func (r *EmailScheduleRepository) FindByName(ctx context.Context, name string) (*entity.EmailSchedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM email_schedules WHERE name = ?`
+	schedule, err := scanSchedule(r.db.QueryRowContext(ctx, query, name))
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// List returns all schedules ordered by name.
+func (r *EmailScheduleRepository) List(ctx context.Context) ([]entity.EmailSchedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM email_schedules ORDER BY name ASC`
+	return r.query(ctx, query)
+}
+
+// ListDue returns enabled schedules whose next run is at or before now, oldest first.
+func (r *EmailScheduleRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]entity.EmailSchedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM email_schedules WHERE enabled = 1 AND next_run_at <= ? ORDER BY next_run_at ASC LIMIT ?`
+	return r.query(ctx, query, now.UTC(), limit)
+}
+
+// DeleteByName removes a schedule; it returns sql.ErrNoRows when nothing was deleted.
+func (r *EmailScheduleRepository) DeleteByName(ctx context.Context, name string) error {
+	const query = `
+		DELETE FROM email_schedules
+		WHERE name = ?
+	`
+	res, err := r.db.ExecContext(ctx, query, name)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Advance records a fired tick and moves the schedule to its next run. It only applies
+// when next_run_at still equals the fired tick, so a tick is claimed exactly once.
+func (r *EmailScheduleRepository) Advance(ctx context.Context, id uint64, tick time.Time, next time.Time) (bool, error) {
+	const query = `
+		UPDATE email_schedules
+		SET last_run_at = ?, next_run_at = ?
+		WHERE id = ? AND next_run_at = ?
+	`
+	res, err := r.db.ExecContext(ctx, query, tick.UTC(), next.UTC(), id, tick.UTC())
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// query runs a schedule select and scans all rows.
+func (r *EmailScheduleRepository) query(ctx context.Context, query string, args ...any) ([]entity.EmailSchedule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []entity.EmailSchedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// scanSchedule maps a row selected with scheduleColumns.
+func scanSchedule(row interface{ Scan(dest ...any) error }) (*entity.EmailSchedule, error) {
+	var (
+		s          entity.EmailSchedule
+		recipients string
+		lastRunAt  sql.NullTime
+	)
+	if err := row.Scan(
+		&s.ID, &s.Name, &s.CronExpr, &s.Timezone, &s.Category, &s.Priority, &s.Subject, &s.Content,
+		&recipients, &s.Enabled, &lastRunAt, &s.NextRunAt, &s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(recipients), &s.Recipients); err != nil {
+		return nil, err
+	}
+	if lastRunAt.Valid {
+		s.LastRunAt = lastRunAt.Time
+	}
+	return &s, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+)
+
+var emailScheduleColumns = []string{
+	"id", "name", "cron_expr", "timezone", "category", "priority", "subject", "content",
+	"recipients", "enabled", "last_run_at", "next_run_at", "updated_at",
+}
+
+func TestEmailScheduleRepositoryUpsertAndFind(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailScheduleRepository(db)
+	next := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO email_schedules").
+		WithArgs("daily", "@daily", "UTC", "", entity.PriorityNormal, "Daily digest", "What happened today.",
+			`{"user_ids":[7],"all_profiles":true}`, true, next, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	schedule := &entity.EmailSchedule{
+		Name:       "daily",
+		CronExpr:   "@daily",
+		Timezone:   "UTC",
+		Priority:   entity.PriorityNormal,
+		Subject:    "Daily digest",
+		Content:    "What happened today.",
+		Recipients: entity.ScheduleRecipients{UserIDs: []uint64{7}, AllProfiles: true},
+		Enabled:    true,
+		NextRunAt:  next,
+	}
+	if err := repo.Upsert(context.Background(), schedule); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if schedule.UpdatedAt.IsZero() {
+		t.Fatalf("expected UpdatedAt to be set")
+	}
+
+	mock.ExpectQuery("FROM email_schedules WHERE name").WithArgs("daily").
+		WillReturnRows(sqlmock.NewRows(emailScheduleColumns).AddRow(
+			uint64(1), "daily", "@daily", "UTC", "", entity.PriorityNormal, "Daily digest", "What happened today.",
+			`{"user_ids":[7],"all_profiles":true}`, true, nil, next, time.Now()))
+	found, err := repo.FindByName(context.Background(), "daily")
+	if err != nil {
+		t.Fatalf("FindByName: %v", err)
+	}
+	if found.ID != 1 || !found.Recipients.AllProfiles || found.Recipients.UserIDs[0] != 7 || !found.LastRunAt.IsZero() {
+		t.Fatalf("unexpected schedule: %+v", found)
+	}
+
+	mock.ExpectQuery("FROM email_schedules WHERE name").WithArgs("missing").WillReturnError(sql.ErrNoRows)
+	if _, err := repo.FindByName(context.Background(), "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailScheduleRepositoryAdvanceAndDelete(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailScheduleRepository(db)
+	tick := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	next := tick.Add(24 * time.Hour)
+
+	mock.ExpectExec("UPDATE email_schedules").WithArgs(tick, next, uint64(1), tick).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	advanced, err := repo.Advance(context.Background(), 1, tick, next)
+	if err != nil || !advanced {
+		t.Fatalf("expected advance, got %v %v", advanced, err)
+	}
+
+	mock.ExpectExec("UPDATE email_schedules").WithArgs(tick, next, uint64(1), tick).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	advanced, err = repo.Advance(context.Background(), 1, tick, next)
+	if err != nil || advanced {
+		t.Fatalf("expected tick already claimed, got %v %v", advanced, err)
+	}
+
+	mock.ExpectExec("DELETE FROM email_schedules").WithArgs("daily").WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := repo.DeleteByName(context.Background(), "daily"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/lock"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+const (
+	// LeaderLockKey is held by the replica that evaluates schedules during a tick.
+	LeaderLockKey = "notifications:scheduler:leader"
+
+	dueBatchSize     = 50
+	profileBatchSize = 500
+)
+
+// Scheduler fires recurring email schedules. Replicas elect a leader per tick through
+// the locker; each fired tick enqueues emails under request IDs derived from the
+// schedule, tick, and recipient, so a tick re-run after a crash or a lost leader lock
+// never enqueues a recipient twice. The tick is then claimed with a conditional update.
+type Scheduler struct {
+	schedules    *repository.EmailScheduleRepository
+	profiles     *repository.RecipientProfileRepository
+	emailService *service.EmailService
+	locker       lock.Locker
+	interval     time.Duration
+}
+
+// New constructs a scheduler that checks for due schedules every interval.
+func New(
+	schedules *repository.EmailScheduleRepository,
+	profiles *repository.RecipientProfileRepository,
+	emailService *service.EmailService,
+	locker lock.Locker,
+	interval time.Duration,
+) *Scheduler {
+	return &Scheduler{
+		schedules:    schedules,
+		profiles:     profiles,
+		emailService: emailService,
+		locker:       locker,
+		interval:     interval,
+	}
+}
+
+// Run evaluates schedules every interval until the context is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	logrus.WithField("interval", s.interval.String()).Info("Scheduler started")
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Scheduler shutting down")
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Tick(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Warn("Scheduler tick failed")
+		}
+	}
+}
+
+// Tick fires every schedule due at now if this replica wins leadership for the tick.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) error {
+	if err := s.locker.Acquire(ctx, LeaderLockKey, s.leaderTTL()); err != nil {
+		if errors.Is(err, lock.ErrNotAcquired) || errors.Is(err, lock.ErrAlreadyHeld) {
+			return nil
+		}
+		return fmt.Errorf("acquire leader lock: %w", err)
+	}
+	defer func() {
+		_ = s.locker.Release(context.Background(), LeaderLockKey)
+	}()
+
+	due, err := s.schedules.ListDue(ctx, now, dueBatchSize)
+	if err != nil {
+		return fmt.Errorf("list due schedules: %w", err)
+	}
+	for _, schedule := range due {
+		if err := s.fire(ctx, schedule, now); err != nil {
+			logrus.WithError(err).WithField("schedule", schedule.Name).Warn("Failed to fire schedule")
+		}
+	}
+	return nil
+}
+
+// leaderTTL keeps leadership for a few intervals so a slow tick is not run twice concurrently.
+func (s *Scheduler) leaderTTL() time.Duration {
+	return 5 * s.interval
+}
+
+// fire enqueues the schedule's emails for its pending tick and advances it. Missed
+// ticks are not replayed: the next run is computed from now.
+func (s *Scheduler) fire(ctx context.Context, schedule entity.EmailSchedule, now time.Time) error {
+	tick := schedule.NextRunAt
+	next, err := service.NextScheduleRun(schedule.CronExpr, schedule.Timezone, now)
+	if err != nil {
+		return err
+	}
+
+	enqueued := 0
+	err = s.forEachRecipient(ctx, schedule.Recipients, func(email service.RawEmail) error {
+		ok, err := s.enqueue(ctx, schedule, tick, email)
+		if ok {
+			enqueued++
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	advanced, err := s.schedules.Advance(ctx, schedule.ID, tick, next)
+	if err != nil {
+		return fmt.Errorf("advance schedule: %w", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"schedule": schedule.Name,
+		"tick":     tick.UTC().Format(time.RFC3339),
+		"next":     next.Format(time.RFC3339),
+		"enqueued": enqueued,
+		"advanced": advanced,
+	}).Info("Schedule fired")
+	return nil
+}
+
+// enqueue records and queues one email and reports whether it was newly enqueued.
+func (s *Scheduler) enqueue(ctx context.Context, schedule entity.EmailSchedule, tick time.Time, email service.RawEmail) (bool, error) {
+	requestID := tickRequestID(schedule.ID, tick, email)
+	email.Category = schedule.Category
+	email.Priority = schedule.Priority
+	email.Subject = schedule.Subject
+	email.Content = schedule.Content
+
+	if err := s.emailService.CreateRequest(ctx, requestID, email); err != nil {
+		if errors.Is(err, service.ErrDuplicateRequestID) {
+			return false, nil
+		}
+		return false, fmt.Errorf("create email request: %w", err)
+	}
+	return true, nil
+}
+
+// forEachRecipient calls fn for every recipient selected by the schedule.
+func (s *Scheduler) forEachRecipient(ctx context.Context, recipients entity.ScheduleRecipients, fn func(service.RawEmail) error) error {
+	for _, userID := range recipients.UserIDs {
+		if err := fn(service.RawEmail{UserID: userID}); err != nil {
+			return err
+		}
+	}
+	for _, address := range recipients.Emails {
+		if err := fn(service.RawEmail{Recipient: address}); err != nil {
+			return err
+		}
+	}
+	if !recipients.AllProfiles {
+		return nil
+	}
+
+	var after uint64
+	for {
+		userIDs, err := s.profiles.ListUserIDsWithEmail(ctx, after, profileBatchSize)
+		if err != nil {
+			return fmt.Errorf("list recipient profiles: %w", err)
+		}
+		for _, userID := range userIDs {
+			if err := fn(service.RawEmail{UserID: userID}); err != nil {
+				return err
+			}
+		}
+		if len(userIDs) < profileBatchSize {
+			return nil
+		}
+		after = userIDs[len(userIDs)-1]
+	}
+}
+
+// tickRequestID derives a stable request ID for one recipient of one tick. Users are
+// keyed by ID, so a user listed explicitly and through all_profiles gets one email.
+func tickRequestID(scheduleID uint64, tick time.Time, email service.RawEmail) string {
+	recipient := fmt.Sprintf("u%d", email.UserID)
+	if email.UserID == 0 {
+		sum := sha256.Sum256([]byte(email.Recipient))
+		recipient = "e" + hex.EncodeToString(sum[:8])
+	}
+	return fmt.Sprintf("sched-%d-%d-%s", scheduleID, tick.Unix(), recipient)
+}
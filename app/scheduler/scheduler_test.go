@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/lock"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+)
+
+type stubLocker struct {
+	err error
+}
+
+func (l stubLocker) Acquire(_ context.Context, _ string, _ time.Duration) error { return l.err }
+func (l stubLocker) Release(_ context.Context, _ string) error                  { return nil }
+
+var scheduleColumns = []string{
+	"id", "name", "cron_expr", "timezone", "category", "priority", "subject", "content",
+	"recipients", "enabled", "last_run_at", "next_run_at", "updated_at",
+}
+
+func newTestScheduler(t *testing.T, locker lock.Locker) (*Scheduler, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	emailService := service.NewEmailService(nil, nil, repository.NewEmailHistoryRepository(db), nil, nil, nil, locker)
+	s := New(
+		repository.NewEmailScheduleRepository(db),
+		repository.NewRecipientProfileRepository(db),
+		emailService,
+		locker,
+		time.Second,
+	)
+	return s, mock
+}
+
+func TestSchedulerTickFiresDueSchedule(t *testing.T) {
+	t.Parallel()
+
+	s, mock := newTestScheduler(t, stubLocker{})
+
+	tick := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	now := tick.Add(5 * time.Second)
+	mock.ExpectQuery("FROM email_schedules WHERE enabled = 1").
+		WillReturnRows(sqlmock.NewRows(scheduleColumns).AddRow(
+			uint64(3), "daily", "0 9 * * *", "UTC", "digest", entity.PriorityLow, "Daily digest", "What happened today.",
+			`{"user_ids":[7],"emails":["a@b.com"]}`, true, nil, tick, tick))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("sched-3-1894006800-u7", uint64(7), "", "digest", entity.PriorityLow, "Daily digest", "What happened today.", entity.EmailStatusNew, nil, "", "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs("sched-3-1894006800-u7", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs(sqlmock.AnyArg(), uint64(0), "a@b.com", "digest", entity.PriorityLow, "Daily digest", "What happened today.", entity.EmailStatusNew, nil, "", "", "").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").
+		WithArgs(sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE email_schedules").
+		WithArgs(tick, tick.Add(24*time.Hour), uint64(3), tick).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := s.Tick(context.Background(), now); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestSchedulerTickSkipsAlreadyEnqueuedRecipients(t *testing.T) {
+	t.Parallel()
+
+	s, mock := newTestScheduler(t, stubLocker{})
+
+	tick := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM email_schedules WHERE enabled = 1").
+		WillReturnRows(sqlmock.NewRows(scheduleColumns).AddRow(
+			uint64(3), "daily", "0 9 * * *", "UTC", "", entity.PriorityNormal, "Daily digest", "What happened today.",
+			`{"user_ids":[7]}`, true, nil, tick, tick))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO email_history").WillReturnError(&mysql.MySQLError{Number: 1062})
+	mock.ExpectRollback()
+	mock.ExpectExec("UPDATE email_schedules").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := s.Tick(context.Background(), tick); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestSchedulerTickSkipsWhenNotLeader(t *testing.T) {
+	t.Parallel()
+
+	s, mock := newTestScheduler(t, stubLocker{err: lock.ErrNotAcquired})
+
+	if err := s.Tick(context.Background(), time.Now()); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+const (
+	// bulkChunkSize bounds the records written by one multi-row insert.
+	bulkChunkSize = 500
+	// maxSubjectLength is the size of the email history subject column.
+	maxSubjectLength = 255
+)
+
+// BulkEmail is the part of a bulk send shared by all recipients. Subject and Content are
+// templates rendered per recipient with its variables, Content as HTML with escaped values.
+type BulkEmail struct {
+	BatchID  string
+	Category string
+	Priority string
+	Subject  string
+	Content  string
+	SendAt   time.Time
+	Tenant   string
+	Template string
+}
+
+// BulkRecipient is one recipient of a bulk send. Index is its position in the whole send;
+// an empty RequestID defaults to "<batch_id>:<index>". A recipient with an Invalid error
+// failed validation and is rejected with it.
+type BulkRecipient struct {
+	Index     int
+	RequestID string
+	Recipient string
+	UserID    uint64
+	Timezone  string
+	Variables map[string]string
+	Invalid   error
+}
+
+type BulkEmailService struct {
+	history *repository.EmailHistoryRepository
+	batches *repository.EmailBatchRepository
+}
+
+// NewBulkEmailService builds the bulk email service with dependencies.
+func NewBulkEmailService(history *repository.EmailHistoryRepository, batches *repository.EmailBatchRepository) *BulkEmailService {
+	return &BulkEmailService{history: history, batches: batches}
+}
+
+// BulkSend adds recipients to one started batch. It is not safe for concurrent use.
+type BulkSend struct {
+	history *repository.EmailHistoryRepository
+	email   BulkEmail
+	subject *texttemplate.Template
+	content *htmltemplate.Template
+	seen    map[string]struct{}
+}
+
+// Start parses the batch templates and records the batch. Starting a batch that already
+// exists resumes it: recipients already accepted into it are reported as accepted again
+// and not stored twice. A cancelled batch takes no more recipients; a paused one does,
+// and holds them until it is resumed.
+func (s *BulkEmailService) Start(ctx context.Context, email BulkEmail) (*BulkSend, error) {
+	subject, err := texttemplate.New("subject").Option("missingkey=error").Parse(email.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject: %v", ErrInvalidTemplate, err)
+	}
+	content, err := htmltemplate.New("content").Option("missingkey=error").Parse(email.Content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: content: %v", ErrInvalidTemplate, err)
+	}
+	if err := s.batches.Create(ctx, email.BatchID); err != nil {
+		return nil, err
+	}
+	status, err := s.batches.FindStatus(ctx, email.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	if status == entity.EmailBatchStatusCancelled {
+		return nil, ErrBatchCancelled
+	}
+	return &BulkSend{
+		history: s.history,
+		email:   email,
+		subject: subject,
+		content: content,
+		seen:    make(map[string]struct{}),
+	}, nil
+}
+
+// Add renders the recipients' emails and stores them with their outbox entries in chunks,
+// and returns one result per recipient in order. Recipients that are invalid, repeat a
+// request ID, or lack a template variable are rejected; the others are queued by the
+// outbox relay. On error, the chunks written before stay stored.
+func (b *BulkSend) Add(ctx context.Context, recipients []BulkRecipient) ([]entity.BulkEmailResult, error) {
+	results := make([]entity.BulkEmailResult, 0, len(recipients))
+	for start := 0; start < len(recipients); start += bulkChunkSize {
+		end := min(start+bulkChunkSize, len(recipients))
+		chunk, err := b.addChunk(ctx, recipients[start:end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, chunk...)
+	}
+	return results, nil
+}
+
+// addChunk stores one chunk of recipients with a single transaction.
+func (b *BulkSend) addChunk(ctx context.Context, recipients []BulkRecipient) ([]entity.BulkEmailResult, error) {
+	status := entity.EmailStatusNew
+	if b.email.SendAt.After(time.Now()) {
+		status = entity.EmailStatusScheduled
+	}
+
+	results := make([]entity.BulkEmailResult, len(recipients))
+	var positions []int
+	var histories []entity.EmailHistory
+	var timezones []string
+	for i, recipient := range recipients {
+		requestID := recipient.RequestID
+		if requestID == "" {
+			requestID = fmt.Sprintf("%s:%d", b.email.BatchID, recipient.Index)
+		}
+		results[i] = entity.BulkEmailResult{Index: recipient.Index, RequestID: requestID}
+		if recipient.Invalid != nil {
+			results[i].Error = recipient.Invalid.Error()
+			continue
+		}
+		if _, seen := b.seen[requestID]; seen {
+			results[i].Error = ErrDuplicateRequestID.Error()
+			continue
+		}
+		b.seen[requestID] = struct{}{}
+
+		subject, content, err := b.render(recipient.Variables)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		positions = append(positions, i)
+		histories = append(histories, entity.EmailHistory{
+			RequestID: requestID,
+			UserID:    recipient.UserID,
+			Recipient: recipient.Recipient,
+			Category:  b.email.Category,
+			Priority:  b.email.Priority,
+			Subject:   subject,
+			Content:   content,
+			Status:    status,
+			SendAt:    b.email.SendAt,
+			Tenant:    b.email.Tenant,
+			Template:  b.email.Template,
+		})
+		timezones = append(timezones, recipient.Timezone)
+	}
+	if len(histories) == 0 {
+		return results, nil
+	}
+
+	accepted, err := b.history.CreateBatchQueued(ctx, b.email.BatchID, histories, timezones)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range positions {
+		if accepted[j] {
+			results[i].Accepted = true
+		} else {
+			results[i].Error = ErrDuplicateRequestID.Error()
+		}
+	}
+	return results, nil
+}
+
+// render executes the batch templates with one recipient's variables.
+func (b *BulkSend) render(variables map[string]string) (string, string, error) {
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	var subject bytes.Buffer
+	if err := b.subject.Execute(&subject, variables); err != nil {
+		return "", "", fmt.Errorf("render subject: %w", err)
+	}
+	if subject.Len() > maxSubjectLength {
+		return "", "", fmt.Errorf("rendered subject must be at most %d characters", maxSubjectLength)
+	}
+	var content bytes.Buffer
+	if err := b.content.Execute(&content, variables); err != nil {
+		return "", "", fmt.Errorf("render content: %w", err)
+	}
+	return subject.String(), content.String(), nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+func TestBulkEmailServiceStartInvalidTemplate(t *testing.T) {
+	t.Parallel()
+
+	svc := NewBulkEmailService(nil, nil)
+	_, err := svc.Start(context.Background(), BulkEmail{BatchID: "b", Subject: "Hi {{.name", Content: "long enough"})
+	if !errors.Is(err, ErrInvalidTemplate) {
+		t.Fatalf("expected ErrInvalidTemplate, got %v", err)
+	}
+}
+
+func TestBulkEmailServiceAdd(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	svc := NewBulkEmailService(repository.NewEmailHistoryRepository(db), repository.NewEmailBatchRepository(db))
+
+	mock.ExpectExec("INSERT INTO email_batches").WithArgs("b").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT status").WithArgs("b").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailBatchStatusActive))
+	send, err := svc.Start(context.Background(), BulkEmail{
+		BatchID:  "b",
+		Priority: entity.PriorityNormal,
+		Subject:  "Hi {{.name}}",
+		Content:  "<p>{{.name}}, welcome</p>",
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT request_id, batch_id").
+		WithArgs("b:0", "taken").
+		WillReturnRows(sqlmock.NewRows([]string{"request_id", "batch_id"}).AddRow("taken", ""))
+	mock.ExpectExec("INSERT INTO email_history").
+		WithArgs("b:0", uint64(0), "a@b.com", "", entity.PriorityNormal, "Hi <Ana>", "<p>&lt;Ana&gt;, welcome</p>", entity.EmailStatusNew, nil, "b", "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO email_outbox").WithArgs("b:0", "").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	results, err := send.Add(context.Background(), []BulkRecipient{
+		{Index: 0, Recipient: "a@b.com", Variables: map[string]string{"name": "<Ana>"}},
+		{Index: 1, RequestID: "taken", UserID: 7, Variables: map[string]string{"name": "Ion"}},
+		{Index: 2, RequestID: "b:0", UserID: 8, Variables: map[string]string{"name": "Eva"}},
+		{Index: 3, UserID: 9},
+		{Index: 4, Recipient: "bad", Invalid: errors.New("recipient must be a valid email address")},
+	})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	if !results[0].Accepted || results[0].RequestID != "b:0" {
+		t.Fatalf("expected first recipient accepted, got %+v", results[0])
+	}
+	if results[1].Accepted || results[1].Error != ErrDuplicateRequestID.Error() {
+		t.Fatalf("expected request id taken outside the batch to be rejected, got %+v", results[1])
+	}
+	if results[2].Accepted || results[2].Error != ErrDuplicateRequestID.Error() {
+		t.Fatalf("expected repeated request id to be rejected, got %+v", results[2])
+	}
+	if results[3].Accepted || !strings.Contains(results[3].Error, "render subject") || results[3].RequestID != "b:3" {
+		t.Fatalf("expected missing variable to be rejected, got %+v", results[3])
+	}
+	if results[4].Accepted || results[4].Error != "recipient must be a valid email address" {
+		t.Fatalf("expected invalid recipient to be rejected, got %+v", results[4])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
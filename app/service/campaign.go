@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+// CampaignService reports the progress of bulk send batches and pauses, resumes, or
+// cancels them. Consumers check a batch's status before sending each of its emails.
+type CampaignService struct {
+	batches *repository.EmailBatchRepository
+}
+
+// NewCampaignService builds the campaign service with dependencies.
+func NewCampaignService(batches *repository.EmailBatchRepository) *CampaignService {
+	return &CampaignService{batches: batches}
+}
+
+// Get returns a batch with its emails counted by status.
+func (s *CampaignService) Get(ctx context.Context, batchID string) (*entity.EmailBatch, error) {
+	batch, err := s.batches.FindByBatchID(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBatchNotFound
+		}
+		return nil, err
+	}
+	return batch, nil
+}
+
+// Status returns the status of a batch.
+func (s *CampaignService) Status(ctx context.Context, batchID string) (string, error) {
+	status, err := s.batches.FindStatus(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrBatchNotFound
+		}
+		return "", err
+	}
+	return status, nil
+}
+
+// Pause holds the batch's emails that have not been sent yet until it is resumed.
+// Pausing a paused batch does nothing.
+func (s *CampaignService) Pause(ctx context.Context, batchID string) (*entity.EmailBatch, error) {
+	return s.transition(ctx, batchID, entity.EmailBatchStatusActive, entity.EmailBatchStatusPaused)
+}
+
+// Resume lets a paused batch's emails be sent again. Resuming an active batch does nothing.
+func (s *CampaignService) Resume(ctx context.Context, batchID string) (*entity.EmailBatch, error) {
+	return s.transition(ctx, batchID, entity.EmailBatchStatusPaused, entity.EmailBatchStatusActive)
+}
+
+// Cancel cancels the batch and its emails that have not started sending yet, and returns
+// the batch with how many emails this call cancelled. Emails that are being sent
+// meanwhile still go out. Cancelling a cancelled batch cancels nothing more.
+func (s *CampaignService) Cancel(ctx context.Context, batchID string) (*entity.EmailBatch, int64, error) {
+	cancelled, err := s.batches.Cancel(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, ErrBatchNotFound
+		}
+		return nil, 0, err
+	}
+	batch, err := s.Get(ctx, batchID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return batch, cancelled, nil
+}
+
+// transition moves a batch from one status to another and returns it. A batch already in
+// the target status is returned unchanged; a cancelled batch cannot change.
+func (s *CampaignService) transition(ctx context.Context, batchID string, from string, to string) (*entity.EmailBatch, error) {
+	if err := s.batches.UpdateStatus(ctx, batchID, from, to); err != nil {
+		return nil, err
+	}
+	batch, err := s.Get(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != to {
+		return nil, ErrBatchCancelled
+	}
+	return batch, nil
+}
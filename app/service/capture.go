@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
+)
+
+// CaptureService reads the emails kept by the capture provider.
+type CaptureService struct {
+	store provider.CaptureStore
+}
+
+// NewCaptureService builds the capture service with dependencies.
+func NewCaptureService(store provider.CaptureStore) *CaptureService {
+	return &CaptureService{store: store}
+}
+
+// List returns the captured emails, newest first, optionally only those sent to recipient.
+func (s *CaptureService) List(recipient string) ([]provider.CapturedEmail, error) {
+	emails, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make([]provider.CapturedEmail, 0, len(emails))
+	for i := len(emails) - 1; i >= 0; i-- {
+		if recipient == "" || strings.EqualFold(emails[i].Recipient, recipient) {
+			listed = append(listed, emails[i])
+		}
+	}
+	return listed, nil
+}
+
+// Get returns the captured email with the given ID.
+func (s *CaptureService) Get(id string) (*provider.CapturedEmail, error) {
+	email, err := s.store.Get(id)
+	if err != nil {
+		if errors.Is(err, provider.ErrCapturedEmailNotFound) {
+			return nil, ErrCapturedEmailNotFound
+		}
+		return nil, err
+	}
+	return &email, nil
+}
+
+// Clear removes every captured email.
+func (s *CaptureService) Clear() error {
+	return s.store.Clear()
+}
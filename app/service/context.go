@@ -18,3 +18,16 @@ func RequestIDFromContext(ctx context.Context) (string, bool) {
 	requestID, ok := value.(string)
 	return requestID, ok
 }
+
+type workerKey struct{}
+
+// WithWorker stores the name of the worker processing emails in the context.
+func WithWorker(ctx context.Context, worker string) context.Context {
+	return context.WithValue(ctx, workerKey{}, worker)
+}
+
+// WorkerFromContext extracts the worker name from the context.
+func WorkerFromContext(ctx context.Context) (string, bool) {
+	worker, ok := ctx.Value(workerKey{}).(string)
+	return worker, ok
+}
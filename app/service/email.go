@@ -1,9 +1,13 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/mail"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
@@ -15,21 +19,98 @@ import (
 	"github.com/vibast-solutions/ms-go-notifications/app/repository"
 )
 
+// RawEmail is an email send request. When UserID is set, the recipient address is
+// resolved from the user's profile at send time and Recipient is only a fallback.
+// Category subjects the email to the user's notification preferences. Priority and
+// Timezone drive quiet-hours deferral in the consumer; only the outbox keeps Timezone.
+// A future SendAt records the request as scheduled. A DigestKey holds the email back so
+// it is sent as an item of the recipient's next digest instead of on its own. Tenant
+// names the tenant the email is sent for and, like Category, can select the provider
+// sending it. Template names the template the email was rendered from and is passed on
+// to the provider.
+type RawEmail struct {
+	Recipient string
+	UserID    uint64
+	Category  string
+	Priority  string
+	Timezone  string
+	Subject   string
+	Content   string
+	SendAt    time.Time
+	DigestKey string
+	Tenant    string
+	Template  string
+}
+
+// Digested reports whether the email waits for a digest and must not be queued directly.
+func (e RawEmail) Digested() bool {
+	return e.DigestKey != ""
+}
+
 type EmailService struct {
-	preparer preparer.EmailPreparer
-	provider provider.EmailProvider
-	history  *repository.EmailHistoryRepository
-	locker   lock.Locker
+	preparer    preparer.EmailPreparer
+	provider    provider.EmailProvider
+	history     *repository.EmailHistoryRepository
+	attempts    *repository.EmailAttemptRepository
+	profiles    *repository.RecipientProfileRepository
+	preferences *PreferenceService
+	locker      lock.Locker
 }
 
-// NewEmailService builds the email service with dependencies.
-func NewEmailService(preparer preparer.EmailPreparer, provider provider.EmailProvider, history *repository.EmailHistoryRepository, locker lock.Locker) *EmailService {
-	return &EmailService{preparer: preparer, provider: provider, history: history, locker: locker}
+// NewEmailService builds the email service with dependencies. A nil attempts sends
+// without recording each provider send.
+func NewEmailService(
+	preparer preparer.EmailPreparer,
+	provider provider.EmailProvider,
+	history *repository.EmailHistoryRepository,
+	attempts *repository.EmailAttemptRepository,
+	profiles *repository.RecipientProfileRepository,
+	preferences *PreferenceService,
+	locker lock.Locker,
+) *EmailService {
+	return &EmailService{
+		preparer:    preparer,
+		provider:    provider,
+		history:     history,
+		attempts:    attempts,
+		profiles:    profiles,
+		preferences: preferences,
+		locker:      locker,
+	}
 }
 
-// CreateRequest records an email send request in history.
-func (s *EmailService) CreateRequest(ctx context.Context, requestID string, recipient string, subject string, content string) error {
-	if err := s.history.Create(ctx, requestID, recipient, subject, content, entity.EmailStatusNew); err != nil {
+// CreateRequest records an email send request in history. Unless the email waits for a
+// digest, its outbox entry is written in the same transaction, so it is queued exactly
+// when it is recorded.
+func (s *EmailService) CreateRequest(ctx context.Context, requestID string, email RawEmail) error {
+	status := entity.EmailStatusNew
+	switch {
+	case email.Digested():
+		status = entity.EmailStatusDigestPending
+	case email.SendAt.After(time.Now()):
+		status = entity.EmailStatusScheduled
+	}
+	history := entity.EmailHistory{
+		RequestID: requestID,
+		UserID:    email.UserID,
+		Recipient: email.Recipient,
+		Category:  email.Category,
+		Priority:  email.Priority,
+		Subject:   email.Subject,
+		Content:   email.Content,
+		Status:    status,
+		SendAt:    email.SendAt,
+		DigestKey: email.DigestKey,
+		Tenant:    email.Tenant,
+		Template:  email.Template,
+	}
+	var err error
+	if email.Digested() {
+		err = s.history.Create(ctx, history)
+	} else {
+		err = s.history.CreateQueued(ctx, history, email.Timezone)
+	}
+	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
 			return ErrDuplicateRequestID
@@ -39,30 +120,111 @@ func (s *EmailService) CreateRequest(ctx context.Context, requestID string, reci
 	return nil
 }
 
-// DeleteRequest removes a history entry by request ID.
-func (s *EmailService) DeleteRequest(ctx context.Context, requestID string) error {
-	return s.history.DeleteByRequestID(ctx, requestID)
+// MarkDeferred records that a request is waiting for the recipient's quiet hours to end.
+// It reports false when the request was cancelled or already finished and must not be re-queued.
+func (s *EmailService) MarkDeferred(ctx context.Context, requestID string) (bool, error) {
+	return s.moveUnlessFinished(ctx, requestID, entity.EmailStatusDeferred)
+}
+
+// MarkCapDeferred records that a request is waiting for room under the recipient's frequency caps.
+// It reports false when the request was cancelled or already finished and must not be re-queued.
+func (s *EmailService) MarkCapDeferred(ctx context.Context, requestID string) (bool, error) {
+	return s.moveUnlessFinished(ctx, requestID, entity.EmailStatusCapDeferred)
+}
+
+// MarkPaused records that a request of a paused campaign is held until the campaign resumes.
+// It reports false when the request was cancelled or already finished and must not be re-queued.
+func (s *EmailService) MarkPaused(ctx context.Context, requestID string) (bool, error) {
+	return s.moveUnlessFinished(ctx, requestID, entity.EmailStatusPaused)
+}
+
+// MarkCapped records that a request was dropped because the recipient reached a frequency cap.
+func (s *EmailService) MarkCapped(ctx context.Context, requestID string) error {
+	_, err := s.moveUnlessFinished(ctx, requestID, entity.EmailStatusCapped)
+	return err
+}
+
+// MarkUndeliverable records that the queue gave up on a request after its last delivery
+// failed. A request that already finished keeps its status.
+func (s *EmailService) MarkUndeliverable(ctx context.Context, requestID string) error {
+	_, err := s.moveUnlessFinished(ctx, requestID, entity.EmailStatusUnknownFailure)
+	return err
+}
+
+// moveUnlessFinished moves a request to status and reports whether it did; it reports false
+// without an error when the request already reached a terminal status.
+func (s *EmailService) moveUnlessFinished(ctx context.Context, requestID string, status int16) (bool, error) {
+	err := s.history.UpdateStatus(ctx, requestID, status)
+	if _, finished := finishedStatus(err); finished {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// finishedStatus returns the status of a request whose status update failed because it
+// already reached a terminal status.
+func finishedStatus(err error) (int16, bool) {
+	var conflict *repository.StatusConflictError
+	if errors.As(err, &conflict) && entity.IsTerminalEmailStatus(conflict.Current) {
+		return conflict.Current, true
+	}
+	return 0, false
 }
 
-// SendRaw prepares, sends, and updates history for a raw email request.
-func (s *EmailService) SendRaw(ctx context.Context, recipient string, subject string, content string) error {
+// Cancel stops a scheduled, deferred, paused, digest-pending, or not yet processed email. Queued messages of a
+// cancelled request are dropped by the consumer.
+func (s *EmailService) Cancel(ctx context.Context, requestID string) error {
+	cancelled, err := s.history.Cancel(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if cancelled {
+		return nil
+	}
+	if _, err := s.history.FindStatus(ctx, requestID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrEmailNotFound
+		}
+		return err
+	}
+	return ErrEmailNotCancellable
+}
+
+// SendGate decides whether an email that is about to be sent may go out now. It runs once
+// the status, preference and recipient checks passed, so it only sees emails that would
+// otherwise be sent. It reports false to hold the email back.
+type SendGate func(ctx context.Context) (bool, error)
+
+// SendRaw prepares, sends, and updates history for a raw email request. A request that
+// already reached a terminal status (sent, dropped, cancelled, or failed permanently) is
+// not sent again. Retryable send errors leave it as a temporary failure.
+func (s *EmailService) SendRaw(ctx context.Context, email RawEmail) error {
+	return s.SendRawGated(ctx, email, nil)
+}
+
+// SendRawGated is SendRaw with a gate consulted right before the email is prepared. When
+// the gate holds the email back it returns ErrEmailHeld and leaves the request processing
+// for the caller to move on. A nil gate sends like SendRaw.
+func (s *EmailService) SendRawGated(ctx context.Context, email RawEmail, gate SendGate) error {
 	requestID, ok := RequestIDFromContext(ctx)
 	if !ok || requestID == "" {
 		return fmt.Errorf("request_id is required in context")
 	}
-	if recipient == "" {
-		return fmt.Errorf("recipient is required")
+	if email.Recipient == "" && email.UserID == 0 {
+		return fmt.Errorf("recipient or user_id is required")
 	}
-	if subject == "" {
+	if email.Subject == "" {
 		return fmt.Errorf("subject is required")
 	}
-	if content == "" {
+	if email.Content == "" {
 		return fmt.Errorf("content is required")
 	}
 
 	logrus.WithFields(logrus.Fields{
 		"request_id": requestID,
-		"recipient":  recipient,
+		"recipient":  email.Recipient,
+		"user_id":    email.UserID,
+		"category":   email.Category,
 	}).Debug("Sending raw email")
 
 	lockKey := fmt.Sprintf("notifications:email:%s", requestID)
@@ -75,11 +237,64 @@ func (s *EmailService) SendRaw(ctx context.Context, recipient string, subject st
 	}()
 
 	if err := s.history.UpdateStatus(ctx, requestID, entity.EmailStatusProcessing); err != nil {
+		if status, finished := finishedStatus(err); finished {
+			logrus.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"status":     status,
+			}).Info("Email was cancelled or already finished; dropping message")
+			return nil
+		}
 		logrus.WithError(err).WithField("request_id", requestID).Warn("Failed to set status=processing")
 		return fmt.Errorf("update status to processing: %w", err)
 	}
 
-	raw, err := s.preparer.Prepare(ctx, recipient, subject, content)
+	// Preferences are checked at send time so opt-outs also apply to already queued mail.
+	if s.preferences != nil {
+		allowed, err := s.preferences.Allowed(ctx, email.UserID, email.Category, entity.ChannelEmail)
+		if err != nil {
+			logrus.WithError(err).WithField("request_id", requestID).Warn("Preference check failed")
+			return fmt.Errorf("check preferences: %w", err)
+		}
+		if !allowed {
+			if err := s.history.UpdateStatus(ctx, requestID, entity.EmailStatusSkippedByPreference); err != nil {
+				logrus.WithError(err).WithField("request_id", requestID).Warn("Failed to set status=skipped_by_preference")
+				return fmt.Errorf("update status: %w", err)
+			}
+			logrus.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    email.UserID,
+				"category":   email.Category,
+			}).Info("Email skipped by recipient preference")
+			return nil
+		}
+	}
+
+	recipient, err := s.resolveRecipient(ctx, requestID, email)
+	if err != nil {
+		logrus.WithError(err).WithField("request_id", requestID).Warn("Recipient resolution failed")
+		status := entity.EmailStatusTemporaryFailure
+		if errors.Is(err, ErrRecipientUnresolved) {
+			status = entity.EmailStatusPermanentFailure
+		}
+		if updateErr := s.history.UpdateStatus(ctx, requestID, status); updateErr != nil {
+			logrus.WithError(updateErr).WithField("request_id", requestID).Warn("Failed to update status after recipient resolution")
+			return fmt.Errorf("resolve recipient: %v; update status: %w", err, updateErr)
+		}
+		return fmt.Errorf("resolve recipient: %w", err)
+	}
+
+	if gate != nil {
+		allowed, err := gate(ctx)
+		if err != nil {
+			logrus.WithError(err).WithField("request_id", requestID).Warn("Send gate check failed")
+			return fmt.Errorf("check send gate: %w", err)
+		}
+		if !allowed {
+			return ErrEmailHeld
+		}
+	}
+
+	raw, err := s.preparer.Prepare(ctx, recipient, email.Subject, email.Content)
 	if err != nil {
 		logrus.WithError(err).WithField("request_id", requestID).Warn("Prepare failed")
 		if updateErr := s.history.UpdateStatus(ctx, requestID, entity.EmailStatusTemporaryFailure); updateErr != nil {
@@ -98,19 +313,140 @@ func (s *EmailService) SendRaw(ctx context.Context, recipient string, subject st
 		return fmt.Errorf("update email history content: %w", err)
 	}
 
-	if err := s.provider.SendRaw(ctx, recipient, raw); err != nil {
+	sendCtx := provider.WithRoute(ctx, provider.Route{
+		Sender:    fromAddress(raw),
+		Category:  email.Category,
+		Tenant:    email.Tenant,
+		Template:  email.Template,
+		RequestID: requestID,
+	})
+	startedAt := time.Now()
+	result, err := s.provider.SendRaw(sendCtx, recipient, raw)
+	s.recordAttempt(ctx, requestID, startedAt, result, err)
+	if err != nil {
 		logrus.WithError(err).WithField("request_id", requestID).Warn("SendRaw failed")
-		if updateErr := s.history.UpdateStatus(ctx, requestID, entity.EmailStatusPermanentFailure); updateErr != nil {
-			logrus.WithError(updateErr).WithField("request_id", requestID).Warn("Failed to set status=permanent_failure")
+		status := entity.EmailStatusTemporaryFailure
+		if provider.IsPermanent(err) {
+			status = entity.EmailStatusPermanentFailure
+		}
+		if updateErr := s.history.UpdateStatus(ctx, requestID, status); updateErr != nil {
+			logrus.WithError(updateErr).WithField("request_id", requestID).Warn("Failed to update status after send")
 			return fmt.Errorf("send failed: %v; update status: %w", err, updateErr)
 		}
 		return err
 	}
 
-	if err := s.history.UpdateStatus(ctx, requestID, entity.EmailStatusSuccess); err != nil {
+	// The whole result is kept so support can match the email with the provider's records.
+	response, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("encode provider response: %w", err)
+	}
+	if err := s.history.UpdateDelivered(ctx, requestID, result.Provider, result.MessageID, string(response)); err != nil {
 		logrus.WithError(err).WithField("request_id", requestID).Warn("Failed to set status=success")
 		return fmt.Errorf("update status: %w", err)
 	}
-	logrus.WithField("request_id", requestID).Debug("Send raw completed")
+	logrus.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"provider":   result.Provider,
+		"message_id": result.MessageID,
+	}).Debug("Send raw completed")
 	return nil
 }
+
+// Status returns the status of a request, or ErrEmailNotFound.
+func (s *EmailService) Status(ctx context.Context, requestID string) (int16, error) {
+	status, err := s.history.FindStatus(ctx, requestID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrEmailNotFound
+	}
+	return status, err
+}
+
+// Attempts returns the status of a request and its provider send attempts, oldest first.
+func (s *EmailService) Attempts(ctx context.Context, requestID string) (int16, []entity.EmailAttempt, error) {
+	status, err := s.history.FindStatus(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, ErrEmailNotFound
+		}
+		return 0, nil, err
+	}
+	if s.attempts == nil {
+		return status, nil, nil
+	}
+	attempts, err := s.attempts.ListByRequestID(ctx, requestID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return status, attempts, nil
+}
+
+// recordAttempt stores one provider send of a request. The attempt is stored even when
+// the send timed out, and failing to store it does not fail the send.
+func (s *EmailService) recordAttempt(ctx context.Context, requestID string, startedAt time.Time, result provider.SendResult, sendErr error) {
+	if s.attempts == nil {
+		return
+	}
+	worker, _ := WorkerFromContext(ctx)
+	attempt := entity.EmailAttempt{
+		RequestID:  requestID,
+		Worker:     worker,
+		Provider:   result.Provider,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+	}
+	if sendErr != nil {
+		attempt.Provider = provider.FailedProvider(sendErr)
+		attempt.ErrorClass = provider.ClassifyError(sendErr)
+		attempt.Error = sendErr.Error()
+	}
+	if err := s.attempts.Create(context.WithoutCancel(ctx), attempt); err != nil {
+		logrus.WithError(err).WithField("request_id", requestID).Warn("Failed to record send attempt")
+	}
+}
+
+// resolveRecipient returns the address to send to. For user-addressed requests the
+// current profile email wins over the address captured at request time, and the
+// resolved address is written back to history.
+func (s *EmailService) resolveRecipient(ctx context.Context, requestID string, email RawEmail) (string, error) {
+	if email.UserID == 0 {
+		return email.Recipient, nil
+	}
+	if s.profiles == nil {
+		return "", fmt.Errorf("recipient profiles are not configured")
+	}
+
+	recipient := email.Recipient
+	profile, err := s.profiles.FindByUserID(ctx, email.UserID)
+	switch {
+	case err == nil && profile.Email != "":
+		recipient = profile.Email
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return "", fmt.Errorf("load recipient profile: %w", err)
+	}
+	if recipient == "" {
+		return "", ErrRecipientUnresolved
+	}
+
+	if recipient != email.Recipient {
+		if err := s.history.UpdateRecipient(ctx, requestID, recipient); err != nil {
+			return "", fmt.Errorf("update email history recipient: %w", err)
+		}
+	}
+	return recipient, nil
+}
+
+// fromAddress returns the address in the From header of a prepared message, which decides
+// the sender identity providers send and route it as. It is empty when the header cannot
+// be read, and the providers then use their configured source.
+func fromAddress(raw []byte) string {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return ""
+	}
+	address, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return ""
+	}
+	return address.Address
+}
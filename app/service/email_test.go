@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"testing"
 	"time"
@@ -9,6 +11,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-sql-driver/mysql"
 	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
 	"github.com/vibast-solutions/ms-go-notifications/app/repository"
 )
 
@@ -44,11 +47,12 @@ func (p fakePreparer) Prepare(_ context.Context, _ string, _ string, _ string) (
 }
 
 type fakeProvider struct {
-	err error
+	result provider.SendResult
+	err    error
 }
 
-func (p fakeProvider) SendRaw(_ context.Context, _ string, _ []byte) error {
-	return p.err
+func (p fakeProvider) SendRaw(_ context.Context, _ string, _ []byte) (provider.SendResult, error) {
+	return p.result, p.err
 }
 
 func newRepo(t *testing.T) (*repository.EmailHistoryRepository, sqlmock.Sqlmock, func()) {
@@ -60,6 +64,24 @@ func newRepo(t *testing.T) (*repository.EmailHistoryRepository, sqlmock.Sqlmock,
 	return repository.NewEmailHistoryRepository(db), mock, func() { _ = db.Close() }
 }
 
+// statusUpdateArgs returns the arguments of the conditional update moving a request to status.
+func statusUpdateArgs(status int16, requestID string) []driver.Value {
+	args := []driver.Value{status, requestID}
+	for _, source := range entity.EmailStatusSources(status) {
+		args = append(args, source)
+	}
+	return args
+}
+
+// deliveredArgs returns the arguments of the conditional update marking a request as sent.
+func deliveredArgs(provider string, messageID string, response string, requestID string) []driver.Value {
+	args := []driver.Value{entity.EmailStatusSuccess, provider, messageID, response, requestID}
+	for _, source := range entity.EmailStatusSources(entity.EmailStatusSuccess) {
+		args = append(args, source)
+	}
+	return args
+}
+
 func TestEmailServiceCreateRequestDuplicate(t *testing.T) {
 	t.Parallel()
 
@@ -69,14 +91,16 @@ func TestEmailServiceCreateRequestDuplicate(t *testing.T) {
 	prep := fakePreparer{}
 	prov := fakeProvider{}
 	locker := &fakeLocker{}
-	svc := NewEmailService(prep, prov, repo, locker)
+	svc := NewEmailService(prep, prov, repo, nil, nil, nil, locker)
 
 	mysqlErr := &mysql.MySQLError{Number: 1062}
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO email_history").
-		WithArgs("req-1", "a@b.com", "subj", "content", entity.EmailStatusNew).
+		WithArgs("req-1", uint64(0), "a@b.com", "", "", "subj", "content", entity.EmailStatusNew, nil, "", "", "").
 		WillReturnError(mysqlErr)
+	mock.ExpectRollback()
 
-	if err := svc.CreateRequest(context.Background(), "req-1", "a@b.com", "subj", "content"); !errors.Is(err, ErrDuplicateRequestID) {
+	if err := svc.CreateRequest(context.Background(), "req-1", RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}); !errors.Is(err, ErrDuplicateRequestID) {
 		t.Fatalf("expected ErrDuplicateRequestID, got %v", err)
 	}
 
@@ -92,23 +116,28 @@ func TestEmailServiceSendRawSuccess(t *testing.T) {
 	defer cleanup()
 
 	prep := fakePreparer{raw: []byte("raw")}
-	prov := fakeProvider{}
+	prov := fakeProvider{result: provider.SendResult{
+		Provider:   "ses",
+		MessageID:  "ses-msg-1",
+		Recipients: []string{"a@b.com"},
+		Metadata:   map[string]string{"request_id": "aws-req-1"},
+	}}
 	locker := &fakeLocker{}
-	svc := NewEmailService(prep, prov, repo, locker)
+	svc := NewEmailService(prep, prov, repo, nil, nil, nil, locker)
 
 	requestID := "req-1"
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(entity.EmailStatusProcessing, requestID).
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec("UPDATE email_history").
 		WithArgs("raw", requestID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(entity.EmailStatusSuccess, requestID).
+		WithArgs(deliveredArgs("ses", "ses-msg-1", `{"provider":"ses","message_id":"ses-msg-1","recipients":["a@b.com"],"metadata":{"request_id":"aws-req-1"}}`, requestID)...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	ctx := WithRequestID(context.Background(), requestID)
-	if err := svc.SendRaw(ctx, "a@b.com", "subj", "content"); err != nil {
+	if err := svc.SendRaw(ctx, RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}); err != nil {
 		t.Fatalf("SendRaw returned error: %v", err)
 	}
 
@@ -121,6 +150,36 @@ func TestEmailServiceSendRawSuccess(t *testing.T) {
 	}
 }
 
+func TestEmailServiceSendRawGatedHoldsEmail(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newRepo(t)
+	defer cleanup()
+
+	svc := NewEmailService(fakePreparer{raw: []byte("raw")}, fakeProvider{}, repo, nil, nil, nil, &fakeLocker{})
+
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, "req-1")...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	gated := 0
+	gate := func(context.Context) (bool, error) {
+		gated++
+		return false, nil
+	}
+	ctx := WithRequestID(context.Background(), "req-1")
+	if err := svc.SendRawGated(ctx, RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}, gate); !errors.Is(err, ErrEmailHeld) {
+		t.Fatalf("expected ErrEmailHeld, got %v", err)
+	}
+	if gated != 1 {
+		t.Fatalf("expected the gate to be consulted once, got %d", gated)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
 func TestEmailServiceSendRawPrepareFailure(t *testing.T) {
 	t.Parallel()
 
@@ -130,18 +189,18 @@ func TestEmailServiceSendRawPrepareFailure(t *testing.T) {
 	prep := fakePreparer{err: errors.New("prepare failed")}
 	prov := fakeProvider{}
 	locker := &fakeLocker{}
-	svc := NewEmailService(prep, prov, repo, locker)
+	svc := NewEmailService(prep, prov, repo, nil, nil, nil, locker)
 
 	requestID := "req-2"
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(entity.EmailStatusProcessing, requestID).
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(entity.EmailStatusTemporaryFailure, requestID).
+		WithArgs(statusUpdateArgs(entity.EmailStatusTemporaryFailure, requestID)...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	ctx := WithRequestID(context.Background(), requestID)
-	if err := svc.SendRaw(ctx, "a@b.com", "subj", "content"); err == nil {
+	if err := svc.SendRaw(ctx, RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}); err == nil {
 		t.Fatalf("expected error")
 	}
 
@@ -159,21 +218,21 @@ func TestEmailServiceSendRawUpdateContentFailure(t *testing.T) {
 	prep := fakePreparer{raw: []byte("raw")}
 	prov := fakeProvider{}
 	locker := &fakeLocker{}
-	svc := NewEmailService(prep, prov, repo, locker)
+	svc := NewEmailService(prep, prov, repo, nil, nil, nil, locker)
 
 	requestID := "req-3"
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(entity.EmailStatusProcessing, requestID).
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec("UPDATE email_history").
 		WithArgs("raw", requestID).
 		WillReturnError(errors.New("update content failed"))
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(entity.EmailStatusTemporaryFailure, requestID).
+		WithArgs(statusUpdateArgs(entity.EmailStatusTemporaryFailure, requestID)...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	ctx := WithRequestID(context.Background(), requestID)
-	if err := svc.SendRaw(ctx, "a@b.com", "subj", "content"); err == nil {
+	if err := svc.SendRaw(ctx, RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}); err == nil {
 		t.Fatalf("expected error")
 	}
 
@@ -189,23 +248,23 @@ func TestEmailServiceSendRawProviderFailure(t *testing.T) {
 	defer cleanup()
 
 	prep := fakePreparer{raw: []byte("raw")}
-	prov := fakeProvider{err: errors.New("send failed")}
+	prov := fakeProvider{err: provider.Permanent(errors.New("message rejected"))}
 	locker := &fakeLocker{}
-	svc := NewEmailService(prep, prov, repo, locker)
+	svc := NewEmailService(prep, prov, repo, nil, nil, nil, locker)
 
 	requestID := "req-4"
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(entity.EmailStatusProcessing, requestID).
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec("UPDATE email_history").
 		WithArgs("raw", requestID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec("UPDATE email_history").
-		WithArgs(entity.EmailStatusPermanentFailure, requestID).
+		WithArgs(statusUpdateArgs(entity.EmailStatusPermanentFailure, requestID)...).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	ctx := WithRequestID(context.Background(), requestID)
-	if err := svc.SendRaw(ctx, "a@b.com", "subj", "content"); err == nil {
+	if err := svc.SendRaw(ctx, RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}); err == nil {
 		t.Fatalf("expected error")
 	}
 
@@ -214,6 +273,140 @@ func TestEmailServiceSendRawProviderFailure(t *testing.T) {
 	}
 }
 
+func TestEmailServiceSendRawSkipsFinishedEmail(t *testing.T) {
+	t.Parallel()
+
+	for _, status := range []int16{entity.EmailStatusSuccess, entity.EmailStatusCancelled, entity.EmailStatusPermanentFailure} {
+		repo, mock, cleanup := newRepo(t)
+		svc := NewEmailService(fakePreparer{raw: []byte("raw")}, fakeProvider{}, repo, nil, nil, nil, &fakeLocker{})
+
+		requestID := "req-done"
+		mock.ExpectExec("UPDATE email_history").
+			WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT status").
+			WithArgs(requestID).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(status))
+
+		ctx := WithRequestID(context.Background(), requestID)
+		if err := svc.SendRaw(ctx, RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}); err != nil {
+			t.Fatalf("status %d: SendRaw: %v", status, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("status %d: expectations: %v", status, err)
+		}
+		cleanup()
+	}
+}
+
+func TestEmailServiceSendRawStatusConflict(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newRepo(t)
+	defer cleanup()
+
+	svc := NewEmailService(fakePreparer{raw: []byte("raw")}, fakeProvider{}, repo, nil, nil, nil, &fakeLocker{})
+
+	// Another worker finished the request while this one was sending it.
+	requestID := "req-slow"
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs("raw", requestID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(deliveredArgs("", "", `{"provider":"","recipients":null}`, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT status").
+		WithArgs(requestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusPermanentFailure))
+
+	ctx := WithRequestID(context.Background(), requestID)
+	err := svc.SendRaw(ctx, RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"})
+	var conflict *repository.StatusConflictError
+	if !errors.As(err, &conflict) || conflict.Current != entity.EmailStatusPermanentFailure || conflict.Status != entity.EmailStatusSuccess {
+		t.Fatalf("expected status conflict, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailServiceSendRawRecordsAttempt(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	sendErr := &provider.ProviderError{Provider: "mailgun", Err: &provider.APIError{Provider: "mailgun", StatusCode: 429, Body: "slow down"}}
+	svc := NewEmailService(fakePreparer{raw: []byte("raw")}, fakeProvider{err: sendErr}, repository.NewEmailHistoryRepository(db), repository.NewEmailAttemptRepository(db), nil, nil, &fakeLocker{})
+
+	requestID := "req-5"
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs("raw", requestID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO email_attempts").
+		WithArgs(requestID, "worker-1", "mailgun", sqlmock.AnyArg(), sqlmock.AnyArg(), provider.ErrorClassThrottled, "mailgun: mailgun api: status 429: slow down").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusTemporaryFailure, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := WithWorker(WithRequestID(context.Background(), requestID), "worker-1")
+	if err := svc.SendRaw(ctx, RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}); !errors.Is(err, sendErr) {
+		t.Fatalf("expected send error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailServiceAttempts(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	svc := NewEmailService(nil, nil, repository.NewEmailHistoryRepository(db), repository.NewEmailAttemptRepository(db), nil, nil, &fakeLocker{})
+
+	startedAt := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT status").WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusSuccess))
+	mock.ExpectQuery("FROM email_attempts").WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "request_id", "worker", "provider", "started_at", "finished_at", "error_class", "error"}).
+			AddRow(uint64(1), "req-1", "worker-1", "ses", startedAt, startedAt.Add(time.Second), provider.ErrorClassThrottled, "ses: throttled").
+			AddRow(uint64(2), "req-1", "worker-1", "ses", startedAt.Add(time.Minute), startedAt.Add(time.Minute+time.Second), "", ""))
+
+	status, attempts, err := svc.Attempts(context.Background(), "req-1")
+	if err != nil {
+		t.Fatalf("Attempts: %v", err)
+	}
+	if status != entity.EmailStatusSuccess || len(attempts) != 2 || attempts[0].ErrorClass != provider.ErrorClassThrottled {
+		t.Fatalf("unexpected attempts: %d %+v", status, attempts)
+	}
+
+	mock.ExpectQuery("SELECT status").WithArgs("missing").WillReturnError(sql.ErrNoRows)
+	if _, _, err := svc.Attempts(context.Background(), "missing"); !errors.Is(err, ErrEmailNotFound) {
+		t.Fatalf("expected ErrEmailNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
 func TestEmailServiceSendRawLockFailure(t *testing.T) {
 	t.Parallel()
 
@@ -223,10 +416,10 @@ func TestEmailServiceSendRawLockFailure(t *testing.T) {
 	prep := fakePreparer{raw: []byte("raw")}
 	prov := fakeProvider{}
 	locker := &fakeLocker{acquireErr: errors.New("lock failed")}
-	svc := NewEmailService(prep, prov, repo, locker)
+	svc := NewEmailService(prep, prov, repo, nil, nil, nil, locker)
 
 	ctx := WithRequestID(context.Background(), "req-5")
-	if err := svc.SendRaw(ctx, "a@b.com", "subj", "content"); err == nil {
+	if err := svc.SendRaw(ctx, RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}); err == nil {
 		t.Fatalf("expected error")
 	}
 
@@ -241,9 +434,9 @@ func TestEmailServiceSendRawMissingRequestID(t *testing.T) {
 	repo, mock, cleanup := newRepo(t)
 	defer cleanup()
 
-	svc := NewEmailService(fakePreparer{}, fakeProvider{}, repo, &fakeLocker{})
+	svc := NewEmailService(fakePreparer{}, fakeProvider{}, repo, nil, nil, nil, &fakeLocker{})
 
-	if err := svc.SendRaw(context.Background(), "a@b.com", "subj", "content"); err == nil {
+	if err := svc.SendRaw(context.Background(), RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}); err == nil {
 		t.Fatalf("expected error for missing request_id")
 	}
 
@@ -258,10 +451,10 @@ func TestEmailServiceSendRawEmptyRecipient(t *testing.T) {
 	repo, mock, cleanup := newRepo(t)
 	defer cleanup()
 
-	svc := NewEmailService(fakePreparer{}, fakeProvider{}, repo, &fakeLocker{})
+	svc := NewEmailService(fakePreparer{}, fakeProvider{}, repo, nil, nil, nil, &fakeLocker{})
 
 	ctx := WithRequestID(context.Background(), "req-6")
-	if err := svc.SendRaw(ctx, "", "subj", "content"); err == nil {
+	if err := svc.SendRaw(ctx, RawEmail{Recipient: "", Subject: "subj", Content: "content"}); err == nil {
 		t.Fatalf("expected error for empty recipient")
 	}
 
@@ -269,3 +462,279 @@ func TestEmailServiceSendRawEmptyRecipient(t *testing.T) {
 		t.Fatalf("expectations: %v", err)
 	}
 }
+
+func TestEmailServiceSendRawResolvesProfileEmail(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	svc := NewEmailService(
+		fakePreparer{raw: []byte("raw")},
+		fakeProvider{},
+		repository.NewEmailHistoryRepository(db),
+		nil,
+		repository.NewRecipientProfileRepository(db),
+		nil,
+		&fakeLocker{},
+	)
+
+	requestID := "req-7"
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT user_id, email").
+		WithArgs(uint64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "email", "phone", "locale", "timezone", "device_tokens", "updated_at"}).
+			AddRow(uint64(7), "new@b.com", "", "", "", "[]", time.Now()))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs("new@b.com", requestID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs("raw", requestID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(deliveredArgs("", "", `{"provider":"","recipients":null}`, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := WithRequestID(context.Background(), requestID)
+	if err := svc.SendRaw(ctx, RawEmail{Recipient: "old@b.com", UserID: 7, Subject: "subj", Content: "content"}); err != nil {
+		t.Fatalf("SendRaw returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailServiceSendRawUnresolvedRecipient(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	svc := NewEmailService(
+		fakePreparer{raw: []byte("raw")},
+		fakeProvider{},
+		repository.NewEmailHistoryRepository(db),
+		nil,
+		repository.NewRecipientProfileRepository(db),
+		nil,
+		&fakeLocker{},
+	)
+
+	requestID := "req-8"
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT user_id, email").
+		WithArgs(uint64(7)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusPermanentFailure, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := WithRequestID(context.Background(), requestID)
+	if err := svc.SendRaw(ctx, RawEmail{UserID: 7, Subject: "subj", Content: "content"}); !errors.Is(err, ErrRecipientUnresolved) {
+		t.Fatalf("expected ErrRecipientUnresolved, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailServiceSendRawSkippedByPreference(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	svc := NewEmailService(
+		fakePreparer{raw: []byte("raw")},
+		fakeProvider{err: errors.New("must not be called")},
+		repository.NewEmailHistoryRepository(db),
+		nil,
+		nil,
+		NewPreferenceService(repository.NewPreferenceRepository(db)),
+		&fakeLocker{},
+	)
+
+	requestID := "req-9"
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM notification_categories").WithArgs("marketing").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "description", "transactional", "updated_at"}).
+			AddRow("marketing", "", false, time.Now()))
+	mock.ExpectQuery("FROM notification_preferences").WithArgs(uint64(7), "marketing", entity.ChannelEmail).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "category", "channel", "enabled"}).
+			AddRow(uint64(7), "marketing", entity.ChannelEmail, false))
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusSkippedByPreference, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := WithRequestID(context.Background(), requestID)
+	email := RawEmail{Recipient: "a@b.com", UserID: 7, Category: "marketing", Subject: "subj", Content: "content"}
+	if err := svc.SendRaw(ctx, email); err != nil {
+		t.Fatalf("SendRaw returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailServiceSendRawDropsCancelled(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	svc := NewEmailService(
+		fakePreparer{raw: []byte("raw")},
+		fakeProvider{err: errors.New("must not be called")},
+		repository.NewEmailHistoryRepository(db),
+		nil,
+		nil,
+		nil,
+		&fakeLocker{},
+	)
+
+	requestID := "req-10"
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, requestID)...).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT status FROM email_history").
+		WithArgs(requestID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusCancelled))
+
+	ctx := WithRequestID(context.Background(), requestID)
+	if err := svc.SendRaw(ctx, RawEmail{Recipient: "a@b.com", Subject: "subj", Content: "content"}); err != nil {
+		t.Fatalf("SendRaw returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEmailServiceCancel(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	svc := NewEmailService(nil, nil, repository.NewEmailHistoryRepository(db), nil, nil, nil, &fakeLocker{})
+
+	mock.ExpectExec("UPDATE email_history").
+		WithArgs(entity.EmailStatusCancelled, "req-1", entity.EmailStatusNew, entity.EmailStatusDeferred, entity.EmailStatusScheduled, entity.EmailStatusDigestPending, entity.EmailStatusCapDeferred, entity.EmailStatusPaused).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := svc.Cancel(context.Background(), "req-1"); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	mock.ExpectExec("UPDATE email_history").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-2").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.EmailStatusSuccess))
+	if err := svc.Cancel(context.Background(), "req-2"); !errors.Is(err, ErrEmailNotCancellable) {
+		t.Fatalf("expected ErrEmailNotCancellable, got %v", err)
+	}
+
+	mock.ExpectExec("UPDATE email_history").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT status FROM email_history").WithArgs("req-3").WillReturnError(sql.ErrNoRows)
+	if err := svc.Cancel(context.Background(), "req-3"); !errors.Is(err, ErrEmailNotFound) {
+		t.Fatalf("expected ErrEmailNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+type capturingProvider struct {
+	name       string
+	recipients []string
+}
+
+func (p *capturingProvider) SendRaw(_ context.Context, recipient string, _ []byte) (provider.SendResult, error) {
+	p.recipients = append(p.recipients, recipient)
+	return provider.SendResult{Provider: p.name, MessageID: p.name + "-msg"}, nil
+}
+
+func TestEmailServiceSendRawRoutesBySenderIdentity(t *testing.T) {
+	t.Parallel()
+
+	router, err := provider.NewRouter([]provider.RoutingRule{
+		{Name: "billing", Match: provider.RouteMatch{Senders: []string{"billing@example.com"}}, Provider: "billing"},
+		{Name: "news", Match: provider.RouteMatch{Senders: []string{"*@news.example.com"}}, Provider: "news"},
+	}, "default", "noreply@example.com")
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	providers := map[string]*capturingProvider{
+		"billing": {name: "billing"},
+		"news":    {name: "news"},
+		"default": {name: "default"},
+	}
+	routing, err := provider.NewRoutingProvider(router, map[string]provider.EmailProvider{
+		"billing": providers["billing"],
+		"news":    providers["news"],
+		"default": providers["default"],
+	})
+	if err != nil {
+		t.Fatalf("NewRoutingProvider: %v", err)
+	}
+
+	tests := []struct {
+		from      string
+		recipient string
+		provider  string
+	}{
+		{from: "Billing <Billing@Example.com>", recipient: "a@b.com", provider: "billing"},
+		{from: "weekly@news.example.com", recipient: "c@d.com", provider: "news"},
+		{from: "not an address", recipient: "e@f.com", provider: "default"},
+	}
+	for _, tc := range tests {
+		repo, mock, cleanup := newRepo(t)
+		raw := "From: " + tc.from + "\r\nTo: " + tc.recipient + "\r\nSubject: subj\r\n\r\ncontent"
+		svc := NewEmailService(fakePreparer{raw: []byte(raw)}, routing, repo, nil, nil, nil, &fakeLocker{})
+
+		mock.ExpectExec("UPDATE email_history").
+			WithArgs(statusUpdateArgs(entity.EmailStatusProcessing, "req-1")...).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE email_history").
+			WithArgs(raw, "req-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE email_history").
+			WithArgs(deliveredArgs(tc.provider, tc.provider+"-msg", `{"provider":"`+tc.provider+`","message_id":"`+tc.provider+`-msg","recipients":null}`, "req-1")...).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		ctx := WithRequestID(context.Background(), "req-1")
+		if err := svc.SendRaw(ctx, RawEmail{Recipient: tc.recipient, Subject: "subj", Content: "content"}); err != nil {
+			t.Fatalf("%s: SendRaw: %v", tc.from, err)
+		}
+		if got := providers[tc.provider].recipients; len(got) != 1 || got[0] != tc.recipient {
+			t.Fatalf("%s: expected %s to send to %s, got %v", tc.from, tc.provider, tc.recipient, got)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("%s: expectations: %v", tc.from, err)
+		}
+		cleanup()
+	}
+}
@@ -2,4 +2,19 @@ package service
 
 import "errors"
 
-var ErrDuplicateRequestID = errors.New("duplicate request_id")
+var (
+	ErrDuplicateRequestID    = errors.New("duplicate request_id")
+	ErrRecipientUnresolved   = errors.New("recipient has no email address")
+	ErrProfileNotFound       = errors.New("recipient profile not found")
+	ErrEmailNotFound         = errors.New("email request not found")
+	ErrEmailNotCancellable   = errors.New("email is already being processed or finished")
+	ErrScheduleNotFound      = errors.New("email schedule not found")
+	ErrBatchNotFound         = errors.New("email batch not found")
+	ErrBatchCancelled        = errors.New("email batch is cancelled")
+	ErrEmailHeld             = errors.New("email held back by its send gate")
+	ErrInvalidTemplate       = errors.New("invalid template")
+	ErrCapturedEmailNotFound = errors.New("captured email not found")
+
+	ErrUnknownCategory       = errors.New("unknown notification category")
+	ErrTransactionalCategory = errors.New("transactional categories cannot be opted out of")
+)
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/ratelimit"
+)
+
+const (
+	// FrequencyCapPolicyDefer re-queues a capped email for when the recipient has room again.
+	FrequencyCapPolicyDefer = "defer"
+	// FrequencyCapPolicyDrop records a capped email as dropped and never sends it.
+	FrequencyCapPolicyDrop = "drop"
+)
+
+// FrequencyCapAnyCategory makes a cap count every email regardless of its category.
+const FrequencyCapAnyCategory = "*"
+
+// FrequencyCap allows at most Max emails of Category per recipient within Window.
+type FrequencyCap struct {
+	Category string
+	Max      int
+	Window   time.Duration
+}
+
+// FrequencyCapService enforces per-recipient frequency caps with Redis sliding windows.
+// Urgent emails are never capped and do not count towards a cap.
+type FrequencyCapService struct {
+	caps    []FrequencyCap
+	policy  string
+	windows *ratelimit.SlidingWindow
+}
+
+// NewFrequencyCapService parses caps in the form "marketing=3/24h,*=20/1h" and the
+// policy for capped emails. It returns nil when caps is empty, which disables capping.
+func NewFrequencyCapService(caps, policy string, windows *ratelimit.SlidingWindow) (*FrequencyCapService, error) {
+	if strings.TrimSpace(caps) == "" {
+		return nil, nil
+	}
+	parsed, err := ParseFrequencyCaps(caps)
+	if err != nil {
+		return nil, err
+	}
+	if policy != FrequencyCapPolicyDefer && policy != FrequencyCapPolicyDrop {
+		return nil, fmt.Errorf("frequency cap policy must be %q or %q", FrequencyCapPolicyDefer, FrequencyCapPolicyDrop)
+	}
+	return &FrequencyCapService{
+		caps:    parsed,
+		policy:  policy,
+		windows: windows,
+	}, nil
+}
+
+// ParseFrequencyCaps parses a comma-separated list of "category=max/window" caps.
+// The window is a Go duration; "*" as category matches every email.
+func ParseFrequencyCaps(value string) ([]FrequencyCap, error) {
+	var caps []FrequencyCap
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		category, limit, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("frequency cap %q must be category=max/window", part)
+		}
+		maxValue, windowValue, ok := strings.Cut(limit, "/")
+		if !ok {
+			return nil, fmt.Errorf("frequency cap %q must be category=max/window", part)
+		}
+		category = strings.ToLower(strings.TrimSpace(category))
+		if category == "" {
+			return nil, fmt.Errorf("frequency cap %q is missing a category", part)
+		}
+		maxEmails, err := strconv.Atoi(strings.TrimSpace(maxValue))
+		if err != nil || maxEmails < 1 {
+			return nil, fmt.Errorf("frequency cap %q must allow at least one email", part)
+		}
+		window, err := time.ParseDuration(strings.TrimSpace(windowValue))
+		if err != nil || window < time.Second {
+			return nil, fmt.Errorf("frequency cap %q must have a window of at least 1s", part)
+		}
+		caps = append(caps, FrequencyCap{Category: category, Max: maxEmails, Window: window})
+	}
+	if len(caps) == 0 {
+		return nil, fmt.Errorf("no frequency caps configured")
+	}
+	return caps, nil
+}
+
+// Policy returns how capped emails are handled.
+func (s *FrequencyCapService) Policy() string {
+	return s.policy
+}
+
+// Reserve counts the email against every cap that applies to it. When a cap is already
+// reached it counts nothing and reports true with the time the recipient has room again.
+// Reserving the same request again does not count it twice, so retried sends pass.
+func (s *FrequencyCapService) Reserve(ctx context.Context, requestID string, email RawEmail, now time.Time) (time.Time, bool, error) {
+	if entity.IsUrgent(email.Priority) {
+		return time.Time{}, false, nil
+	}
+	recipient := frequencyCapRecipient(email)
+	if recipient == "" {
+		return time.Time{}, false, nil
+	}
+
+	var limits []ratelimit.Limit
+	for _, c := range s.caps {
+		if c.Category != FrequencyCapAnyCategory && c.Category != email.Category {
+			continue
+		}
+		limits = append(limits, ratelimit.Limit{
+			Key:    fmt.Sprintf("notifications:fcap:%s:%s:%d", recipient, c.Category, int64(c.Window/time.Second)),
+			Max:    c.Max,
+			Window: c.Window,
+		})
+	}
+
+	allowed, until, err := s.windows.Reserve(ctx, requestID, limits, now)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return until, !allowed, nil
+}
+
+// frequencyCapRecipient identifies the recipient a cap counts for: the user when the email
+// is user-addressed, else the address.
+func frequencyCapRecipient(email RawEmail) string {
+	if email.UserID != 0 {
+		return "user:" + strconv.FormatUint(email.UserID, 10)
+	}
+	if email.Recipient == "" {
+		return ""
+	}
+	return "email:" + strings.ToLower(email.Recipient)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/ratelimit"
+)
+
+func TestParseFrequencyCaps(t *testing.T) {
+	t.Parallel()
+
+	caps, err := ParseFrequencyCaps(" Marketing=3/24h , *=20/1h ")
+	if err != nil {
+		t.Fatalf("ParseFrequencyCaps: %v", err)
+	}
+	if len(caps) != 2 || caps[0] != (FrequencyCap{Category: "marketing", Max: 3, Window: 24 * time.Hour}) || caps[1].Category != FrequencyCapAnyCategory {
+		t.Fatalf("unexpected caps: %+v", caps)
+	}
+
+	for _, value := range []string{"marketing", "marketing=3", "=3/1h", "marketing=0/1h", "marketing=3/day", "marketing=3/10ms", ","} {
+		if _, err := ParseFrequencyCaps(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
+
+func TestNewFrequencyCapServiceDisabledAndInvalid(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewFrequencyCapService("", "bogus", nil)
+	if err != nil || s != nil {
+		t.Fatalf("expected disabled service, got %v, %v", s, err)
+	}
+	if _, err := NewFrequencyCapService("marketing=3/24h", "bogus", nil); err == nil {
+		t.Fatal("expected invalid policy error")
+	}
+}
+
+func TestFrequencyCapServiceReserve(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	s, err := NewFrequencyCapService("marketing=1/24h", FrequencyCapPolicyDrop, ratelimit.NewSlidingWindow(client))
+	if err != nil {
+		t.Fatalf("NewFrequencyCapService: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	marketing := RawEmail{UserID: 7, Category: "marketing"}
+
+	if _, capped, err := s.Reserve(ctx, "req-1", marketing, now); err != nil || capped {
+		t.Fatalf("first marketing email: capped=%v err=%v", capped, err)
+	}
+	until, capped, err := s.Reserve(ctx, "req-2", marketing, now.Add(time.Minute))
+	if err != nil || !capped {
+		t.Fatalf("second marketing email: capped=%v err=%v", capped, err)
+	}
+	if !until.Equal(now.Add(24 * time.Hour)) {
+		t.Fatalf("expected retry after 24h, got %s", until)
+	}
+
+	// Other categories, other recipients, and urgent emails are not capped.
+	if _, capped, _ := s.Reserve(ctx, "req-3", RawEmail{UserID: 7, Category: "billing"}, now); capped {
+		t.Fatal("expected uncapped category to pass")
+	}
+	if _, capped, _ := s.Reserve(ctx, "req-4", RawEmail{UserID: 8, Category: "marketing"}, now); capped {
+		t.Fatal("expected other recipient to pass")
+	}
+	urgent := marketing
+	urgent.Priority = entity.PriorityHigh
+	if _, capped, _ := s.Reserve(ctx, "req-5", urgent, now); capped {
+		t.Fatal("expected urgent email to pass")
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+// replayPageSize is how many stored notifications are read at a time while the backlog of
+// a new subscriber is replayed.
+const replayPageSize = 100
+
+// InAppBroker fans out in-app notifications to connected subscribers.
+type InAppBroker interface {
+	Publish(ctx context.Context, notification entity.InAppNotification) error
+	Subscribe(userID uint64) (<-chan entity.InAppNotification, func())
+}
+
+type InAppService struct {
+	repo   *repository.InAppNotificationRepository
+	broker InAppBroker
+}
+
+// NewInAppService builds the in-app notification service with dependencies.
+func NewInAppService(repo *repository.InAppNotificationRepository, broker InAppBroker) *InAppService {
+	return &InAppService{repo: repo, broker: broker}
+}
+
+// Send stores an in-app notification and broadcasts it to connected clients.
+func (s *InAppService) Send(ctx context.Context, notification *entity.InAppNotification) error {
+	if err := s.repo.Create(ctx, notification); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return ErrDuplicateRequestID
+		}
+		return err
+	}
+
+	// The notification is already stored, so clients that miss the live event
+	// still get it when they reconnect with their last seen id.
+	if err := s.broker.Publish(ctx, *notification); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"request_id": notification.RequestID,
+			"user_id":    notification.UserID,
+		}).Warn("Failed to publish in-app notification")
+	}
+	return nil
+}
+
+// FindByRequestID returns the in-app notification stored under requestID.
+func (s *InAppService) FindByRequestID(ctx context.Context, requestID string) (*entity.InAppNotification, error) {
+	return s.repo.FindByRequestID(ctx, requestID)
+}
+
+// List returns a user's notifications created after afterID, oldest first.
+func (s *InAppService) List(ctx context.Context, userID uint64, afterID uint64, limit int) ([]entity.InAppNotification, error) {
+	return s.repo.ListByUser(ctx, userID, afterID, limit)
+}
+
+// Subscribe streams a user's notifications: every stored one after afterID first (when
+// set), then live events. The channel is closed when ctx is done, the broker shuts down,
+// or a page of the backlog cannot be read; the client then reconnects with its last seen id.
+func (s *InAppService) Subscribe(ctx context.Context, userID uint64, afterID uint64) (<-chan entity.InAppNotification, error) {
+	// Subscribe before reading the backlog so nothing created in between is lost.
+	live, unsubscribe := s.broker.Subscribe(userID)
+
+	var page []entity.InAppNotification
+	if afterID > 0 {
+		var err error
+		page, err = s.repo.ListByUser(ctx, userID, afterID, replayPageSize)
+		if err != nil {
+			unsubscribe()
+			return nil, err
+		}
+	}
+
+	out := make(chan entity.InAppNotification)
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+
+		// Live events that arrive while the backlog is replayed are held, so the broker does
+		// not drop them for a full buffer during a long replay.
+		var held []entity.InAppNotification
+		liveClosed := false
+		send := func(n entity.InAppNotification) bool {
+			for {
+				select {
+				case out <- n:
+					return true
+				case event, ok := <-live:
+					if !ok {
+						live, liveClosed = nil, true
+						continue
+					}
+					held = append(held, event)
+				case <-ctx.Done():
+					return false
+				}
+			}
+		}
+
+		replayedUpTo := afterID
+		for len(page) > 0 {
+			for _, n := range page {
+				if !send(n) {
+					return
+				}
+				replayedUpTo = n.ID
+			}
+			if len(page) < replayPageSize {
+				break
+			}
+			var err error
+			page, err = s.repo.ListByUser(ctx, userID, replayedUpTo, replayPageSize)
+			if err != nil {
+				if ctx.Err() == nil {
+					logrus.WithError(err).WithField("user_id", userID).Warn("Failed to replay in-app notifications")
+				}
+				return
+			}
+		}
+
+		// Held events the backlog already covered are skipped; send may hold more meanwhile.
+		for i := 0; i < len(held); i++ {
+			if held[i].ID <= replayedUpTo {
+				continue
+			}
+			if !send(held[i]) {
+				return
+			}
+			replayedUpTo = held[i].ID
+		}
+		if liveClosed {
+			return
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n, ok := <-live:
+				if !ok {
+					return
+				}
+				if n.ID <= replayedUpTo {
+					continue
+				}
+				select {
+				case out <- n:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+type fakeBroker struct {
+	publishErr error
+	published  []entity.InAppNotification
+	live       chan entity.InAppNotification
+}
+
+func (b *fakeBroker) Publish(_ context.Context, notification entity.InAppNotification) error {
+	if b.publishErr != nil {
+		return b.publishErr
+	}
+	b.published = append(b.published, notification)
+	return nil
+}
+
+func (b *fakeBroker) Subscribe(_ uint64) (<-chan entity.InAppNotification, func()) {
+	return b.live, func() {}
+}
+
+func newInAppRepo(t *testing.T) (*repository.InAppNotificationRepository, sqlmock.Sqlmock, func()) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	return repository.NewInAppNotificationRepository(db), mock, func() { _ = db.Close() }
+}
+
+func TestInAppServiceSendPublishes(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newInAppRepo(t)
+	defer cleanup()
+
+	broker := &fakeBroker{}
+	svc := NewInAppService(repo, broker)
+
+	mock.ExpectExec("INSERT INTO inapp_notifications").
+		WithArgs("req-1", uint64(7), "comment", "title", "body", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+
+	n := &entity.InAppNotification{RequestID: "req-1", UserID: 7, Type: "comment", Title: "title", Body: "body"}
+	if err := svc.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(broker.published) != 1 || broker.published[0].ID != 5 {
+		t.Fatalf("expected published notification with id 5, got %+v", broker.published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestInAppServiceSendPublishFailureIsNotFatal(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newInAppRepo(t)
+	defer cleanup()
+
+	svc := NewInAppService(repo, &fakeBroker{publishErr: errors.New("redis down")})
+
+	mock.ExpectExec("INSERT INTO inapp_notifications").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+
+	n := &entity.InAppNotification{RequestID: "req-1", UserID: 7, Type: "comment", Title: "title", Body: "body"}
+	if err := svc.Send(context.Background(), n); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+}
+
+func TestInAppServiceSendDuplicate(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newInAppRepo(t)
+	defer cleanup()
+
+	svc := NewInAppService(repo, &fakeBroker{})
+
+	mock.ExpectExec("INSERT INTO inapp_notifications").
+		WillReturnError(&mysql.MySQLError{Number: 1062})
+
+	n := &entity.InAppNotification{RequestID: "req-1", UserID: 7, Type: "comment", Title: "title", Body: "body"}
+	if err := svc.Send(context.Background(), n); !errors.Is(err, ErrDuplicateRequestID) {
+		t.Fatalf("expected ErrDuplicateRequestID, got %v", err)
+	}
+}
+
+func TestInAppServiceSubscribeReplaysThenStreams(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newInAppRepo(t)
+	defer cleanup()
+
+	broker := &fakeBroker{live: make(chan entity.InAppNotification, 4)}
+	svc := NewInAppService(repo, broker)
+
+	mock.ExpectQuery("SELECT id, request_id, user_id, type, title, body, created_at FROM inapp_notifications").
+		WithArgs(uint64(7), uint64(3), replayPageSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "request_id", "user_id", "type", "title", "body", "created_at"}).
+			AddRow(4, "req-4", 7, "comment", "t", "b", time.Now()).
+			AddRow(5, "req-5", 7, "comment", "t", "b", time.Now()))
+
+	// Event 5 was stored before subscribing and is also seen live; it must not repeat.
+	broker.live <- entity.InAppNotification{ID: 5, UserID: 7}
+	broker.live <- entity.InAppNotification{ID: 6, UserID: 7}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := svc.Subscribe(ctx, 7, 3)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	var got []uint64
+	for len(got) < 3 {
+		select {
+		case n := <-events:
+			got = append(got, n.ID)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout, got %v", got)
+		}
+	}
+	if got[0] != 4 || got[1] != 5 || got[2] != 6 {
+		t.Fatalf("unexpected event order: %v", got)
+	}
+
+	cancel()
+	for range events {
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestInAppServiceSubscribeReplaysWholeBacklog(t *testing.T) {
+	t.Parallel()
+
+	repo, mock, cleanup := newInAppRepo(t)
+	defer cleanup()
+
+	broker := &fakeBroker{live: make(chan entity.InAppNotification, 4)}
+	svc := NewInAppService(repo, broker)
+
+	// The backlog after id 1 is a full page (2..101) and one more notification (102);
+	// 103 arrives live.
+	columns := []string{"id", "request_id", "user_id", "type", "title", "body", "created_at"}
+	firstPage := sqlmock.NewRows(columns)
+	for id := 2; id <= replayPageSize+1; id++ {
+		firstPage.AddRow(id, "req", 7, "comment", "t", "b", time.Now())
+	}
+	mock.ExpectQuery("FROM inapp_notifications").
+		WithArgs(uint64(7), uint64(1), replayPageSize).
+		WillReturnRows(firstPage)
+	mock.ExpectQuery("FROM inapp_notifications").
+		WithArgs(uint64(7), uint64(replayPageSize+1), replayPageSize).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(replayPageSize+2, "req", 7, "comment", "t", "b", time.Now()))
+
+	broker.live <- entity.InAppNotification{ID: uint64(replayPageSize + 3), UserID: 7}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := svc.Subscribe(ctx, 7, 1)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	var got []uint64
+	for len(got) < replayPageSize+2 {
+		select {
+		case n := <-events:
+			got = append(got, n.ID)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout after %d events", len(got))
+		}
+	}
+	for i, id := range got {
+		if id != uint64(i+2) {
+			t.Fatalf("expected event %d at position %d, got %d", i+2, i, id)
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
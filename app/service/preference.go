@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+type PreferenceService struct {
+	repo *repository.PreferenceRepository
+}
+
+// NewPreferenceService builds the notification preferences service with dependencies.
+func NewPreferenceService(repo *repository.PreferenceRepository) *PreferenceService {
+	return &PreferenceService{repo: repo}
+}
+
+// UpsertCategory creates or updates a notification category.
+func (s *PreferenceService) UpsertCategory(ctx context.Context, category *entity.NotificationCategory) error {
+	return s.repo.UpsertCategory(ctx, category)
+}
+
+// ListCategories returns all notification categories.
+func (s *PreferenceService) ListCategories(ctx context.Context) ([]entity.NotificationCategory, error) {
+	return s.repo.ListCategories(ctx)
+}
+
+// Get returns a user's effective preferences for every category and supported channel.
+// Channels without a stored preference are enabled, and transactional categories are
+// always enabled.
+func (s *PreferenceService) Get(ctx context.Context, userID uint64) ([]entity.CategoryPreferences, error) {
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.repo.ListPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	disabled := make(map[string]bool, len(stored))
+	for _, p := range stored {
+		if !p.Enabled {
+			disabled[p.Category+"/"+p.Channel] = true
+		}
+	}
+
+	result := make([]entity.CategoryPreferences, 0, len(categories))
+	for _, category := range categories {
+		channels := make(map[string]bool, len(entity.SupportedChannels))
+		for _, channel := range entity.SupportedChannels {
+			channels[channel] = category.Transactional || !disabled[category.Name+"/"+channel]
+		}
+		result = append(result, entity.CategoryPreferences{Category: category, Channels: channels})
+	}
+	return result, nil
+}
+
+// Update stores a user's preferences. Every category must exist and must not be transactional.
+func (s *PreferenceService) Update(ctx context.Context, userID uint64, preferences []entity.NotificationPreference) error {
+	checked := make(map[string]bool)
+	for i := range preferences {
+		preferences[i].UserID = userID
+		name := preferences[i].Category
+		if checked[name] {
+			continue
+		}
+		category, err := s.repo.FindCategory(ctx, name)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s", ErrUnknownCategory, name)
+			}
+			return err
+		}
+		if category.Transactional {
+			return fmt.Errorf("%w: %s", ErrTransactionalCategory, name)
+		}
+		checked[name] = true
+	}
+	return s.repo.UpsertPreferences(ctx, preferences)
+}
+
+// Allowed reports whether a notification of the category may be sent to the user on the
+// channel. Uncategorized notifications, unknown and transactional categories, and
+// anonymous recipients are always allowed.
+func (s *PreferenceService) Allowed(ctx context.Context, userID uint64, category string, channel string) (bool, error) {
+	if userID == 0 || category == "" {
+		return true, nil
+	}
+
+	c, err := s.repo.FindCategory(ctx, category)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+		return false, fmt.Errorf("load category: %w", err)
+	}
+	if c.Transactional {
+		return true, nil
+	}
+
+	preference, err := s.repo.FindPreference(ctx, userID, category, channel)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+		return false, fmt.Errorf("load preference: %w", err)
+	}
+	return preference.Enabled, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+var categoryColumns = []string{"name", "description", "transactional", "updated_at"}
+
+func TestPreferenceServiceAllowed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		allowed bool
+	}{
+		{
+			name: "unknown category",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM notification_categories").WithArgs("marketing").WillReturnError(sql.ErrNoRows)
+			},
+			allowed: true,
+		},
+		{
+			name: "transactional category",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM notification_categories").WithArgs("marketing").
+					WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow("marketing", "", true, time.Now()))
+			},
+			allowed: true,
+		},
+		{
+			name: "no stored preference",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM notification_categories").WithArgs("marketing").
+					WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow("marketing", "", false, time.Now()))
+				mock.ExpectQuery("FROM notification_preferences").WithArgs(uint64(7), "marketing", entity.ChannelEmail).
+					WillReturnError(sql.ErrNoRows)
+			},
+			allowed: true,
+		},
+		{
+			name: "opted out",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM notification_categories").WithArgs("marketing").
+					WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow("marketing", "", false, time.Now()))
+				mock.ExpectQuery("FROM notification_preferences").WithArgs(uint64(7), "marketing", entity.ChannelEmail).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "category", "channel", "enabled"}).
+						AddRow(uint64(7), "marketing", entity.ChannelEmail, false))
+			},
+			allowed: false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New: %v", err)
+			}
+			defer db.Close()
+			tc.expect(mock)
+
+			svc := NewPreferenceService(repository.NewPreferenceRepository(db))
+			allowed, err := svc.Allowed(context.Background(), 7, "marketing", entity.ChannelEmail)
+			if err != nil {
+				t.Fatalf("Allowed: %v", err)
+			}
+			if allowed != tc.allowed {
+				t.Fatalf("expected allowed=%v, got %v", tc.allowed, allowed)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPreferenceServiceAllowedUncategorized(t *testing.T) {
+	t.Parallel()
+
+	svc := NewPreferenceService(repository.NewPreferenceRepository(nil))
+	allowed, err := svc.Allowed(context.Background(), 7, "", entity.ChannelEmail)
+	if err != nil || !allowed {
+		t.Fatalf("expected uncategorized notifications to be allowed, got %v %v", allowed, err)
+	}
+}
+
+func TestPreferenceServiceUpdateRejectsTransactional(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM notification_categories").WithArgs("security").
+		WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow("security", "", true, time.Now()))
+
+	svc := NewPreferenceService(repository.NewPreferenceRepository(db))
+	err = svc.Update(context.Background(), 7, []entity.NotificationPreference{{Category: "security", Channel: entity.ChannelEmail}})
+	if !errors.Is(err, ErrTransactionalCategory) {
+		t.Fatalf("expected ErrTransactionalCategory, got %v", err)
+	}
+}
+
+func TestPreferenceServiceGetAppliesDefaults(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM notification_categories").
+		WillReturnRows(sqlmock.NewRows(categoryColumns).
+			AddRow("marketing", "", false, time.Now()).
+			AddRow("security", "", true, time.Now()))
+	mock.ExpectQuery("FROM notification_preferences").WithArgs(uint64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "category", "channel", "enabled"}).
+			AddRow(uint64(7), "marketing", entity.ChannelEmail, false))
+
+	svc := NewPreferenceService(repository.NewPreferenceRepository(db))
+	prefs, err := svc.Get(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(prefs) != 2 {
+		t.Fatalf("expected 2 categories, got %d", len(prefs))
+	}
+	if prefs[0].Channels[entity.ChannelEmail] || !prefs[0].Channels[entity.ChannelInApp] {
+		t.Fatalf("unexpected marketing channels: %+v", prefs[0].Channels)
+	}
+	if !prefs[1].Channels[entity.ChannelEmail] {
+		t.Fatalf("transactional category must stay enabled: %+v", prefs[1].Channels)
+	}
+}
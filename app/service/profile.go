@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+type ProfileService struct {
+	repo *repository.RecipientProfileRepository
+}
+
+// NewProfileService builds the recipient profile service with dependencies.
+func NewProfileService(repo *repository.RecipientProfileRepository) *ProfileService {
+	return &ProfileService{repo: repo}
+}
+
+// Upsert creates or replaces a user's recipient profile.
+func (s *ProfileService) Upsert(ctx context.Context, profile *entity.RecipientProfile) error {
+	return s.repo.Upsert(ctx, profile)
+}
+
+// Get returns a user's recipient profile.
+func (s *ProfileService) Get(ctx context.Context, userID uint64) (*entity.RecipientProfile, error) {
+	profile, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProfileNotFound
+		}
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Delete removes a user's recipient profile.
+func (s *ProfileService) Delete(ctx context.Context, userID uint64) error {
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProfileNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+func TestProfileServiceGetNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id").WithArgs(uint64(7)).WillReturnError(sql.ErrNoRows)
+
+	svc := NewProfileService(repository.NewRecipientProfileRepository(db))
+	if _, err := svc.Get(context.Background(), 7); !errors.Is(err, ErrProfileNotFound) {
+		t.Fatalf("expected ErrProfileNotFound, got %v", err)
+	}
+}
+
+func TestProfileServiceDeleteNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM recipient_profiles").WithArgs(uint64(7)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	svc := NewProfileService(repository.NewRecipientProfileRepository(db))
+	if err := svc.Delete(context.Background(), 7); !errors.Is(err, ErrProfileNotFound) {
+		t.Fatalf("expected ErrProfileNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+// QuietHoursService decides whether a non-urgent email must wait until the recipient's
+// local quiet hours end. The window is a daily [start, end) range of wall-clock times
+// and may cross midnight (for example 22:00-08:00).
+type QuietHoursService struct {
+	start           time.Duration
+	end             time.Duration
+	defaultLocation *time.Location
+	profiles        *repository.RecipientProfileRepository
+}
+
+// NewQuietHoursService parses the window bounds ("HH:MM") and default time zone.
+// It returns nil when start or end is empty, which disables quiet hours.
+func NewQuietHoursService(start, end, defaultTimezone string, profiles *repository.RecipientProfileRepository) (*QuietHoursService, error) {
+	if start == "" || end == "" {
+		return nil, nil
+	}
+	startOffset, err := parseClock(start)
+	if err != nil {
+		return nil, fmt.Errorf("quiet hours start: %w", err)
+	}
+	endOffset, err := parseClock(end)
+	if err != nil {
+		return nil, fmt.Errorf("quiet hours end: %w", err)
+	}
+	if startOffset == endOffset {
+		return nil, fmt.Errorf("quiet hours start and end must differ")
+	}
+	location, err := time.LoadLocation(defaultTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("quiet hours timezone: %w", err)
+	}
+	return &QuietHoursService{
+		start:           startOffset,
+		end:             endOffset,
+		defaultLocation: location,
+		profiles:        profiles,
+	}, nil
+}
+
+// DeferUntil reports whether the email falls into quiet hours at now and, if so, when
+// the window ends. Urgent emails are never deferred. The time zone comes from the
+// email, then from the recipient profile, then from the configured default.
+func (s *QuietHoursService) DeferUntil(ctx context.Context, email RawEmail, now time.Time) (time.Time, bool, error) {
+	if entity.IsUrgent(email.Priority) {
+		return time.Time{}, false, nil
+	}
+	location, err := s.location(ctx, email)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	until, deferred := s.windowEnd(now.In(location))
+	return until, deferred, nil
+}
+
+// location resolves the recipient's time zone; unknown zone names fall back to the default.
+func (s *QuietHoursService) location(ctx context.Context, email RawEmail) (*time.Location, error) {
+	timezone := email.Timezone
+	if timezone == "" && email.UserID != 0 && s.profiles != nil {
+		profile, err := s.profiles.FindByUserID(ctx, email.UserID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("load recipient profile: %w", err)
+		}
+		if err == nil {
+			timezone = profile.Timezone
+		}
+	}
+	if timezone == "" {
+		return s.defaultLocation, nil
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return s.defaultLocation, nil
+	}
+	return location, nil
+}
+
+// windowEnd returns the end of the quiet window containing local, if any.
+func (s *QuietHoursService) windowEnd(local time.Time) (time.Time, bool) {
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+	year, month, day := local.Date()
+	// Build the end from wall-clock fields so DST transitions do not shift it.
+	endAt := func(dayOffset int) time.Time {
+		hour, minute := int(s.end/time.Hour), int(s.end%time.Hour/time.Minute)
+		return time.Date(year, month, day+dayOffset, hour, minute, 0, 0, local.Location())
+	}
+
+	if s.start < s.end {
+		if clock >= s.start && clock < s.end {
+			return endAt(0), true
+		}
+		return time.Time{}, false
+	}
+	// The window crosses midnight.
+	switch {
+	case clock >= s.start:
+		return endAt(1), true
+	case clock < s.end:
+		return endAt(0), true
+	}
+	return time.Time{}, false
+}
+
+// parseClock converts "HH:MM" into an offset from midnight.
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q must be in HH:MM format", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+func TestQuietHoursServiceDeferUntil(t *testing.T) {
+	t.Parallel()
+
+	svc, err := NewQuietHoursService("22:00", "08:00", "UTC", nil)
+	if err != nil {
+		t.Fatalf("NewQuietHoursService: %v", err)
+	}
+	bucharest, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		email    RawEmail
+		now      time.Time
+		deferred bool
+		until    time.Time
+	}{
+		{
+			name:     "before midnight",
+			email:    RawEmail{},
+			now:      time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC),
+			deferred: true,
+			until:    time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "after midnight",
+			email:    RawEmail{},
+			now:      time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC),
+			deferred: true,
+			until:    time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "outside window",
+			email: RawEmail{},
+			now:   time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "urgent",
+			email: RawEmail{Priority: entity.PriorityHigh},
+			now:   time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "request timezone",
+			email:    RawEmail{Timezone: "Europe/Bucharest"},
+			now:      time.Date(2026, 3, 10, 21, 0, 0, 0, time.UTC),
+			deferred: true,
+			until:    time.Date(2026, 3, 11, 8, 0, 0, 0, bucharest),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			until, deferred, err := svc.DeferUntil(context.Background(), tc.email, tc.now)
+			if err != nil {
+				t.Fatalf("DeferUntil: %v", err)
+			}
+			if deferred != tc.deferred || !until.Equal(tc.until) {
+				t.Fatalf("expected (%v, %v), got (%v, %v)", tc.until, tc.deferred, until, deferred)
+			}
+		})
+	}
+}
+
+func TestQuietHoursServiceUsesProfileTimezone(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id").WithArgs(uint64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "email", "phone", "locale", "timezone", "device_tokens", "updated_at"}).
+			AddRow(uint64(7), "a@b.com", "", "", "America/New_York", "[]", time.Now()))
+
+	svc, err := NewQuietHoursService("22:00", "07:00", "UTC", repository.NewRecipientProfileRepository(db))
+	if err != nil {
+		t.Fatalf("NewQuietHoursService: %v", err)
+	}
+
+	// 04:00 UTC is 23:00 in New York, inside the window there but not in UTC.
+	now := time.Date(2026, 1, 15, 4, 0, 0, 0, time.UTC)
+	until, deferred, err := svc.DeferUntil(context.Background(), RawEmail{UserID: 7}, now)
+	if err != nil {
+		t.Fatalf("DeferUntil: %v", err)
+	}
+	if !deferred || !until.Equal(time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected deferral: %v %v", until, deferred)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestNewQuietHoursServiceDisabledAndInvalid(t *testing.T) {
+	t.Parallel()
+
+	svc, err := NewQuietHoursService("", "", "UTC", nil)
+	if err != nil || svc != nil {
+		t.Fatalf("expected disabled service, got %v %v", svc, err)
+	}
+	if _, err := NewQuietHoursService("25:00", "08:00", "UTC", nil); err == nil {
+		t.Fatalf("expected invalid start error")
+	}
+	if _, err := NewQuietHoursService("22:00", "08:00", "Nowhere/City", nil); err == nil {
+		t.Fatalf("expected invalid timezone error")
+	}
+}
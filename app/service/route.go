@@ -0,0 +1,27 @@
+package service
+
+import "github.com/vibast-solutions/ms-go-notifications/app/provider"
+
+// RouteDecision is the provider an email would be sent through. Rule names the routing rule
+// that chose it; Matched is false when no rule matched and the default provider was chosen.
+type RouteDecision struct {
+	Provider string
+	Rule     string
+	Matched  bool
+}
+
+// RouteService shows which provider the configured routing rules select for an email.
+type RouteService struct {
+	router *provider.Router
+}
+
+// NewRouteService builds the route service with dependencies.
+func NewRouteService(router *provider.Router) *RouteService {
+	return &RouteService{router: router}
+}
+
+// DryRun returns the provider route would be sent through, without sending anything.
+func (s *RouteService) DryRun(route provider.Route) RouteDecision {
+	name, rule, matched := s.router.Select(route)
+	return RouteDecision{Provider: name, Rule: rule.Name, Matched: matched}
+}
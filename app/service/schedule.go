@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/vibast-solutions/ms-go-notifications/app/entity"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+type ScheduleService struct {
+	repo *repository.EmailScheduleRepository
+}
+
+// NewScheduleService builds the recurring schedule service with dependencies.
+func NewScheduleService(repo *repository.EmailScheduleRepository) *ScheduleService {
+	return &ScheduleService{repo: repo}
+}
+
+// Upsert creates or replaces a schedule and computes its next run from now. Changing
+// a schedule therefore never fires a tick that was due under the old expression.
+func (s *ScheduleService) Upsert(ctx context.Context, schedule *entity.EmailSchedule) (*entity.EmailSchedule, error) {
+	next, err := NextScheduleRun(schedule.CronExpr, schedule.Timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	schedule.NextRunAt = next
+	if err := s.repo.Upsert(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByName(ctx, schedule.Name)
+}
+
+// Get returns a schedule by name.
+func (s *ScheduleService) Get(ctx context.Context, name string) (*entity.EmailSchedule, error) {
+	schedule, err := s.repo.FindByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// List returns all schedules.
+func (s *ScheduleService) List(ctx context.Context) ([]entity.EmailSchedule, error) {
+	return s.repo.List(ctx)
+}
+
+// Delete removes a schedule by name.
+func (s *ScheduleService) Delete(ctx context.Context, name string) error {
+	if err := s.repo.DeleteByName(ctx, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrScheduleNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// NextScheduleRun returns the first activation of a standard five-field cron expression
+// (or descriptor such as @weekly) strictly after the given time, evaluated in timezone.
+func NextScheduleRun(cronExpr string, timezone string, after time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(cronExpr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse cron expression: %w", err)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("load timezone: %w", err)
+	}
+	next := schedule.Next(after.In(location))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("cron expression %q never fires", cronExpr)
+	}
+	return next.UTC(), nil
+}
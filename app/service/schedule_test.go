@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+)
+
+func TestNextScheduleRun(t *testing.T) {
+	t.Parallel()
+
+	after := time.Date(2030, 3, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		cron     string
+		timezone string
+		want     time.Time
+	}{
+		{name: "utc daily", cron: "0 9 * * *", timezone: "UTC", want: time.Date(2030, 3, 31, 9, 0, 0, 0, time.UTC)},
+		{name: "local wall clock across DST", cron: "0 9 * * *", timezone: "Europe/Bucharest", want: time.Date(2030, 3, 31, 6, 0, 0, 0, time.UTC)},
+		{name: "descriptor", cron: "@hourly", timezone: "UTC", want: time.Date(2030, 3, 30, 13, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NextScheduleRun(tc.cron, tc.timezone, after)
+			if err != nil {
+				t.Fatalf("NextScheduleRun: %v", err)
+			}
+			if !got.Equal(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	if _, err := NextScheduleRun("not a cron", "UTC", after); err == nil {
+		t.Fatalf("expected invalid cron expression error")
+	}
+}
+
+func TestScheduleServiceGetNotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM email_schedules WHERE name").WithArgs("daily").WillReturnError(sql.ErrNoRows)
+
+	svc := NewScheduleService(repository.NewEmailScheduleRepository(db))
+	if _, err := svc.Get(context.Background(), "daily"); !errors.Is(err, ErrScheduleNotFound) {
+		t.Fatalf("expected ErrScheduleNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vibast-solutions/ms-go-notifications/app/ratelimit"
+)
+
+// Send rate wait metrics, published under /debug/vars and keyed by provider.
+var (
+	sendRateWaits       = expvar.NewMap("send_rate_limit_waits")
+	sendRateWaitSeconds = expvar.NewMap("send_rate_limit_wait_seconds")
+)
+
+// SendRate allows Max sends per Window for Key, a provider name or a sending domain.
+type SendRate struct {
+	Key    string
+	Max    int
+	Window time.Duration
+}
+
+// SendRateLimiter paces provider sends with Redis token buckets shared by every worker:
+// one per provider and one per domain emails are sent from, when configured.
+type SendRateLimiter struct {
+	providers map[string]ratelimit.Bucket
+	domains   map[string]ratelimit.Bucket
+	tokens    *ratelimit.TokenBucket
+}
+
+// NewSendRateLimiter parses provider and domain rates in the form "ses=14/1s". It returns
+// nil when neither is set, which disables send rate limiting.
+func NewSendRateLimiter(providerRates, domainRates string, tokens *ratelimit.TokenBucket) (*SendRateLimiter, error) {
+	limiter := &SendRateLimiter{
+		providers: make(map[string]ratelimit.Bucket),
+		domains:   make(map[string]ratelimit.Bucket),
+		tokens:    tokens,
+	}
+	for _, scope := range []struct {
+		name    string
+		rates   string
+		buckets map[string]ratelimit.Bucket
+	}{
+		{name: "provider", rates: providerRates, buckets: limiter.providers},
+		{name: "domain", rates: domainRates, buckets: limiter.domains},
+	} {
+		if strings.TrimSpace(scope.rates) == "" {
+			continue
+		}
+		rates, err := ParseSendRates(scope.rates)
+		if err != nil {
+			return nil, fmt.Errorf("%s send rate: %w", scope.name, err)
+		}
+		for _, rate := range rates {
+			scope.buckets[rate.Key] = ratelimit.Bucket{
+				Key:      fmt.Sprintf("notifications:sendrate:%s:%s", scope.name, rate.Key),
+				Capacity: rate.Max,
+				Period:   rate.Window,
+			}
+		}
+	}
+	if len(limiter.providers) == 0 && len(limiter.domains) == 0 {
+		return nil, nil
+	}
+	return limiter, nil
+}
+
+// ParseSendRates parses a comma-separated list of "key=max/window" rates. The window is a
+// Go duration; max is also the burst a rested bucket allows.
+func ParseSendRates(value string) ([]SendRate, error) {
+	var rates []SendRate
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, limit, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("send rate %q must be key=max/window", part)
+		}
+		maxValue, windowValue, ok := strings.Cut(limit, "/")
+		if !ok {
+			return nil, fmt.Errorf("send rate %q must be key=max/window", part)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			return nil, fmt.Errorf("send rate %q is missing a key", part)
+		}
+		maxSends, err := strconv.Atoi(strings.TrimSpace(maxValue))
+		if err != nil || maxSends < 1 {
+			return nil, fmt.Errorf("send rate %q must allow at least one email", part)
+		}
+		window, err := time.ParseDuration(strings.TrimSpace(windowValue))
+		if err != nil || window < time.Millisecond {
+			return nil, fmt.Errorf("send rate %q must have a window of at least 1ms", part)
+		}
+		rates = append(rates, SendRate{Key: key, Max: maxSends, Window: window})
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no send rates configured")
+	}
+	return rates, nil
+}
+
+// Wait blocks until the buckets of providerName and of the domain of sender have a token
+// for one more send and takes it; it returns at once when neither has a rate. Time spent
+// waiting is added to the send rate metrics of providerName. It returns the context's
+// error when the context ends first.
+func (l *SendRateLimiter) Wait(ctx context.Context, providerName string, sender string) error {
+	buckets := l.buckets(providerName, sender)
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	waited := false
+	defer func() {
+		if waited {
+			sendRateWaits.Add(providerName, 1)
+			sendRateWaitSeconds.AddFloat(providerName, time.Since(start).Seconds())
+		}
+	}()
+
+	for {
+		now := time.Now()
+		allowed, until, err := l.tokens.Take(ctx, buckets, now)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			if waited {
+				logrus.WithFields(logrus.Fields{
+					"provider": providerName,
+					"sender":   sender,
+					"waited":   time.Since(start).String(),
+				}).Debug("Waited for send rate limit")
+			}
+			return nil
+		}
+
+		waited = true
+		timer := time.NewTimer(until.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// buckets returns the buckets a send through providerName from sender takes a token from.
+func (l *SendRateLimiter) buckets(providerName string, sender string) []ratelimit.Bucket {
+	var buckets []ratelimit.Bucket
+	if bucket, ok := l.providers[strings.ToLower(providerName)]; ok {
+		buckets = append(buckets, bucket)
+	}
+	if bucket, ok := l.domains[senderDomain(sender)]; ok {
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// senderDomain returns the lowercased domain of a From address such as
+// "News <news@example.com>".
+func senderDomain(sender string) string {
+	address := sender
+	if parsed, err := mail.ParseAddress(sender); err == nil {
+		address = parsed.Address
+	}
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(address[at+1:]))
+}
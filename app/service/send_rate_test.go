@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/vibast-solutions/ms-go-notifications/app/ratelimit"
+)
+
+func TestParseSendRates(t *testing.T) {
+	t.Parallel()
+
+	rates, err := ParseSendRates(" SES=14/1s , noop=5/1m ")
+	if err != nil {
+		t.Fatalf("ParseSendRates: %v", err)
+	}
+	if len(rates) != 2 || rates[0] != (SendRate{Key: "ses", Max: 14, Window: time.Second}) || rates[1].Key != "noop" {
+		t.Fatalf("unexpected rates: %+v", rates)
+	}
+
+	for _, value := range []string{"ses", "ses=14", "=14/1s", "ses=0/1s", "ses=14/second", "ses=14/0s", ","} {
+		if _, err := ParseSendRates(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
+
+func TestNewSendRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	l, err := NewSendRateLimiter("", "", nil)
+	if err != nil || l != nil {
+		t.Fatalf("expected disabled limiter, got %v, %v", l, err)
+	}
+	if _, err := NewSendRateLimiter("ses=fast", "", nil); err == nil {
+		t.Fatal("expected invalid rate error")
+	}
+
+	l, err = NewSendRateLimiter("ses=14/1s,smtp=5/1s", "example.com=100/1m", nil)
+	if err != nil {
+		t.Fatalf("NewSendRateLimiter: %v", err)
+	}
+	tests := []struct {
+		provider string
+		sender   string
+		want     []ratelimit.Bucket
+	}{
+		{provider: "SES", sender: "News <news@Example.com>", want: []ratelimit.Bucket{
+			{Key: "notifications:sendrate:provider:ses", Capacity: 14, Period: time.Second},
+			{Key: "notifications:sendrate:domain:example.com", Capacity: 100, Period: time.Minute},
+		}},
+		{provider: "smtp", sender: "noreply@other.com", want: []ratelimit.Bucket{
+			{Key: "notifications:sendrate:provider:smtp", Capacity: 5, Period: time.Second},
+		}},
+		{provider: "noop", sender: "noreply@example.com", want: []ratelimit.Bucket{
+			{Key: "notifications:sendrate:domain:example.com", Capacity: 100, Period: time.Minute},
+		}},
+		{provider: "noop", sender: "noreply@other.com", want: nil},
+	}
+	for _, tt := range tests {
+		if got := l.buckets(tt.provider, tt.sender); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("buckets(%q, %q) = %+v, want %+v", tt.provider, tt.sender, got, tt.want)
+		}
+	}
+}
+
+func TestSendRateLimiterWait(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	l, err := NewSendRateLimiter("wait-test=1/200ms", "", ratelimit.NewTokenBucket(client))
+	if err != nil {
+		t.Fatalf("NewSendRateLimiter: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := l.Wait(ctx, "wait-test", "noreply@example.com"); err != nil {
+		t.Fatalf("Wait(first): %v", err)
+	}
+	if sendRateWaits.Get("wait-test") != nil {
+		t.Fatal("expected the first send not to wait")
+	}
+
+	start := time.Now()
+	if err := l.Wait(ctx, "wait-test", "noreply@example.com"); err != nil {
+		t.Fatalf("Wait(second): %v", err)
+	}
+	if waited := time.Since(start); waited < 150*time.Millisecond {
+		t.Fatalf("expected the second send to wait for a token, waited %s", waited)
+	}
+	if got := sendRateWaits.Get("wait-test"); got == nil || got.String() != "1" {
+		t.Fatalf("expected one recorded wait, got %v", got)
+	}
+	if sendRateWaitSeconds.Get("wait-test") == nil {
+		t.Fatal("expected recorded wait time")
+	}
+
+	// Another provider, reached through failover or routing, has its own rate.
+	start = time.Now()
+	if err := l.Wait(ctx, "wait-test-other", "noreply@example.com"); err != nil {
+		t.Fatalf("Wait(other provider): %v", err)
+	}
+	if waited := time.Since(start); waited > 100*time.Millisecond || sendRateWaits.Get("wait-test-other") != nil {
+		t.Fatalf("expected another provider not to wait, waited %s", waited)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.Wait(cancelled, "wait-test", "noreply@example.com"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -19,7 +19,31 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	NotificationsService_SendRawEmail_FullMethodName = "/notifications.NotificationsService/SendRawEmail"
+	NotificationsService_SendRawEmail_FullMethodName                  = "/notifications.NotificationsService/SendRawEmail"
+	NotificationsService_CancelEmail_FullMethodName                   = "/notifications.NotificationsService/CancelEmail"
+	NotificationsService_SendInAppNotification_FullMethodName         = "/notifications.NotificationsService/SendInAppNotification"
+	NotificationsService_ListInAppNotifications_FullMethodName        = "/notifications.NotificationsService/ListInAppNotifications"
+	NotificationsService_SubscribeNotifications_FullMethodName        = "/notifications.NotificationsService/SubscribeNotifications"
+	NotificationsService_Notify_FullMethodName                        = "/notifications.NotificationsService/Notify"
+	NotificationsService_GetNotification_FullMethodName               = "/notifications.NotificationsService/GetNotification"
+	NotificationsService_UpsertRecipientProfile_FullMethodName        = "/notifications.NotificationsService/UpsertRecipientProfile"
+	NotificationsService_GetRecipientProfile_FullMethodName           = "/notifications.NotificationsService/GetRecipientProfile"
+	NotificationsService_DeleteRecipientProfile_FullMethodName        = "/notifications.NotificationsService/DeleteRecipientProfile"
+	NotificationsService_UpsertNotificationCategory_FullMethodName    = "/notifications.NotificationsService/UpsertNotificationCategory"
+	NotificationsService_ListNotificationCategories_FullMethodName    = "/notifications.NotificationsService/ListNotificationCategories"
+	NotificationsService_GetNotificationPreferences_FullMethodName    = "/notifications.NotificationsService/GetNotificationPreferences"
+	NotificationsService_UpdateNotificationPreferences_FullMethodName = "/notifications.NotificationsService/UpdateNotificationPreferences"
+	NotificationsService_UpsertEmailSchedule_FullMethodName           = "/notifications.NotificationsService/UpsertEmailSchedule"
+	NotificationsService_GetEmailSchedule_FullMethodName              = "/notifications.NotificationsService/GetEmailSchedule"
+	NotificationsService_ListEmailSchedules_FullMethodName            = "/notifications.NotificationsService/ListEmailSchedules"
+	NotificationsService_DeleteEmailSchedule_FullMethodName           = "/notifications.NotificationsService/DeleteEmailSchedule"
+	NotificationsService_SendBulkEmail_FullMethodName                 = "/notifications.NotificationsService/SendBulkEmail"
+	NotificationsService_GetEmailBatch_FullMethodName                 = "/notifications.NotificationsService/GetEmailBatch"
+	NotificationsService_PauseEmailBatch_FullMethodName               = "/notifications.NotificationsService/PauseEmailBatch"
+	NotificationsService_ResumeEmailBatch_FullMethodName              = "/notifications.NotificationsService/ResumeEmailBatch"
+	NotificationsService_CancelEmailBatch_FullMethodName              = "/notifications.NotificationsService/CancelEmailBatch"
+	NotificationsService_DryRunEmailRoute_FullMethodName              = "/notifications.NotificationsService/DryRunEmailRoute"
+	NotificationsService_ListEmailAttempts_FullMethodName             = "/notifications.NotificationsService/ListEmailAttempts"
 )
 
 // NotificationsServiceClient is the client API for NotificationsService service.
@@ -27,6 +51,30 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type NotificationsServiceClient interface {
 	SendRawEmail(ctx context.Context, in *SendRawEmailRequest, opts ...grpc.CallOption) (*SendRawEmailResponse, error)
+	CancelEmail(ctx context.Context, in *CancelEmailRequest, opts ...grpc.CallOption) (*CancelEmailResponse, error)
+	SendInAppNotification(ctx context.Context, in *SendInAppNotificationRequest, opts ...grpc.CallOption) (*SendInAppNotificationResponse, error)
+	ListInAppNotifications(ctx context.Context, in *ListInAppNotificationsRequest, opts ...grpc.CallOption) (*ListInAppNotificationsResponse, error)
+	SubscribeNotifications(ctx context.Context, in *SubscribeNotificationsRequest, opts ...grpc.CallOption) (NotificationsService_SubscribeNotificationsClient, error)
+	Notify(ctx context.Context, in *NotifyRequest, opts ...grpc.CallOption) (*NotifyResponse, error)
+	GetNotification(ctx context.Context, in *GetNotificationRequest, opts ...grpc.CallOption) (*GetNotificationResponse, error)
+	UpsertRecipientProfile(ctx context.Context, in *UpsertRecipientProfileRequest, opts ...grpc.CallOption) (*UpsertRecipientProfileResponse, error)
+	GetRecipientProfile(ctx context.Context, in *GetRecipientProfileRequest, opts ...grpc.CallOption) (*GetRecipientProfileResponse, error)
+	DeleteRecipientProfile(ctx context.Context, in *DeleteRecipientProfileRequest, opts ...grpc.CallOption) (*DeleteRecipientProfileResponse, error)
+	UpsertNotificationCategory(ctx context.Context, in *UpsertNotificationCategoryRequest, opts ...grpc.CallOption) (*UpsertNotificationCategoryResponse, error)
+	ListNotificationCategories(ctx context.Context, in *ListNotificationCategoriesRequest, opts ...grpc.CallOption) (*ListNotificationCategoriesResponse, error)
+	GetNotificationPreferences(ctx context.Context, in *GetNotificationPreferencesRequest, opts ...grpc.CallOption) (*GetNotificationPreferencesResponse, error)
+	UpdateNotificationPreferences(ctx context.Context, in *UpdateNotificationPreferencesRequest, opts ...grpc.CallOption) (*UpdateNotificationPreferencesResponse, error)
+	UpsertEmailSchedule(ctx context.Context, in *UpsertEmailScheduleRequest, opts ...grpc.CallOption) (*UpsertEmailScheduleResponse, error)
+	GetEmailSchedule(ctx context.Context, in *GetEmailScheduleRequest, opts ...grpc.CallOption) (*GetEmailScheduleResponse, error)
+	ListEmailSchedules(ctx context.Context, in *ListEmailSchedulesRequest, opts ...grpc.CallOption) (*ListEmailSchedulesResponse, error)
+	DeleteEmailSchedule(ctx context.Context, in *DeleteEmailScheduleRequest, opts ...grpc.CallOption) (*DeleteEmailScheduleResponse, error)
+	SendBulkEmail(ctx context.Context, opts ...grpc.CallOption) (NotificationsService_SendBulkEmailClient, error)
+	GetEmailBatch(ctx context.Context, in *GetEmailBatchRequest, opts ...grpc.CallOption) (*GetEmailBatchResponse, error)
+	PauseEmailBatch(ctx context.Context, in *PauseEmailBatchRequest, opts ...grpc.CallOption) (*PauseEmailBatchResponse, error)
+	ResumeEmailBatch(ctx context.Context, in *ResumeEmailBatchRequest, opts ...grpc.CallOption) (*ResumeEmailBatchResponse, error)
+	CancelEmailBatch(ctx context.Context, in *CancelEmailBatchRequest, opts ...grpc.CallOption) (*CancelEmailBatchResponse, error)
+	DryRunEmailRoute(ctx context.Context, in *DryRunEmailRouteRequest, opts ...grpc.CallOption) (*DryRunEmailRouteResponse, error)
+	ListEmailAttempts(ctx context.Context, in *ListEmailAttemptsRequest, opts ...grpc.CallOption) (*ListEmailAttemptsResponse, error)
 }
 
 type notificationsServiceClient struct {
@@ -46,11 +94,299 @@ func (c *notificationsServiceClient) SendRawEmail(ctx context.Context, in *SendR
 	return out, nil
 }
 
+func (c *notificationsServiceClient) CancelEmail(ctx context.Context, in *CancelEmailRequest, opts ...grpc.CallOption) (*CancelEmailResponse, error) {
+	out := new(CancelEmailResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_CancelEmail_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) SendInAppNotification(ctx context.Context, in *SendInAppNotificationRequest, opts ...grpc.CallOption) (*SendInAppNotificationResponse, error) {
+	out := new(SendInAppNotificationResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_SendInAppNotification_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) ListInAppNotifications(ctx context.Context, in *ListInAppNotificationsRequest, opts ...grpc.CallOption) (*ListInAppNotificationsResponse, error) {
+	out := new(ListInAppNotificationsResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_ListInAppNotifications_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) SubscribeNotifications(ctx context.Context, in *SubscribeNotificationsRequest, opts ...grpc.CallOption) (NotificationsService_SubscribeNotificationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &NotificationsService_ServiceDesc.Streams[0], NotificationsService_SubscribeNotifications_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &notificationsServiceSubscribeNotificationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NotificationsService_SubscribeNotificationsClient interface {
+	Recv() (*InAppNotification, error)
+	grpc.ClientStream
+}
+
+type notificationsServiceSubscribeNotificationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *notificationsServiceSubscribeNotificationsClient) Recv() (*InAppNotification, error) {
+	m := new(InAppNotification)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *notificationsServiceClient) Notify(ctx context.Context, in *NotifyRequest, opts ...grpc.CallOption) (*NotifyResponse, error) {
+	out := new(NotifyResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_Notify_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) GetNotification(ctx context.Context, in *GetNotificationRequest, opts ...grpc.CallOption) (*GetNotificationResponse, error) {
+	out := new(GetNotificationResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_GetNotification_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) UpsertRecipientProfile(ctx context.Context, in *UpsertRecipientProfileRequest, opts ...grpc.CallOption) (*UpsertRecipientProfileResponse, error) {
+	out := new(UpsertRecipientProfileResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_UpsertRecipientProfile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) GetRecipientProfile(ctx context.Context, in *GetRecipientProfileRequest, opts ...grpc.CallOption) (*GetRecipientProfileResponse, error) {
+	out := new(GetRecipientProfileResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_GetRecipientProfile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) DeleteRecipientProfile(ctx context.Context, in *DeleteRecipientProfileRequest, opts ...grpc.CallOption) (*DeleteRecipientProfileResponse, error) {
+	out := new(DeleteRecipientProfileResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_DeleteRecipientProfile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) UpsertNotificationCategory(ctx context.Context, in *UpsertNotificationCategoryRequest, opts ...grpc.CallOption) (*UpsertNotificationCategoryResponse, error) {
+	out := new(UpsertNotificationCategoryResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_UpsertNotificationCategory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) ListNotificationCategories(ctx context.Context, in *ListNotificationCategoriesRequest, opts ...grpc.CallOption) (*ListNotificationCategoriesResponse, error) {
+	out := new(ListNotificationCategoriesResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_ListNotificationCategories_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) GetNotificationPreferences(ctx context.Context, in *GetNotificationPreferencesRequest, opts ...grpc.CallOption) (*GetNotificationPreferencesResponse, error) {
+	out := new(GetNotificationPreferencesResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_GetNotificationPreferences_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) UpdateNotificationPreferences(ctx context.Context, in *UpdateNotificationPreferencesRequest, opts ...grpc.CallOption) (*UpdateNotificationPreferencesResponse, error) {
+	out := new(UpdateNotificationPreferencesResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_UpdateNotificationPreferences_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) UpsertEmailSchedule(ctx context.Context, in *UpsertEmailScheduleRequest, opts ...grpc.CallOption) (*UpsertEmailScheduleResponse, error) {
+	out := new(UpsertEmailScheduleResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_UpsertEmailSchedule_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) GetEmailSchedule(ctx context.Context, in *GetEmailScheduleRequest, opts ...grpc.CallOption) (*GetEmailScheduleResponse, error) {
+	out := new(GetEmailScheduleResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_GetEmailSchedule_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) ListEmailSchedules(ctx context.Context, in *ListEmailSchedulesRequest, opts ...grpc.CallOption) (*ListEmailSchedulesResponse, error) {
+	out := new(ListEmailSchedulesResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_ListEmailSchedules_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) DeleteEmailSchedule(ctx context.Context, in *DeleteEmailScheduleRequest, opts ...grpc.CallOption) (*DeleteEmailScheduleResponse, error) {
+	out := new(DeleteEmailScheduleResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_DeleteEmailSchedule_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) SendBulkEmail(ctx context.Context, opts ...grpc.CallOption) (NotificationsService_SendBulkEmailClient, error) {
+	stream, err := c.cc.NewStream(ctx, &NotificationsService_ServiceDesc.Streams[1], NotificationsService_SendBulkEmail_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &notificationsServiceSendBulkEmailClient{stream}
+	return x, nil
+}
+
+type NotificationsService_SendBulkEmailClient interface {
+	Send(*SendBulkEmailRequest) error
+	CloseAndRecv() (*SendBulkEmailResponse, error)
+	grpc.ClientStream
+}
+
+type notificationsServiceSendBulkEmailClient struct {
+	grpc.ClientStream
+}
+
+func (x *notificationsServiceSendBulkEmailClient) Send(m *SendBulkEmailRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *notificationsServiceSendBulkEmailClient) CloseAndRecv() (*SendBulkEmailResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(SendBulkEmailResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *notificationsServiceClient) GetEmailBatch(ctx context.Context, in *GetEmailBatchRequest, opts ...grpc.CallOption) (*GetEmailBatchResponse, error) {
+	out := new(GetEmailBatchResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_GetEmailBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) PauseEmailBatch(ctx context.Context, in *PauseEmailBatchRequest, opts ...grpc.CallOption) (*PauseEmailBatchResponse, error) {
+	out := new(PauseEmailBatchResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_PauseEmailBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) ResumeEmailBatch(ctx context.Context, in *ResumeEmailBatchRequest, opts ...grpc.CallOption) (*ResumeEmailBatchResponse, error) {
+	out := new(ResumeEmailBatchResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_ResumeEmailBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) CancelEmailBatch(ctx context.Context, in *CancelEmailBatchRequest, opts ...grpc.CallOption) (*CancelEmailBatchResponse, error) {
+	out := new(CancelEmailBatchResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_CancelEmailBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) DryRunEmailRoute(ctx context.Context, in *DryRunEmailRouteRequest, opts ...grpc.CallOption) (*DryRunEmailRouteResponse, error) {
+	out := new(DryRunEmailRouteResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_DryRunEmailRoute_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationsServiceClient) ListEmailAttempts(ctx context.Context, in *ListEmailAttemptsRequest, opts ...grpc.CallOption) (*ListEmailAttemptsResponse, error) {
+	out := new(ListEmailAttemptsResponse)
+	err := c.cc.Invoke(ctx, NotificationsService_ListEmailAttempts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NotificationsServiceServer is the server API for NotificationsService service.
 // All implementations must embed UnimplementedNotificationsServiceServer
 // for forward compatibility
 type NotificationsServiceServer interface {
 	SendRawEmail(context.Context, *SendRawEmailRequest) (*SendRawEmailResponse, error)
+	CancelEmail(context.Context, *CancelEmailRequest) (*CancelEmailResponse, error)
+	SendInAppNotification(context.Context, *SendInAppNotificationRequest) (*SendInAppNotificationResponse, error)
+	ListInAppNotifications(context.Context, *ListInAppNotificationsRequest) (*ListInAppNotificationsResponse, error)
+	SubscribeNotifications(*SubscribeNotificationsRequest, NotificationsService_SubscribeNotificationsServer) error
+	Notify(context.Context, *NotifyRequest) (*NotifyResponse, error)
+	GetNotification(context.Context, *GetNotificationRequest) (*GetNotificationResponse, error)
+	UpsertRecipientProfile(context.Context, *UpsertRecipientProfileRequest) (*UpsertRecipientProfileResponse, error)
+	GetRecipientProfile(context.Context, *GetRecipientProfileRequest) (*GetRecipientProfileResponse, error)
+	DeleteRecipientProfile(context.Context, *DeleteRecipientProfileRequest) (*DeleteRecipientProfileResponse, error)
+	UpsertNotificationCategory(context.Context, *UpsertNotificationCategoryRequest) (*UpsertNotificationCategoryResponse, error)
+	ListNotificationCategories(context.Context, *ListNotificationCategoriesRequest) (*ListNotificationCategoriesResponse, error)
+	GetNotificationPreferences(context.Context, *GetNotificationPreferencesRequest) (*GetNotificationPreferencesResponse, error)
+	UpdateNotificationPreferences(context.Context, *UpdateNotificationPreferencesRequest) (*UpdateNotificationPreferencesResponse, error)
+	UpsertEmailSchedule(context.Context, *UpsertEmailScheduleRequest) (*UpsertEmailScheduleResponse, error)
+	GetEmailSchedule(context.Context, *GetEmailScheduleRequest) (*GetEmailScheduleResponse, error)
+	ListEmailSchedules(context.Context, *ListEmailSchedulesRequest) (*ListEmailSchedulesResponse, error)
+	DeleteEmailSchedule(context.Context, *DeleteEmailScheduleRequest) (*DeleteEmailScheduleResponse, error)
+	SendBulkEmail(NotificationsService_SendBulkEmailServer) error
+	GetEmailBatch(context.Context, *GetEmailBatchRequest) (*GetEmailBatchResponse, error)
+	PauseEmailBatch(context.Context, *PauseEmailBatchRequest) (*PauseEmailBatchResponse, error)
+	ResumeEmailBatch(context.Context, *ResumeEmailBatchRequest) (*ResumeEmailBatchResponse, error)
+	CancelEmailBatch(context.Context, *CancelEmailBatchRequest) (*CancelEmailBatchResponse, error)
+	DryRunEmailRoute(context.Context, *DryRunEmailRouteRequest) (*DryRunEmailRouteResponse, error)
+	ListEmailAttempts(context.Context, *ListEmailAttemptsRequest) (*ListEmailAttemptsResponse, error)
 	mustEmbedUnimplementedNotificationsServiceServer()
 }
 
@@ -61,6 +397,78 @@ type UnimplementedNotificationsServiceServer struct {
 func (UnimplementedNotificationsServiceServer) SendRawEmail(context.Context, *SendRawEmailRequest) (*SendRawEmailResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendRawEmail not implemented")
 }
+func (UnimplementedNotificationsServiceServer) CancelEmail(context.Context, *CancelEmailRequest) (*CancelEmailResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelEmail not implemented")
+}
+func (UnimplementedNotificationsServiceServer) SendInAppNotification(context.Context, *SendInAppNotificationRequest) (*SendInAppNotificationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendInAppNotification not implemented")
+}
+func (UnimplementedNotificationsServiceServer) ListInAppNotifications(context.Context, *ListInAppNotificationsRequest) (*ListInAppNotificationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInAppNotifications not implemented")
+}
+func (UnimplementedNotificationsServiceServer) SubscribeNotifications(*SubscribeNotificationsRequest, NotificationsService_SubscribeNotificationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeNotifications not implemented")
+}
+func (UnimplementedNotificationsServiceServer) Notify(context.Context, *NotifyRequest) (*NotifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Notify not implemented")
+}
+func (UnimplementedNotificationsServiceServer) GetNotification(context.Context, *GetNotificationRequest) (*GetNotificationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNotification not implemented")
+}
+func (UnimplementedNotificationsServiceServer) UpsertRecipientProfile(context.Context, *UpsertRecipientProfileRequest) (*UpsertRecipientProfileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpsertRecipientProfile not implemented")
+}
+func (UnimplementedNotificationsServiceServer) GetRecipientProfile(context.Context, *GetRecipientProfileRequest) (*GetRecipientProfileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecipientProfile not implemented")
+}
+func (UnimplementedNotificationsServiceServer) DeleteRecipientProfile(context.Context, *DeleteRecipientProfileRequest) (*DeleteRecipientProfileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRecipientProfile not implemented")
+}
+func (UnimplementedNotificationsServiceServer) UpsertNotificationCategory(context.Context, *UpsertNotificationCategoryRequest) (*UpsertNotificationCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpsertNotificationCategory not implemented")
+}
+func (UnimplementedNotificationsServiceServer) ListNotificationCategories(context.Context, *ListNotificationCategoriesRequest) (*ListNotificationCategoriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNotificationCategories not implemented")
+}
+func (UnimplementedNotificationsServiceServer) GetNotificationPreferences(context.Context, *GetNotificationPreferencesRequest) (*GetNotificationPreferencesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNotificationPreferences not implemented")
+}
+func (UnimplementedNotificationsServiceServer) UpdateNotificationPreferences(context.Context, *UpdateNotificationPreferencesRequest) (*UpdateNotificationPreferencesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateNotificationPreferences not implemented")
+}
+func (UnimplementedNotificationsServiceServer) UpsertEmailSchedule(context.Context, *UpsertEmailScheduleRequest) (*UpsertEmailScheduleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpsertEmailSchedule not implemented")
+}
+func (UnimplementedNotificationsServiceServer) GetEmailSchedule(context.Context, *GetEmailScheduleRequest) (*GetEmailScheduleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEmailSchedule not implemented")
+}
+func (UnimplementedNotificationsServiceServer) ListEmailSchedules(context.Context, *ListEmailSchedulesRequest) (*ListEmailSchedulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEmailSchedules not implemented")
+}
+func (UnimplementedNotificationsServiceServer) DeleteEmailSchedule(context.Context, *DeleteEmailScheduleRequest) (*DeleteEmailScheduleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteEmailSchedule not implemented")
+}
+func (UnimplementedNotificationsServiceServer) SendBulkEmail(NotificationsService_SendBulkEmailServer) error {
+	return status.Errorf(codes.Unimplemented, "method SendBulkEmail not implemented")
+}
+func (UnimplementedNotificationsServiceServer) GetEmailBatch(context.Context, *GetEmailBatchRequest) (*GetEmailBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEmailBatch not implemented")
+}
+func (UnimplementedNotificationsServiceServer) PauseEmailBatch(context.Context, *PauseEmailBatchRequest) (*PauseEmailBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseEmailBatch not implemented")
+}
+func (UnimplementedNotificationsServiceServer) ResumeEmailBatch(context.Context, *ResumeEmailBatchRequest) (*ResumeEmailBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeEmailBatch not implemented")
+}
+func (UnimplementedNotificationsServiceServer) CancelEmailBatch(context.Context, *CancelEmailBatchRequest) (*CancelEmailBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelEmailBatch not implemented")
+}
+func (UnimplementedNotificationsServiceServer) DryRunEmailRoute(context.Context, *DryRunEmailRouteRequest) (*DryRunEmailRouteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DryRunEmailRoute not implemented")
+}
+func (UnimplementedNotificationsServiceServer) ListEmailAttempts(context.Context, *ListEmailAttemptsRequest) (*ListEmailAttemptsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEmailAttempts not implemented")
+}
 func (UnimplementedNotificationsServiceServer) mustEmbedUnimplementedNotificationsServiceServer() {}
 
 // UnsafeNotificationsServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -92,18 +500,560 @@ func _NotificationsService_SendRawEmail_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
-// NotificationsService_ServiceDesc is the grpc.ServiceDesc for NotificationsService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var NotificationsService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "notifications.NotificationsService",
-	HandlerType: (*NotificationsServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "SendRawEmail",
-			Handler:    _NotificationsService_SendRawEmail_Handler,
+func _NotificationsService_CancelEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelEmailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).CancelEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_CancelEmail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).CancelEmail(ctx, req.(*CancelEmailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_SendInAppNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendInAppNotificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).SendInAppNotification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_SendInAppNotification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).SendInAppNotification(ctx, req.(*SendInAppNotificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_ListInAppNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInAppNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).ListInAppNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_ListInAppNotifications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).ListInAppNotifications(ctx, req.(*ListInAppNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_SubscribeNotifications_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeNotificationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NotificationsServiceServer).SubscribeNotifications(m, &notificationsServiceSubscribeNotificationsServer{stream})
+}
+
+type NotificationsService_SubscribeNotificationsServer interface {
+	Send(*InAppNotification) error
+	grpc.ServerStream
+}
+
+type notificationsServiceSubscribeNotificationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *notificationsServiceSubscribeNotificationsServer) Send(m *InAppNotification) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _NotificationsService_Notify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NotifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).Notify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_Notify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).Notify(ctx, req.(*NotifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_GetNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNotificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).GetNotification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_GetNotification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).GetNotification(ctx, req.(*GetNotificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_UpsertRecipientProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpsertRecipientProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).UpsertRecipientProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_UpsertRecipientProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).UpsertRecipientProfile(ctx, req.(*UpsertRecipientProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_GetRecipientProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecipientProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).GetRecipientProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_GetRecipientProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).GetRecipientProfile(ctx, req.(*GetRecipientProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_DeleteRecipientProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRecipientProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).DeleteRecipientProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_DeleteRecipientProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).DeleteRecipientProfile(ctx, req.(*DeleteRecipientProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_UpsertNotificationCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpsertNotificationCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).UpsertNotificationCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_UpsertNotificationCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).UpsertNotificationCategory(ctx, req.(*UpsertNotificationCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_ListNotificationCategories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNotificationCategoriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).ListNotificationCategories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_ListNotificationCategories_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).ListNotificationCategories(ctx, req.(*ListNotificationCategoriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_GetNotificationPreferences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNotificationPreferencesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).GetNotificationPreferences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_GetNotificationPreferences_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).GetNotificationPreferences(ctx, req.(*GetNotificationPreferencesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_UpdateNotificationPreferences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateNotificationPreferencesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).UpdateNotificationPreferences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_UpdateNotificationPreferences_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).UpdateNotificationPreferences(ctx, req.(*UpdateNotificationPreferencesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_UpsertEmailSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpsertEmailScheduleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).UpsertEmailSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_UpsertEmailSchedule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).UpsertEmailSchedule(ctx, req.(*UpsertEmailScheduleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_GetEmailSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEmailScheduleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).GetEmailSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_GetEmailSchedule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).GetEmailSchedule(ctx, req.(*GetEmailScheduleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_ListEmailSchedules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEmailSchedulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).ListEmailSchedules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_ListEmailSchedules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).ListEmailSchedules(ctx, req.(*ListEmailSchedulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_DeleteEmailSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteEmailScheduleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).DeleteEmailSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_DeleteEmailSchedule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).DeleteEmailSchedule(ctx, req.(*DeleteEmailScheduleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_SendBulkEmail_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NotificationsServiceServer).SendBulkEmail(&notificationsServiceSendBulkEmailServer{stream})
+}
+
+type NotificationsService_SendBulkEmailServer interface {
+	SendAndClose(*SendBulkEmailResponse) error
+	Recv() (*SendBulkEmailRequest, error)
+	grpc.ServerStream
+}
+
+type notificationsServiceSendBulkEmailServer struct {
+	grpc.ServerStream
+}
+
+func (x *notificationsServiceSendBulkEmailServer) SendAndClose(m *SendBulkEmailResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *notificationsServiceSendBulkEmailServer) Recv() (*SendBulkEmailRequest, error) {
+	m := new(SendBulkEmailRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _NotificationsService_GetEmailBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEmailBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).GetEmailBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_GetEmailBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).GetEmailBatch(ctx, req.(*GetEmailBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_PauseEmailBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseEmailBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).PauseEmailBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_PauseEmailBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).PauseEmailBatch(ctx, req.(*PauseEmailBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_ResumeEmailBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeEmailBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).ResumeEmailBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_ResumeEmailBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).ResumeEmailBatch(ctx, req.(*ResumeEmailBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_CancelEmailBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelEmailBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).CancelEmailBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_CancelEmailBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).CancelEmailBatch(ctx, req.(*CancelEmailBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_DryRunEmailRoute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DryRunEmailRouteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).DryRunEmailRoute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_DryRunEmailRoute_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).DryRunEmailRoute(ctx, req.(*DryRunEmailRouteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationsService_ListEmailAttempts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEmailAttemptsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationsServiceServer).ListEmailAttempts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationsService_ListEmailAttempts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationsServiceServer).ListEmailAttempts(ctx, req.(*ListEmailAttemptsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// NotificationsService_ServiceDesc is the grpc.ServiceDesc for NotificationsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NotificationsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "notifications.NotificationsService",
+	HandlerType: (*NotificationsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendRawEmail",
+			Handler:    _NotificationsService_SendRawEmail_Handler,
+		},
+		{
+			MethodName: "CancelEmail",
+			Handler:    _NotificationsService_CancelEmail_Handler,
+		},
+		{
+			MethodName: "SendInAppNotification",
+			Handler:    _NotificationsService_SendInAppNotification_Handler,
+		},
+		{
+			MethodName: "ListInAppNotifications",
+			Handler:    _NotificationsService_ListInAppNotifications_Handler,
+		},
+		{
+			MethodName: "Notify",
+			Handler:    _NotificationsService_Notify_Handler,
+		},
+		{
+			MethodName: "GetNotification",
+			Handler:    _NotificationsService_GetNotification_Handler,
+		},
+		{
+			MethodName: "UpsertRecipientProfile",
+			Handler:    _NotificationsService_UpsertRecipientProfile_Handler,
+		},
+		{
+			MethodName: "GetRecipientProfile",
+			Handler:    _NotificationsService_GetRecipientProfile_Handler,
+		},
+		{
+			MethodName: "DeleteRecipientProfile",
+			Handler:    _NotificationsService_DeleteRecipientProfile_Handler,
+		},
+		{
+			MethodName: "UpsertNotificationCategory",
+			Handler:    _NotificationsService_UpsertNotificationCategory_Handler,
+		},
+		{
+			MethodName: "ListNotificationCategories",
+			Handler:    _NotificationsService_ListNotificationCategories_Handler,
+		},
+		{
+			MethodName: "GetNotificationPreferences",
+			Handler:    _NotificationsService_GetNotificationPreferences_Handler,
+		},
+		{
+			MethodName: "UpdateNotificationPreferences",
+			Handler:    _NotificationsService_UpdateNotificationPreferences_Handler,
+		},
+		{
+			MethodName: "UpsertEmailSchedule",
+			Handler:    _NotificationsService_UpsertEmailSchedule_Handler,
+		},
+		{
+			MethodName: "GetEmailSchedule",
+			Handler:    _NotificationsService_GetEmailSchedule_Handler,
+		},
+		{
+			MethodName: "ListEmailSchedules",
+			Handler:    _NotificationsService_ListEmailSchedules_Handler,
+		},
+		{
+			MethodName: "DeleteEmailSchedule",
+			Handler:    _NotificationsService_DeleteEmailSchedule_Handler,
+		},
+		{
+			MethodName: "GetEmailBatch",
+			Handler:    _NotificationsService_GetEmailBatch_Handler,
+		},
+		{
+			MethodName: "PauseEmailBatch",
+			Handler:    _NotificationsService_PauseEmailBatch_Handler,
+		},
+		{
+			MethodName: "ResumeEmailBatch",
+			Handler:    _NotificationsService_ResumeEmailBatch_Handler,
+		},
+		{
+			MethodName: "CancelEmailBatch",
+			Handler:    _NotificationsService_CancelEmailBatch_Handler,
+		},
+		{
+			MethodName: "DryRunEmailRoute",
+			Handler:    _NotificationsService_DryRunEmailRoute_Handler,
+		},
+		{
+			MethodName: "ListEmailAttempts",
+			Handler:    _NotificationsService_ListEmailAttempts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeNotifications",
+			Handler:       _NotificationsService_SubscribeNotifications_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SendBulkEmail",
+			Handler:       _NotificationsService_SendBulkEmail_Handler,
+			ClientStreams: true,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "notifications.proto",
 }
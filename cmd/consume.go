@@ -3,13 +3,21 @@ package cmd
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/vibast-solutions/ms-go-notifications/app/digest"
 	"github.com/vibast-solutions/ms-go-notifications/app/lock"
 	"github.com/vibast-solutions/ms-go-notifications/app/preparer"
 	"github.com/vibast-solutions/ms-go-notifications/app/queue"
+	"github.com/vibast-solutions/ms-go-notifications/app/ratelimit"
 	"github.com/vibast-solutions/ms-go-notifications/app/repository"
 	"github.com/vibast-solutions/ms-go-notifications/app/service"
 	"github.com/vibast-solutions/ms-go-notifications/config"
@@ -20,10 +28,13 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// digestFlushInterval is how often consumers check for digests whose window has passed.
+const digestFlushInterval = 30 * time.Second
+
 var consumeCmd = &cobra.Command{
 	Use:   "consume",
 	Short: "Consume queued messages",
-	Long:  "Consume queued messages from Redis streams.",
+	Long:  "Consume queued messages from the configured queue backend.",
 }
 
 // init registers consume subcommands.
@@ -35,7 +46,7 @@ func init() {
 var consumeEmailsCmd = &cobra.Command{
 	Use:   "emails [consumer_name]",
 	Short: "Start the email queue consumer",
-	Long:  "Start a worker that reads email messages from the Redis stream and sends them via SES.",
+	Long:  "Start a worker that reads email messages from the configured queue backend and sends them via SES.",
 	Args:  cobra.ExactArgs(1),
 	Run:   runConsumeEmails,
 }
@@ -65,29 +76,44 @@ func runConsumeEmails(_ *cobra.Command, args []string) {
 	if err := db.Ping(); err != nil {
 		logrus.WithError(err).Fatal("Failed to ping database")
 	}
+	checkSchema(db)
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.Redis.Addr,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
-	defer rdb.Close()
-
-	if err := rdb.Ping(context.Background()).Err(); err != nil {
-		logrus.WithError(err).Fatal("Failed to connect to Redis")
+	rdb := connectRedis(cfg)
+	if rdb != nil {
+		defer rdb.Close()
 	}
 
-	emailProvider, err := buildEmailProvider(cfg)
+	emailRouter, err := buildEmailRouter(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load email routing rules")
+	}
+	captures, err := buildCaptureStore(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to build capture store")
+	}
+	emailProvider, err := buildEmailProvider(cfg, emailRouter, captures, buildSendPacer(cfg, rdb))
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to build email provider")
 	}
 
 	emailPreparer := preparer.NewChain(preparer.NewRawPreparer(cfg.EmailProviders.AWS.SourceEmail))
 	emailHistory := repository.NewEmailHistoryRepository(db)
-	locker := lock.NewRedisLocker(rdb)
-	emailService := service.NewEmailService(emailPreparer, emailProvider, emailHistory, locker)
+	profiles := repository.NewRecipientProfileRepository(db)
+	preferenceService := service.NewPreferenceService(repository.NewPreferenceRepository(db))
+	locker := newLocker(db, rdb)
+	emailService := service.NewEmailService(emailPreparer, emailProvider, emailHistory, repository.NewEmailAttemptRepository(db), profiles, preferenceService, locker)
+	campaignService := service.NewCampaignService(repository.NewEmailBatchRepository(db))
 
-	consumer := queue.NewEmailConsumer(rdb, emailService, consumerName)
+	laneScheduler, err := queue.NewLaneScheduler(cfg.Queue.LaneStrategy, cfg.Queue.LaneWeights)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid queue lane configuration")
+	}
+	receiver, closeQueue, err := buildEmailReceiver(cfg, db, rdb, consumerName, laneScheduler)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to build email queue")
+	}
+	defer closeQueue()
+	consumer, digestFlusher := newEmailWorker(cfg, rdb, receiver, emailHistory, profiles, emailService, campaignService, locker)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -101,9 +127,83 @@ func runConsumeEmails(_ *cobra.Command, args []string) {
 		cancel()
 	}()
 
-	if err := consumer.Run(ctx); err != nil {
+	go digestFlusher.Run(ctx)
+
+	if cfg.Metrics.Addr != "" {
+		go serveMetrics(cfg.Metrics.Addr)
+	}
+
+	// Send attempts are recorded under the consumer name.
+	if err := consumer.Run(service.WithWorker(ctx, consumerName)); err != nil {
 		logrus.WithError(err).Fatal("Consumer error")
 	}
 
 	logrus.Info("Consumer stopped")
 }
+
+// buildEmailReceiver returns the consuming side of the configured queue backend, reading
+// the priority lanes in the order the scheduler picks, and a function that closes the
+// backend's connection.
+func buildEmailReceiver(cfg *config.Config, db *sql.DB, rdb *redis.Client, consumerName string, scheduler *queue.LaneScheduler) (queue.EmailReceiver, func(), error) {
+	switch strings.ToLower(cfg.Queue.Backend) {
+	case "", "redis":
+		return queue.NewRedisReceiver(rdb, consumerName, scheduler), func() {}, nil
+	case "mysql":
+		return queue.NewMySQLQueue(db, scheduler), func() {}, nil
+	case "nats":
+		return connectNATSQueue(cfg, scheduler)
+	case "memory":
+		return nil, nil, errors.New("QUEUE_BACKEND memory is consumed inside serve")
+	default:
+		return nil, nil, fmt.Errorf("unsupported QUEUE_BACKEND: %s", cfg.Queue.Backend)
+	}
+}
+
+// newEmailWorker builds the email consumer and the digest flusher that run next to it, for
+// `consume emails` and for the in-process worker serve runs with the memory queue.
+func newEmailWorker(
+	cfg *config.Config,
+	rdb *redis.Client,
+	receiver queue.EmailReceiver,
+	emailHistory *repository.EmailHistoryRepository,
+	profiles *repository.RecipientProfileRepository,
+	emailService *service.EmailService,
+	campaigns *service.CampaignService,
+	locker lock.Locker,
+) (*queue.EmailConsumer, *digest.Flusher) {
+	quietHours, err := service.NewQuietHoursService(cfg.QuietHours.Start, cfg.QuietHours.End, cfg.QuietHours.DefaultTimezone, profiles)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid quiet hours configuration")
+	}
+
+	// Frequency caps are counted in Redis, shared by every worker.
+	caps := cfg.FrequencyCaps.Caps
+	if rdb == nil && strings.TrimSpace(caps) != "" {
+		logrus.Warn("FREQUENCY_CAPS needs Redis; frequency caps are disabled")
+		caps = ""
+	}
+
+	frequencyCaps, err := service.NewFrequencyCapService(caps, cfg.FrequencyCaps.Policy, ratelimit.NewSlidingWindow(rdb))
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid frequency cap configuration")
+	}
+
+	digestRenderer, err := digest.NewRenderer(cfg.Digest.Subject, cfg.Digest.TemplatePath)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid digest template")
+	}
+
+	consumer := queue.NewEmailConsumer(receiver, emailService, quietHours, frequencyCaps, campaigns)
+	digestFlusher := digest.NewFlusher(emailHistory, digestRenderer, locker, cfg.Digest.Window, digestFlushInterval)
+	return consumer, digestFlusher
+}
+
+// serveMetrics serves the expvar metrics, such as send rate waits, on /debug/vars.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	logrus.WithField("addr", addr).Info("Starting metrics server")
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logrus.WithError(err).Error("Metrics server error")
+	}
+}
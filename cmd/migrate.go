@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/migrate"
+	"github.com/vibast-solutions/ms-go-notifications/config"
+	"github.com/vibast-solutions/ms-go-notifications/migrations"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage the database schema",
+	Long:  "Apply, revert, and inspect the versioned schema migrations embedded in this build.",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	Args:  cobra.NoArgs,
+	Run:   runMigrateUp,
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Revert the latest applied migrations",
+	Args:  cobra.NoArgs,
+	Run:   runMigrateDown,
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List migrations and whether they are applied",
+	Args:  cobra.NoArgs,
+	Run:   runMigrateStatus,
+}
+
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create empty up and down files for a new migration",
+	Long:  "Create empty up and down files for a new migration in the migrations directory. Rebuild to embed them.",
+	Args:  cobra.ExactArgs(1),
+	Run:   runMigrateCreate,
+}
+
+var (
+	migrateUpLimit  int
+	migrateDownStep int
+	migrateDir      string
+)
+
+// init registers migrate subcommands and flags.
+func init() {
+	migrateUpCmd.Flags().IntVar(&migrateUpLimit, "limit", 0, "apply at most this many migrations; 0 applies all")
+	migrateDownCmd.Flags().IntVar(&migrateDownStep, "steps", 1, "number of migrations to revert")
+	migrateCreateCmd.Flags().StringVar(&migrateDir, "dir", "migrations", "directory holding the migration files")
+
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd, migrateCreateCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+// runMigrateUp applies the pending migrations.
+func runMigrateUp(_ *cobra.Command, _ []string) {
+	db := openMigrateDatabase()
+	defer db.Close()
+
+	applied, err := newMigrator(db).Up(context.Background(), migrateUpLimit)
+	for _, migration := range applied {
+		fmt.Printf("%04d_%s\tapplied\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to apply migrations")
+	}
+	if len(applied) == 0 {
+		fmt.Println("schema is up to date")
+	}
+}
+
+// runMigrateDown reverts the latest applied migrations.
+func runMigrateDown(_ *cobra.Command, _ []string) {
+	if migrateDownStep < 1 {
+		logrus.Fatal("--steps must be at least 1")
+	}
+	db := openMigrateDatabase()
+	defer db.Close()
+
+	reverted, err := newMigrator(db).Down(context.Background(), migrateDownStep)
+	for _, migration := range reverted {
+		fmt.Printf("%04d_%s\treverted\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to revert migrations")
+	}
+}
+
+// runMigrateStatus prints every known or applied migration.
+func runMigrateStatus(_ *cobra.Command, _ []string) {
+	db := openMigrateDatabase()
+	defer db.Close()
+
+	statuses, err := newMigrator(db).Status(context.Background())
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load migration status")
+	}
+	for _, status := range statuses {
+		switch {
+		case !status.Known:
+			fmt.Printf("%04d_%s\tapplied=%s\tunknown to this build\n", status.Version, status.Name, status.AppliedAt.Format(time.RFC3339))
+		case status.Applied:
+			fmt.Printf("%04d_%s\tapplied=%s\n", status.Version, status.Name, status.AppliedAt.Format(time.RFC3339))
+		default:
+			fmt.Printf("%04d_%s\tpending\n", status.Version, status.Name)
+		}
+	}
+}
+
+// runMigrateCreate writes the files of a new migration.
+func runMigrateCreate(_ *cobra.Command, args []string) {
+	up, down, err := migrate.Create(migrateDir, args[0])
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create migration")
+	}
+	fmt.Println(up)
+	fmt.Println(down)
+}
+
+// newMigrator builds a migrator over the migrations embedded in this build.
+func newMigrator(db *sql.DB) *migrate.Migrator {
+	embedded, err := migrate.Load(migrations.FS)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load migrations")
+	}
+	return migrate.NewMigrator(db, embedded)
+}
+
+// checkSchema stops the process when the database misses migrations of this build.
+func checkSchema(db *sql.DB) {
+	if err := newMigrator(db).Check(context.Background()); err != nil {
+		logrus.WithError(err).Fatal("Database schema check failed; run `migrate up`")
+	}
+}
+
+// openMigrateDatabase loads configuration and logging and connects to MySQL.
+func openMigrateDatabase() *sql.DB {
+	cfg, err := config.LoadMySQL()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load configuration")
+	}
+	if err := configureLogging(cfg); err != nil {
+		logrus.WithError(err).Fatal("Failed to configure logging")
+	}
+
+	db, err := sql.Open("mysql", cfg.MySQL.DSN)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	if err := db.Ping(); err != nil {
+		logrus.WithError(err).Fatal("Failed to ping database")
+	}
+	return db
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/vibast-solutions/ms-go-notifications/app/dto"
+	"github.com/vibast-solutions/ms-go-notifications/app/repository"
+	"github.com/vibast-solutions/ms-go-notifications/app/scheduler"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
+	"github.com/vibast-solutions/ms-go-notifications/config"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage recurring email schedules",
+	Long:  "Create, list, and delete recurring email schedules and run the scheduler that fires them.",
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Start the recurring schedule runner",
+	Long:  "Start a scheduler that enqueues due schedules. Run several replicas; one leader fires each tick.",
+	Args:  cobra.NoArgs,
+	Run:   runScheduler,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recurring email schedules",
+	Args:  cobra.NoArgs,
+	Run:   runScheduleList,
+}
+
+var scheduleUpsertCmd = &cobra.Command{
+	Use:   "upsert [name]",
+	Short: "Create or replace a recurring email schedule",
+	Args:  cobra.ExactArgs(1),
+	Run:   runScheduleUpsert,
+}
+
+var scheduleDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a recurring email schedule",
+	Args:  cobra.ExactArgs(1),
+	Run:   runScheduleDelete,
+}
+
+var (
+	scheduleInterval time.Duration
+	scheduleUpsert   dto.UpsertScheduleRequest
+	scheduleUserIDs  []uint
+	scheduleDisabled bool
+)
+
+// init registers schedule subcommands and flags.
+func init() {
+	scheduleRunCmd.Flags().DurationVar(&scheduleInterval, "interval", 15*time.Second, "how often to check for due schedules")
+
+	flags := scheduleUpsertCmd.Flags()
+	flags.StringVar(&scheduleUpsert.CronExpr, "cron", "", "five-field cron expression or descriptor such as @daily")
+	flags.StringVar(&scheduleUpsert.Timezone, "timezone", "UTC", "IANA time zone the cron expression is evaluated in")
+	flags.StringVar(&scheduleUpsert.Category, "category", "", "notification category of the sent emails")
+	flags.StringVar(&scheduleUpsert.Priority, "priority", "", "priority of the sent emails: low, normal, or high")
+	flags.StringVar(&scheduleUpsert.Subject, "subject", "", "email subject")
+	flags.StringVar(&scheduleUpsert.Content, "content", "", "email content")
+	flags.UintSliceVar(&scheduleUserIDs, "user-ids", nil, "user IDs resolved through recipient profiles")
+	flags.StringSliceVar(&scheduleUpsert.Recipients.Emails, "emails", nil, "literal recipient email addresses")
+	flags.BoolVar(&scheduleUpsert.Recipients.AllProfiles, "all-profiles", false, "send to every user with a recipient profile")
+	flags.BoolVar(&scheduleDisabled, "disabled", false, "store the schedule without firing it")
+
+	scheduleCmd.AddCommand(scheduleRunCmd, scheduleListCmd, scheduleUpsertCmd, scheduleDeleteCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
+
+// runScheduler starts the leader-elected recurring schedule runner.
+func runScheduler(_ *cobra.Command, _ []string) {
+	cfg := loadScheduleConfig()
+	db := openScheduleDatabase(cfg)
+	defer db.Close()
+
+	rdb := connectRedis(cfg)
+	if rdb != nil {
+		defer rdb.Close()
+	}
+
+	locker := newLocker(db, rdb)
+	emailService := service.NewEmailService(nil, nil, repository.NewEmailHistoryRepository(db), nil, nil, nil, locker)
+	runner := scheduler.New(
+		repository.NewEmailScheduleRepository(db),
+		repository.NewRecipientProfileRepository(db),
+		emailService,
+		locker,
+		scheduleInterval,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		<-quit
+		logrus.Info("Received shutdown signal, stopping scheduler...")
+		cancel()
+	}()
+
+	runner.Run(ctx)
+	logrus.Info("Scheduler stopped")
+}
+
+// runScheduleList prints every schedule with its next run.
+func runScheduleList(_ *cobra.Command, _ []string) {
+	cfg := loadScheduleConfig()
+	db := openScheduleDatabase(cfg)
+	defer db.Close()
+
+	schedules, err := service.NewScheduleService(repository.NewEmailScheduleRepository(db)).List(context.Background())
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to list email schedules")
+	}
+	for _, s := range schedules {
+		fmt.Printf("%s\t%s\t%s\tenabled=%t\tnext=%s\n", s.Name, s.CronExpr, s.Timezone, s.Enabled, s.NextRunAt.Format(time.RFC3339))
+	}
+}
+
+// runScheduleUpsert validates the flags and stores the schedule.
+func runScheduleUpsert(_ *cobra.Command, args []string) {
+	req := scheduleUpsert
+	req.Name = args[0]
+	for _, userID := range scheduleUserIDs {
+		req.Recipients.UserIDs = append(req.Recipients.UserIDs, uint64(userID))
+	}
+	enabled := !scheduleDisabled
+	req.Enabled = &enabled
+	req = dto.UpsertScheduleFromCLI(req)
+	if err := req.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid schedule")
+	}
+
+	cfg := loadScheduleConfig()
+	db := openScheduleDatabase(cfg)
+	defer db.Close()
+
+	schedule, err := service.NewScheduleService(repository.NewEmailScheduleRepository(db)).Upsert(context.Background(), req.ToEntity())
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to store email schedule")
+	}
+	fmt.Printf("%s\tnext=%s\n", schedule.Name, schedule.NextRunAt.Format(time.RFC3339))
+}
+
+// runScheduleDelete removes a schedule by name.
+func runScheduleDelete(_ *cobra.Command, args []string) {
+	name, err := dto.ValidateScheduleName(args[0])
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid schedule name")
+	}
+
+	cfg := loadScheduleConfig()
+	db := openScheduleDatabase(cfg)
+	defer db.Close()
+
+	if err := service.NewScheduleService(repository.NewEmailScheduleRepository(db)).Delete(context.Background(), name); err != nil {
+		logrus.WithError(err).Fatal("Failed to delete email schedule")
+	}
+	fmt.Printf("%s\tdeleted\n", name)
+}
+
+// loadScheduleConfig loads configuration and logging for schedule commands.
+func loadScheduleConfig() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load configuration")
+	}
+	if err := configureLogging(cfg); err != nil {
+		logrus.WithError(err).Fatal("Failed to configure logging")
+	}
+	return cfg
+}
+
+// openScheduleDatabase connects to MySQL with the configured pool settings.
+func openScheduleDatabase(cfg *config.Config) *sql.DB {
+	db, err := sql.Open("mysql", cfg.MySQL.DSN)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	db.SetMaxOpenConns(cfg.MySQL.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MySQL.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.MySQL.ConnMaxLifetime)
+
+	if err := db.Ping(); err != nil {
+		logrus.WithError(err).Fatal("Failed to ping database")
+	}
+	return db
+}
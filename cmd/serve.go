@@ -3,11 +3,13 @@ package cmd
 import (
 	"context"
 	"database/sql"
+	"expvar"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -18,24 +20,41 @@ import (
 	"github.com/vibast-solutions/ms-go-notifications/app/controller"
 	grpcserver "github.com/vibast-solutions/ms-go-notifications/app/grpc"
 	"github.com/vibast-solutions/ms-go-notifications/app/lock"
+	"github.com/vibast-solutions/ms-go-notifications/app/notify"
 	"github.com/vibast-solutions/ms-go-notifications/app/preparer"
 	"github.com/vibast-solutions/ms-go-notifications/app/provider"
 	"github.com/vibast-solutions/ms-go-notifications/app/queue"
+	"github.com/vibast-solutions/ms-go-notifications/app/ratelimit"
+	"github.com/vibast-solutions/ms-go-notifications/app/realtime"
 	"github.com/vibast-solutions/ms-go-notifications/app/repository"
 	"github.com/vibast-solutions/ms-go-notifications/app/service"
 	types "github.com/vibast-solutions/ms-go-notifications/app/types"
 	"github.com/vibast-solutions/ms-go-notifications/config"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 )
 
+// outboxRelayInterval is how often the outbox is polled for emails to publish.
+const outboxRelayInterval = 500 * time.Millisecond
+
+// notifyReconcileInterval is how often notifications that are still processing are
+// checked; notifyStuckAfter is how long a notification may go without deliveries before
+// its dispatch is considered interrupted.
+const (
+	notifyReconcileInterval = 2 * time.Second
+	notifyStuckAfter        = time.Minute
+)
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the HTTP and gRPC servers",
@@ -71,30 +90,99 @@ func runServe(_ *cobra.Command, _ []string) {
 	if err := db.Ping(); err != nil {
 		logrus.WithError(err).Fatal("Failed to ping database")
 	}
+	checkSchema(db)
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.Redis.Addr,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
-	defer rdb.Close()
-
-	if err := rdb.Ping(context.Background()).Err(); err != nil {
-		logrus.WithError(err).Fatal("Failed to connect to Redis")
+	rdb := connectRedis(cfg)
+	if rdb != nil {
+		defer rdb.Close()
 	}
 
-	emailProvider, err := buildEmailProvider(cfg)
+	emailRouter, err := buildEmailRouter(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load email routing rules")
+	}
+	captures, err := buildCaptureStore(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to build capture store")
+	}
+	emailProvider, err := buildEmailProvider(cfg, emailRouter, captures, buildSendPacer(cfg, rdb))
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to build email provider")
 	}
 
 	emailPreparer := preparer.NewChain(preparer.NewRawPreparer(cfg.EmailProviders.AWS.SourceEmail))
 	emailHistory := repository.NewEmailHistoryRepository(db)
-	locker := lock.NewRedisLocker(rdb)
-	emailService := service.NewEmailService(emailPreparer, emailProvider, emailHistory, locker)
-	producer := queue.NewEmailProducer(rdb)
-	emailController := controller.NewEmailController(emailService, producer)
-	grpcEmailServer := grpcserver.NewServer(emailService, producer)
+	profiles := repository.NewRecipientProfileRepository(db)
+	preferenceService := service.NewPreferenceService(repository.NewPreferenceRepository(db))
+	locker := newLocker(db, rdb)
+	emailService := service.NewEmailService(emailPreparer, emailProvider, emailHistory, repository.NewEmailAttemptRepository(db), profiles, preferenceService, locker)
+	emailController := controller.NewEmailController(emailService)
+	emailBatches := repository.NewEmailBatchRepository(db)
+	campaignService := service.NewCampaignService(emailBatches)
+
+	laneScheduler, err := queue.NewLaneScheduler(cfg.Queue.LaneStrategy, cfg.Queue.LaneWeights)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid queue lane configuration")
+	}
+	publisher, closeQueue, err := buildEmailPublisher(cfg, db, rdb, laneScheduler)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to build email queue")
+	}
+	defer closeQueue()
+	relay := queue.NewOutboxRelay(repository.NewEmailOutboxRepository(db), publisher, locker, outboxRelayInterval)
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+	go relay.Run(relayCtx)
+
+	// Nothing outside this process can read the memory queue, so serve consumes it itself.
+	if memoryQueue, ok := publisher.(*queue.MemoryQueue); ok {
+		consumer, digestFlusher := newEmailWorker(cfg, rdb, memoryQueue, emailHistory, profiles, emailService, campaignService, locker)
+		go digestFlusher.Run(relayCtx)
+		go func() {
+			if err := consumer.Run(service.WithWorker(relayCtx, "serve")); err != nil {
+				logrus.WithError(err).Fatal("Consumer error")
+			}
+		}()
+	}
+
+	// Without Redis the hub delivers in-app events to this replica's subscribers only.
+	hub := realtime.NewHub(rdb)
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	defer stopHub()
+	go func() {
+		if err := hub.Run(hubCtx); err != nil {
+			logrus.WithError(err).Fatal("Realtime hub error")
+		}
+	}()
+
+	inAppService := service.NewInAppService(repository.NewInAppNotificationRepository(db), hub)
+	inAppController := controller.NewInAppController(inAppService)
+
+	notifications := repository.NewNotificationRepository(db)
+	notifyService := notify.NewService(
+		notifications,
+		preferenceService,
+		notify.NewEmailChannel(emailService),
+		notify.NewInAppChannel(inAppService),
+	)
+	reconciler := notify.NewReconciler(notifyService, notifications, locker, notifyReconcileInterval, notifyStuckAfter)
+	go reconciler.Run(relayCtx)
+	notificationController := controller.NewNotificationController(notifyService)
+	profileService := service.NewProfileService(profiles)
+	profileController := controller.NewProfileController(profileService)
+	preferenceController := controller.NewPreferenceController(preferenceService)
+	scheduleService := service.NewScheduleService(repository.NewEmailScheduleRepository(db))
+	scheduleController := controller.NewScheduleController(scheduleService)
+	bulkService := service.NewBulkEmailService(emailHistory, emailBatches)
+	bulkController := controller.NewBulkEmailController(bulkService)
+	campaignController := controller.NewCampaignController(campaignService)
+	routeService := service.NewRouteService(emailRouter)
+	routeController := controller.NewRouteController(routeService)
+	var captureController *controller.CaptureController
+	if usesEmailProvider(cfg, emailRouter, "capture") {
+		captureController = controller.NewCaptureController(service.NewCaptureService(captures))
+	}
+	grpcEmailServer := grpcserver.NewServer(emailService, inAppService, notifyService, profileService, preferenceService, scheduleService, bulkService, campaignService, routeService)
 
 	authGRPCClient, err := authclient.NewGRPCClientFromAddr(context.Background(), cfg.InternalEndpoints.AuthGRPCAddr)
 	if err != nil {
@@ -105,7 +193,7 @@ func runServe(_ *cobra.Command, _ []string) {
 	echoInternalAuthMiddleware := authmiddleware.NewEchoInternalAuthMiddleware(internalAuthService)
 	grpcInternalAuthMiddleware := authmiddleware.NewGRPCInternalAuthMiddleware(internalAuthService)
 
-	e := setupHTTPServer(emailController, echoInternalAuthMiddleware, cfg.App.ServiceName)
+	e := setupHTTPServer(emailController, bulkController, campaignController, routeController, captureController, inAppController, notificationController, profileController, preferenceController, scheduleController, echoInternalAuthMiddleware, cfg.App.ServiceName)
 	grpcServer, lis := setupGRPCServer(cfg, grpcEmailServer, grpcInternalAuthMiddleware, cfg.App.ServiceName)
 
 	go func() {
@@ -128,6 +216,9 @@ func runServe(_ *cobra.Command, _ []string) {
 	<-quit
 	logrus.Info("Shutting down...")
 
+	// Stopping the hub closes open SSE and gRPC subscriptions so shutdown does not wait on them.
+	stopHub()
+
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -135,6 +226,7 @@ func runServe(_ *cobra.Command, _ []string) {
 		logrus.WithError(err).Warn("HTTP shutdown error")
 	}
 	grpcServer.GracefulStop()
+	stopRelay()
 
 	logrus.Info("Server stopped")
 }
@@ -142,6 +234,15 @@ func runServe(_ *cobra.Command, _ []string) {
 // setupHTTPServer configures the Echo HTTP server and routes.
 func setupHTTPServer(
 	emailController *controller.EmailController,
+	bulkController *controller.BulkEmailController,
+	campaignController *controller.CampaignController,
+	routeController *controller.RouteController,
+	captureController *controller.CaptureController,
+	inAppController *controller.InAppController,
+	notificationController *controller.NotificationController,
+	profileController *controller.ProfileController,
+	preferenceController *controller.PreferenceController,
+	scheduleController *controller.ScheduleController,
 	internalAuthMiddleware *authmiddleware.EchoInternalAuthMiddleware,
 	appServiceName string,
 ) *echo.Echo {
@@ -182,6 +283,55 @@ func setupHTTPServer(
 
 	email := e.Group("/email")
 	email.POST("/send/raw", emailController.SendRaw)
+	email.POST("/send/bulk", bulkController.SendBulk)
+	email.GET("/batches/:batch_id", campaignController.Get)
+	email.POST("/batches/:batch_id/pause", campaignController.Pause)
+	email.POST("/batches/:batch_id/resume", campaignController.Resume)
+	email.POST("/batches/:batch_id/cancel", campaignController.Cancel)
+	email.POST("/route/dry-run", routeController.DryRun)
+	email.POST("/:request_id/cancel", emailController.Cancel)
+	email.GET("/:request_id/attempts", emailController.Attempts)
+
+	// The inbox of captured emails is only served while the capture provider is in use.
+	if captureController != nil {
+		capture := e.Group("/capture")
+		capture.GET("", captureController.Inbox)
+		capture.GET("/messages", captureController.List)
+		capture.DELETE("/messages", captureController.Clear)
+		capture.GET("/messages/:id", captureController.Get)
+		capture.GET("/messages/:id/html", captureController.HTML)
+		capture.GET("/messages/:id/raw", captureController.Raw)
+	}
+
+	inApp := e.Group("/inapp")
+	inApp.POST("/send", inAppController.Send)
+	inApp.GET("/notifications", inAppController.List)
+	inApp.GET("/stream", inAppController.Stream)
+
+	notifications := e.Group("/notifications")
+	notifications.POST("", notificationController.Notify)
+	notifications.GET("/:request_id", notificationController.Get)
+
+	profiles := e.Group("/profiles")
+	profiles.PUT("/:user_id", profileController.Upsert)
+	profiles.GET("/:user_id", profileController.Get)
+	profiles.DELETE("/:user_id", profileController.Delete)
+
+	categories := e.Group("/categories")
+	categories.GET("", preferenceController.ListCategories)
+	categories.PUT("/:name", preferenceController.UpsertCategory)
+
+	preferences := e.Group("/preferences")
+	preferences.GET("/:user_id", preferenceController.Get)
+	preferences.PUT("/:user_id", preferenceController.Update)
+
+	schedules := e.Group("/schedules")
+	schedules.GET("", scheduleController.List)
+	schedules.PUT("/:name", scheduleController.Upsert)
+	schedules.GET("/:name", scheduleController.Get)
+	schedules.DELETE("/:name", scheduleController.Delete)
+
+	e.GET("/debug/vars", echo.WrapHandler(expvar.Handler()))
 
 	e.GET("/health", func(c echo.Context) error {
 		return c.JSON(200, map[string]string{"status": "ok"})
@@ -207,23 +357,258 @@ func setupGRPCServer(
 		grpc.ChainUnaryInterceptor(
 			internalAuthMiddleware.UnaryRequireInternalAccess(appServiceName),
 		),
+		grpc.ChainStreamInterceptor(
+			internalAuthMiddleware.StreamRequireInternalAccess(appServiceName),
+		),
 	)
 	types.RegisterNotificationsServiceServer(grpcServer, emailServer)
 
 	return grpcServer, lis
 }
 
-func buildEmailProvider(cfg *config.Config) (provider.EmailProvider, error) {
-	switch strings.ToLower(cfg.EmailProviders.Provider) {
-	case "", "ses":
+// connectRedis connects to the configured Redis. It returns nil when REDIS_ADDR is not set,
+// which queue backends other than redis allow.
+func connectRedis(cfg *config.Config) *redis.Client {
+	if cfg.Redis.Addr == "" {
+		logrus.Warn("REDIS_ADDR is not set; running without Redis")
+		return nil
+	}
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to Redis")
+	}
+	return rdb
+}
+
+// newLocker returns Redis locks, or MySQL named locks when Redis is not configured.
+func newLocker(db *sql.DB, rdb *redis.Client) lock.Locker {
+	if rdb == nil {
+		return lock.NewMySQLLocker(db)
+	}
+	return lock.NewRedisLocker(rdb)
+}
+
+// buildEmailPublisher returns the publishing side of the configured queue backend and a
+// function that closes the backend's connection. The scheduler is used by backends that
+// are also consumed in this process.
+func buildEmailPublisher(cfg *config.Config, db *sql.DB, rdb *redis.Client, scheduler *queue.LaneScheduler) (queue.EmailPublisher, func(), error) {
+	switch strings.ToLower(cfg.Queue.Backend) {
+	case "", "redis":
+		return queue.NewEmailProducer(rdb), func() {}, nil
+	case "mysql":
+		return queue.NewMySQLQueue(db, scheduler), func() {}, nil
+	case "nats":
+		return connectNATSQueue(cfg, scheduler)
+	case "memory":
+		return queue.NewMemoryQueue(scheduler), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported QUEUE_BACKEND: %s", cfg.Queue.Backend)
+	}
+}
+
+// connectNATSQueue connects to NATS and sets up the JetStream email queue.
+func connectNATSQueue(cfg *config.Config, scheduler *queue.LaneScheduler) (*queue.NATSQueue, func(), error) {
+	nc, err := nats.Connect(cfg.NATS.URL, nats.Name(cfg.App.ServiceName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, nil, err
+	}
+	natsQueue, err := queue.NewNATSQueue(context.Background(), js, cfg.NATS.MaxDeliver, scheduler)
+	if err != nil {
+		nc.Close()
+		return nil, nil, err
+	}
+	return natsQueue, func() { _ = nc.Drain() }, nil
+}
+
+// buildSendPacer returns the limiter of the configured send rates, or nil when none are
+// set. The buckets are shared through Redis, so rates are ignored without it.
+func buildSendPacer(cfg *config.Config, rdb *redis.Client) provider.Pacer {
+	rates := cfg.SendRate
+	if rdb == nil {
+		if strings.TrimSpace(rates.Providers) != "" || strings.TrimSpace(rates.Domains) != "" {
+			logrus.Warn("SEND_RATE_PROVIDER_LIMITS and SEND_RATE_DOMAIN_LIMITS need Redis; send rate limits are disabled")
+		}
+		return nil
+	}
+	limiter, err := service.NewSendRateLimiter(rates.Providers, rates.Domains, ratelimit.NewTokenBucket(rdb))
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid send rate configuration")
+	}
+	if limiter == nil {
+		return nil
+	}
+	return limiter
+}
+
+// buildEmailRouter builds the provider router from the configured routing rules; without
+// rules it sends every email through the default provider.
+func buildEmailRouter(cfg *config.Config) (*provider.Router, error) {
+	var rules []provider.RoutingRule
+	if cfg.EmailProviders.RoutingRules != "" {
+		loaded, err := provider.LoadRoutingRules(cfg.EmailProviders.RoutingRules)
+		if err != nil {
+			return nil, err
+		}
+		rules = loaded
+	}
+	fallback := strings.Join(cfg.EmailProviders.Names(), ",")
+	return provider.NewRouter(rules, fallback, cfg.EmailProviders.AWS.SourceEmail)
+}
+
+// buildCaptureStore builds the store of the capture provider: a Maildir when CAPTURE_DIR
+// is set, memory otherwise.
+func buildCaptureStore(cfg *config.Config) (provider.CaptureStore, error) {
+	if cfg.EmailProviders.Capture.Dir != "" {
+		return provider.NewMaildirCaptureStore(cfg.EmailProviders.Capture.Dir)
+	}
+	return provider.NewMemoryCaptureStore(cfg.EmailProviders.Capture.Limit), nil
+}
+
+// usesEmailProvider reports whether the named provider is configured or a routing rule
+// sends through it.
+func usesEmailProvider(cfg *config.Config, router *provider.Router, name string) bool {
+	if slices.Contains(cfg.EmailProviders.Names(), name) {
+		return true
+	}
+	for _, rule := range router.Rules() {
+		if rule.Provider == name {
+			return true
+		}
+	}
+	return false
+}
+
+// buildEmailProvider builds the default provider and, when router has rules, a routing
+// provider sending through it or the providers the rules name. captures is the store of
+// the capture provider; a non-nil pacer paces the sends of each provider.
+func buildEmailProvider(cfg *config.Config, router *provider.Router, captures provider.CaptureStore, pacer provider.Pacer) (provider.EmailProvider, error) {
+	defaultProvider, err := buildDefaultEmailProvider(cfg, captures, pacer)
+	if err != nil || len(router.Rules()) == 0 {
+		return defaultProvider, err
+	}
+
+	providers := map[string]provider.EmailProvider{router.Fallback(): defaultProvider}
+	for _, rule := range router.Rules() {
+		if _, ok := providers[rule.Provider]; ok {
+			continue
+		}
+		p, err := buildNamedEmailProvider(cfg, rule.Provider, captures, pacer)
+		if err != nil {
+			return nil, fmt.Errorf("routing rule %q: %w", rule.Name, err)
+		}
+		providers[rule.Provider] = p
+	}
+	return provider.NewRoutingProvider(router, providers)
+}
+
+// buildDefaultEmailProvider builds the configured provider, or a failover provider trying
+// the configured providers in order when several are listed.
+func buildDefaultEmailProvider(cfg *config.Config, captures provider.CaptureStore, pacer provider.Pacer) (provider.EmailProvider, error) {
+	names := cfg.EmailProviders.Names()
+	if len(names) == 1 {
+		return buildNamedEmailProvider(cfg, names[0], captures, pacer)
+	}
+
+	members := make([]provider.FailoverMember, 0, len(names))
+	for _, name := range names {
+		p, err := buildNamedEmailProvider(cfg, name, captures, pacer)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, provider.FailoverMember{Name: name, Provider: p})
+	}
+	breaker := cfg.EmailProviders.Breaker
+	return provider.NewFailoverProvider(members, provider.BreakerConfig{
+		Window:         breaker.Window,
+		MinRequests:    breaker.MinRequests,
+		FailureRate:    float64(breaker.FailurePercent) / 100,
+		OpenTimeout:    breaker.OpenTimeout,
+		HalfOpenProbes: breaker.HalfOpenProbes,
+	}), nil
+}
+
+// buildNamedEmailProvider builds a single provider by name, paced by the provider's own
+// send rate and attributing its send failures to the name.
+func buildNamedEmailProvider(cfg *config.Config, name string, captures provider.CaptureStore, pacer provider.Pacer) (provider.EmailProvider, error) {
+	p, err := buildProviderByName(cfg, name, captures)
+	if err != nil {
+		return nil, err
+	}
+	if pacer != nil {
+		p = provider.NewPacedProvider(name, p, pacer, cfg.EmailProviders.AWS.SourceEmail)
+	}
+	return provider.NewNamedProvider(name, p), nil
+}
+
+// buildProviderByName builds the provider implementation of name.
+func buildProviderByName(cfg *config.Config, name string, captures provider.CaptureStore) (provider.EmailProvider, error) {
+	switch name {
+	case "ses":
+		if cfg.EmailProviders.AWS.Region == "" {
+			return nil, fmt.Errorf("AWS_REGION is required for the ses provider")
+		}
 		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.EmailProviders.AWS.Region))
 		if err != nil {
 			return nil, err
 		}
-		return provider.NewSESProvider(awsCfg, cfg.EmailProviders.AWS.SourceEmail), nil
+		return buildSESProvider(awsCfg, cfg.EmailProviders.AWS)
+	case "smtp":
+		if cfg.EmailProviders.SMTP.Addr == "" {
+			return nil, fmt.Errorf("SMTP_ADDR is required for the smtp provider")
+		}
+		smtpCfg := cfg.EmailProviders.SMTP
+		return provider.NewSMTPProvider(smtpCfg.Addr, smtpCfg.Username, smtpCfg.Password, cfg.EmailProviders.AWS.SourceEmail), nil
+	case "mailgun":
+		mailgunCfg := cfg.EmailProviders.Mailgun
+		if mailgunCfg.Domain == "" || mailgunCfg.APIKey == "" {
+			return nil, fmt.Errorf("MAILGUN_DOMAIN and MAILGUN_API_KEY are required for the mailgun provider")
+		}
+		return provider.NewMailgunProvider(mailgunCfg.BaseURL, mailgunCfg.Domain, mailgunCfg.APIKey), nil
+	case "sendgrid":
+		if cfg.EmailProviders.SendGrid.APIKey == "" {
+			return nil, fmt.Errorf("SENDGRID_API_KEY is required for the sendgrid provider")
+		}
+		return provider.NewSendGridProvider(cfg.EmailProviders.SendGrid.BaseURL, cfg.EmailProviders.SendGrid.APIKey), nil
+	case "postmark":
+		postmarkCfg := cfg.EmailProviders.Postmark
+		if postmarkCfg.ServerToken == "" {
+			return nil, fmt.Errorf("POSTMARK_SERVER_TOKEN is required for the postmark provider")
+		}
+		return provider.NewPostmarkProvider(postmarkCfg.BaseURL, postmarkCfg.ServerToken, postmarkCfg.MessageStream), nil
+	case "capture":
+		return provider.NewCaptureProvider(captures), nil
 	case "noop":
 		return provider.NewNoopProvider(), nil
 	default:
-		return nil, fmt.Errorf("unsupported EMAIL_PROVIDER: %s", cfg.EmailProviders.Provider)
+		return nil, fmt.Errorf("unsupported EMAIL_PROVIDER: %s", name)
+	}
+}
+
+// buildSESProvider builds the ses provider with the default SES options and, when
+// SES_IDENTITY_OPTIONS_FILE is set, the options of each sender identity.
+func buildSESProvider(awsCfg aws.Config, sesCfg config.AWSEmailConfig) (provider.EmailProvider, error) {
+	var identities map[string]provider.SESOptions
+	if sesCfg.IdentityOptions != "" {
+		loaded, err := provider.LoadSESIdentityOptions(sesCfg.IdentityOptions)
+		if err != nil {
+			return nil, err
+		}
+		identities = loaded
+	}
+	defaults := provider.SESOptions{
+		ConfigurationSet:          sesCfg.ConfigurationSet,
+		FeedbackForwardingAddress: sesCfg.FeedbackForwardingAddress,
+		ContactList:               sesCfg.ContactList,
+		ContactListTopic:          sesCfg.ContactListTopic,
 	}
+	return provider.NewSESProvider(awsCfg, sesCfg.SourceEmail, defaults, identities), nil
 }
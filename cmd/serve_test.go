@@ -11,6 +11,8 @@ import (
 	authmiddleware "github.com/vibast-solutions/lib-go-auth/middleware"
 	authservice "github.com/vibast-solutions/lib-go-auth/service"
 	"github.com/vibast-solutions/ms-go-notifications/app/controller"
+	"github.com/vibast-solutions/ms-go-notifications/app/provider"
+	"github.com/vibast-solutions/ms-go-notifications/app/service"
 )
 
 type notificationsInternalAuthClientStub struct{}
@@ -38,9 +40,21 @@ func newNotificationsInternalAuthMiddlewareStub() *authmiddleware.EchoInternalAu
 }
 
 func newNotificationsTestServer() *http.Server {
+	return newNotificationsTestServerWithCapture(nil)
+}
+
+func newNotificationsTestServerWithCapture(captureController *controller.CaptureController) *http.Server {
 	emailController := &controller.EmailController{}
+	bulkController := &controller.BulkEmailController{}
+	campaignController := &controller.CampaignController{}
+	routeController := &controller.RouteController{}
+	inAppController := &controller.InAppController{}
+	notificationController := &controller.NotificationController{}
+	profileController := &controller.ProfileController{}
+	preferenceController := &controller.PreferenceController{}
+	scheduleController := &controller.ScheduleController{}
 	internalAuthMW := newNotificationsInternalAuthMiddlewareStub()
-	e := setupHTTPServer(emailController, internalAuthMW, "notifications-service")
+	e := setupHTTPServer(emailController, bulkController, campaignController, routeController, captureController, inAppController, notificationController, profileController, preferenceController, scheduleController, internalAuthMW, "notifications-service")
 	return &http.Server{Handler: e}
 }
 
@@ -84,3 +98,25 @@ func TestSetupHTTPServerHealthRouteAuthorized(t *testing.T) {
 		t.Fatalf("unexpected health payload: %s", rec.Body.String())
 	}
 }
+
+func TestSetupHTTPServerCaptureRoutes(t *testing.T) {
+	store := provider.NewMemoryCaptureStore(0)
+	tests := []struct {
+		name   string
+		server *http.Server
+		status int
+	}{
+		{name: "capture in use", server: newNotificationsTestServerWithCapture(controller.NewCaptureController(service.NewCaptureService(store))), status: http.StatusOK},
+		{name: "capture not in use", server: newNotificationsTestServer(), status: http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/capture/messages", nil)
+		req.Header.Set("X-API-Key", "valid-key")
+		rec := httptest.NewRecorder()
+		tc.server.Handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Fatalf("%s: expected status %d, got %d", tc.name, tc.status, rec.Code)
+		}
+	}
+}
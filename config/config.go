@@ -3,7 +3,9 @@ package config
 import (
 	"errors"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -18,6 +20,13 @@ type Config struct {
 	Redis             RedisConfig
 	InternalEndpoints InternalEndpointsConfig
 	EmailProviders    EmailProvidersConfig
+	QuietHours        QuietHoursConfig
+	Digest            DigestConfig
+	FrequencyCaps     FrequencyCapConfig
+	Queue             QueueConfig
+	NATS              NATSConfig
+	SendRate          SendRateConfig
+	Metrics           MetricsConfig
 }
 
 type AppConfig struct {
@@ -41,6 +50,8 @@ type LogConfig struct {
 	Level string
 }
 
+// RedisConfig holds the Redis connection. Addr is required by the redis queue backend;
+// the other backends run without Redis when it is empty.
 type RedisConfig struct {
 	Addr     string
 	Password string
@@ -51,14 +62,174 @@ type InternalEndpointsConfig struct {
 	AuthGRPCAddr string
 }
 
+// QuietHoursConfig holds the daily window ("HH:MM") in which non-urgent email is deferred.
+// Leaving Start or End empty disables quiet hours.
+type QuietHoursConfig struct {
+	Start           string
+	End             string
+	DefaultTimezone string
+}
+
+// DigestConfig holds how long digest items accumulate and the templates they are rendered with.
+// Empty Subject or TemplatePath select the built-in templates.
+type DigestConfig struct {
+	Window       time.Duration
+	Subject      string
+	TemplatePath string
+}
+
+// FrequencyCapConfig holds per-recipient email caps ("marketing=3/24h,*=20/1h") and whether
+// capped emails are deferred or dropped. Leaving Caps empty disables frequency capping.
+type FrequencyCapConfig struct {
+	Caps   string
+	Policy string
+}
+
+// QueueConfig selects the email queue backend: "redis" (streams), "mysql" (the email_queue
+// table), "nats" (JetStream), or "memory" (in-process, consumed by serve itself), and how
+// consumers share reads between the priority lanes ("weighted" or "strict").
+type QueueConfig struct {
+	Backend      string
+	LaneStrategy string
+	LaneWeights  string
+}
+
+// NATSConfig holds the JetStream connection used by the nats queue backend and how many
+// times a message is delivered before it is given up.
+type NATSConfig struct {
+	URL        string
+	MaxDeliver int
+}
+
+// SendRateConfig holds the shared send rates ("ses=14/1s") per provider and per sending
+// domain. Leaving both empty disables send rate limiting.
+type SendRateConfig struct {
+	Providers string
+	Domains   string
+}
+
+// MetricsConfig holds the address `consume emails` serves /debug/vars on; empty disables it.
+type MetricsConfig struct {
+	Addr string
+}
+
+// EmailProvidersConfig selects the email provider. Provider may list several providers
+// separated by commas, which are tried in order with a circuit breaker each. RoutingRules
+// names a JSON file of rules sending matching emails through other providers; Provider
+// then sends the emails no rule matches.
 type EmailProvidersConfig struct {
-	Provider string
-	AWS      AWSEmailConfig
+	Provider     string
+	RoutingRules string
+	AWS          AWSEmailConfig
+	SMTP         SMTPConfig
+	Mailgun      MailgunConfig
+	SendGrid     SendGridConfig
+	Postmark     PostmarkConfig
+	Capture      CaptureConfig
+	Breaker      ProviderBreakerConfig
+}
+
+// ProviderBreakerConfig configures the per-provider circuit breakers used when several
+// providers are listed: a circuit opens once FailurePercent of at least MinRequests sends
+// within Window failed, and lets HalfOpenProbes sends through after OpenTimeout.
+type ProviderBreakerConfig struct {
+	Window         time.Duration
+	MinRequests    int
+	FailurePercent int
+	OpenTimeout    time.Duration
+	HalfOpenProbes int
+}
+
+// Names returns the configured provider names in order, lowercased, defaulting to "ses".
+func (c EmailProvidersConfig) Names() []string {
+	var names []string
+	for _, name := range strings.Split(c.Provider, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return []string{"ses"}
+	}
+	return names
 }
 
+// AWSEmailConfig holds the region and source address of the ses provider and the SES
+// options it sends with. IdentityOptions names a JSON file of options per sender identity,
+// overriding the defaults here.
 type AWSEmailConfig struct {
-	Region      string
-	SourceEmail string
+	Region                    string
+	SourceEmail               string
+	ConfigurationSet          string
+	FeedbackForwardingAddress string
+	ContactList               string
+	ContactListTopic          string
+	IdentityOptions           string
+}
+
+// SMTPConfig holds the relay used by the smtp provider; Username enables authentication.
+type SMTPConfig struct {
+	Addr     string
+	Username string
+	Password string
+}
+
+// MailgunConfig holds the sending domain and API key of the mailgun provider. BaseURL is
+// the API host, https://api.eu.mailgun.net for EU domains.
+type MailgunConfig struct {
+	Domain  string
+	APIKey  string
+	BaseURL string
+}
+
+// SendGridConfig holds the API key of the sendgrid provider.
+type SendGridConfig struct {
+	APIKey  string
+	BaseURL string
+}
+
+// PostmarkConfig holds the server token and message stream of the postmark provider.
+type PostmarkConfig struct {
+	ServerToken   string
+	MessageStream string
+	BaseURL       string
+}
+
+// CaptureConfig holds where the capture provider keeps emails: a Maildir at Dir, shared
+// by serve and consume, or, when Dir is empty, the Limit most recent emails in memory.
+type CaptureConfig struct {
+	Dir   string
+	Limit int
+}
+
+// LoadMySQL reads only the MySQL and log settings, for commands that use nothing but the
+// database, such as migrate.
+func LoadMySQL() (*Config, error) {
+	_ = godotenv.Load()
+
+	mysql, err := loadMySQL()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		MySQL: mysql,
+		Log: LogConfig{
+			Level: getEnv("LOG_LEVEL", "info"),
+		},
+	}, nil
+}
+
+func loadMySQL() (MySQLConfig, error) {
+	dsn := os.Getenv("MYSQL_DSN")
+	if dsn == "" {
+		return MySQLConfig{}, errors.New("MYSQL_DSN environment variable is required")
+	}
+	return MySQLConfig{
+		DSN:             dsn,
+		MaxOpenConns:    getIntEnv("MYSQL_MAX_OPEN_CONNS", 10),
+		MaxIdleConns:    getIntEnv("MYSQL_MAX_IDLE_CONNS", 5),
+		ConnMaxLifetime: getDurationEnv("MYSQL_CONN_MAX_LIFETIME_MINUTES", 30*time.Minute),
+	}, nil
 }
 
 // Load reads configuration from environment variables (and .env when present).
@@ -70,19 +241,37 @@ func Load() (*Config, error) {
 		return nil, errors.New("SES_SOURCE_EMAIL environment variable is required")
 	}
 
-	emailProvider := getEnv("EMAIL_PROVIDER", "ses")
+	emailProviders := EmailProvidersConfig{Provider: getEnv("EMAIL_PROVIDER", "ses")}
 	awsRegion := os.Getenv("AWS_REGION")
-	if emailProvider != "noop" && awsRegion == "" {
+	if slices.Contains(emailProviders.Names(), "ses") && awsRegion == "" {
 		return nil, errors.New("AWS_REGION environment variable is required")
 	}
+	smtpAddr := os.Getenv("SMTP_ADDR")
+	if slices.Contains(emailProviders.Names(), "smtp") && smtpAddr == "" {
+		return nil, errors.New("SMTP_ADDR environment variable is required")
+	}
+	mailgunDomain := os.Getenv("MAILGUN_DOMAIN")
+	mailgunAPIKey := os.Getenv("MAILGUN_API_KEY")
+	if slices.Contains(emailProviders.Names(), "mailgun") && (mailgunDomain == "" || mailgunAPIKey == "") {
+		return nil, errors.New("MAILGUN_DOMAIN and MAILGUN_API_KEY environment variables are required")
+	}
+	sendGridAPIKey := os.Getenv("SENDGRID_API_KEY")
+	if slices.Contains(emailProviders.Names(), "sendgrid") && sendGridAPIKey == "" {
+		return nil, errors.New("SENDGRID_API_KEY environment variable is required")
+	}
+	postmarkToken := os.Getenv("POSTMARK_SERVER_TOKEN")
+	if slices.Contains(emailProviders.Names(), "postmark") && postmarkToken == "" {
+		return nil, errors.New("POSTMARK_SERVER_TOKEN environment variable is required")
+	}
 
-	mysqlDSN := os.Getenv("MYSQL_DSN")
-	if mysqlDSN == "" {
-		return nil, errors.New("MYSQL_DSN environment variable is required")
+	mysql, err := loadMySQL()
+	if err != nil {
+		return nil, err
 	}
 
+	queueBackend := getEnv("QUEUE_BACKEND", "redis")
 	redisAddr := os.Getenv("REDIS_ADDR")
-	if redisAddr == "" {
+	if strings.EqualFold(queueBackend, "redis") && redisAddr == "" {
 		return nil, errors.New("REDIS_ADDR environment variable is required")
 	}
 
@@ -99,12 +288,7 @@ func Load() (*Config, error) {
 			Host: getEnv("GRPC_HOST", "0.0.0.0"),
 			Port: getEnv("GRPC_PORT", "9090"),
 		},
-		MySQL: MySQLConfig{
-			DSN:             mysqlDSN,
-			MaxOpenConns:    getIntEnv("MYSQL_MAX_OPEN_CONNS", 10),
-			MaxIdleConns:    getIntEnv("MYSQL_MAX_IDLE_CONNS", 5),
-			ConnMaxLifetime: getDurationEnv("MYSQL_CONN_MAX_LIFETIME_MINUTES", 30*time.Minute),
-		},
+		MySQL: mysql,
 		Log: LogConfig{
 			Level: getEnv("LOG_LEVEL", "info"),
 		},
@@ -117,11 +301,77 @@ func Load() (*Config, error) {
 			AuthGRPCAddr: getEnv("AUTH_SERVICE_GRPC_ADDR", "localhost:9090"),
 		},
 		EmailProviders: EmailProvidersConfig{
-			Provider: emailProvider,
+			Provider:     emailProviders.Provider,
+			RoutingRules: getEnv("EMAIL_ROUTING_RULES_FILE", ""),
 			AWS: AWSEmailConfig{
-				Region:      awsRegion,
-				SourceEmail: sesSource,
+				Region:                    awsRegion,
+				SourceEmail:               sesSource,
+				ConfigurationSet:          getEnv("SES_CONFIGURATION_SET", ""),
+				FeedbackForwardingAddress: getEnv("SES_FEEDBACK_FORWARDING_ADDRESS", ""),
+				ContactList:               getEnv("SES_CONTACT_LIST", ""),
+				ContactListTopic:          getEnv("SES_CONTACT_LIST_TOPIC", ""),
+				IdentityOptions:           getEnv("SES_IDENTITY_OPTIONS_FILE", ""),
+			},
+			SMTP: SMTPConfig{
+				Addr:     smtpAddr,
+				Username: getEnv("SMTP_USERNAME", ""),
+				Password: getEnv("SMTP_PASSWORD", ""),
+			},
+			Mailgun: MailgunConfig{
+				Domain:  mailgunDomain,
+				APIKey:  mailgunAPIKey,
+				BaseURL: getEnv("MAILGUN_API_BASE", "https://api.mailgun.net"),
 			},
+			SendGrid: SendGridConfig{
+				APIKey:  sendGridAPIKey,
+				BaseURL: getEnv("SENDGRID_API_BASE", "https://api.sendgrid.com"),
+			},
+			Postmark: PostmarkConfig{
+				ServerToken:   postmarkToken,
+				MessageStream: getEnv("POSTMARK_MESSAGE_STREAM", "outbound"),
+				BaseURL:       getEnv("POSTMARK_API_BASE", "https://api.postmarkapp.com"),
+			},
+			Capture: CaptureConfig{
+				Dir:   getEnv("CAPTURE_DIR", ""),
+				Limit: getIntEnv("CAPTURE_LIMIT", 1000),
+			},
+			Breaker: ProviderBreakerConfig{
+				Window:         getSecondsEnv("PROVIDER_BREAKER_WINDOW_SECONDS", time.Minute),
+				MinRequests:    getIntEnv("PROVIDER_BREAKER_MIN_REQUESTS", 10),
+				FailurePercent: getIntEnv("PROVIDER_BREAKER_FAILURE_PERCENT", 50),
+				OpenTimeout:    getSecondsEnv("PROVIDER_BREAKER_OPEN_SECONDS", 30*time.Second),
+				HalfOpenProbes: getIntEnv("PROVIDER_BREAKER_HALF_OPEN_PROBES", 3),
+			},
+		},
+		QuietHours: QuietHoursConfig{
+			Start:           getEnv("QUIET_HOURS_START", ""),
+			End:             getEnv("QUIET_HOURS_END", ""),
+			DefaultTimezone: getEnv("QUIET_HOURS_DEFAULT_TIMEZONE", "UTC"),
+		},
+		Digest: DigestConfig{
+			Window:       getDurationEnv("DIGEST_WINDOW_MINUTES", 60*time.Minute),
+			Subject:      getEnv("DIGEST_SUBJECT", ""),
+			TemplatePath: getEnv("DIGEST_TEMPLATE_PATH", ""),
+		},
+		FrequencyCaps: FrequencyCapConfig{
+			Caps:   getEnv("FREQUENCY_CAPS", ""),
+			Policy: getEnv("FREQUENCY_CAP_POLICY", "defer"),
+		},
+		Queue: QueueConfig{
+			Backend:      queueBackend,
+			LaneStrategy: getEnv("QUEUE_LANE_STRATEGY", "weighted"),
+			LaneWeights:  getEnv("QUEUE_LANE_WEIGHTS", "high=6,normal=3,low=1"),
+		},
+		NATS: NATSConfig{
+			URL:        getEnv("NATS_URL", "nats://localhost:4222"),
+			MaxDeliver: getIntEnv("NATS_MAX_DELIVER", 10),
+		},
+		SendRate: SendRateConfig{
+			Providers: getEnv("SEND_RATE_PROVIDER_LIMITS", ""),
+			Domains:   getEnv("SEND_RATE_DOMAIN_LIMITS", ""),
+		},
+		Metrics: MetricsConfig{
+			Addr: getEnv("METRICS_ADDR", ""),
 		},
 	}, nil
 }
@@ -153,3 +403,13 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getSecondsEnv returns a seconds-based duration from env or the default.
+func getSecondsEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultValue
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"slices"
 	"testing"
 	"time"
 )
@@ -35,6 +36,34 @@ func TestLoadDefaults(t *testing.T) {
 	t.Setenv("AUTH_SERVICE_GRPC_ADDR", "")
 	t.Setenv("APP_SERVICE_NAME", "")
 	t.Setenv("APP_API_KEY", "")
+	t.Setenv("QUEUE_BACKEND", "")
+	t.Setenv("QUEUE_LANE_STRATEGY", "")
+	t.Setenv("QUEUE_LANE_WEIGHTS", "")
+	t.Setenv("NATS_URL", "")
+	t.Setenv("NATS_MAX_DELIVER", "")
+	t.Setenv("SEND_RATE_PROVIDER_LIMITS", "")
+	t.Setenv("SEND_RATE_DOMAIN_LIMITS", "")
+	t.Setenv("METRICS_ADDR", "")
+	t.Setenv("PROVIDER_BREAKER_WINDOW_SECONDS", "")
+	t.Setenv("PROVIDER_BREAKER_MIN_REQUESTS", "")
+	t.Setenv("PROVIDER_BREAKER_FAILURE_PERCENT", "")
+	t.Setenv("PROVIDER_BREAKER_OPEN_SECONDS", "")
+	t.Setenv("PROVIDER_BREAKER_HALF_OPEN_PROBES", "")
+	t.Setenv("EMAIL_ROUTING_RULES_FILE", "")
+	t.Setenv("SMTP_ADDR", "")
+	t.Setenv("SMTP_USERNAME", "")
+	t.Setenv("SMTP_PASSWORD", "")
+	t.Setenv("MAILGUN_API_BASE", "")
+	t.Setenv("SENDGRID_API_BASE", "")
+	t.Setenv("POSTMARK_API_BASE", "")
+	t.Setenv("POSTMARK_MESSAGE_STREAM", "")
+	t.Setenv("CAPTURE_DIR", "")
+	t.Setenv("CAPTURE_LIMIT", "")
+	t.Setenv("SES_CONFIGURATION_SET", "")
+	t.Setenv("SES_FEEDBACK_FORWARDING_ADDRESS", "")
+	t.Setenv("SES_CONTACT_LIST", "")
+	t.Setenv("SES_CONTACT_LIST_TOPIC", "")
+	t.Setenv("SES_IDENTITY_OPTIONS_FILE", "")
 
 	cfg, err := Load()
 	if err != nil {
@@ -71,6 +100,48 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.EmailProviders.AWS.SourceEmail != "noreply@example.com" {
 		t.Fatalf("unexpected SES_SOURCE_EMAIL: %q", cfg.EmailProviders.AWS.SourceEmail)
 	}
+	if cfg.QuietHours.Start != "" || cfg.QuietHours.End != "" || cfg.QuietHours.DefaultTimezone != "UTC" {
+		t.Fatalf("unexpected quiet hours defaults: %+v", cfg.QuietHours)
+	}
+	if cfg.Digest.Window != 60*time.Minute || cfg.Digest.Subject != "" || cfg.Digest.TemplatePath != "" {
+		t.Fatalf("unexpected digest defaults: %+v", cfg.Digest)
+	}
+	if cfg.FrequencyCaps.Caps != "" || cfg.FrequencyCaps.Policy != "defer" {
+		t.Fatalf("unexpected frequency cap defaults: %+v", cfg.FrequencyCaps)
+	}
+	if cfg.Queue.Backend != "redis" {
+		t.Fatalf("expected QUEUE_BACKEND default 'redis', got %q", cfg.Queue.Backend)
+	}
+	if cfg.Queue.LaneStrategy != "weighted" || cfg.Queue.LaneWeights != "high=6,normal=3,low=1" {
+		t.Fatalf("unexpected lane defaults: %+v", cfg.Queue)
+	}
+	if cfg.NATS.URL != "nats://localhost:4222" || cfg.NATS.MaxDeliver != 10 {
+		t.Fatalf("unexpected NATS defaults: %+v", cfg.NATS)
+	}
+	if cfg.SendRate.Providers != "" || cfg.SendRate.Domains != "" || cfg.Metrics.Addr != "" {
+		t.Fatalf("unexpected send rate defaults: %+v %+v", cfg.SendRate, cfg.Metrics)
+	}
+	if cfg.EmailProviders.Breaker != (ProviderBreakerConfig{
+		Window:         time.Minute,
+		MinRequests:    10,
+		FailurePercent: 50,
+		OpenTimeout:    30 * time.Second,
+		HalfOpenProbes: 3,
+	}) {
+		t.Fatalf("unexpected provider breaker defaults: %+v", cfg.EmailProviders.Breaker)
+	}
+	if cfg.EmailProviders.Mailgun.BaseURL != "https://api.mailgun.net" || cfg.EmailProviders.SendGrid.BaseURL != "https://api.sendgrid.com" {
+		t.Fatalf("unexpected API base defaults: %+v %+v", cfg.EmailProviders.Mailgun, cfg.EmailProviders.SendGrid)
+	}
+	if cfg.EmailProviders.Postmark.BaseURL != "https://api.postmarkapp.com" || cfg.EmailProviders.Postmark.MessageStream != "outbound" {
+		t.Fatalf("unexpected Postmark defaults: %+v", cfg.EmailProviders.Postmark)
+	}
+	if cfg.EmailProviders.Capture != (CaptureConfig{Limit: 1000}) {
+		t.Fatalf("unexpected capture defaults: %+v", cfg.EmailProviders.Capture)
+	}
+	if aws := cfg.EmailProviders.AWS; aws.ConfigurationSet != "" || aws.ContactList != "" || aws.IdentityOptions != "" {
+		t.Fatalf("unexpected SES option defaults: %+v", aws)
+	}
 }
 
 func TestLoadCustomValues(t *testing.T) {
@@ -92,6 +163,30 @@ func TestLoadCustomValues(t *testing.T) {
 	t.Setenv("AUTH_SERVICE_GRPC_ADDR", "auth:9090")
 	t.Setenv("APP_SERVICE_NAME", "notifications-service")
 	t.Setenv("APP_API_KEY", "notifications-key")
+	t.Setenv("QUEUE_BACKEND", "nats")
+	t.Setenv("QUEUE_LANE_STRATEGY", "strict")
+	t.Setenv("QUEUE_LANE_WEIGHTS", "high=10")
+	t.Setenv("NATS_URL", "nats://nats:4222")
+	t.Setenv("NATS_MAX_DELIVER", "3")
+	t.Setenv("SEND_RATE_PROVIDER_LIMITS", "ses=14/1s")
+	t.Setenv("SEND_RATE_DOMAIN_LIMITS", "example.com=100/1m")
+	t.Setenv("METRICS_ADDR", ":9102")
+	t.Setenv("PROVIDER_BREAKER_WINDOW_SECONDS", "120")
+	t.Setenv("PROVIDER_BREAKER_MIN_REQUESTS", "20")
+	t.Setenv("PROVIDER_BREAKER_FAILURE_PERCENT", "25")
+	t.Setenv("PROVIDER_BREAKER_OPEN_SECONDS", "10")
+	t.Setenv("PROVIDER_BREAKER_HALF_OPEN_PROBES", "1")
+	t.Setenv("EMAIL_ROUTING_RULES_FILE", "/etc/notifications/routing.json")
+	t.Setenv("SMTP_ADDR", "relay:587")
+	t.Setenv("SMTP_USERNAME", "relay-user")
+	t.Setenv("SMTP_PASSWORD", "relay-pass")
+	t.Setenv("CAPTURE_DIR", "/var/mail/captured")
+	t.Setenv("CAPTURE_LIMIT", "50")
+	t.Setenv("SES_CONFIGURATION_SET", "notifications")
+	t.Setenv("SES_FEEDBACK_FORWARDING_ADDRESS", "bounces@example.com")
+	t.Setenv("SES_CONTACT_LIST", "newsletter")
+	t.Setenv("SES_CONTACT_LIST_TOPIC", "weekly")
+	t.Setenv("SES_IDENTITY_OPTIONS_FILE", "/etc/notifications/ses-identities.json")
 
 	cfg, err := Load()
 	if err != nil {
@@ -131,6 +226,133 @@ func TestLoadCustomValues(t *testing.T) {
 	if cfg.EmailProviders.AWS.Region != "eu-west-1" {
 		t.Fatalf("unexpected AWS_REGION: %q", cfg.EmailProviders.AWS.Region)
 	}
+	if cfg.Queue.Backend != "nats" || cfg.Queue.LaneStrategy != "strict" || cfg.Queue.LaneWeights != "high=10" {
+		t.Fatalf("unexpected queue config: %+v", cfg.Queue)
+	}
+	if cfg.NATS.URL != "nats://nats:4222" || cfg.NATS.MaxDeliver != 3 {
+		t.Fatalf("unexpected NATS config: %+v", cfg.NATS)
+	}
+	if cfg.SendRate.Providers != "ses=14/1s" || cfg.SendRate.Domains != "example.com=100/1m" || cfg.Metrics.Addr != ":9102" {
+		t.Fatalf("unexpected send rate config: %+v %+v", cfg.SendRate, cfg.Metrics)
+	}
+	if cfg.EmailProviders.Breaker != (ProviderBreakerConfig{
+		Window:         2 * time.Minute,
+		MinRequests:    20,
+		FailurePercent: 25,
+		OpenTimeout:    10 * time.Second,
+		HalfOpenProbes: 1,
+	}) {
+		t.Fatalf("unexpected provider breaker config: %+v", cfg.EmailProviders.Breaker)
+	}
+	if cfg.EmailProviders.RoutingRules != "/etc/notifications/routing.json" {
+		t.Fatalf("unexpected EMAIL_ROUTING_RULES_FILE: %q", cfg.EmailProviders.RoutingRules)
+	}
+	if cfg.EmailProviders.SMTP != (SMTPConfig{Addr: "relay:587", Username: "relay-user", Password: "relay-pass"}) {
+		t.Fatalf("unexpected SMTP config: %+v", cfg.EmailProviders.SMTP)
+	}
+	if cfg.EmailProviders.Capture != (CaptureConfig{Dir: "/var/mail/captured", Limit: 50}) {
+		t.Fatalf("unexpected capture config: %+v", cfg.EmailProviders.Capture)
+	}
+	if cfg.EmailProviders.AWS != (AWSEmailConfig{
+		Region:                    "eu-west-1",
+		SourceEmail:               "noreply@example.com",
+		ConfigurationSet:          "notifications",
+		FeedbackForwardingAddress: "bounces@example.com",
+		ContactList:               "newsletter",
+		ContactListTopic:          "weekly",
+		IdentityOptions:           "/etc/notifications/ses-identities.json",
+	}) {
+		t.Fatalf("unexpected SES config: %+v", cfg.EmailProviders.AWS)
+	}
+}
+
+func TestLoadRequiresAWSRegionWhenSESListed(t *testing.T) {
+	t.Setenv("SES_SOURCE_EMAIL", "noreply@example.com")
+	t.Setenv("EMAIL_PROVIDER", "noop, SES")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("MYSQL_DSN", "dsn")
+	t.Setenv("REDIS_ADDR", "redis:6379")
+
+	if _, err := Load(); err == nil {
+		t.Fatal("expected missing AWS_REGION error")
+	}
+}
+
+func TestLoadRequiresSMTPAddrWhenSMTPListed(t *testing.T) {
+	t.Setenv("SES_SOURCE_EMAIL", "noreply@example.com")
+	t.Setenv("EMAIL_PROVIDER", "smtp")
+	t.Setenv("SMTP_ADDR", "")
+	t.Setenv("MYSQL_DSN", "dsn")
+	t.Setenv("REDIS_ADDR", "redis:6379")
+
+	if _, err := Load(); err == nil {
+		t.Fatal("expected missing SMTP_ADDR error")
+	}
+}
+
+func TestLoadRedisOnlyRequiredByRedisBackend(t *testing.T) {
+	t.Setenv("SES_SOURCE_EMAIL", "noreply@example.com")
+	t.Setenv("EMAIL_PROVIDER", "noop")
+	t.Setenv("MYSQL_DSN", "dsn")
+	t.Setenv("REDIS_ADDR", "")
+
+	t.Setenv("QUEUE_BACKEND", "redis")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected missing REDIS_ADDR error")
+	}
+
+	t.Setenv("QUEUE_BACKEND", "memory")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if cfg.Queue.Backend != "memory" || cfg.Redis.Addr != "" {
+		t.Fatalf("unexpected config: queue=%+v redis=%+v", cfg.Queue, cfg.Redis)
+	}
+}
+
+func TestLoadRequiresHTTPProviderCredentials(t *testing.T) {
+	t.Setenv("SES_SOURCE_EMAIL", "noreply@example.com")
+	t.Setenv("MYSQL_DSN", "dsn")
+	t.Setenv("REDIS_ADDR", "redis:6379")
+	t.Setenv("MAILGUN_DOMAIN", "mg.example.com")
+	t.Setenv("MAILGUN_API_KEY", "")
+	t.Setenv("SENDGRID_API_KEY", "")
+	t.Setenv("POSTMARK_SERVER_TOKEN", "")
+
+	for _, name := range []string{"mailgun", "sendgrid", "postmark"} {
+		t.Setenv("EMAIL_PROVIDER", "noop,"+name)
+		if _, err := Load(); err == nil {
+			t.Fatalf("expected missing %s credentials error", name)
+		}
+	}
+
+	t.Setenv("EMAIL_PROVIDER", "postmark")
+	t.Setenv("POSTMARK_SERVER_TOKEN", "pm-token")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if cfg.EmailProviders.Postmark.ServerToken != "pm-token" {
+		t.Fatalf("unexpected POSTMARK_SERVER_TOKEN: %q", cfg.EmailProviders.Postmark.ServerToken)
+	}
+}
+
+func TestEmailProvidersConfigNames(t *testing.T) {
+	tests := []struct {
+		provider string
+		names    []string
+	}{
+		{provider: "", names: []string{"ses"}},
+		{provider: "noop", names: []string{"noop"}},
+		{provider: " SES , noop ,", names: []string{"ses", "noop"}},
+	}
+	for _, tc := range tests {
+		names := EmailProvidersConfig{Provider: tc.provider}.Names()
+		if !slices.Equal(names, tc.names) {
+			t.Fatalf("Names(%q) = %v, want %v", tc.provider, names, tc.names)
+		}
+	}
 }
 
 func TestGetIntAndDurationFallback(t *testing.T) {
@@ -144,3 +366,22 @@ func TestGetIntAndDurationFallback(t *testing.T) {
 		t.Fatalf("expected fallback duration 3m, got %v", got)
 	}
 }
+
+func TestLoadMySQLOnlyNeedsDSN(t *testing.T) {
+	t.Setenv("SES_SOURCE_EMAIL", "")
+	t.Setenv("REDIS_ADDR", "")
+	t.Setenv("MYSQL_DSN", "")
+	if _, err := LoadMySQL(); err == nil {
+		t.Fatal("expected error without MYSQL_DSN")
+	}
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/notifications")
+	t.Setenv("MYSQL_MAX_OPEN_CONNS", "")
+	cfg, err := LoadMySQL()
+	if err != nil {
+		t.Fatalf("LoadMySQL: %v", err)
+	}
+	if cfg.MySQL.DSN != "user:pass@tcp(localhost:3306)/notifications" || cfg.MySQL.MaxOpenConns != 10 {
+		t.Fatalf("unexpected mysql config: %+v", cfg.MySQL)
+	}
+}
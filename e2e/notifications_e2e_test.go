@@ -12,6 +12,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -81,6 +82,28 @@ func (c *httpClient) postJSONWithAPIKey(t *testing.T, path string, body any, api
 	return resp, bodyBytes
 }
 
+func (c *httpClient) getJSON(t *testing.T, path string) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		t.Fatalf("new request failed: %v", err)
+	}
+	req.Header.Set("X-API-Key", notificationsCallerAPIKey())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		t.Fatalf("http request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := ioReadAll(resp)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	return resp, bodyBytes
+}
+
 func waitForHTTP(baseURL string, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
 	client := &http.Client{Timeout: 2 * time.Second}
@@ -278,6 +301,96 @@ func TestNotificationsE2E(t *testing.T) {
 		}
 	})
 
+	t.Run("HTTPCapturedEmail", func(t *testing.T) {
+		requestID := fmt.Sprintf("e2e-capture-%d", time.Now().UnixNano())
+		recipient := requestID + "@example.com"
+		resp, body := client.postJSON(t, "/email/send/raw", map[string]string{
+			"request_id": requestID,
+			"recipient":  recipient,
+			"subject":    "Captured hello",
+			"content":    "<p>hello world from capture e2e</p>",
+		})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("http send raw failed: %d body: %s", resp.StatusCode, string(body))
+		}
+		waitForStatus(t, db, requestID, entity.EmailStatusSuccess, 20*time.Second)
+
+		resp, body = client.getJSON(t, "/capture/messages?recipient="+recipient)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("capture list failed: %d body: %s", resp.StatusCode, string(body))
+		}
+		var list struct {
+			Messages []struct {
+				ID      string `json:"id"`
+				Subject string `json:"subject"`
+			} `json:"messages"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			t.Fatalf("decode capture list failed: %v", err)
+		}
+		if len(list.Messages) != 1 || list.Messages[0].Subject != "Captured hello" {
+			t.Fatalf("unexpected captured emails: %s", string(body))
+		}
+
+		resp, body = client.getJSON(t, "/capture/messages/"+list.Messages[0].ID+"/raw")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("capture raw failed: %d body: %s", resp.StatusCode, string(body))
+		}
+		raw := string(body)
+		if !strings.Contains(raw, "To: "+recipient+"\r\n") || !strings.Contains(raw, "Content-Type: text/html; charset=UTF-8\r\n") ||
+			!strings.HasSuffix(raw, "<p>hello world from capture e2e</p>") {
+			t.Fatalf("unexpected captured MIME: %q", raw)
+		}
+
+		resp, body = client.getJSON(t, "/email/"+requestID+"/attempts")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("email attempts failed: %d body: %s", resp.StatusCode, string(body))
+		}
+		var timeline struct {
+			Attempts []struct {
+				Provider   string `json:"provider"`
+				ErrorClass string `json:"error_class"`
+			} `json:"attempts"`
+		}
+		if err := json.Unmarshal(body, &timeline); err != nil {
+			t.Fatalf("decode email attempts failed: %v", err)
+		}
+		if len(timeline.Attempts) != 1 || timeline.Attempts[0].Provider != "capture" || timeline.Attempts[0].ErrorClass != "" {
+			t.Fatalf("unexpected email attempts: %s", string(body))
+		}
+	})
+
+	t.Run("HTTPInAppSendAndList", func(t *testing.T) {
+		userID := uint64(time.Now().UnixNano() % 1_000_000_000)
+		requestID := fmt.Sprintf("e2e-inapp-%d", time.Now().UnixNano())
+		resp, body := client.postJSON(t, "/inapp/send", map[string]any{
+			"request_id": requestID,
+			"user_id":    userID,
+			"type":       "comment",
+			"title":      "New comment",
+			"body":       "hello from e2e",
+		})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("inapp send failed: %d body: %s", resp.StatusCode, string(body))
+		}
+
+		resp, body = client.getJSON(t, fmt.Sprintf("/inapp/notifications?user_id=%d", userID))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("inapp list failed: %d body: %s", resp.StatusCode, string(body))
+		}
+		var list struct {
+			Notifications []struct {
+				RequestID string `json:"request_id"`
+			} `json:"notifications"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			t.Fatalf("decode list failed: %v", err)
+		}
+		if len(list.Notifications) != 1 || list.Notifications[0].RequestID != requestID {
+			t.Fatalf("unexpected inapp list: %s", string(body))
+		}
+	})
+
 	conn := dialNotificationsGRPC(t, grpcAddr)
 	defer conn.Close()
 	grpcClient := types.NewNotificationsServiceClient(conn)
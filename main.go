@@ -1,6 +1,11 @@
 package main
 
-import "github.com/vibast-solutions/ms-go-notifications/cmd"
+import (
+	// Embedded zone data keeps recipient timezones resolvable in minimal container images.
+	_ "time/tzdata"
+
+	"github.com/vibast-solutions/ms-go-notifications/cmd"
+)
 
 // main boots the CLI entrypoint.
 func main() {
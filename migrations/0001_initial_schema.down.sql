@@ -0,0 +1,2 @@
+-- Drops the email history, with its data.
+DROP TABLE IF EXISTS email_history;
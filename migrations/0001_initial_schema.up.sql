@@ -1,7 +1,8 @@
-CREATE DATABASE IF NOT EXISTS notifications;
-USE notifications;
+-- Schema of the service before migrations existed, as the former hand-run schema.sql
+-- created it. The table is created only when missing, so this migration also records
+-- databases created from schema.sql; later migrations bring them up to date.
 
-CREATE TABLE email_history
+CREATE TABLE IF NOT EXISTS email_history
 (
     id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
     request_id VARCHAR(64)                        NOT NULL,
@@ -13,14 +14,8 @@ CREATE TABLE email_history
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL ON UPDATE CURRENT_TIMESTAMP,
     CONSTRAINT idx_email_history_request_id
-        UNIQUE (request_id)
+        UNIQUE (request_id),
+    INDEX idx_email_history_created_at (created_at),
+    INDEX idx_email_history_recipient (recipient),
+    INDEX idx_email_history_status (status)
 );
-
-CREATE INDEX idx_email_history_created_at
-    ON email_history (created_at);
-
-CREATE INDEX idx_email_history_recipient
-    ON email_history (recipient);
-
-CREATE INDEX idx_email_history_status
-    ON email_history (status);
@@ -0,0 +1,16 @@
+ALTER TABLE email_history
+    DROP INDEX idx_email_history_provider_message_id,
+    DROP INDEX idx_email_history_batch,
+    DROP INDEX idx_email_history_digest,
+    DROP COLUMN template,
+    DROP COLUMN tenant,
+    DROP COLUMN provider_response,
+    DROP COLUMN provider_message_id,
+    DROP COLUMN provider,
+    DROP COLUMN batch_id,
+    DROP COLUMN digest_request_id,
+    DROP COLUMN digest_key,
+    DROP COLUMN send_at,
+    DROP COLUMN priority,
+    DROP COLUMN category,
+    DROP COLUMN user_id;
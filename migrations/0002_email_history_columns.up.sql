@@ -0,0 +1,18 @@
+-- Columns and indexes email_history gained for user-addressed email, categories, priorities,
+-- scheduled sends, digests, bulk sends, provider failover and send results, and routing.
+ALTER TABLE email_history
+    ADD COLUMN user_id             BIGINT UNSIGNED DEFAULT 0 NOT NULL AFTER request_id,
+    ADD COLUMN category            VARCHAR(64)  NOT NULL DEFAULT '' AFTER recipient,
+    ADD COLUMN priority            VARCHAR(16)  NOT NULL DEFAULT 'normal' AFTER category,
+    ADD COLUMN send_at             DATETIME     NULL AFTER retries,
+    ADD COLUMN digest_key          VARCHAR(64)  NOT NULL DEFAULT '' AFTER send_at,
+    ADD COLUMN digest_request_id   VARCHAR(64)  NULL AFTER digest_key,
+    ADD COLUMN batch_id            VARCHAR(64)  NOT NULL DEFAULT '' AFTER digest_request_id,
+    ADD COLUMN provider            VARCHAR(32)  NOT NULL DEFAULT '' AFTER batch_id,
+    ADD COLUMN provider_message_id VARCHAR(255) NOT NULL DEFAULT '' AFTER provider,
+    ADD COLUMN provider_response   TEXT         NULL AFTER provider_message_id,
+    ADD COLUMN tenant              VARCHAR(64)  NOT NULL DEFAULT '' AFTER provider_response,
+    ADD COLUMN template            VARCHAR(64)  NOT NULL DEFAULT '' AFTER tenant,
+    ADD INDEX idx_email_history_digest (status, digest_key),
+    ADD INDEX idx_email_history_batch (batch_id, status),
+    ADD INDEX idx_email_history_provider_message_id (provider_message_id);
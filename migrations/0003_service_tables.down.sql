@@ -0,0 +1,12 @@
+-- Drops the tables added since the initial schema, with their data.
+DROP TABLE IF EXISTS email_schedules;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notification_categories;
+DROP TABLE IF EXISTS recipient_profiles;
+DROP TABLE IF EXISTS notification_deliveries;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS inapp_notifications;
+DROP TABLE IF EXISTS email_queue;
+DROP TABLE IF EXISTS email_outbox;
+DROP TABLE IF EXISTS email_batches;
+DROP TABLE IF EXISTS email_attempts;
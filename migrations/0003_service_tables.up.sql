@@ -0,0 +1,149 @@
+-- Tables added since the initial schema: the email attempt log, bulk batches, the outbox and
+-- MySQL queue, in-app and unified notifications, recipient profiles, preferences, and
+-- recurring schedules. The status indexes let the reconciler find unfinished notifications.
+
+CREATE TABLE email_attempts
+(
+    id          BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    request_id  VARCHAR(64)                        NOT NULL,
+    worker      VARCHAR(64)                        NOT NULL DEFAULT '',
+    provider    VARCHAR(32)                        NOT NULL DEFAULT '',
+    started_at  DATETIME(3)                        NOT NULL,
+    finished_at DATETIME(3)                        NOT NULL,
+    error_class VARCHAR(16)                        NOT NULL DEFAULT '',
+    error       TEXT                               NOT NULL,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    INDEX idx_email_attempts_request_id (request_id, id)
+);
+
+CREATE TABLE email_batches
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    batch_id   VARCHAR(64)                        NOT NULL,
+    status     VARCHAR(16)                        NOT NULL DEFAULT 'active',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT idx_email_batches_batch_id
+        UNIQUE (batch_id)
+);
+
+CREATE TABLE email_outbox
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    request_id VARCHAR(64)                        NOT NULL,
+    timezone   VARCHAR(64)                        NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE TABLE email_queue
+(
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    request_id   VARCHAR(64)                        NOT NULL,
+    lane         VARCHAR(16)                        NOT NULL DEFAULT 'normal',
+    payload      MEDIUMTEXT                         NOT NULL,
+    available_at DATETIME(3)                        NOT NULL,
+    created_at   DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    INDEX idx_email_queue_available (lane, available_at)
+);
+
+CREATE TABLE inapp_notifications
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    request_id VARCHAR(64)                        NOT NULL,
+    user_id    BIGINT UNSIGNED                    NOT NULL,
+    type       VARCHAR(64)                        NOT NULL,
+    title      VARCHAR(255)                       NOT NULL,
+    body       TEXT                               NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT idx_inapp_notifications_request_id
+        UNIQUE (request_id),
+    INDEX idx_inapp_notifications_user_id (user_id, id)
+);
+
+CREATE TABLE notifications
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    request_id VARCHAR(64)                        NOT NULL,
+    user_id    BIGINT UNSIGNED                    NOT NULL,
+    type       VARCHAR(64)                        NOT NULL,
+    category   VARCHAR(64)                        NOT NULL DEFAULT '',
+    priority   VARCHAR(16)                        NOT NULL DEFAULT 'normal',
+    title      VARCHAR(255)                       NOT NULL,
+    body       TEXT                               NOT NULL,
+    email      VARCHAR(255)                       NOT NULL DEFAULT '',
+    policy     TEXT                               NOT NULL,
+    status     SMALLINT                           NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT idx_notifications_request_id
+        UNIQUE (request_id),
+    INDEX idx_notifications_user_id (user_id),
+    INDEX idx_notifications_status (status, created_at)
+);
+
+CREATE TABLE notification_deliveries
+(
+    id                      BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    notification_request_id VARCHAR(64)                        NOT NULL,
+    channel                 VARCHAR(32)                        NOT NULL,
+    tier                    VARCHAR(16)                        NOT NULL,
+    position                INT                                NOT NULL,
+    status                  SMALLINT                           NOT NULL,
+    reference               VARCHAR(128)                       NOT NULL DEFAULT '',
+    error                   VARCHAR(255)                       NOT NULL DEFAULT '',
+    created_at              DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT idx_notification_deliveries_channel
+        UNIQUE (notification_request_id, channel),
+    INDEX idx_notification_deliveries_status (status, channel)
+);
+
+CREATE TABLE recipient_profiles
+(
+    user_id       BIGINT UNSIGNED PRIMARY KEY,
+    email         VARCHAR(255)                       NOT NULL DEFAULT '',
+    phone         VARCHAR(32)                        NOT NULL DEFAULT '',
+    locale        VARCHAR(35)                        NOT NULL DEFAULT '',
+    timezone      VARCHAR(64)                        NOT NULL DEFAULT '',
+    device_tokens TEXT                               NOT NULL,
+    created_at    DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at    DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE TABLE notification_categories
+(
+    name          VARCHAR(64) PRIMARY KEY,
+    description   VARCHAR(255)                       NOT NULL DEFAULT '',
+    `transactional` TINYINT(1) DEFAULT 0               NOT NULL,
+    created_at    DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at    DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE TABLE notification_preferences
+(
+    user_id    BIGINT UNSIGNED                    NOT NULL,
+    category   VARCHAR(64)                        NOT NULL,
+    channel    VARCHAR(32)                        NOT NULL,
+    enabled    TINYINT(1)                         NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, category, channel)
+);
+
+CREATE TABLE email_schedules
+(
+    id          BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name        VARCHAR(64)                        NOT NULL,
+    cron_expr   VARCHAR(128)                       NOT NULL,
+    timezone    VARCHAR(64)                        NOT NULL DEFAULT 'UTC',
+    category    VARCHAR(64)                        NOT NULL DEFAULT '',
+    priority    VARCHAR(16)                        NOT NULL DEFAULT 'normal',
+    subject     VARCHAR(255)                       NOT NULL,
+    content     TEXT                               NOT NULL,
+    recipients  TEXT                               NOT NULL,
+    enabled     TINYINT(1) DEFAULT 1               NOT NULL,
+    last_run_at DATETIME                           NULL,
+    next_run_at DATETIME                           NOT NULL,
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT idx_email_schedules_name
+        UNIQUE (name),
+    INDEX idx_email_schedules_due (enabled, next_run_at)
+);
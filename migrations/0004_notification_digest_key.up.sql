@@ -0,0 +1,2 @@
+-- The digest key of a notification, so a re-dispatched email is still held for its digest.
+ALTER TABLE notifications ADD COLUMN digest_key VARCHAR(64) NOT NULL DEFAULT '' AFTER email;
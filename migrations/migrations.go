@@ -0,0 +1,10 @@
+// Package migrations holds the versioned MySQL schema migrations of the service, applied
+// with `migrate up`.
+package migrations
+
+import "embed"
+
+// FS holds the migration files, named <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS